@@ -0,0 +1,126 @@
+package gomadore
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Default access log format
+const defaultAccessLogFormat = "slog"
+
+// Timestamp layout of the combined log format
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// --- Access Log ---
+
+// accessLogger logs every request. Every method is safe to call on nil
+// (access log disabled).
+type accessLogger struct {
+	format string // "slog" or "combined"
+	mu     sync.Mutex
+	out    io.Writer     // combined format only
+	logger *slog.Logger  // slog format written to file; nil: the server log
+	file   *rotatingFile // nil when writing to stdout (or the server log)
+
+	proxies *trustedProxies // client addresses behind reverse proxies
+}
+
+// newAccessLogger returns nil if the access log is disabled. Both formats
+// are appended to file, rotated like the server log; without a file, the
+// combined format goes to stdout and the slog format to the server log.
+func newAccessLogger(cfg Config, proxies *trustedProxies) (*accessLogger, error) {
+	if !cfg.AccessLog.Enabled {
+		return nil, nil
+	}
+	a := &accessLogger{format: cfg.AccessLog.Format, proxies: proxies, out: os.Stdout}
+	if cfg.AccessLog.File != "" {
+		f, err := openLogFile(cfg.AccessLog.File, cfg)
+		if err != nil {
+			return nil, err
+		}
+		a.out, a.file = f, f
+		if a.format != "combined" {
+			a.logger = slog.New(newLogHandler(f, "info", cfg.General.LogType))
+		}
+	}
+	return a, nil
+}
+
+// close closes the log file (if any).
+func (a *accessLogger) close() error {
+	if a == nil || a.file == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// wrap logs the requests handled by next.
+func (a *accessLogger) wrap(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		a.log(r, status, rec.bytes, rec.Header().Get("X-Cache"), start)
+	})
+}
+
+func (a *accessLogger) log(r *http.Request, status int, bytes int64, cache string, start time.Time) {
+	if a.format != "combined" {
+		logger := a.logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Info("Access",
+			"method", r.Method,
+			"path", r.URL.RequestURI(),
+			"status", status,
+			"bytes", bytes,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"remote_addr", a.proxies.clientAddr(r),
+			"cache", cache,
+		)
+		return
+	}
+
+	addr := a.proxies.clientAddr(r)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	size := "-"
+	if bytes > 0 {
+		size = fmt.Sprint(bytes)
+	}
+	line := fmt.Sprintf("%s - - [%s] %q %d %s %q %q\n",
+		host, start.Format(combinedTimeLayout),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		status, size, orDash(r.Referer()), orDash(r.UserAgent()))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := io.WriteString(a.out, line); err != nil {
+		slog.Debug("Failed to write access log", "err", err)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+package gomadore
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogCombined(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	logFile := filepath.Join(dir, "access.log")
+	srv.config.AccessLog.Enabled = true
+	srv.config.AccessLog.Format = "combined"
+	srv.config.AccessLog.File = logFile
+	al, err := newAccessLogger(srv.config, nil)
+	if err != nil {
+		t.Fatalf("newAccessLogger failed: %v", err)
+	}
+	srv.accessLog = al
+	defer func() { _ = al.close() }()
+	h := srv.handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/about?x=1", nil)
+	req.RemoteAddr = "192.0.2.1:51234"
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b)
+	}
+	want := regexp.MustCompile(`^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /about\?x=1 HTTP/1\.1" 200 (\d+) "https://example\.com/" "test-agent"$`)
+	m := want.FindStringSubmatch(lines[0])
+	if m == nil {
+		t.Fatalf("Unexpected line: %s", lines[0])
+	}
+	if m[1] != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("Size mismatch: logged %s, body %d", m[1], w.Body.Len())
+	}
+	if !strings.HasPrefix(lines[1], "2001:db8::1 - - [") || !strings.Contains(lines[1], `" 404 `) || !strings.HasSuffix(lines[1], `"-" "-"`) {
+		t.Errorf("Unexpected line: %s", lines[1])
+	}
+}
+
+func TestAccessLogSlog(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	srv, _ := setupTestServer(t)
+	srv.config.AccessLog.Enabled = true
+	srv.config.AccessLog.Format = "slog"
+	al, err := newAccessLogger(srv.config, nil)
+	if err != nil {
+		t.Fatalf("newAccessLogger failed: %v", err)
+	}
+	srv.accessLog = al
+	h := srv.handler()
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/about", nil)
+		req.RemoteAddr = "192.0.2.1:51234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var access []string
+	for _, l := range lines {
+		if strings.Contains(l, "msg=Access") {
+			access = append(access, l)
+		}
+	}
+	if len(access) != 2 {
+		t.Fatalf("Expected 2 access records, got %q", buf.String())
+	}
+	for i, cache := range []string{"MISS", "HIT"} {
+		for _, want := range []string{"method=GET", "path=/about", "status=200", "remote_addr=192.0.2.1:51234", "cache=" + cache, "duration_ms="} {
+			if !strings.Contains(access[i], want) {
+				t.Errorf("Record %d lacks %q: %s", i, want, access[i])
+			}
+		}
+		if strings.Contains(access[i], "bytes=0 ") {
+			t.Errorf("Record %d has no size: %s", i, access[i])
+		}
+	}
+}
+
+func TestAccessLogDisabled(t *testing.T) {
+	var cfg Config
+	al, err := newAccessLogger(cfg, nil)
+	if err != nil || al != nil {
+		t.Fatalf("Expected nil logger, got %v, %v", al, err)
+	}
+	h := http.NotFoundHandler()
+	if al.wrap(h) == nil || al.close() != nil {
+		t.Error("nil logger should be a no-op")
+	}
+}
+
+func TestAccessLogSlogFile(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	srv, dir := setupTestServer(t)
+	file := filepath.Join(dir, "log", "access.log")
+	srv.config.AccessLog.Enabled = true
+	srv.config.AccessLog.Format = "slog"
+	srv.config.AccessLog.File = file
+	srv.config.General.LogType = "json"
+	al, err := newAccessLogger(srv.config, nil)
+	if err != nil {
+		t.Fatalf("newAccessLogger failed: %v", err)
+	}
+	srv.accessLog = al
+	srv.handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/about", nil))
+	if err := al.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil || !strings.Contains(string(b), `"msg":"Access"`) || !strings.Contains(string(b), `"path":"/about"`) {
+		t.Errorf("Expected a JSON access record in the file, got %q, %v", b, err)
+	}
+	if strings.Contains(buf.String(), "msg=Access") {
+		t.Errorf("The access record should not go to the server log: %s", buf.String())
+	}
+}
@@ -0,0 +1,80 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Prefix of the JSON page API: /api/pages/guide/setup -> /guide/setup
+const apiPagesPath = "/api/pages/"
+
+// --- JSON Page API ---
+
+// apiPage is the JSON document of a page.
+type apiPage struct {
+	Path      string         `json:"path"` // internal page path ("/guide/setup", "/index")
+	URL       string         `json:"url"`  // URL of the HTML page
+	Title     string         `json:"title"`
+	Meta      map[string]any `json:"meta"`
+	Body      string         `json:"body"` // rendered HTML, as {{ .Body }}
+	TOC       []*tocEntry    `json:"toc"`
+	Modified  time.Time      `json:"modified"`
+	WordCount int            `json:"word_count"`
+}
+
+// handlePageAPI serves the rendered content of a page as JSON, for
+// applications that render it themselves. The path after the prefix is the
+// page's URL path ("" or "guide/" for index pages, ".html" optional).
+// Protected pages need the credentials of their own URL.
+func (s *Server) handlePageAPI(w http.ResponseWriter, r *http.Request) {
+	reqPath := pageKey("/" + r.PathValue("path"))
+	if !s.authorizePage(w, r, reqPath) {
+		return
+	}
+	pd, err := s.renderDocument(reqPath)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOutsideRoot):
+			slog.Info("Attack attempt detected", "path", r.URL.Path, "remote_addr", s.proxies.clientAddr(r))
+			http.NotFound(w, r)
+		case errors.Is(err, fs.ErrNotExist):
+			http.NotFound(w, r)
+		default:
+			slog.Error("Failed to render page for the API", "path", reqPath, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	toc := pd.TOCEntries
+	if toc == nil {
+		toc = []*tocEntry{}
+	}
+	b, err := json.Marshal(apiPage{
+		Path:      reqPath,
+		URL:       urlPathFor(strings.TrimPrefix(reqPath, "/"), s.config.HTML.StrictHtmlUrl),
+		Title:     pd.Title,
+		Meta:      pd.Meta,
+		Body:      string(pd.Body),
+		TOC:       toc,
+		Modified:  pd.ModTime,
+		WordCount: pd.WordCount,
+	})
+	if err != nil {
+		slog.Error("Failed to encode page", "path", reqPath, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if notModified(w, r, pageETag(b), pd.ModTime) {
+		return
+	}
+	_ = writeBody(w, b)
+}
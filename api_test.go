@@ -0,0 +1,130 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPageAPI(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.API.Enabled = true
+	srv.config.TOC.MinLevel, srv.config.TOC.MaxLevel = 2, 3
+	mux := srv.routes()
+	createFile(t, dir, "setup.md", "---\ntitle: Setup\ntags: [go]\n---\n# Setup\n\n## Install\n\ntext\n")
+	createFile(t, dir, "draft.md", "---\ndraft: true\n---\n# Draft\n")
+
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	w := get("/api/pages/setup")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type: %s", ct)
+	}
+	var page apiPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if page.Path != "/setup" || page.URL != "/setup" || page.Title != "Setup" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+	if page.Meta["title"] != "Setup" {
+		t.Errorf("Expected the front matter, got %v", page.Meta)
+	}
+	if !strings.Contains(page.Body, "<h2 id=\"install\">Install</h2>") {
+		t.Errorf("Expected the rendered body, got %s", page.Body)
+	}
+	if len(page.TOC) == 0 || page.TOC[0].Title != "Install" {
+		t.Errorf("Expected the table of contents, got %+v", page.TOC)
+	}
+	if page.Modified.IsZero() {
+		t.Error("Expected the modification time")
+	}
+
+	t.Run("Conditional request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/pages/setup.html", nil)
+		req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, req)
+		if rw.Code != http.StatusNotModified {
+			t.Errorf("Expected 304, got %d", rw.Code)
+		}
+	})
+
+	for _, p := range []string{"/api/pages/missing", "/api/pages/draft"} {
+		if w := get(p); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", p, w.Code)
+		}
+	}
+}
+
+func TestPageAPIDisabled(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "setup.md", "# Setup\n")
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pages/setup", nil))
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Error("Expected no JSON API when disabled")
+	}
+}
+
+func TestPageAPIProtected(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "sub/secret.md", "# Secret\n\nclassified\n")
+	createFile(t, dir, "t1/_gomadore.toml", "[auth]\nenabled = true\nusers = [\"carol:"+string(hash)+"\"]\n")
+	cfg := srv.config
+	cfg.API.Enabled = true
+	cfg.Auth.Enabled = true
+	cfg.Auth.Users = []string{"alice:" + string(hash)}
+	cfg.Auth.Paths = []string{"/sub"}
+	srv, err = newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+
+	tests := []struct {
+		path string
+		user string
+		code int
+	}{
+		{"/sub/secret", "", http.StatusUnauthorized},
+		{"/api/pages/sub/secret", "", http.StatusUnauthorized},
+		{"/api/pages/sub/secret.html", "", http.StatusUnauthorized},
+		{"/api/pages/sub/missing", "", http.StatusUnauthorized},
+		{"/api/pages/sub/secret", "carol", http.StatusUnauthorized},
+		{"/api/pages/sub/secret", "alice", http.StatusOK},
+		{"/api/pages/t1/cococo", "", http.StatusUnauthorized},
+		{"/api/pages/t1/cococo", "alice", http.StatusUnauthorized},
+		{"/api/pages/t1/cococo", "carol", http.StatusOK},
+		{"/api/pages/about", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.user != "" {
+			r.SetBasicAuth(tt.user, "secret")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s (%q): status %d, want %d", tt.path, tt.user, w.Code, tt.code)
+		}
+		if tt.code == http.StatusUnauthorized && strings.Contains(w.Body.String(), "classified") {
+			t.Errorf("%s: the protected page leaked: %s", tt.path, w.Body.String())
+		}
+	}
+}
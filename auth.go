@@ -0,0 +1,228 @@
+package gomadore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Default realm of the WWW-Authenticate challenge
+const defaultAuthRealm = "gomadore"
+
+// Hash compared against for unknown users, so that they take as long as known ones
+var authDummyHash = sync.OnceValue(func() []byte {
+	h, _ := bcrypt.GenerateFromPassword([]byte("gomadore"), bcrypt.DefaultCost)
+	return h
+})
+
+// --- Basic Authentication ---
+
+// basicAuth protects the configured paths with HTTP Basic authentication.
+// Every method is safe to call on nil (auth disabled).
+type basicAuth struct {
+	realm    string
+	paths    []string          // protected path prefixes (empty: every path)
+	users    map[string][]byte // user -> bcrypt hash
+	mu       sync.Mutex
+	verified map[string][32]byte // user -> sha256 of the last accepted password
+}
+
+// newBasicAuth returns nil if authentication is disabled.
+// Users come from the htpasswd file and the inline "user:hash" entries.
+func newBasicAuth(cfg Config) (*basicAuth, error) {
+	if !cfg.Auth.Enabled {
+		return nil, nil
+	}
+	a := &basicAuth{
+		realm:    cfg.Auth.Realm,
+		paths:    cfg.Auth.Paths,
+		users:    make(map[string][]byte),
+		verified: make(map[string][32]byte),
+	}
+	if cfg.Auth.HtpasswdFile != "" {
+		b, err := os.ReadFile(cfg.Auth.HtpasswdFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.addUsers(b); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.Auth.HtpasswdFile, err)
+		}
+	}
+	if err := a.addUsers([]byte(strings.Join(cfg.Auth.Users, "\n"))); err != nil {
+		return nil, err
+	}
+	if len(a.users) == 0 {
+		return nil, errors.New("no users configured")
+	}
+	return a, nil
+}
+
+// addUsers parses htpasswd lines ("user:$2y$..."). Only bcrypt hashes are supported.
+func (a *basicAuth) addUsers(b []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return fmt.Errorf("line %d: expected user:hash", n)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("line %d: user %s: not a bcrypt hash (use htpasswd -B)", n, user)
+		}
+		a.users[user] = []byte(hash)
+	}
+	return sc.Err()
+}
+
+// protects reports whether a request path requires authentication.
+func (a *basicAuth) protects(p string) bool {
+	return pathsCover(a.paths, p)
+}
+
+// pathsCover reports whether a request path is under one of the prefixes
+// (every path if there are none).
+func pathsCover(prefixes []string, p string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if p == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// protectsPage reports whether one of the URL paths of a page (internal
+// page path) is protected, unless the whole site is. Safe to call on nil.
+func (a *basicAuth) protectsPage(pagePath string) bool {
+	return a != nil && coversPage(a.paths, pagePath)
+}
+
+// coversPage reports whether the prefixes cover one of the URL paths of a
+// page, but not every path (see pageProtected).
+func coversPage(prefixes []string, pagePath string) bool {
+	if pathsCover(prefixes, "/") {
+		return false
+	}
+	return slices.ContainsFunc(pageURLPaths(pagePath), func(p string) bool {
+		return pathsCover(prefixes, p)
+	})
+}
+
+// pageURLPaths returns the URL paths a page (internal page path) is served
+// at: "/guide/setup" and "/guide/setup.html", and "/guide/" for the page
+// "/guide/index".
+func pageURLPaths(pagePath string) []string {
+	paths := []string{pagePath, pagePath + ".html"}
+	if dir, ok := strings.CutSuffix(pagePath, "/index"); ok {
+		paths = append(paths, dir+"/")
+	}
+	return paths
+}
+
+// pageProtected reports whether a page (internal page path) requires a login
+// that the pages derived from the page index do not: by [auth] (Basic or
+// OIDC) or the _gomadore.toml of one of its directories. Authentication is
+// decided by the request path, so the search index, feeds and sitemap leave
+// such pages out, and the page API checks them itself (see authorizePage).
+// Protection of the whole site covers those paths as well and does not count.
+func (s *Server) pageProtected(pagePath string) bool {
+	return s.auth.protectsPage(pagePath) || s.oidc.protectsPage(pagePath) || s.overlays.protectsPage(pagePath)
+}
+
+// authorizePage applies the authentication of the URL paths of a page
+// (internal page path) to a request for it through another path (the page
+// API, its Markdown source or its attachments), and answers 401
+// Unauthorized if it fails. An OIDC session is accepted, but nobody is sent
+// to sign in.
+func (s *Server) authorizePage(w http.ResponseWriter, r *http.Request, pagePath string) bool {
+	paths := pageURLPaths(pagePath)
+	covered := func(prefixes []string) bool {
+		return slices.ContainsFunc(paths, func(p string) bool { return pathsCover(prefixes, p) })
+	}
+	switch {
+	case s.oidc != nil:
+		if covered(s.oidc.paths) && s.oidc.session(r) == nil {
+			if _, _, ok := r.BasicAuth(); !ok || s.auth == nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return false
+			}
+			if !s.auth.authorize(w, r) {
+				return false
+			}
+		}
+	case s.auth != nil:
+		if covered(s.auth.paths) && !s.auth.authorize(w, r) {
+			return false
+		}
+	}
+	for _, p := range paths {
+		if a := s.overlays.authFor(p); a != nil {
+			return a.authorize(w, r)
+		}
+	}
+	return true
+}
+
+// check verifies a user's password. A successful bcrypt comparison is
+// remembered, so that every request of a logged-in client is not slowed down.
+func (a *basicAuth) check(user, password string) bool {
+	sum := sha256.Sum256([]byte(password))
+	a.mu.Lock()
+	last, ok := a.verified[user]
+	a.mu.Unlock()
+	if ok && last == sum {
+		return true
+	}
+
+	hash, known := a.users[user]
+	if !known {
+		_ = bcrypt.CompareHashAndPassword(authDummyHash(), []byte(password))
+		return false
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return false
+	}
+	a.mu.Lock()
+	a.verified[user] = sum
+	a.mu.Unlock()
+	return true
+}
+
+// wrap answers 401 Unauthorized to unauthenticated requests of protected paths.
+func (a *basicAuth) wrap(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.protects(r.URL.Path) && !a.authorize(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize checks the credentials of a request, and answers 401
+// Unauthorized if they are missing or wrong.
+func (a *basicAuth) authorize(w http.ResponseWriter, r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok || !a.check(user, password) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
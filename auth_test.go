@@ -0,0 +1,206 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// htpasswd -B writes "$2y$" hashes
+	createFile(t, dir, ".htpasswd", "# users\nalice:"+strings.Replace(string(hash), "$2a$", "$2y$", 1)+"\n")
+
+	srv.config.Auth.Enabled = true
+	srv.config.Auth.Realm = "Docs"
+	srv.config.Auth.HtpasswdFile = filepath.Join(dir, ".htpasswd")
+	srv.config.Auth.Users = []string{"bob:" + string(hash)}
+	srv.config.Auth.Paths = []string{"/sub/"}
+	auth, err := newBasicAuth(srv.config)
+	if err != nil {
+		t.Fatalf("newBasicAuth failed: %v", err)
+	}
+	srv.auth = auth
+	h := srv.handler()
+
+	tests := []struct {
+		name       string
+		path       string
+		user, pass string
+		wantStatus int
+	}{
+		{"Unprotected path", "/about", "", "", http.StatusOK},
+		{"No credentials", "/sub/deep", "", "", http.StatusUnauthorized},
+		{"Directory itself", "/sub", "", "", http.StatusUnauthorized},
+		{"Wrong password", "/sub/deep", "alice", "wrong", http.StatusUnauthorized},
+		{"Unknown user", "/sub/deep", "carol", "secret", http.StatusUnauthorized},
+		{"htpasswd user", "/sub/deep", "alice", "secret", http.StatusOK},
+		{"htpasswd user again (remembered)", "/sub/deep", "alice", "secret", http.StatusOK},
+		{"Inline user", "/sub/deep", "bob", "secret", http.StatusOK},
+		{"Similar prefix", "/subway", "", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d", tt.wantStatus, w.Code)
+			}
+			if w.Code == http.StatusUnauthorized {
+				if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="Docs", charset="UTF-8"` {
+					t.Errorf("Unexpected challenge %q", got)
+				}
+			}
+		})
+	}
+
+	t.Run("Markdown source and attachments", func(t *testing.T) {
+		srv.config.HTML.ServeRawMarkdown = true
+		defer func() { srv.config.HTML.ServeRawMarkdown = false }()
+		auth.paths = []string{"/about"}
+		defer func() { auth.paths = []string{"/sub/"} }()
+		get := func(path, user string) int {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if user != "" {
+				req.SetBasicAuth(user, "secret")
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			return w.Code
+		}
+		if code := get("/about.md", ""); code != http.StatusUnauthorized {
+			t.Errorf("Expected the source of a protected page to need credentials, got %d", code)
+		}
+		if code := get("/about.md", "bob"); code != http.StatusOK {
+			t.Errorf("Expected 200 with credentials, got %d", code)
+		}
+
+		// Attachments of the page
+		if err := os.Mkdir(filepath.Join(dir, "about.assets"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		createFile(t, filepath.Join(dir, "about.assets"), "shot.txt", "attached")
+		enabled := true
+		srv.config.Markdown.PageAssets = &enabled
+		defer func() { srv.config.Markdown.PageAssets = nil }()
+		if code := get("/about.assets/shot.txt", ""); code != http.StatusUnauthorized {
+			t.Errorf("Expected the attachment of a protected page to need credentials, got %d", code)
+		}
+		if code := get("/about.assets/shot.txt", "bob"); code != http.StatusOK {
+			t.Errorf("Expected 200 with credentials, got %d", code)
+		}
+		srv.config.Static.Enabled = true
+		defer func() { srv.config.Static.Enabled = false }()
+		if code := get("/about.assets/shot.txt", ""); code != http.StatusUnauthorized {
+			t.Errorf("Expected the attachment to be protected as a static file, got %d", code)
+		}
+	})
+
+	t.Run("Whole site", func(t *testing.T) {
+		auth.paths = nil
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestNewBasicAuthErrors(t *testing.T) {
+	var cfg Config
+	cfg.Auth.Enabled = true
+	if _, err := newBasicAuth(cfg); err == nil {
+		t.Error("Expected error without users")
+	}
+	cfg.Auth.Users = []string{"alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="}
+	if _, err := newBasicAuth(cfg); err == nil || !strings.Contains(err.Error(), "bcrypt") {
+		t.Errorf("Expected bcrypt error, got %v", err)
+	}
+	cfg.Auth.Users = []string{"no-separator"}
+	if _, err := newBasicAuth(cfg); err == nil {
+		t.Error("Expected format error")
+	}
+}
+
+func TestPageProtected(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	if srv.pageProtected("/sub/deep") {
+		t.Error("No page is protected without [auth]")
+	}
+	srv.auth = &basicAuth{paths: []string{"/sub/", "/about.html", "/t1/"}}
+	tests := []struct {
+		page string
+		want bool
+	}{
+		{"/sub/deep", true},
+		{"/sub/index", true},
+		{"/subway", false},
+		{"/about", true}, // through its ".html" URL
+		{"/t1/index", true},
+		{"/index", false},
+	}
+	for _, tt := range tests {
+		if got := srv.pageProtected(tt.page); got != tt.want {
+			t.Errorf("pageProtected(%q) = %v, want %v", tt.page, got, tt.want)
+		}
+	}
+	for _, paths := range [][]string{nil, {"/"}, {"/sub/", "/"}} {
+		srv.auth.paths = paths
+		if srv.pageProtected("/sub/deep") {
+			t.Errorf("%q: the listing paths are protected along with the whole site", paths)
+		}
+	}
+}
+
+func TestProtectedListings(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "sub/other.md", "# Other Page\nSee [about](/about) and [deep](/sub/deep).")
+	createFile(t, dir, "t1/public.md", "# Public Page")
+	srv.config.HTML.AutoIndex = true
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`{{ range .Nav }}[{{ .Path }}]{{ end }}|{{ range .Backlinks }}({{ .URL }}){{ end }}|{{ with .Next }}{{ .Path }}{{ end }}|{{ .Body }}`))
+	srv.auth = &basicAuth{paths: []string{"/sub/", "/t1/cococo"}}
+	srv.purgeCache()
+	h := srv.routes() // rendering only; authentication is tested above
+	get := func(p string) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", p, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	// An unprotected page does not show protected ones
+	about := get("/about")
+	if strings.Contains(about, "[/sub/]") || !strings.Contains(about, "[/t1/]") {
+		t.Errorf("Navigation of an unprotected page: %s", about)
+	}
+	if strings.Contains(about, "(/sub/other)") {
+		t.Errorf("Backlink from a protected page: %s", about)
+	}
+	if index := get("/t1/"); strings.Contains(index, "cococo") || !strings.Contains(index, "Public Page") {
+		t.Errorf("Directory listing of an unprotected directory: %s", index)
+	}
+
+	// A protected page, read by a signed in user, shows them
+	deep := get("/sub/deep")
+	if !strings.Contains(deep, "[/sub/]") || !strings.Contains(deep, "(/sub/other)") || !strings.Contains(deep, "|/sub/other|") {
+		t.Errorf("Navigation of a protected page: %s", deep)
+	}
+}
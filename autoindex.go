@@ -0,0 +1,154 @@
+package gomadore
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// --- Directory Auto-Index ---
+
+// dirIndexEntry is an item of a generated directory listing.
+type dirIndexEntry struct {
+	URL   string
+	Title string
+	IsDir bool
+}
+
+// isDirIndexPath reports whether an internal page path refers to the index
+// page of a directory ("/index", "/sub/index").
+func isDirIndexPath(reqPath string) bool {
+	return path.Base(reqPath) == "index"
+}
+
+// dirIndexEntries lists the markdown pages (titled by front matter or first
+// H1) and subdirectories of a directory under the content roots (rel is
+// slash separated, "" for the root). Directories come first; both sorted by
+// title. Protected pages and directories are left out unless the directory
+// is protected itself. It returns an error wrapping fs.ErrNotExist if no
+// root has the directory.
+func (s *Server) dirIndexEntries(rel string) ([]dirIndexEntry, error) {
+	roots := s.config.HTML.MarkdownRootDir
+	exts := s.config.HTML.MarkdownExts
+	hidden := func(pagePath string) bool {
+		return s.pageProtected(pagePath) && !s.pageProtected("/"+path.Join(rel, "index"))
+	}
+	var entries []dirIndexEntry
+	found := false
+	seenDirs := make(map[string]bool) // merged from every content root
+	for _, root := range roots {
+		dir := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		for _, d := range dirEntries {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") {
+				continue
+			}
+			child := path.Join(rel, name)
+			ext := markdownExt(name, exts)
+			switch {
+			case d.IsDir():
+				if seenDirs[name] || hidden("/"+child+"/index") {
+					continue
+				}
+				seenDirs[name] = true
+				entries = append(entries, dirIndexEntry{
+					URL:   urlPathFor(child+"/index", s.config.HTML.StrictHtmlUrl),
+					Title: name + "/",
+					IsDir: true,
+				})
+			case d.Type().IsRegular() && ext != "":
+				// Only the file the page is served from
+				if r, src, ok := roots.source(strings.TrimSuffix(child, ext), exts); !ok || r != root || src != child {
+					continue
+				}
+				p, err := s.files.meta(root, child)
+				if err != nil || (p.Draft && !s.config.HTML.ShowDrafts) || hidden(p.Path) {
+					continue
+				}
+				title := p.Title
+				if title == "" {
+					title = strings.TrimSuffix(name, ext)
+				}
+				entries = append(entries, dirIndexEntry{URL: p.URL, Title: title})
+			}
+		}
+	}
+	if !found {
+		return nil, fs.ErrNotExist
+	}
+
+	slices.SortFunc(entries, func(a, b dirIndexEntry) int {
+		if a.IsDir != b.IsDir {
+			if a.IsDir {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(
+			strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+			strings.Compare(a.URL, b.URL),
+		)
+	})
+	return entries, nil
+}
+
+// renderDirIndex renders a listing of a directory without index.md.
+// It returns an error wrapping fs.ErrNotExist if the directory does not exist.
+func (s *Server) renderDirIndex(st *site, reqPath string) ([]byte, error) {
+	if _, err := s.markdownFile(reqPath); err != nil {
+		return nil, err
+	}
+
+	rel := strings.TrimPrefix(path.Dir(reqPath), "/")
+	entries, err := s.dirIndexEntries(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	dirURL := "/"
+	if rel != "" {
+		dirURL = "/" + rel + "/"
+	}
+	var b strings.Builder
+	b.WriteString("<h1>Index of " + template.HTMLEscapeString(dirURL) + "</h1>\n")
+	b.WriteString("<ul class=\"auto-index\">\n")
+	if rel != "" {
+		b.WriteString(`<li><a href="` + urlPathFor(path.Join(path.Dir(rel), "index"), s.config.HTML.StrictHtmlUrl) + `">../</a></li>` + "\n")
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", template.HTMLEscapeString(e.URL), template.HTMLEscapeString(e.Title))
+	}
+	b.WriteString("</ul>\n")
+
+	title := "Index of " + dirURL
+	if s.forcedTitle != "" {
+		title = s.forcedTitle
+	} else if st.title != "" {
+		title = fmt.Sprintf("%s - %s", title, st.title)
+	}
+
+	data := s.templateData(st, title, template.HTML(b.String()), "index")
+	data["IndexEntries"] = entries
+	data["Nav"] = s.nav.get(reqPath)
+	data["Breadcrumbs"] = s.nav.breadcrumbs(reqPath)
+	respBody, err := s.executeTemplate(st.tmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errTemplateExecution, err)
+	}
+	return respBody, nil
+}
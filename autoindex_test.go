@@ -0,0 +1,99 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutoIndex(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SiteTitle = "Site"
+	srv.tmpl = template.Must(template.New("base").Parse(`<title>{{.Title}}</title>[Entries:{{range .IndexEntries}}{{.Title}},{{end}}]{{.Body}}`))
+
+	for _, d := range []string{"guide/advanced", "guide/.git"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(d)), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+	}
+	createFile(t, dir, "guide/zeta.md", "# Alpha <Setup>\nbody")
+	createFile(t, dir, "guide/beta.md", "---\ntitle: Beta\n---\n# Ignored H1")
+	createFile(t, dir, "guide/untitled.md", "no heading")
+	createFile(t, dir, "guide/.draft.md", "# Hidden")
+	createFile(t, dir, "guide/image.png", "PNG")
+
+	get := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	if w := get(t, "/guide/"); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 when auto_index is disabled, got %d", w.Code)
+	}
+
+	srv.config.HTML.AutoIndex = true
+	w := get(t, "/guide/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected listing, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"<title>Index of /guide/ - Site</title>",
+		"[Entries:advanced/,Alpha &lt;Setup&gt;,Beta,untitled,]",
+		`<li><a href="/">../</a></li>`,
+		`<li><a href="/guide/advanced/">advanced/</a></li>`,
+		`<li><a href="/guide/zeta">Alpha &lt;Setup&gt;</a></li>`,
+		`<li><a href="/guide/beta">Beta</a></li>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Listing should contain %q:\n%s", want, body)
+		}
+	}
+	for _, notWant := range []string{".git", ".draft", "image.png"} {
+		if strings.Contains(body, notWant) {
+			t.Errorf("Listing should not contain %q:\n%s", notWant, body)
+		}
+	}
+
+	// Nested empty directory links back to its parent
+	if w := get(t, "/guide/advanced/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<a href="/guide/">../</a>`) {
+		t.Errorf("Nested listing mismatch: %d %s", w.Code, w.Body.String())
+	}
+
+	// Existing index.md still wins; missing directories stay 404
+	if w := get(t, "/"); !strings.Contains(w.Body.String(), "Hello World") {
+		t.Errorf("index.md should be rendered: %s", w.Body.String())
+	}
+	if w := get(t, "/nothing/"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing directory, got %d", w.Code)
+	}
+
+	t.Run("Strict URLs", func(t *testing.T) {
+		srv.config.HTML.StrictHtmlUrl, srv.files.strict = true, true
+		defer func() { srv.config.HTML.StrictHtmlUrl, srv.files.strict = false, false }()
+		srv.purgeCache()
+		body := get(t, "/guide/index.html").Body.String()
+		for _, want := range []string{`<a href="/index.html">../</a>`, `<a href="/guide/advanced/index.html">`, `<a href="/guide/zeta.html">`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Strict listing should contain %q:\n%s", want, body)
+			}
+		}
+	})
+
+	t.Run("Invalidation", func(t *testing.T) {
+		srv.purgeCache()
+		get(t, "/guide/")
+		createFile(t, dir, "guide/new.md", "# New Page")
+		srv.invalidateFiles([]string{filepath.Join(dir, "guide", "new.md")})
+		if body := get(t, "/guide/").Body.String(); !strings.Contains(body, "New Page") {
+			t.Errorf("Listing should be re-rendered after a change:\n%s", body)
+		}
+	})
+}
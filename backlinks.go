@@ -0,0 +1,79 @@
+package gomadore
+
+import (
+	"cmp"
+	"log/slog"
+)
+
+// --- Backlinks ---
+
+// backlinks returns the pages that link to a page, available in templates
+// as {{ .Backlinks }} (with .URL and .Title), sorted by path. Protected
+// pages are left out unless the page is protected itself.
+func (s *Server) backlinks(reqPath string) []dirIndexEntry {
+	pages, err := s.pages.backlinks(reqPath)
+	if err != nil {
+		slog.Warn("Failed to list backlinks", "path", reqPath, "err", err)
+		return nil
+	}
+	shown := s.pageProtected(reqPath)
+	entries := make([]dirIndexEntry, 0, len(pages))
+	for _, p := range pages {
+		if !shown && s.pageProtected(p.Path) {
+			continue
+		}
+		entries = append(entries, dirIndexEntry{URL: p.URL, Title: cmp.Or(p.Title, p.Path)})
+	}
+	return entries
+}
+
+// backlinks returns the pages that link to a page, sorted by path.
+func (ix *pageIndex) backlinks(reqPath string) ([]*pageMeta, error) {
+	if _, err := ix.all(); err != nil {
+		return nil, err
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.linked[reqPath], nil
+}
+
+// backlinkTable maps the path of every page that other pages link to (by
+// URL, old URL of an alias, or wiki link) to those pages.
+func backlinkTable(pages []*pageMeta, alias map[string]*pageMeta) map[string][]*pageMeta {
+	byPath := make(map[string]*pageMeta, len(pages))
+	for _, p := range pages {
+		byPath[p.Path] = p
+	}
+	find := func(urlPath string) *pageMeta {
+		key := pageKey(urlPath)
+		if p := byPath[key]; p != nil {
+			return p
+		}
+		if p := byPath[key+"/index"]; p != nil {
+			return p // directory without trailing slash
+		}
+		return alias[aliasKey(urlPath)]
+	}
+
+	table := make(map[string][]*pageMeta)
+	for _, p := range pages {
+		targets := make([]*pageMeta, 0, len(p.links)+len(p.wikiLinks))
+		for _, l := range p.links {
+			targets = append(targets, find(l))
+		}
+		for _, w := range p.wikiLinks {
+			targets = append(targets, resolveWikiLink(pages, w))
+		}
+		for _, t := range targets {
+			if t == nil || t == p {
+				continue
+			}
+			// Pages are visited in order, so a repeated link is the last entry
+			if l := table[t.Path]; len(l) > 0 && l[len(l)-1] == p {
+				continue
+			}
+			table[t.Path] = append(table[t.Path], p)
+		}
+	}
+	return table
+}
@@ -0,0 +1,68 @@
+package gomadore
+
+import (
+	"html/template"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBacklinks(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "a.md", "# Page A\n\n[about](about.md), [again](/about), [deep](sub/deep) and [self](#top)\n")
+	createFile(t, dir, "b.md", "---\naliases: [/old-b]\n---\n# Page B\n\n[About](./about.html) and [sub](/sub) and [external](https://example.com/about)\n")
+	createFile(t, dir, "c.md", "# Page C\n\n[old](/old-b) and [missing](/nowhere)\n")
+
+	linking := func(p string) []string {
+		t.Helper()
+		var urls []string
+		for _, e := range srv.backlinks(p) {
+			urls = append(urls, e.URL+" "+e.Title)
+		}
+		return urls
+	}
+	if got, want := linking("/about"), []string{"/a Page A", "/b Page B"}; !slices.Equal(got, want) {
+		t.Errorf("Backlinks of /about: got %v, want %v", got, want)
+	}
+	if got, want := linking("/sub/deep"), []string{"/a Page A"}; !slices.Equal(got, want) {
+		t.Errorf("Backlinks of /sub/deep: got %v, want %v", got, want)
+	}
+	if got, want := linking("/b"), []string{"/c Page C"}; !slices.Equal(got, want) {
+		t.Errorf("Backlinks of /b (by alias): got %v, want %v", got, want)
+	}
+	if got := linking("/a"); got != nil {
+		t.Errorf("Expected no backlinks of /a, got %v", got)
+	}
+
+	// Rendered pages, and their update by the watcher
+	srv.tmpl = template.Must(template.New("base").Parse(`{{ range .Backlinks }}[{{ .Title }}]{{ end }}`))
+	st := srv.siteForHost("")
+	for _, p := range []string{"/about", "/sub/deep", "/index", "/t1/cococo"} {
+		renderCached(t, srv, st, p)
+	}
+
+	createFile(t, dir, "a.md", "# Page A\n\n[home](/)\n")
+	srv.invalidateFiles([]string{filepath.Join(dir, "a.md")})
+	for p, want := range map[string]bool{"/about": false, "/sub/deep": false, "/index": false, "/t1/cococo": true} {
+		if _, ok := srv.cache.items[p]; ok != want {
+			t.Errorf("%s: expected cached %v, got %v", p, want, ok)
+		}
+	}
+	if got := renderCached(t, srv, st, "/about"); got != "[Page B]" {
+		t.Errorf("Unexpected backlinks of /about: %q", got)
+	}
+}
+
+// renderCached renders a page into the cache of the server.
+func renderCached(t *testing.T, srv *Server, st *site, reqPath string) string {
+	t.Helper()
+	out, err := srv.renderPage(st, reqPath)
+	if err != nil {
+		t.Fatalf("renderPage(%s): %v", reqPath, err)
+	}
+	srv.cache.Lock()
+	srv.cache.items[st.cacheKey(reqPath)] = CacheItem{Content: out}
+	srv.cache.Unlock()
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,80 @@
+package gomadore
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// CacheRuleConfig is a [[cache.rule]] entry: the cache lifetime of the
+// pages matching a path pattern, overriding cache_limit.
+type CacheRuleConfig struct {
+	Path string `toml:"path" validate:"required,startswith=/"`
+	TTL  *int   `toml:"ttl" validate:"required,min=0"` // seconds; 0: never cached
+}
+
+// --- Cache Rules ---
+
+// cacheRule is a parsed [[cache.rule]] entry.
+type cacheRule struct {
+	pattern []string // path segments, "**" for any number of them
+	ttl     int
+}
+
+// cacheRules are the [[cache.rule]] entries, in configuration order.
+type cacheRules []cacheRule
+
+// newCacheRules parses the rules. Patterns use path.Match syntax per path
+// segment, "**" matches any number of segments, and a trailing slash stands
+// for the index page of a directory ("/news/" is "/news/index").
+func newCacheRules(list []CacheRuleConfig) (cacheRules, error) {
+	var rules cacheRules
+	for _, rc := range list {
+		for seg := range strings.SplitSeq(rc.Path, "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("path %q: %w", rc.Path, err)
+			}
+		}
+		rules = append(rules, cacheRule{
+			pattern: strings.Split(strings.TrimPrefix(pageKey(rc.Path), "/"), "/"),
+			ttl:     *rc.TTL,
+		})
+	}
+	return rules, nil
+}
+
+// match returns the lifetime of the first rule matching a page path.
+func (cr cacheRules) match(reqPath string) (int, bool) {
+	if len(cr) == 0 {
+		return 0, false
+	}
+	segs := strings.Split(strings.TrimPrefix(reqPath, "/"), "/")
+	for _, r := range cr {
+		if globSegments(r.pattern, segs) {
+			return r.ttl, true
+		}
+	}
+	return 0, false
+}
+
+// shortest returns the shortest positive lifetime of the rules (0 if none).
+func (cr cacheRules) shortest() time.Duration {
+	var d time.Duration
+	for _, r := range cr {
+		if t := time.Duration(r.ttl) * time.Second; t > 0 && (d == 0 || t < d) {
+			d = t
+		}
+	}
+	return d
+}
+
+// pageTTL returns how long a page is cached, in seconds (0: until it is
+// invalidated), and false if it must not be cached at all. The first
+// matching [[cache.rule]] applies, or else the cache_limit of the site.
+func (s *Server) pageTTL(st *site, reqPath string) (int, bool) {
+	if ttl, ok := s.cacheRules.match(reqPath); ok {
+		return ttl, ttl > 0
+	}
+	return st.cacheLimit, true
+}
@@ -0,0 +1,110 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestCacheRulesMatch(t *testing.T) {
+	var cfg Config
+	_, err := toml.Decode(`
+[[cache.rule]]
+path = "/status/**"
+ttl = 0
+
+[[cache.rule]]
+path = "/news/*"
+ttl = 300
+
+[[cache.rule]]
+path = "/guide/"
+ttl = 60
+
+[[cache.rule]]
+path = "/news/**"
+ttl = 900
+`, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := newCacheRules(cfg.Cache.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ttl  int
+		ok   bool
+	}{
+		{"/status/index", 0, true},
+		{"/status/api/health", 0, true},
+		{"/news/today", 300, true},
+		{"/news/2024/today", 900, true},
+		{"/guide/index", 60, true},
+		{"/guide/setup", 0, false},
+		{"/about", 0, false},
+	}
+	for _, tt := range tests {
+		if ttl, ok := rules.match(tt.path); ttl != tt.ttl || ok != tt.ok {
+			t.Errorf("match(%s) = %d, %v; want %d, %v", tt.path, ttl, ok, tt.ttl, tt.ok)
+		}
+	}
+	if got := rules.shortest(); got != 60*time.Second {
+		t.Errorf("shortest = %v", got)
+	}
+
+	ttl := 1
+	if _, err := newCacheRules([]CacheRuleConfig{{Path: "/[a", TTL: &ttl}}); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
+
+func TestCacheRules(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	if err := os.Mkdir(filepath.Join(dir, "status"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "status/index.md", "# Status")
+	zero, short := 0, 5
+	srv.config.Cache.Rules = []CacheRuleConfig{{Path: "/status/", TTL: &zero}, {Path: "/sub/*", TTL: &short}}
+	srv.config.Cache.CacheDir = t.TempDir()
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	for range 2 {
+		w := get("/status/")
+		if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "MISS" || w.Header().Get("Cache-Control") != "no-cache" {
+			t.Errorf("/status/: %d, X-Cache %s, Cache-Control %s", w.Code, w.Header().Get("X-Cache"), w.Header().Get("Cache-Control"))
+		}
+	}
+	if _, ok := srv.cachedPage("/status/index"); ok {
+		t.Error("A page with ttl = 0 was cached")
+	}
+
+	if w := get("/sub/deep"); w.Header().Get("Cache-Control") != "max-age=5" {
+		t.Errorf("/sub/deep: Cache-Control %s", w.Header().Get("Cache-Control"))
+	}
+	srv.cache.RLock()
+	item := srv.cache.items["/sub/deep"]
+	srv.cache.RUnlock()
+	if d := time.Until(item.Expires); item.NoExpiry || d <= 0 || d > 5*time.Second {
+		t.Errorf("/sub/deep expires in %v", d)
+	}
+	if w := get("/about"); w.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("/about: Cache-Control %s", w.Header().Get("Cache-Control"))
+	}
+}
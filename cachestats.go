@@ -0,0 +1,84 @@
+package gomadore
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// --- Cache Statistics ---
+
+// cacheStats is a snapshot of the counters of one or more caches.
+type cacheStats struct {
+	Items     int
+	Bytes     int64
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// hitRatio returns the share of page requests served from the cache (0 if
+// there were none).
+func (cs cacheStats) hitRatio() float64 {
+	if total := cs.Hits + cs.Misses; total > 0 {
+		return float64(cs.Hits) / float64(total)
+	}
+	return 0
+}
+
+func (c *Cache) stats() cacheStats {
+	return cacheStats{
+		Items:     c.len(),
+		Bytes:     c.bytes.Load(),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// cacheStats sums the cache statistics of the server and its subsites.
+func (s *Server) cacheStats() cacheStats {
+	var total cacheStats
+	for _, srv := range s.servers() {
+		cs := srv.cache.stats()
+		total.Items += cs.Items
+		total.Bytes += cs.Bytes
+		total.Hits += cs.Hits
+		total.Misses += cs.Misses
+		total.Evictions += cs.Evictions
+	}
+	return total
+}
+
+// startCacheStatsLogger logs a summary of the cache statistics every
+// interval, unless no page was requested since the last one.
+func (s *Server) startCacheStatsLogger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last cacheStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs := s.cacheStats()
+			if cs.Hits == last.Hits && cs.Misses == last.Misses {
+				continue
+			}
+			last = cs
+			logCacheStats(cs)
+		}
+	}
+}
+
+func logCacheStats(cs cacheStats) {
+	slog.Info("Cache statistics",
+		"hits", cs.Hits,
+		"misses", cs.Misses,
+		"hit_ratio", float64(int(cs.hitRatio()*1000))/1000,
+		"evictions", cs.Evictions,
+		"items", cs.Items,
+		"bytes", cs.Bytes,
+	)
+}
@@ -0,0 +1,63 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheStats(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.MaxCacheItems = 1
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+	get := func(target string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", target, w.Code)
+		}
+	}
+
+	get("/about")
+	get("/about")
+	cs := srv.cacheStats()
+	if cs.Hits != 1 || cs.Misses != 1 || cs.Evictions != 0 || cs.Items != 1 {
+		t.Errorf("After two requests: %+v", cs)
+	}
+	item, _ := srv.cachedPage("/about")
+	if cs.Bytes != item.size() || cs.Bytes == 0 {
+		t.Errorf("Expected %d bytes, got %d", item.size(), cs.Bytes)
+	}
+	if r := cs.hitRatio(); r != 0.5 {
+		t.Errorf("Expected a hit ratio of 0.5, got %v", r)
+	}
+
+	// The second page replaces the first one (max_cache_items = 1)
+	get("/sub/deep")
+	cs = srv.cacheStats()
+	deep, _ := srv.cachedPage("/sub/deep")
+	if cs.Evictions != 1 || cs.Items != 1 || cs.Bytes != deep.size() {
+		t.Errorf("After an eviction: %+v", cs)
+	}
+
+	// Invalidation is not an eviction, but releases the bytes
+	srv.invalidateFiles([]string{filepath.Join(dir, "sub", "deep.md")})
+	if cs = srv.cacheStats(); cs.Evictions != 1 || cs.Items != 0 || cs.Bytes != 0 {
+		t.Errorf("After an invalidation: %+v", cs)
+	}
+
+	get("/about")
+	srv.purgeCache()
+	if cs = srv.cacheStats(); cs.Items != 0 || cs.Bytes != 0 || cs.Misses != 3 {
+		t.Errorf("After a purge: %+v", cs)
+	}
+	if r := (cacheStats{}).hitRatio(); r != 0 {
+		t.Errorf("Expected no hit ratio without requests, got %v", r)
+	}
+}
@@ -0,0 +1,192 @@
+package gomadore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// --- Content Check (-check) ---
+
+// checkSite checks every markdown file under markdown_rootdir, drafts
+// included: the front matter (syntax and the values gomadore interprets),
+// rendering with the template, and links and images to the site itself. It
+// writes one line per problem to w and returns the number of problems.
+func (s *Server) checkSite(w io.Writer) (int, error) {
+	st := s.siteForHost("")
+	routes := s.hostRoutes()
+	problems := 0
+	report := func(file, format string, args ...any) {
+		problems++
+		fmt.Fprintf(w, "%s: %s\n", file, fmt.Sprintf(format, args...))
+	}
+
+	files := 0
+	err := walkMarkdown(s.config.HTML.MarkdownRootDir, s.config.HTML.MarkdownExts, func(root, rel string) error {
+		files++
+		file := filepath.Join(root, filepath.FromSlash(rel))
+		p, doc, _, err := readPage(s.md, root, rel, s.config.HTML.StrictHtmlUrl, s.config.HTML.SourceEncoding)
+		if err != nil {
+			report(file, "%v", errors.Unwrap(err))
+			return nil
+		}
+		for _, msg := range s.checkFrontMatter(p.Meta) {
+			report(file, "front matter: %s", msg)
+		}
+		if !p.Draft || s.config.HTML.ShowDrafts {
+			if _, err := s.renderPage(st, p.Path); err != nil {
+				report(file, "render: %v", err)
+			}
+		}
+		for _, dest := range linkDestinations(doc) {
+			target, ok := internalLink(p.URL, dest)
+			if !ok {
+				continue
+			}
+			if code := checkTarget(routes, target); code >= http.StatusBadRequest {
+				report(file, "broken link %q (%d %s)", dest, code, http.StatusText(code))
+			}
+		}
+		for _, target := range wikiTargets(doc) {
+			if _, ok := s.wiki.resolve(target); !ok {
+				report(file, "broken wiki link [[%s]]", target)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return problems, err
+	}
+	fmt.Fprintf(w, "%d problem(s) in %d file(s)\n", problems, files)
+	return problems, nil
+}
+
+// checkFrontMatter returns the problems of the front matter values gomadore
+// interprets (the same values are logged and ignored when serving).
+func (s *Server) checkFrontMatter(meta map[string]any) []string {
+	var msgs []string
+	for _, key := range []string{"title", "description", "author", "template"} {
+		switch meta[key].(type) {
+		case nil, string:
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s: must be a string", key))
+		}
+	}
+	if _, ok := meta["date"]; ok {
+		if _, ok := metaTime(meta, "date"); !ok {
+			msgs = append(msgs, fmt.Sprintf("date: cannot parse %q", metaString(meta, "date")))
+		}
+	}
+	if v, ok := meta["draft"]; ok {
+		if _, isBool := v.(bool); !isBool {
+			if _, err := strconv.ParseBool(metaString(meta, "draft")); err != nil {
+				msgs = append(msgs, "draft: must be true or false")
+			}
+		}
+	}
+	if v, ok := meta["weight"]; ok && metaInt(meta, "weight") == 0 && metaString(meta, "weight") != "0" {
+		msgs = append(msgs, fmt.Sprintf("weight: must be an integer, got %v", v))
+	}
+	for _, key := range []string{"tags", "aliases"} {
+		switch meta[key].(type) {
+		case nil, string, []any:
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s: must be a list or a comma separated string", key))
+		}
+	}
+	if name := metaString(meta, "template"); name != "" && s.tmpl != nil &&
+		s.tmpl.Lookup(name) == nil && s.tmpl.Lookup(name+".html") == nil {
+		msgs = append(msgs, fmt.Sprintf("template: unknown template %q", name))
+	}
+
+	switch v := meta["sitemap"].(type) {
+	case nil, bool:
+	case map[string]any:
+		if pr, ok := v["priority"]; ok {
+			if f, err := strconv.ParseFloat(fmt.Sprint(pr), 64); err != nil || f < 0 || f > 1 {
+				msgs = append(msgs, fmt.Sprintf("sitemap.priority: must be between 0.0 and 1.0, got %v", pr))
+			}
+		}
+		if freq := strings.ToLower(metaString(v, "changefreq")); freq != "" && !slices.Contains(sitemapChangeFreqs, freq) {
+			msgs = append(msgs, fmt.Sprintf("sitemap.changefreq: must be one of %s, got %q", strings.Join(sitemapChangeFreqs, ", "), freq))
+		}
+	default:
+		msgs = append(msgs, "sitemap: must be a boolean or a table")
+	}
+	return msgs
+}
+
+// linkDestinations returns the destinations of the links and images of a
+// parsed document (after the .md link rewriting).
+func linkDestinations(doc ast.Node) []string {
+	var dests []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch l := n.(type) {
+		case *ast.Link:
+			dests = append(dests, string(l.Destination))
+		case *ast.Image:
+			dests = append(dests, string(l.Destination))
+		}
+		return ast.WalkContinue, nil
+	})
+	return dests
+}
+
+// internalLink resolves a link of the page at pageURL to the path it
+// requests on this site. It returns false for links to other sites, other
+// schemes and fragments of the page itself.
+func internalLink(pageURL, dest string) (string, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	base := &url.URL{Path: pageURL}
+	return base.ResolveReference(&url.URL{Path: u.Path}).Path, true
+}
+
+// checkTarget requests a path from the routes of the server and returns
+// the status code (redirects count as resolved).
+func checkTarget(routes http.Handler, target string) int {
+	req, err := http.NewRequest(http.MethodGet, (&url.URL{Path: target}).String(), nil)
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	w := &discardWriter{header: make(http.Header)}
+	routes.ServeHTTP(w, req)
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// discardWriter is a ResponseWriter that keeps only the status code.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header { return w.header }
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
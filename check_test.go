@@ -0,0 +1,58 @@
+package gomadore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCheckSite(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "good.md", "---\ntitle: Good\ndate: 2026-01-02\ntags: [a, b]\n---\n[About](about.md) [Deep](sub/deep) [Top](/) [Self](#x) [Ext](https://example.com/missing)\n")
+	createFile(t, dir, "bad.md", "---\ndate: next week\nweight: heavy\nsitemap:\n  priority: 2\n---\n[Missing](./missing) ![Image](/img/none.png)\n")
+	createFile(t, dir, "broken.md", "---\ntitle: [unclosed\n---\n# Broken\n")
+
+	var buf bytes.Buffer
+	n, err := srv.checkSite(&buf)
+	if err != nil {
+		t.Fatalf("checkSite failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"bad.md: front matter: date: cannot parse \"next week\"",
+		"bad.md: front matter: weight: must be an integer",
+		"bad.md: front matter: sitemap.priority",
+		"bad.md: broken link \"./missing\" (404 Not Found)",
+		"bad.md: broken link \"/img/none.png\"",
+		"broken.md: invalid front matter",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the report:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "good.md") {
+		t.Errorf("Expected no problems in good.md:\n%s", out)
+	}
+	if n != 6 || !strings.Contains(out, "6 problem(s) in ") {
+		t.Errorf("Expected 6 problems, got %d:\n%s", n, out)
+	}
+}
+
+func TestInternalLink(t *testing.T) {
+	tests := []struct {
+		page, dest, want string
+		ok               bool
+	}{
+		{"/sub/deep", "other", "/sub/other", true},
+		{"/sub/", "../about?x=1#top", "/about", true},
+		{"/about", "/img/a.png", "/img/a.png", true},
+		{"/about", "#section", "", false},
+		{"/about", "mailto:a@example.com", "", false},
+		{"/about", "//cdn.example.com/a.js", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := internalLink(tt.page, tt.dest); got != tt.want || ok != tt.ok {
+			t.Errorf("internalLink(%q, %q) = %q, %v; want %q, %v", tt.page, tt.dest, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build embed
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/kumakaba/gomadore"
+)
+
+// content is the site served with content_source = "embedded". Copy the
+// markdown tree to cmd/gomadore/content before building with -tags embed.
+//
+//go:embed all:content
+var content embed.FS
+
+func init() {
+	sub, err := fs.Sub(content, "content")
+	if err != nil {
+		panic(err)
+	}
+	gomadore.EmbeddedContent = sub
+}
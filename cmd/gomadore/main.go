@@ -0,0 +1,10 @@
+// Command gomadore serves a directory of Markdown files as HTML.
+//
+// See the README for the command line options and the configuration file.
+package main
+
+import "github.com/kumakaba/gomadore"
+
+func main() {
+	gomadore.Main()
+}
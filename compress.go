@@ -0,0 +1,68 @@
+package gomadore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// Pages smaller than this are not worth compressing
+const gzipMinSize = 256
+
+// --- Response Compression ---
+
+// gzipPage returns the gzip compressed content, or nil if the content is too
+// small or does not shrink.
+func gzipPage(content []byte) []byte {
+	if len(content) < gzipMinSize {
+		return nil
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression) // compressed once, served from the cache
+	if _, err := zw.Write(content); err != nil {
+		return nil
+	}
+	if err := zw.Close(); err != nil || buf.Len() >= len(content) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// gunzipPage decompresses a page compressed by gzipPage; size is the length
+// of the plain content.
+func gunzipPage(gz []byte, size int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err := buf.ReadFrom(zr); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipETag derives the entity tag of the gzip representation; it must differ
+// from the tag of the plain content.
+func gzipETag(etag string) string {
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// negotiatePage selects the representation of a cached page: the gzip bytes
+// if there are any and the client accepts them, otherwise the plain content
+// (decompressed if only the gzip bytes are kept). It sets the response
+// headers (except Content-Type, which the caller sets so that the compressed
+// bytes are not sniffed) and returns the body and its entity tag.
+func negotiatePage(w http.ResponseWriter, r *http.Request, item CacheItem, etag string) ([]byte, string, error) {
+	if item.Gzip == nil {
+		return item.Content, etag, nil
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") {
+		content, err := item.plain()
+		return content, etag, err
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	return item.Gzip, gzipETag(etag), nil
+}
@@ -0,0 +1,118 @@
+package gomadore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipPage(t *testing.T) {
+	if gz := gzipPage([]byte("<p>short</p>")); gz != nil {
+		t.Errorf("Expected no compression for small content, got %d bytes", len(gz))
+	}
+
+	content := []byte(strings.Repeat("<p>Hello World</p>\n", 100))
+	gz := gzipPage(content)
+	if gz == nil || len(gz) >= len(content) {
+		t.Fatalf("Expected compressed content, got %d bytes", len(gz))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if !bytes.Equal(plain, content) {
+		t.Error("Decompressed content mismatch")
+	}
+	if plain, err := gunzipPage(gz, len(content)); err != nil || !bytes.Equal(plain, content) {
+		t.Errorf("gunzipPage mismatch: %v", err)
+	}
+	if _, err := gunzipPage(content, len(content)); err == nil {
+		t.Error("Expected an error for plain content")
+	}
+}
+
+func TestHandleRequestGzip(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.Gzip = true
+	long := strings.TrimSpace(strings.Repeat("Lorem ipsum dolor sit amet. ", 50))
+	createFile(t, dir, "long.md", "# Long\n"+long)
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/long", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	for _, cache := range []string{"MISS", "HIT"} {
+		w := get("gzip, deflate", "")
+		if got := w.Header().Get("X-Cache"); got != cache {
+			t.Fatalf("Expected X-Cache %s, got %s", cache, got)
+		}
+		if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("%s: unexpected headers %v", cache, w.Header())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: unexpected Content-Type %q", cache, ct)
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("%s: body is not gzip: %v", cache, err)
+		}
+		plain, _ := io.ReadAll(zr)
+		if !strings.Contains(string(plain), long) {
+			t.Errorf("%s: decompressed body mismatch", cache)
+		}
+	}
+
+	// Only the compressed page is kept in memory
+	item, _ := srv.cachedPage("/long")
+	if item.Content != nil || item.Gzip == nil || item.Size <= len(item.Gzip) {
+		t.Errorf("Expected only gzip bytes in the cache, got %d plain and %d gzip bytes (size %d)", len(item.Content), len(item.Gzip), item.Size)
+	}
+
+	t.Run("Not accepted", func(t *testing.T) {
+		w := get("br, gzip;q=0", "")
+		if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Unexpected headers %v", w.Header())
+		}
+		if !strings.Contains(w.Body.String(), long) {
+			t.Error("Expected plain body")
+		}
+	})
+
+	t.Run("ETag per representation", func(t *testing.T) {
+		plainTag := get("", "").Header().Get("ETag")
+		gzipTag := get("gzip", "").Header().Get("ETag")
+		if plainTag == gzipTag {
+			t.Fatalf("Expected different ETags, got %s", plainTag)
+		}
+		if w := get("gzip", gzipTag); w.Code != http.StatusNotModified {
+			t.Errorf("Expected 304, got %d", w.Code)
+		}
+		if w := get("gzip", plainTag); w.Code != http.StatusOK {
+			t.Errorf("Expected 200 for the plain ETag, got %d", w.Code)
+		}
+	})
+
+	t.Run("Small page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/about", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Error("Small pages should not be compressed")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package gomadore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Conditional Requests ---
+
+// pageETag returns a strong entity tag for rendered page content.
+func pageETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches the entity tag
+// (weak comparison, as required for If-None-Match; "*" matches any tag).
+func etagMatches(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiedSince reports whether a resource modified at modTime is newer than
+// an If-Modified-Since header (HTTP dates have a resolution of one second).
+// An invalid header counts as modified.
+func modifiedSince(header string, modTime time.Time) bool {
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return true
+	}
+	return modTime.Truncate(time.Second).After(t)
+}
+
+// notModified sets the ETag and Last-Modified (unless modTime is zero) headers
+// and answers 304 Not Modified if the request's If-None-Match matches the
+// ETag, or, without If-None-Match, if the page has not been modified since
+// If-Modified-Since. It returns true if the response is done.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modTime time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2)
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else {
+		ims := r.Header.Get("If-Modified-Since")
+		if ims == "" || modTime.IsZero() || modifiedSince(ims, modTime) {
+			return false
+		}
+	}
+	// A 304 response carries no body (and no body related headers)
+	w.Header().Del("Content-Type")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// servePage answers with a rendered page (body is the variant chosen by
+// negotiatePage) through http.ServeContent, which handles the conditional
+// headers (If-None-Match, If-Modified-Since, If-Match, If-Unmodified-Since)
+// and byte ranges (Range, If-Range). modTime may be zero.
+func servePage(w http.ResponseWriter, r *http.Request, body []byte, etag string, modTime time.Time) {
+	w.Header().Set("ETag", etag)
+	http.ServeContent(contentLengthWriter{w, len(body)}, r, "", modTime, bytes.NewReader(body))
+}
+
+// contentLengthWriter sets the Content-Length of a complete response, which
+// http.ServeContent leaves out when Content-Encoding is set, so that HEAD
+// requests of compressed pages get it as well.
+type contentLengthWriter struct {
+	http.ResponseWriter
+	size int
+}
+
+func (w contentLengthWriter) WriteHeader(code int) {
+	if code == http.StatusOK && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w contentLengthWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,192 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{`"abcd"`, false},
+		{`abc`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPageETag(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	get := func(t *testing.T, inm string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	// Cache miss
+	w := get(t, "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || etag != pageETag(w.Body.Bytes()) {
+		t.Fatalf("Expected 200 with ETag of the body, got %d %q", w.Code, etag)
+	}
+
+	// Cache hit: same ETag
+	w = get(t, "")
+	if w.Header().Get("X-Cache") != "HIT" || w.Header().Get("ETag") != etag {
+		t.Errorf("ETag mismatch on cache hit: got %q, want %q", w.Header().Get("ETag"), etag)
+	}
+
+	// Matching If-None-Match
+	w = get(t, etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected empty 304, got %d (%d bytes)", w.Code, w.Body.Len())
+	}
+	if w.Header().Get("ETag") != etag || w.Header().Get("Cache-Control") == "" {
+		t.Errorf("304 should keep ETag and Cache-Control: %v", w.Header())
+	}
+
+	// Stale If-None-Match
+	if w = get(t, `"stale"`); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for stale ETag, got %d", w.Code)
+	}
+
+	// Cache miss after purge answers 304 as well (content unchanged)
+	srv.purgeCache()
+	if w = get(t, etag); w.Code != http.StatusNotModified || w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("Expected 304 on cache miss, got %d %s", w.Code, w.Header().Get("X-Cache"))
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	mtime := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "about.md"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(t *testing.T, header, value string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	const lastModified = "Thu, 02 Jan 2025 03:04:05 GMT"
+	for _, cache := range []string{"MISS", "HIT"} {
+		w := get(t, "", "")
+		if w.Header().Get("X-Cache") != cache || w.Header().Get("Last-Modified") != lastModified {
+			t.Errorf("%s: Last-Modified mismatch: %q", cache, w.Header().Get("Last-Modified"))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"Same time", "If-Modified-Since", lastModified, http.StatusNotModified},
+		{"Later", "If-Modified-Since", "Fri, 03 Jan 2025 00:00:00 GMT", http.StatusNotModified},
+		{"Earlier", "If-Modified-Since", "Wed, 01 Jan 2025 00:00:00 GMT", http.StatusOK},
+		{"Invalid date", "If-Modified-Since", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(t, tt.header, tt.value); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+
+	t.Run("If-None-Match takes precedence", func(t *testing.T) {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		req.Header.Set("If-None-Match", `"stale"`)
+		req.Header.Set("If-Modified-Since", lastModified)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+}
+
+func TestPageRanges(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "about.md", "# About\n\n"+strings.Repeat("A long page worth compressing. ", 100))
+	srv.config.Cache.Gzip = true
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/about", nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, r)
+		return w
+	}
+
+	full := do(http.MethodGet, nil)
+	etag := full.Header().Get("ETag")
+	if full.Code != http.StatusOK || full.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("GET: %d, Accept-Ranges %q", full.Code, full.Header().Get("Accept-Ranges"))
+	}
+
+	for range 2 { // rendered, then from the cache
+		w := do(http.MethodGet, map[string]string{"Range": "bytes=0-9"})
+		if w.Code != http.StatusPartialContent || w.Body.String() != full.Body.String()[:10] {
+			t.Errorf("Range: %d %q", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Range"); got != "bytes 0-9/"+strconv.Itoa(full.Body.Len()) {
+			t.Errorf("Content-Range: %s", got)
+		}
+	}
+
+	// A stale If-Range gets the whole page
+	if w := do(http.MethodGet, map[string]string{"Range": "bytes=0-9", "If-Range": `"old"`}); w.Code != http.StatusOK || w.Body.Len() != full.Body.Len() {
+		t.Errorf("Stale If-Range: %d, %d bytes", w.Code, w.Body.Len())
+	}
+	if w := do(http.MethodGet, map[string]string{"Range": "bytes=0-9", "If-Range": etag}); w.Code != http.StatusPartialContent {
+		t.Errorf("Current If-Range: %d", w.Code)
+	}
+	if w := do(http.MethodGet, map[string]string{"Range": "bytes=999999-"}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Unsatisfiable range: %d", w.Code)
+	}
+	if w := do(http.MethodGet, map[string]string{"If-Match": `"other"`}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("If-Match: %d", w.Code)
+	}
+
+	// The compressed variant keeps its Content-Length, also for HEAD
+	w := do(http.MethodHead, map[string]string{"Accept-Encoding": "gzip"})
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") == "" {
+		t.Errorf("HEAD gzip: Content-Encoding %q, Content-Length %q", w.Header().Get("Content-Encoding"), w.Header().Get("Content-Length"))
+	}
+}
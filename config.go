@@ -0,0 +1,300 @@
+package gomadore
+
+import (
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+// Fully commented configuration with every option and its default,
+// written by "gomadore config init".
+//
+//go:embed config.sample.toml
+var configScaffold []byte
+
+// loadConfig reads and validates a configuration file and applies the defaults.
+func loadConfig(path string) (Config, error) {
+	var cfg Config
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		return cfg, err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return cfg, err
+	}
+	if err := checkListener(cfg); err != nil {
+		return cfg, fmt.Errorf("validation failed: %w", err)
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.useEmbeddedContent(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// validateConfig checks the validate tags of a configuration, except for the
+// given fields (e.g. "General.ListenPort"). Errors name the options by their
+// toml keys.
+func validateConfig(cfg Config, except ...string) error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		// get toml-tag
+		name := strings.SplitN(fld.Tag.Get("toml"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := validate.StructExcept(cfg, except...); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// applyDefaults fills unset options with their default values.
+func (c *Config) applyDefaults() {
+	if c.General.LogLevel == "" {
+		c.General.LogLevel = "info"
+	}
+	if c.General.LogType == "" {
+		c.General.LogType = "text"
+	}
+	if c.General.SocketMode == "" {
+		c.General.SocketMode = defaultSocketMode
+	}
+	for _, t := range []struct {
+		opt *int
+		def int
+	}{
+		{&c.General.ReadHeaderTimeout, defaultReadHeaderTimeout},
+		{&c.General.ReadTimeout, defaultReadTimeout},
+		{&c.General.WriteTimeout, defaultWriteTimeout},
+		{&c.General.IdleTimeout, defaultIdleTimeout},
+		{&c.General.MaxHeaderBytes, defaultMaxHeaderBytes},
+	} {
+		if *t.opt == 0 {
+			*t.opt = t.def
+		}
+	}
+
+	if c.HTML.PageOrder == "" {
+		c.HTML.PageOrder = pageOrderFilename
+	}
+	if c.HTML.ContentSource == "" {
+		c.HTML.ContentSource = contentSourceDirectory
+	}
+	if len(c.HTML.MarkdownExts) == 0 {
+		c.HTML.MarkdownExts = []string{defaultMarkdownExt}
+	}
+	if c.HTML.SourceEncoding == "" {
+		c.HTML.SourceEncoding = sourceEncodingUTF8
+	}
+	if c.HTML.Charset == "" {
+		c.HTML.Charset = sourceEncodingUTF8
+	}
+
+	if c.Cache.CacheLimit < 0 {
+		c.Cache.CacheLimit = 0
+	}
+	if c.Cache.WatchDebounceMs == 0 {
+		c.Cache.WatchDebounceMs = defaultWatchDebounceMs
+	}
+	if c.Cache.WatchMode == "" {
+		c.Cache.WatchMode = watchModeFsnotify
+	}
+	if c.Cache.WatchPollIntervalMs == 0 {
+		c.Cache.WatchPollIntervalMs = defaultWatchPollIntervalMs
+	}
+	if c.Cache.MaxCacheItems < 1 {
+		c.Cache.MaxCacheItems = 1000
+	}
+
+	if c.Favicon.MaxAge == 0 {
+		c.Favicon.MaxAge = defaultFaviconMaxAge
+	}
+
+	if c.Manifest.Name == "" {
+		c.Manifest.Name = c.HTML.SiteTitle
+	}
+	if c.Manifest.StartURL == "" {
+		c.Manifest.StartURL = "/"
+	}
+	if c.Manifest.Display == "" {
+		c.Manifest.Display = "standalone"
+	}
+	if len(c.Manifest.IconSizes) == 0 {
+		c.Manifest.IconSizes = slices.Clone(defaultIconSizes)
+	}
+
+	if c.Feed.Title == "" {
+		c.Feed.Title = c.HTML.SiteTitle
+	}
+	if c.Feed.MaxEntries <= 0 {
+		c.Feed.MaxEntries = defaultFeedMaxEntries
+	}
+
+	if c.Webmention.Timeout <= 0 {
+		c.Webmention.Timeout = defaultWebmentionTimeout
+	}
+
+	if c.Search.MaxResults <= 0 {
+		c.Search.MaxResults = defaultSearchMaxResults
+	}
+
+	if c.Sanitize.Policy == "" {
+		c.Sanitize.Policy = defaultSanitizePolicy
+	}
+
+	if c.TOC.MinLevel == 0 {
+		c.TOC.MinLevel = defaultTOCMinLevel
+	}
+	if c.TOC.MaxLevel == 0 {
+		c.TOC.MaxLevel = defaultTOCMaxLevel
+	}
+
+	if c.Template.MissingKey == "" {
+		c.Template.MissingKey = "default"
+	}
+	if c.Template.RenderTimeout == 0 {
+		c.Template.RenderTimeout = defaultRenderTimeout
+	}
+	if c.Template.MaxOutputBytes == 0 {
+		c.Template.MaxOutputBytes = defaultMaxOutputBytes
+	}
+
+	if c.Metrics.ListenAddr == "" {
+		c.Metrics.ListenAddr = defaultMetricsListenAddr
+	}
+	if c.Metrics.ListenPort == 0 {
+		c.Metrics.ListenPort = defaultMetricsListenPort
+	}
+
+	if c.Auth.Realm == "" {
+		c.Auth.Realm = defaultAuthRealm
+	}
+
+	if c.AccessLog.Format == "" {
+		c.AccessLog.Format = defaultAccessLogFormat
+	}
+
+	if c.TLS.Mode == "" {
+		c.TLS.Mode = tlsModeFile
+	}
+	if c.TLS.ACMECacheDir == "" {
+		c.TLS.ACMECacheDir = defaultACMECacheDir
+	}
+	if c.TLS.MinVersion == "" {
+		c.TLS.MinVersion = defaultTLSMinVersion
+	}
+	if c.TLS.HTTPPort == 0 {
+		c.TLS.HTTPPort = defaultTLSHTTPPort
+	}
+
+	if c.IndexNow.Endpoint == "" {
+		c.IndexNow.Endpoint = defaultIndexNowEndpoint
+	}
+	if c.IndexNow.Debounce <= 0 {
+		c.IndexNow.Debounce = defaultIndexNowDebounce
+	}
+	if c.IndexNow.Timeout <= 0 {
+		c.IndexNow.Timeout = defaultIndexNowTimeout
+	}
+}
+
+// Printed by "config dump" instead of a secret
+const redacted = "***"
+
+// redactSecrets replaces the passwords, tokens and password hashes of a
+// configuration (unless empty) with redacted, keeping the user names.
+func redactSecrets(c *Config) {
+	for _, v := range []*string{
+		&c.Cache.Redis.Password,
+		&c.Git.Token,
+		&c.Auth.OIDC.ClientSecret,
+		&c.Auth.OIDC.CookieSecret,
+	} {
+		if *v != "" {
+			*v = redacted
+		}
+	}
+	for _, users := range [][]string{c.Auth.Users, c.Edit.Users} {
+		for i, u := range users {
+			name, _, _ := strings.Cut(u, ":")
+			users[i] = name + ":" + redacted
+		}
+	}
+}
+
+// runConfigCommand implements "gomadore config init|dump".
+func runConfigCommand(args []string, stdout io.Writer) error {
+	usage := errors.New("usage: gomadore config init [-o config.toml] [-f] | gomadore config dump [-c config.toml] [-show-secrets]")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "init":
+		fset := flag.NewFlagSet("config init", flag.ContinueOnError)
+		out := fset.String("o", "config.toml", `Output file ("-" for stdout)`)
+		force := fset.Bool("f", false, "Overwrite an existing file")
+		if err := fset.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *out == "-" {
+			_, err := stdout.Write(configScaffold)
+			return err
+		}
+
+		mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if !*force {
+			mode |= os.O_EXCL
+		}
+		f, err := os.OpenFile(*out, mode, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists (use -f to overwrite)", *out)
+		} else if err != nil {
+			return err
+		}
+		if _, err := f.Write(configScaffold); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "Wrote %s\n", *out)
+		return err
+
+	case "dump":
+		fset := flag.NewFlagSet("config dump", flag.ContinueOnError)
+		configPath := fset.String("c", "config.toml", "Path to configuration file")
+		showSecrets := fset.Bool("show-secrets", false, "Print passwords, tokens and password hashes")
+		if err := fset.Parse(args[1:]); err != nil {
+			return err
+		}
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *configPath, err)
+		}
+		if !*showSecrets {
+			redactSecrets(&cfg)
+		}
+		if _, err := fmt.Fprintf(stdout, "# Effective configuration of %s (defaults applied)\n", *configPath); err != nil {
+			return err
+		}
+		return toml.NewEncoder(stdout).Encode(cfg)
+
+	default:
+		return usage
+	}
+}
@@ -0,0 +1,228 @@
+package gomadore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configKeys returns the dotted toml keys of every option in a config struct type
+func configKeys(typ reflect.Type, prefix string) []string {
+	var keys []string
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name := strings.SplitN(f.Tag.Get("toml"), ",", 2)[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(f.Type, prefix+name+".")...)
+			continue
+		}
+		if f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct {
+			// Arrays of tables are documented as commented out examples
+			keys = append(keys, "[["+prefix+name+"]]")
+			keys = append(keys, configKeys(f.Type.Elem(), "[["+prefix+name+"]].")...)
+			continue
+		}
+		keys = append(keys, prefix+name)
+	}
+	return keys
+}
+
+func TestConfigScaffold(t *testing.T) {
+	var cfg Config
+	md, err := toml.Decode(string(configScaffold), &cfg)
+	if err != nil {
+		t.Fatalf("Scaffold is not valid TOML: %v", err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		t.Errorf("Scaffold has unknown keys: %v", undecoded)
+	}
+
+	// Every option must be documented in the scaffold
+	for _, key := range configKeys(reflect.TypeFor[Config](), "") {
+		if table, option, ok := strings.Cut(key, "]]."); ok {
+			if !bytes.Contains(configScaffold, []byte("\n#"+option+" =")) {
+				t.Errorf("Option %s]].%s is missing in config.sample.toml", table, option)
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "[[") {
+			if !bytes.Contains(configScaffold, []byte("\n#"+key+"\n")) {
+				t.Errorf("Table %s is missing in config.sample.toml", key)
+			}
+			continue
+		}
+		if !md.IsDefined(strings.Split(key, ".")...) {
+			t.Errorf("Option %s is missing in config.sample.toml", key)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Defaults", func(t *testing.T) {
+		path := filepath.Join(dir, "min.toml")
+		createFile(t, dir, "min.toml", "[general]\nlisten_addr = \"127.0.0.1\"\nlisten_port = 8080\n[html]\nmarkdown_rootdir = \"./docs\"\nsite_title = \"My Site\"\n[cache]\ncache_limit = -5\n")
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.General.LogLevel != "info" || cfg.General.LogType != "text" {
+			t.Errorf("Log defaults mismatch: %+v", cfg.General)
+		}
+		if cfg.Cache.CacheLimit != 0 || cfg.Cache.MaxCacheItems != 1000 {
+			t.Errorf("Cache defaults mismatch: %+v", cfg.Cache)
+		}
+		if cfg.Feed.Title != "My Site" || cfg.Feed.MaxEntries != defaultFeedMaxEntries {
+			t.Errorf("Feed defaults mismatch: %+v", cfg.Feed)
+		}
+		if !slices.Equal(cfg.Manifest.IconSizes, defaultIconSizes) {
+			t.Errorf("Icon sizes mismatch: %v", cfg.Manifest.IconSizes)
+		}
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.toml")
+		createFile(t, dir, "invalid.toml", "[general]\nlog_level = \"verbose\"\n")
+		if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "log_level") {
+			t.Errorf("Expected validation error for log_level, got %v", err)
+		}
+	})
+
+	t.Run("TLS requires certificate", func(t *testing.T) {
+		path := filepath.Join(dir, "tls.toml")
+		createFile(t, dir, "tls.toml", "[general]\nlisten_addr = \"127.0.0.1\"\nlisten_port = 8443\n[html]\nmarkdown_rootdir = \"./docs\"\n[tls]\nenabled = true\n")
+		if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "cert_file") {
+			t.Errorf("Expected validation error for cert_file, got %v", err)
+		}
+	})
+
+	t.Run("ACME requires domains", func(t *testing.T) {
+		path := filepath.Join(dir, "acme.toml")
+		createFile(t, dir, "acme.toml", "[general]\nlisten_addr = \"127.0.0.1\"\nlisten_port = 443\n[html]\nmarkdown_rootdir = \"./docs\"\n[tls]\nenabled = true\nmode = \"acme\"\n")
+		if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "acme_domains") {
+			t.Errorf("Expected validation error for acme_domains, got %v", err)
+		}
+
+		createFile(t, dir, "acme.toml", "[general]\nlisten_addr = \"127.0.0.1\"\nlisten_port = 443\n[html]\nmarkdown_rootdir = \"./docs\"\n[tls]\nenabled = true\nmode = \"acme\"\nacme_domains = [\"docs.example.com\"]\n")
+		cfg, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.TLS.ACMECacheDir != defaultACMECacheDir {
+			t.Errorf("ACME cache default mismatch: %q", cfg.TLS.ACMECacheDir)
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		if _, err := loadConfig(filepath.Join(dir, "none.toml")); err == nil {
+			t.Error("Expected error for missing file")
+		}
+	})
+}
+
+func TestRunConfigCommand(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "config.toml")
+
+	t.Run("init", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := runConfigCommand([]string{"init", "-o", out}, &buf); err != nil {
+			t.Fatalf("init failed: %v", err)
+		}
+		written, err := os.ReadFile(out)
+		if err != nil || !bytes.Equal(written, configScaffold) {
+			t.Errorf("Written file should equal the scaffold (err=%v)", err)
+		}
+
+		// Refuse to overwrite without -f
+		if err := runConfigCommand([]string{"init", "-o", out}, &buf); err == nil {
+			t.Error("Expected error for existing file")
+		}
+		if err := runConfigCommand([]string{"init", "-o", out, "-f"}, &buf); err != nil {
+			t.Errorf("init -f failed: %v", err)
+		}
+
+		buf.Reset()
+		if err := runConfigCommand([]string{"init", "-o", "-"}, &buf); err != nil || !bytes.Equal(buf.Bytes(), configScaffold) {
+			t.Errorf("init -o - should print the scaffold (err=%v)", err)
+		}
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := runConfigCommand([]string{"dump", "-c", out}, &buf); err != nil {
+			t.Fatalf("dump failed: %v", err)
+		}
+		// Output is a valid configuration with the same values
+		var cfg Config
+		if _, err := toml.Decode(buf.String(), &cfg); err != nil {
+			t.Fatalf("Dump is not valid TOML: %v", err)
+		}
+		want, _ := loadConfig(out)
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Dumped config mismatch:\n got: %+v\nwant: %+v", cfg, want)
+		}
+	})
+
+	t.Run("dump secrets", func(t *testing.T) {
+		secret := filepath.Join(dir, "secret.toml")
+		createFile(t, dir, "secret.toml", `[general]
+listen_addr = "127.0.0.1"
+listen_port = 18085
+[html]
+markdown_rootdir = "`+dir+`"
+[auth]
+users = ["alice:$2y$05$hashhashhash"]
+[auth.oidc]
+client_secret = "oidc-client-secret"
+cookie_secret = "oidc-cookie-secret"
+[git]
+token = "git-token"
+[cache.redis]
+password = "redis-password"
+`)
+		var buf bytes.Buffer
+		if err := runConfigCommand([]string{"dump", "-c", secret}, &buf); err != nil {
+			t.Fatalf("dump failed: %v", err)
+		}
+		for _, s := range []string{"hashhash", "oidc-client-secret", "oidc-cookie-secret", "git-token", "redis-password"} {
+			if strings.Contains(buf.String(), s) {
+				t.Errorf("Dump shows the secret %q", s)
+			}
+		}
+		if !strings.Contains(buf.String(), `"alice:***"`) || !strings.Contains(buf.String(), `token = "***"`) {
+			t.Errorf("Expected redacted values:\n%s", buf.String())
+		}
+
+		buf.Reset()
+		if err := runConfigCommand([]string{"dump", "-c", secret, "-show-secrets"}, &buf); err != nil {
+			t.Fatalf("dump -show-secrets failed: %v", err)
+		}
+		var cfg Config
+		if _, err := toml.Decode(buf.String(), &cfg); err != nil {
+			t.Fatalf("Dump is not valid TOML: %v", err)
+		}
+		if want, _ := loadConfig(secret); !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Dumped config mismatch:\n got: %+v\nwant: %+v", cfg, want)
+		}
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"unknown"}} {
+			if err := runConfigCommand(args, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "usage") {
+				t.Errorf("%v: expected usage error, got %v", args, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package gomadore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// --- Disk Cache ---
+
+// diskCache keeps rendered pages in cache_dir, so that a restarted server
+// does not start cold. A page is stored in a file named by a hash of its
+// cache key and the modification time of its markdown file, under a
+// directory of the site (a hash of the content roots, the configuration and
+// the templates) and of the generation of the content (a hash of the names,
+// sizes and modification times of the files under the content roots). Pages
+// show each other (navigation, backlinks, listings), so any change to the
+// content starts a new generation, and the files of older ones are removed.
+//
+// A page is read from disk at most once per run, in place of its first
+// render. Later renders (after expiry or a change) replace the file.
+type diskCache struct {
+	roots    []string // absolute content roots
+	dir      string   // directory of the site
+	cacheDir string
+
+	mu     sync.RWMutex // held for writing while generations are removed
+	gen    string       // "": the content root cannot be read
+	loaded sync.Map     // cache keys looked up on disk in this run
+}
+
+// newDiskCache opens the disk cache of a site in cache_dir. templates are
+// the templates the pages are rendered with, parsed but not yet executed
+// (html/template escapes the parse tree on first use).
+func newDiskCache(cfg Config, templates ...*template.Template) (*diskCache, error) {
+	var roots []string
+	for _, dir := range cfg.HTML.MarkdownRootDir {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	cacheDir, err := filepath.Abs(cfg.Cache.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	site, err := siteHash(cfg, roots, templates...)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &diskCache{roots: roots, cacheDir: cacheDir, dir: filepath.Join(cacheDir, site)}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return nil, err
+	}
+	d.refresh()
+	return d, nil
+}
+
+// refresh starts a new generation if the content has changed, and removes
+// the files of the others. Safe to call on nil.
+func (d *diskCache) refresh() {
+	if d == nil {
+		return
+	}
+	gen, err := contentHash(d.roots, d.cacheDir, false)
+	if err != nil {
+		slog.Warn("Disk cache disabled; cannot read the content", "err", err)
+		gen = ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if gen == d.gen && gen != "" {
+		return
+	}
+	d.gen = gen
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		slog.Warn("Failed to read the disk cache", "dir", d.dir, "err", err)
+		return
+	}
+	for _, e := range entries {
+		if e.Name() == gen {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(d.dir, e.Name())); err != nil {
+			slog.Warn("Failed to remove an old disk cache generation", "dir", e.Name(), "err", err)
+		}
+	}
+}
+
+// file returns the file of a page in the current generation. The caller
+// holds d.mu.
+func (d *diskCache) file(cacheKey string, modTime time.Time) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d", cacheKey, modTime.UnixNano()))
+	return filepath.Join(d.dir, d.gen, hex.EncodeToString(sum[:])+".html")
+}
+
+// get returns the stored page of a cache key, unless the page has been
+// looked up before in this run. Safe to call on nil.
+func (d *diskCache) get(cacheKey string, modTime time.Time) ([]byte, bool) {
+	if d == nil {
+		return nil, false
+	}
+	if _, seen := d.loaded.LoadOrStore(cacheKey, true); seen {
+		return nil, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.gen == "" {
+		return nil, false
+	}
+	b, err := os.ReadFile(d.file(cacheKey, modTime))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// put stores a rendered page atomically (temp file + rename). Errors are
+// logged. Safe to call on nil.
+func (d *diskCache) put(cacheKey string, modTime time.Time, content []byte) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.gen == "" {
+		return
+	}
+	file := d.file(cacheKey, modTime)
+	if err := writeFileAtomic(file, content, 0644); err != nil {
+		slog.Warn("Failed to write the disk cache", "key", cacheKey, "err", err)
+	}
+}
+
+// writeFileAtomic writes a file through a temporary file in the same
+// directory (created with its parents if needed), so that readers never see
+// it partially written.
+func writeFileAtomic(file string, content []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
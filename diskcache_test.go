@@ -0,0 +1,79 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiskCache(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	cfg := srv.config
+	cfg.Cache.CacheDir = t.TempDir()
+
+	get := func(srv *Server, p string) string {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w.Body.String()
+	}
+	stored := func() []string {
+		files, err := filepath.Glob(filepath.Join(cfg.Cache.CacheDir, "*", "*", "*.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	// Every run parses its template (executing one changes its parse tree)
+	start := func() *Server {
+		t.Helper()
+		tmpl, _, _, err := loadTemplate("", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv, err := newServer(cfg, tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return srv
+	}
+
+	srv = start()
+	if body := get(srv, "/about"); !strings.Contains(body, "This is about page") {
+		t.Fatalf("Unexpected page: %s", body)
+	}
+	files := stored()
+	if len(files) != 1 {
+		t.Fatalf("Expected one stored page, got %v", files)
+	}
+
+	// After a restart, the first render of the page is read from disk
+	if err := os.WriteFile(files[0], []byte("stored copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restarted := start()
+	if body := get(restarted, "/about"); body != "stored copy" {
+		t.Errorf("Expected the stored page, got %s", body)
+	}
+	// ...only once per run
+	restarted.purgeCache()
+	if body := get(restarted, "/about"); !strings.Contains(body, "This is about page") {
+		t.Errorf("Expected a new render, got %s", body)
+	}
+
+	// A change to the content starts a new generation
+	if err := os.WriteFile(files[0], []byte("stored copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "new.md", "# New\n")
+	restarted = start()
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected the old generation to be removed, got %v", err)
+	}
+	if body := get(restarted, "/about"); !strings.Contains(body, "This is about page") {
+		t.Errorf("Expected a new render, got %s", body)
+	}
+}
@@ -0,0 +1,93 @@
+package gomadore
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+// --- Parsed Document Cache ---
+
+// docCache keeps what the page index, the search index and directory
+// listings extract from a markdown file (metadata and plain text), keyed by
+// file and checked against its modification time and size. A rescan after a
+// change then only parses the files that changed. Rendering parses on its
+// own, since it modifies the AST, and its result is kept in the page cache.
+type docCache struct {
+	md     goldmark.Markdown
+	strict bool
+	enc    string   // source encoding
+	exts   []string // suffixes of markdown files
+
+	mu    sync.Mutex
+	items map[string]docEntry // absolute file path -> parsed file
+}
+
+type docEntry struct {
+	modTime time.Time
+	size    int64
+	meta    *pageMeta
+	text    string // plain text of the body
+}
+
+func newDocCache(md goldmark.Markdown, strict bool, enc string, exts []string) *docCache {
+	return &docCache{md: md, strict: strict, enc: enc, exts: exts, items: make(map[string]docEntry)}
+}
+
+// load returns the metadata and the plain text of a markdown file (rel is
+// relative to root, slash separated), parsing it unless the cached version
+// is current. The returned pageMeta is shared and must not be modified.
+func (c *docCache) load(root, rel string) (*pageMeta, string, error) {
+	file := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(file)
+	if err != nil {
+		c.drop(file)
+		return nil, "", err
+	}
+
+	c.mu.Lock()
+	e, ok := c.items[file]
+	c.mu.Unlock()
+	if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.meta, e.text, nil
+	}
+
+	p, doc, body, err := readPage(c.md, root, rel, c.strict, c.enc)
+	if err != nil {
+		return nil, "", err
+	}
+	// Stamped with the state before reading: a write in between makes the
+	// next load parse the file again
+	e = docEntry{modTime: info.ModTime(), size: info.Size(), meta: p, text: nodeText(doc, body)}
+	p.WordCount = wordCount(e.text)
+	c.mu.Lock()
+	c.items[file] = e
+	c.mu.Unlock()
+	return e.meta, e.text, nil
+}
+
+// meta returns the metadata of a markdown file (see load).
+func (c *docCache) meta(root, rel string) (*pageMeta, error) {
+	p, _, err := c.load(root, rel)
+	return p, err
+}
+
+// drop forgets files, e.g. reported by the watcher (a file rewritten within
+// the timestamp resolution of the file system keeps its modification time).
+func (c *docCache) drop(files ...string) {
+	c.mu.Lock()
+	for _, f := range files {
+		delete(c.items, f)
+	}
+	c.mu.Unlock()
+}
+
+// reset forgets every file.
+func (c *docCache) reset() {
+	c.mu.Lock()
+	clear(c.items)
+	c.mu.Unlock()
+}
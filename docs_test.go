@@ -0,0 +1,58 @@
+package gomadore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDocCache(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	c := newDocCache(srv.md, false, "", srv.config.HTML.MarkdownExts)
+	createFile(t, dir, "doc.md", "---\ntitle: First\n---\nSome *text*.\n")
+	file := filepath.Join(dir, "doc.md")
+
+	p1, text, err := c.load(dir, "doc.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Title != "First" || text != "Some text." {
+		t.Errorf("Unexpected document: %q %q", p1.Title, text)
+	}
+	if p2, _, _ := c.load(dir, "doc.md"); p2 != p1 {
+		t.Error("Expected an unchanged file to come from the cache")
+	}
+
+	t.Run("Changed file", func(t *testing.T) {
+		createFile(t, dir, "doc.md", "---\ntitle: Second\n---\nSome *text*.\n")
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(file, later, later); err != nil {
+			t.Fatal(err)
+		}
+		p, _, err := c.load(dir, "doc.md")
+		if err != nil || p.Title != "Second" {
+			t.Errorf("Expected the file to be parsed again, got %v %v", p, err)
+		}
+	})
+
+	t.Run("Dropped file", func(t *testing.T) {
+		p, _, _ := c.load(dir, "doc.md")
+		c.drop(file)
+		if q, _, _ := c.load(dir, "doc.md"); q == p {
+			t.Error("Expected a dropped file to be parsed again")
+		}
+	})
+
+	t.Run("Removed file", func(t *testing.T) {
+		if err := os.Remove(file); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := c.load(dir, "doc.md"); !os.IsNotExist(err) {
+			t.Errorf("Expected ErrNotExist, got %v", err)
+		}
+		if len(c.items) != 0 {
+			t.Errorf("Expected the entry to be dropped, got %d", len(c.items))
+		}
+	})
+}
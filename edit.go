@@ -0,0 +1,133 @@
+package gomadore
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Upper bound of a page uploaded with PUT
+const editMaxBytes = 10 << 20
+
+// --- Editing API ---
+
+// editAPI lets authenticated users create, replace and delete markdown files
+// of the first content root with PUT and DELETE requests for their paths
+// (e.g. PUT /guide/setup.md).
+type editAPI struct {
+	auth *basicAuth
+	root string // absolute path of the first content root
+	exts []string
+}
+
+// newEditAPI returns nil if [edit] is disabled.
+func newEditAPI(cfg Config) (*editAPI, error) {
+	ec := cfg.Edit
+	if !ec.Enabled {
+		return nil, nil
+	}
+	if cfg.HTML.ContentSource == contentSourceEmbedded {
+		return nil, errors.New(`embedded content cannot be edited (content_source = "embedded")`)
+	}
+	root, err := filepath.Abs(cfg.HTML.MarkdownRootDir.primary())
+	if err != nil {
+		return nil, err
+	}
+	var ac Config
+	ac.Auth.Enabled = true
+	ac.Auth.Realm = cmp.Or(ec.Realm, defaultAuthRealm)
+	ac.Auth.HtpasswdFile = ec.HtpasswdFile
+	ac.Auth.Users = ec.Users
+	auth, err := newBasicAuth(ac)
+	if err != nil {
+		return nil, err
+	}
+	return &editAPI{auth: auth, root: root, exts: markdownSuffixes(cfg)}, nil
+}
+
+// file resolves a request path to a markdown file under the root. Other
+// files, hidden files and directories, and paths leaving the root are
+// refused.
+func (e *editAPI) file(urlPath string) (string, bool) {
+	if markdownExt(urlPath, e.exts) == "" {
+		return "", false
+	}
+	for seg := range strings.SplitSeq(urlPath, "/") {
+		if strings.HasPrefix(seg, ".") {
+			return "", false
+		}
+	}
+	file := filepath.Join(e.root, filepath.FromSlash(path.Clean("/"+urlPath)))
+	if rel, err := filepath.Rel(e.root, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+	return file, true
+}
+
+// handleEdit writes (PUT) or removes (DELETE) a markdown file, and applies
+// the change at once, without waiting for hot_reload.
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	if !s.edit.auth.authorize(w, r) {
+		return
+	}
+	file, ok := s.edit.file(r.URL.Path)
+	if !ok {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	user, _, _ := r.BasicAuth()
+	info, statErr := os.Stat(file)
+	if statErr == nil && !info.Mode().IsRegular() {
+		http.Error(w, "Conflict", http.StatusConflict)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, editMaxBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+			}
+			return
+		}
+		if err := writeFileAtomic(file, body, 0644); err != nil {
+			slog.Error("Failed to write an edited page", "file", file, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		s.filesChanged([]string{file})
+		if errors.Is(statErr, fs.ErrNotExist) {
+			slog.Info("Page created", "file", file, "user", user)
+			w.Header().Set("Location", r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		slog.Info("Page updated", "file", file, "user", user)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if statErr != nil {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if err := os.Remove(file); err != nil {
+			slog.Error("Failed to delete a page", "file", file, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		s.filesChanged([]string{file})
+		slog.Info("Page deleted", "file", file, "user", user)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
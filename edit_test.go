@@ -0,0 +1,77 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestEditAPI(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, dir := setupTestServer(t)
+	srv.config.Edit.Enabled = true
+	srv.config.Edit.Users = []string{"editor:" + string(hash)}
+	srv, err = newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+	do := func(method, p, body string, auth bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, p, strings.NewReader(body))
+		if auth {
+			r.SetBasicAuth("editor", "pw")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// Render once, so that the cache has to be invalidated
+	if w := do(http.MethodGet, "/about", "", false); w.Code != http.StatusOK {
+		t.Fatalf("GET /about: %d", w.Code)
+	}
+
+	tests := []struct {
+		method, path, body string
+		auth               bool
+		code               int
+	}{
+		{http.MethodPut, "/new/page.md", "# New Page", false, http.StatusUnauthorized},
+		{http.MethodPut, "/new/page.md", "# New Page", true, http.StatusCreated},
+		{http.MethodPut, "/about.md", "# Edited About", true, http.StatusNoContent},
+		{http.MethodPut, "/style.css", "body {}", true, http.StatusForbidden},
+		{http.MethodPut, "/.hidden/page.md", "# Hidden", true, http.StatusForbidden},
+		{http.MethodDelete, "/missing.md", "", true, http.StatusNotFound},
+		{http.MethodDelete, "/sub/deep.md", "", false, http.StatusUnauthorized},
+		{http.MethodDelete, "/sub/deep.md", "", true, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if w := do(tt.method, tt.path, tt.body, tt.auth); w.Code != tt.code {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, w.Code, tt.code)
+		}
+	}
+
+	if b, err := os.ReadFile(filepath.Join(dir, "new", "page.md")); err != nil || string(b) != "# New Page" {
+		t.Errorf("new/page.md = %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".hidden")); err == nil {
+		t.Error("A hidden directory was created")
+	}
+	if body := do(http.MethodGet, "/new/page", "", false).Body.String(); !strings.Contains(body, "New Page") {
+		t.Errorf("Expected the created page, got %s", body)
+	}
+	if body := do(http.MethodGet, "/about", "", false).Body.String(); !strings.Contains(body, "Edited About") {
+		t.Errorf("Expected the edited page, got %s", body)
+	}
+	if w := do(http.MethodGet, "/sub/deep", "", false); w.Code != http.StatusNotFound {
+		t.Errorf("Deleted page: status %d", w.Code)
+	}
+}
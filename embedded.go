@@ -0,0 +1,106 @@
+package gomadore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Values of content_source
+const (
+	contentSourceDirectory = "directory"
+	contentSourceEmbedded  = "embedded"
+)
+
+// EmbeddedContent is the content tree compiled into the binary, served with
+// content_source = "embedded" (nil if there is none). The gomadore command
+// sets it when built with -tags embed (from cmd/gomadore/content); other
+// programs can set it before loading the configuration or calling New.
+var EmbeddedContent fs.FS
+
+// --- Embedded Content ---
+
+var embedded struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+// useEmbeddedContent points markdown_rootdir at the extracted copy of
+// EmbeddedContent if content_source is "embedded". The content cannot
+// change, so hot_reload is turned off.
+func (c *Config) useEmbeddedContent() error {
+	if c.HTML.ContentSource != contentSourceEmbedded {
+		return nil
+	}
+	if EmbeddedContent == nil {
+		return errors.New(`content_source = "embedded": this binary has no embedded content (build it with -tags embed)`)
+	}
+	embedded.once.Do(func() {
+		embedded.dir, embedded.err = extractContent(EmbeddedContent, os.TempDir())
+	})
+	if embedded.err != nil {
+		return fmt.Errorf("extract embedded content: %w", embedded.err)
+	}
+	c.HTML.MarkdownRootDir = RootDirs{embedded.dir}
+	c.Cache.HotReload = false
+	return nil
+}
+
+// extractContent copies a content tree into a directory under parent named
+// by a hash of its files, and returns the directory. The rest of gomadore
+// reads files from disk; the name lets a restarted binary reuse the copy
+// (with the same modification times, so Last-Modified stays the same), and
+// a copy is only ever renamed into place when complete.
+func extractContent(fsys fs.FS, parent string) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, _ = fmt.Fprintf(h, "%s\x00", p)
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(parent, "gomadore-content-"+hex.EncodeToString(h.Sum(nil))[:16])
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, nil
+	}
+
+	tmp, err := os.MkdirTemp(parent, ".gomadore-content-*")
+	if err != nil {
+		return "", err
+	}
+	if err := os.CopyFS(tmp, fsys); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// Extracted by another process in the meantime
+		_ = os.RemoveAll(tmp)
+		if info, serr := os.Stat(dir); serr != nil || !info.IsDir() {
+			return "", err
+		}
+	}
+	slog.Debug("Extracted embedded content", "dir", dir)
+	return dir, nil
+}
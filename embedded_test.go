@@ -0,0 +1,71 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractContent(t *testing.T) {
+	parent := t.TempDir()
+	fsys := fstest.MapFS{
+		"index.md":       {Data: []byte("# Home")},
+		"guide/setup.md": {Data: []byte("# Setup")},
+		"_gomadore.toml": {Data: []byte(`site_title = "Embedded"`)},
+	}
+
+	dir, err := extractContent(fsys, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "guide", "setup.md")); err != nil || string(b) != "# Setup" {
+		t.Errorf("setup.md = %q, %v", b, err)
+	}
+
+	// The same content reuses the copy; other content gets its own
+	again, err := extractContent(fsys, parent)
+	if err != nil || again != dir {
+		t.Errorf("Expected %s to be reused, got %s, %v", dir, again, err)
+	}
+	fsys["index.md"] = &fstest.MapFile{Data: []byte("# Changed")}
+	other, err := extractContent(fsys, parent)
+	if err != nil || other == dir {
+		t.Errorf("Expected a new directory, got %s, %v", other, err)
+	}
+	entries, _ := os.ReadDir(parent)
+	if len(entries) != 2 {
+		t.Errorf("Expected no leftover temporary directories, got %d entries", len(entries))
+	}
+}
+
+func TestEmbeddedContent(t *testing.T) {
+	var cfg Config
+	cfg.HTML.ContentSource = contentSourceEmbedded
+	cfg.Cache.HotReload = true
+
+	old := EmbeddedContent
+	defer func() { EmbeddedContent = old }()
+	EmbeddedContent = nil
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), "-tags embed") {
+		t.Errorf("Expected an error without embedded content, got %v", err)
+	}
+
+	t.Setenv("TMPDIR", t.TempDir())
+	EmbeddedContent = fstest.MapFS{"index.md": {Data: []byte("# Embedded Home")}}
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.config.Cache.HotReload {
+		t.Error("hot_reload should be off for embedded content")
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Embedded Home") {
+		t.Errorf("GET /: %d %s", w.Code, w.Body.String())
+	}
+}
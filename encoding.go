@@ -0,0 +1,110 @@
+package gomadore
+
+import (
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encodings of markdown files (html.source_encoding)
+const (
+	sourceEncodingUTF8     = "utf-8"
+	sourceEncodingShiftJIS = "shift_jis"
+	sourceEncodingEUCJP    = "euc-jp"
+	sourceEncodingAuto     = "auto" // UTF-8, or whichever of Shift_JIS and EUC-JP fits better
+)
+
+// --- Source Encoding ---
+
+// readSource reads a markdown file and converts it from enc to UTF-8.
+func readSource(file, enc string) ([]byte, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSource(b, enc), nil
+}
+
+// decodeSource converts markdown source from enc to UTF-8. Content that is
+// already valid UTF-8 is returned as is, since a tree is often converted
+// file by file. Invalid bytes become U+FFFD.
+func decodeSource(b []byte, enc string) []byte {
+	if enc == "" || enc == sourceEncodingUTF8 || utf8.Valid(b) {
+		return b
+	}
+	switch enc {
+	case sourceEncodingShiftJIS:
+		return decodeWith(japanese.ShiftJIS, b)
+	case sourceEncodingEUCJP:
+		return decodeWith(japanese.EUCJP, b)
+	}
+
+	// auto: the same bytes often decode without errors in both encodings
+	// (EUC-JP kana read as Shift_JIS are half-width katakana), so prefer
+	// the result with fewer invalid and half-width characters.
+	sjis, euc := decodeWith(japanese.ShiftJIS, b), decodeWith(japanese.EUCJP, b)
+	if decodingPenalty(euc) < decodingPenalty(sjis) {
+		return euc
+	}
+	return sjis
+}
+
+func decodeWith(e encoding.Encoding, b []byte) []byte {
+	out, err := e.NewDecoder().Bytes(b)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// decodingPenalty counts the characters that are unlikely in a correctly
+// decoded Japanese document: replacement characters and half-width katakana.
+func decodingPenalty(b []byte) int {
+	n := 0
+	for _, r := range string(b) {
+		if r == utf8.RuneError || (r >= 0xFF61 && r <= 0xFF9F) {
+			n++
+		}
+	}
+	return n
+}
+
+// --- Output Charset ---
+
+// charsetLabel returns the name of a charset (html.charset) for the
+// Content-Type header and <meta charset>.
+func charsetLabel(charset string) string {
+	switch charset {
+	case sourceEncodingShiftJIS:
+		return "Shift_JIS"
+	case sourceEncodingEUCJP:
+		return "EUC-JP"
+	}
+	return "utf-8"
+}
+
+// htmlContentType returns the Content-Type of rendered pages.
+func (s *Server) htmlContentType() string {
+	return "text/html; charset=" + charsetLabel(s.config.HTML.Charset)
+}
+
+// encodePage converts a rendered page from UTF-8 to charset. Characters the
+// charset cannot represent are written as numeric character references.
+func encodePage(b []byte, charset string) []byte {
+	var e encoding.Encoding
+	switch charset {
+	case sourceEncodingShiftJIS:
+		e = japanese.ShiftJIS
+	case sourceEncodingEUCJP:
+		e = japanese.EUCJP
+	default:
+		return b
+	}
+	out, err := encoding.HTMLEscapeUnsupported(e.NewEncoder()).Bytes(b)
+	if err != nil {
+		return b
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestDecodeSource(t *testing.T) {
+	const text = "# 日本語の文書\n\nこれはテストです。カタカナも含みます。"
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	euc, err := japanese.EUCJP.NewEncoder().String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		enc  string
+		want string
+	}{
+		{"Shift_JIS", sjis, sourceEncodingShiftJIS, text},
+		{"EUC-JP", euc, sourceEncodingEUCJP, text},
+		{"Auto Shift_JIS", sjis, sourceEncodingAuto, text},
+		{"Auto EUC-JP", euc, sourceEncodingAuto, text},
+		{"UTF-8 in any mode", text, sourceEncodingShiftJIS, text},
+		{"No conversion", sjis, sourceEncodingUTF8, sjis},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(decodeSource([]byte(tt.src), tt.enc)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceEncoding(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SourceEncoding = sourceEncodingAuto
+	src, err := japanese.ShiftJIS.NewEncoder().String("# 旧文書\n\n本文です。")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "legacy.md", src)
+
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+	if body := w.Body.String(); !strings.Contains(body, "<p>本文です。</p>") || !strings.Contains(body, "旧文書") {
+		t.Errorf("Expected the transcoded page, got %s", body)
+	}
+}
+
+func TestPageCharset(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "ja.md", "# 日本語\n\n絵文字 😀 とカタカナ。\n")
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/ja", nil))
+		return w
+	}
+	w := get()
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cl := w.Header().Get("Content-Length"); cl != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("Content-Length = %q, body %d bytes", cl, w.Body.Len())
+	}
+
+	// With the default template
+	srv.config.HTML.Charset = sourceEncodingShiftJIS
+	tmpl, _, _, err := loadTemplate("", srv.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv, err = newServer(srv.config, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	w = get()
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=Shift_JIS" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, err := japanese.ShiftJIS.NewDecoder().String(w.Body.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"絵文字 &#128512; とカタカナ。", `<meta charset="Shift_JIS">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Missing %s in %s", want, body)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package gomadore
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// --- Static Site Export ---
+
+// isInside reports whether path is dir itself or below it (both absolute).
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// exportSite renders every markdown page of the default site into outDir
+// ("/sub/deep" -> "sub/deep.html", "/sub/index" -> "sub/index.html").
+// If [static] is enabled, the static files are copied as well.
+// It returns the number of exported pages.
+func (s *Server) exportSite(outDir string) (int, error) {
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return 0, err
+	}
+	roots := slices.Clone(s.config.HTML.MarkdownRootDir)
+	if s.config.Static.Enabled && s.config.Static.AssetsDir != "" {
+		roots = append(roots, s.config.Static.AssetsDir)
+	}
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return 0, err
+		}
+		if isInside(absOut, absRoot) {
+			return 0, fmt.Errorf("export directory %s must not be inside %s", outDir, root)
+		}
+	}
+
+	pages, err := s.pages.all()
+	if err != nil {
+		return 0, fmt.Errorf("scan pages: %w", err)
+	}
+
+	paths := make([]string, 0, len(pages))
+	for _, p := range pages {
+		paths = append(paths, p.Path)
+	}
+	if s.config.Tags.Enabled {
+		paths = append(paths, tagPagePaths(pages)...)
+		paths = slices.Compact(slices.Sorted(slices.Values(paths))) // content may have its own tag pages
+	}
+
+	st := s.siteForHost("")
+	for _, p := range paths {
+		html, err := s.renderPage(st, p)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", p, err)
+		}
+		dst := filepath.Join(absOut, filepath.FromSlash(strings.TrimPrefix(p, "/"))+".html")
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(dst, html, 0644); err != nil {
+			return 0, err
+		}
+		slog.Debug("Exported page", "path", p, "file", dst)
+	}
+
+	if s.config.Static.Enabled {
+		copied := make(map[string]bool) // earlier directories take precedence
+		for _, root := range roots {
+			if err := copyStaticFiles(root, absOut, s.config.HTML.MarkdownExts, copied); err != nil {
+				return 0, fmt.Errorf("copy static files: %w", err)
+			}
+		}
+	}
+	return len(paths), nil
+}
+
+// copyStaticFiles copies the servable non-markdown files under root to outDir,
+// skipping hidden files and directories and relative paths already in copied.
+func copyStaticFiles(root, outDir string, exts []string, copied map[string]bool) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || markdownExt(d.Name(), exts) != "" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || copied[rel] {
+			return err
+		}
+		copied[rel] = true
+
+		dst := filepath.Join(outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		return copyFile(p, dst)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
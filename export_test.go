@@ -0,0 +1,87 @@
+package gomadore
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSite(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Static.Enabled = true
+	if err := os.MkdirAll(filepath.Join(dir, "img", ".cache"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createFile(t, dir, "img/logo.png", "PNG")
+	createFile(t, dir, "img/.cache/tmp", "hidden")
+	createFile(t, dir, ".env", "secret")
+
+	assets := t.TempDir()
+	srv.config.Static.AssetsDir = assets
+	createFile(t, assets, "app.js", "js")
+	if err := os.MkdirAll(filepath.Join(assets, "img"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createFile(t, assets, "img/logo.png", "SHADOWED")
+
+	out := filepath.Join(t.TempDir(), "public")
+	n, err := srv.exportSite(out)
+	if err != nil {
+		t.Fatalf("exportSite failed: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Exported page count mismatch: got %d", n)
+	}
+
+	read := func(rel string) (string, bool) {
+		b, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+		return string(b), err == nil
+	}
+	for rel, want := range map[string]string{
+		"index.html":     "Hello World",
+		"about.html":     "This is about page",
+		"sub/deep.html":  "Deep content",
+		"t1/cococo.html": "Secret content",
+		"img/logo.png":   "PNG",
+		"app.js":         "js",
+	} {
+		got, ok := read(rel)
+		if !ok || !strings.Contains(got, want) {
+			t.Errorf("%s: got %q (exists: %v), want %q", rel, got, ok, want)
+		}
+	}
+	for _, rel := range []string{".env", "img/.cache/tmp", "about.md", "index.md"} {
+		if _, ok := read(rel); ok {
+			t.Errorf("%s should not be exported", rel)
+		}
+	}
+
+	t.Run("Static disabled", func(t *testing.T) {
+		srv.config.Static.Enabled = false
+		defer func() { srv.config.Static.Enabled = true }()
+		out := t.TempDir()
+		if _, err := srv.exportSite(out); err != nil {
+			t.Fatalf("exportSite failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "img", "logo.png")); err == nil {
+			t.Error("Static files should not be copied when [static] is disabled")
+		}
+	})
+
+	t.Run("Output inside root", func(t *testing.T) {
+		if _, err := srv.exportSite(filepath.Join(dir, "public")); err == nil {
+			t.Error("Expected error for export directory inside markdown_rootdir")
+		}
+	})
+
+	t.Run("Template error", func(t *testing.T) {
+		createFile(t, dir, "broken.md", "# Broken")
+		srv.tmpl = template.Must(template.New("base").Option("missingkey=error").Parse(`{{.NoSuchKey}}`))
+		defer func() { _ = os.Remove(filepath.Join(dir, "broken.md")) }()
+		if _, err := srv.exportSite(t.TempDir()); err == nil {
+			t.Error("Expected error for failing template")
+		}
+	})
+}
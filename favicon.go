@@ -0,0 +1,103 @@
+package gomadore
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default browser cache lifetime of the configured icons (seconds)
+const defaultFaviconMaxAge = 2592000 // 30 days
+
+// --- Favicon ---
+
+// siteIcons serves the configured /favicon.ico and /apple-touch-icon.png
+// from memory. Every method is safe to call on nil (no icon configured).
+type siteIcons struct {
+	files  map[string]iconFile // URL path -> icon
+	maxAge int
+}
+
+type iconFile struct {
+	body        []byte
+	contentType string
+	etag        string
+	modTime     time.Time
+}
+
+// newSiteIcons reads the configured icon files. It returns nil if neither
+// [favicon] path nor apple_touch_icon is set.
+func newSiteIcons(cfg Config) (*siteIcons, error) {
+	fc := cfg.Favicon
+	ic := &siteIcons{files: make(map[string]iconFile), maxAge: fc.MaxAge}
+	for _, f := range []struct {
+		file string
+		urls []string
+	}{
+		{fc.Path, []string{"/favicon.ico"}},
+		// Older iOS versions ask for the precomposed name first
+		{fc.AppleTouchIcon, []string{"/apple-touch-icon.png", "/apple-touch-icon-precomposed.png"}},
+	} {
+		if f.file == "" {
+			continue
+		}
+		icon, err := readIconFile(f.file)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range f.urls {
+			ic.files[u] = icon
+		}
+	}
+	if len(ic.files) == 0 {
+		return nil, nil
+	}
+	return ic, nil
+}
+
+func readIconFile(file string) (iconFile, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return iconFile{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return iconFile{}, err
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	ct := mime.TypeByExtension(ext)
+	if ext == ".ico" {
+		ct = "image/x-icon" // understood by every browser, unlike image/vnd.microsoft.icon
+	}
+	if ct == "" {
+		ct = http.DetectContentType(body)
+	}
+	return iconFile{body: body, contentType: ct, etag: pageETag(body), modTime: info.ModTime()}, nil
+}
+
+// has reports whether an icon is configured for a URL path.
+func (ic *siteIcons) has(urlPath string) bool {
+	if ic == nil {
+		return false
+	}
+	_, ok := ic.files[urlPath]
+	return ok
+}
+
+func (ic *siteIcons) handleIcon(w http.ResponseWriter, r *http.Request) {
+	icon, ok := ic.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", icon.contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", ic.maxAge))
+	if notModified(w, r, icon.etag, icon.modTime) {
+		return
+	}
+	_ = writeBody(w, icon.body)
+}
@@ -0,0 +1,71 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestFavicon(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	get := func(p string, h ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, p, nil)
+		if len(h) == 2 {
+			req.Header.Set(h[0], h[1])
+		}
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Not configured", func(t *testing.T) {
+		if w := get("/favicon.ico"); w.Code != http.StatusNoContent {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+	})
+
+	createFile(t, dir, "icon.svg", "<svg xmlns=\"http://www.w3.org/2000/svg\"/>")
+	srv.config.Favicon.Path = filepath.Join(dir, "icon.svg")
+	srv.config.Favicon.AppleTouchIcon = createIconSource(t, dir, 180, 180)
+	srv.config.Favicon.MaxAge = 3600
+	icons, err := newSiteIcons(srv.config)
+	if err != nil {
+		t.Fatalf("newSiteIcons failed: %v", err)
+	}
+	srv.icons = icons
+
+	w := get("/favicon.ico")
+	if w.Code != http.StatusOK || w.Body.String() != "<svg xmlns=\"http://www.w3.org/2000/svg\"/>" {
+		t.Fatalf("Unexpected favicon response: %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("Content-Type mismatch: %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("Cache-Control mismatch: %s", cc)
+	}
+	if w := get("/favicon.ico", "If-None-Match", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304, got %d", w.Code)
+	}
+
+	for _, p := range []string{"/apple-touch-icon.png", "/apple-touch-icon-precomposed.png"} {
+		if w := get(p); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("%s: unexpected response %d %s", p, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+
+	t.Run("Precedence over the manifest icon", func(t *testing.T) {
+		srv.config.Manifest.IconSource = createIconSource(t, dir, 64, 64)
+		m, err := newWebManifest(srv.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.manifest = m
+		// Registering both would panic
+		if w := get("/apple-touch-icon.png"); w.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", w.Code)
+		}
+	})
+}
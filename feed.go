@@ -0,0 +1,445 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default number of entries per feed
+const defaultFeedMaxEntries = 20
+
+// Feed file names recognized at the end of a URL path, and their formats.
+var feedFormats = map[string]string{
+	"feed.xml":  "atom",
+	"rss.xml":   "rss",
+	"feed.json": "json",
+}
+
+// --- Feeds (Atom / RSS / JSON Feed) ---
+
+// feedScope is the set of pages a feed URL refers to.
+type feedScope struct {
+	format  string // "atom", "rss" or "json"
+	section string // path prefix ("/" for the whole site)
+	tag     string // tag name (tag feeds only)
+}
+
+// parseFeedPath maps a request path to a feed scope.
+//
+//	/feed.xml            -> whole site (Atom)
+//	/docs/rss.xml        -> pages under /docs/ (RSS 2.0)
+//	/tags/go/feed.json   -> pages tagged "go" (JSON Feed 1.1)
+func parseFeedPath(p string) (feedScope, bool) {
+	i := strings.LastIndex(p, "/")
+	format, ok := feedFormats[p[i+1:]]
+	if !ok {
+		return feedScope{}, false
+	}
+	dir := p[:i+1]
+
+	if tag, found := strings.CutPrefix(dir, "/tags/"); found {
+		tag = strings.TrimSuffix(tag, "/")
+		if tag != "" && !strings.Contains(tag, "/") {
+			return feedScope{format: format, section: "/", tag: tag}, true
+		}
+	}
+	return feedScope{format: format, section: dir}, true
+}
+
+// isPost reports whether a page is under one of the post directories
+// ("blog", "/news/"); every page is a post if there are none.
+func isPost(p *pageMeta, postDirs []string) bool {
+	if len(postDirs) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(postDirs, func(dir string) bool {
+		dir = strings.Trim(dir, "/")
+		return dir == "" || strings.HasPrefix(p.Path, "/"+dir+"/")
+	})
+}
+
+// feedEntries selects the posts of a scope, newest first.
+// Directory index pages are listing pages and are not feed entries.
+func feedEntries(pages []*pageMeta, scope feedScope, postDirs []string) []*pageMeta {
+	var entries []*pageMeta
+	for _, p := range pages {
+		if p.IsIndex() || !strings.HasPrefix(p.Path, scope.section) || !isPost(p, postDirs) {
+			continue
+		}
+		if scope.tag != "" && !p.HasTag(scope.tag) {
+			continue
+		}
+		entries = append(entries, p)
+	}
+	slices.SortStableFunc(entries, func(a, b *pageMeta) int { return b.Date.Compare(a.Date) })
+	return entries
+}
+
+// feedPageURL returns the URL of page n of a paged feed (page 1 has no query).
+func feedPageURL(feedURL string, n int) string {
+	if n <= 1 {
+		return feedURL
+	}
+	return fmt.Sprintf("%s?page=%d", feedURL, n)
+}
+
+// feedPageLinks returns the RFC 5005 paging links of page n (of total pages).
+func feedPageLinks(feedURL string, n, total int) []atomLink {
+	links := []atomLink{
+		{Rel: "first", Href: feedPageURL(feedURL, 1)},
+		{Rel: "last", Href: feedPageURL(feedURL, total)},
+	}
+	if n > 1 {
+		links = append(links, atomLink{Rel: "previous", Href: feedPageURL(feedURL, n-1)})
+	}
+	if n < total {
+		links = append(links, atomLink{Rel: "next", Href: feedPageURL(feedURL, n+1)})
+	}
+	return links
+}
+
+// serveFeed writes the feed for a request path if it is a feed URL.
+// It returns false if the path is not a feed URL (or feeds are disabled).
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request) bool {
+	if !s.config.Feed.Enabled {
+		return false
+	}
+	scope, ok := parseFeedPath(r.URL.Path)
+	if !ok {
+		return false
+	}
+
+	pages, err := s.pages.all()
+	if err != nil {
+		slog.Error("Failed to scan pages for feed", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+	// Protected pages are left out, along with their content (see pageProtected)
+	pages = slices.DeleteFunc(slices.Clone(pages), func(p *pageMeta) bool { return s.pageProtected(p.Path) })
+
+	limit := s.config.Feed.MaxEntries
+	if limit <= 0 {
+		limit = defaultFeedMaxEntries
+	}
+	entries := feedEntries(pages, scope, s.config.Feed.PostDirs)
+
+	// Unknown section or tag
+	if len(entries) == 0 && (scope.tag != "" || !slices.ContainsFunc(pages, func(p *pageMeta) bool {
+		return strings.HasPrefix(p.Path, scope.section)
+	})) {
+		http.NotFound(w, r)
+		return true
+	}
+
+	base := s.siteURL(r)
+	feedURL := base + r.URL.Path
+
+	// Paging (RFC 5005): max_entries per page, or truncation if disabled
+	pageNum, total := 1, 1
+	var pageLinks []atomLink
+	if s.config.Feed.Paginate {
+		if q := r.URL.Query().Get("page"); q != "" {
+			n, err := strconv.Atoi(q)
+			if err != nil || n < 1 {
+				http.Error(w, "Invalid page", http.StatusBadRequest)
+				return true
+			}
+			pageNum = n
+		}
+		total = max(1, (len(entries)+limit-1)/limit)
+		if pageNum > total {
+			http.NotFound(w, r)
+			return true
+		}
+		pageLinks = feedPageLinks(feedURL, pageNum, total)
+	}
+	start := (pageNum - 1) * limit
+	entries = entries[start:min(start+limit, len(entries))]
+
+	// Virtual hosts use their own site title
+	st := s.siteFor(r)
+	title := s.config.Feed.Title
+	if title == "" || st.key != "" {
+		title = st.title
+	}
+	homePath := scope.section
+	if scope.tag != "" {
+		title = fmt.Sprintf("%s - #%s", title, scope.tag)
+		homePath = "/tags/" + url.PathEscape(scope.tag) + "/"
+	} else if scope.section != "/" {
+		title = fmt.Sprintf("%s - %s", title, strings.Trim(scope.section, "/"))
+	}
+
+	f := feedDoc{
+		title:     title,
+		author:    st.author,
+		lang:      st.lang,
+		base:      base,
+		feedURL:   feedURL,
+		selfURL:   feedPageURL(feedURL, pageNum),
+		homeURL:   base + homePath,
+		pageLinks: pageLinks,
+		entries:   entries,
+	}
+	if pageNum < total {
+		f.nextURL = feedPageURL(feedURL, pageNum+1)
+	}
+
+	var body []byte
+	var contentType string
+	switch scope.format {
+	case "atom":
+		body, err = f.atom()
+		contentType = "application/atom+xml; charset=utf-8"
+	case "rss":
+		body, err = f.rss()
+		contentType = "application/rss+xml; charset=utf-8"
+	default:
+		body, err = f.jsonFeed()
+		contentType = "application/feed+json; charset=utf-8"
+	}
+	if err != nil {
+		slog.Error("Failed to build feed", "path", r.URL.Path, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", st.cacheLimit))
+	if err := writeBody(w, body); err != nil {
+		slog.Debug("Failed to write response (feed)", "err", err)
+	}
+	return true
+}
+
+// feedDoc holds format independent feed data.
+type feedDoc struct {
+	title     string
+	author    string
+	lang      string
+	base      string     // absolute site URL
+	feedURL   string     // absolute URL of the feed (first page)
+	selfURL   string     // absolute URL of this feed document (current page)
+	homeURL   string     // absolute URL of the HTML page the feed belongs to
+	pageLinks []atomLink // RFC 5005 first/last/previous/next (paged feeds only)
+	nextURL   string     // next page (paged feeds only)
+	entries   []*pageMeta
+}
+
+func (f feedDoc) updated() time.Time {
+	var t time.Time
+	for _, e := range f.entries {
+		if e.ModTime.After(t) {
+			t = e.ModTime
+		}
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t
+}
+
+// Atom 1.0 (RFC 4287)
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"xml:lang,attr,omitempty"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func (f feedDoc) atom() ([]byte, error) {
+	doc := atomFeed{
+		Lang:    f.lang,
+		Title:   f.title,
+		ID:      f.feedURL,
+		Updated: f.updated().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.selfURL},
+			{Rel: "alternate", Type: "text/html", Href: f.homeURL},
+		},
+	}
+	for _, l := range f.pageLinks {
+		doc.Links = append(doc.Links, atomLink{Rel: l.Rel, Type: "application/atom+xml", Href: l.Href})
+	}
+	if f.author != "" {
+		doc.Author = &atomPerson{Name: f.author}
+	}
+	for _, e := range f.entries {
+		entry := atomEntry{
+			Title:     e.Title,
+			ID:        f.base + e.URL,
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: f.base + e.URL},
+			Published: e.Date.Format(time.RFC3339),
+			Updated:   e.ModTime.Format(time.RFC3339),
+			Summary:   e.Description,
+		}
+		if e.Author != "" {
+			entry.Author = &atomPerson{Name: e.Author}
+		}
+		for _, tag := range e.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+// RSS 2.0
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language,omitempty"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	AtomLinks     []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Items         []rssItem  `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func (f feedDoc) rss() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.title,
+			Link:          f.homeURL,
+			Description:   f.title,
+			Language:      f.lang,
+			LastBuildDate: f.updated().Format(time.RFC1123Z),
+			AtomLinks:     []atomLink{{Rel: "self", Type: "application/rss+xml", Href: f.selfURL}},
+		},
+	}
+	for _, l := range f.pageLinks {
+		doc.Channel.AtomLinks = append(doc.Channel.AtomLinks, atomLink{Rel: l.Rel, Type: "application/rss+xml", Href: l.Href})
+	}
+	for _, e := range f.entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        f.base + e.URL,
+			GUID:        f.base + e.URL,
+			PubDate:     e.Date.Format(time.RFC1123Z),
+			Description: e.Description,
+			Categories:  e.Tags,
+		})
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// JSON Feed 1.1 (https://www.jsonfeed.org/version/1.1/)
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	NextURL     string           `json:"next_url,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+func (f feedDoc) jsonFeed() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.title,
+		HomePageURL: f.homeURL,
+		FeedURL:     f.selfURL,
+		NextURL:     f.nextURL,
+		Language:    f.lang,
+		Items:       []jsonFeedItem{},
+	}
+	if f.author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: f.author}}
+	}
+	for _, e := range f.entries {
+		item := jsonFeedItem{
+			ID:            f.base + e.URL,
+			URL:           f.base + e.URL,
+			Title:         e.Title,
+			ContentText:   e.Description,
+			Summary:       e.Description,
+			DatePublished: e.Date.Format(time.RFC3339),
+			DateModified:  e.ModTime.Format(time.RFC3339),
+			Tags:          e.Tags,
+		}
+		if e.Author != "" {
+			item.Authors = []jsonFeedAuthor{{Name: e.Author}}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
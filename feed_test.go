@@ -0,0 +1,271 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setupFeedServer creates a server with dated and tagged pages
+func setupFeedServer(t *testing.T) *Server {
+	t.Helper()
+	srv, dir := setupTestServer(t)
+	srv.config.Feed.Enabled = true
+	srv.config.HTML.SiteURL = "https://example.com/"
+	srv.config.HTML.SiteTitle = "Example"
+
+	if err := os.Mkdir(filepath.Join(dir, "blog"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createFile(t, dir, "blog/index.md", "# Blog\nAll posts")
+	createFile(t, dir, "blog/first.md", "---\ntitle: First Post\ndate: 2025-01-01\ntags: [go, web]\n---\n# Ignored H1\nHello first.")
+	createFile(t, dir, "blog/second.md", "+++\ndate = 2025-02-01\ntags = [\"Go\"]\n+++\n# Second Post\nHello second.")
+	createFile(t, dir, "blog/third.md", "---\ndate: \"2025-03-01 10:00\"\ndescription: Custom summary\n---\n# Third Post\nBody.")
+	return srv
+}
+
+func TestParseFeedPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		ok      bool
+		format  string
+		section string
+		tag     string
+	}{
+		{"/feed.xml", true, "atom", "/", ""},
+		{"/rss.xml", true, "rss", "/", ""},
+		{"/docs/feed.json", true, "json", "/docs/", ""},
+		{"/tags/go/feed.json", true, "json", "/", "go"},
+		{"/tags/feed.xml", true, "atom", "/tags/", ""},
+		{"/docs/about", false, "", "", ""},
+	}
+	for _, tt := range tests {
+		scope, ok := parseFeedPath(tt.path)
+		if ok != tt.ok {
+			t.Errorf("%s: ok mismatch: got %v", tt.path, ok)
+			continue
+		}
+		if ok && (scope.format != tt.format || scope.section != tt.section || scope.tag != tt.tag) {
+			t.Errorf("%s: scope mismatch: got %+v", tt.path, scope)
+		}
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	srv := setupFeedServer(t)
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	t.Run("Section Atom feed", func(t *testing.T) {
+		w := get(t, "/blog/feed.xml")
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+			t.Errorf("Content-Type mismatch: got %s", ct)
+		}
+		var feed atomFeed
+		if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid Atom: %v", err)
+		}
+		// Newest first, index page excluded
+		want := []string{"Third Post", "Second Post", "First Post"}
+		if len(feed.Entries) != len(want) {
+			t.Fatalf("Entry count mismatch: got %d, want %d", len(feed.Entries), len(want))
+		}
+		for i, e := range feed.Entries {
+			if e.Title != want[i] {
+				t.Errorf("Entry %d title mismatch: got %q, want %q", i, e.Title, want[i])
+			}
+		}
+		if feed.Entries[0].Summary != "Custom summary" {
+			t.Errorf("Summary should come from front matter, got %q", feed.Entries[0].Summary)
+		}
+		if feed.Entries[2].Link.Href != "https://example.com/blog/first" {
+			t.Errorf("Absolute link mismatch: got %s", feed.Entries[2].Link.Href)
+		}
+	})
+
+	t.Run("Tag JSON feed", func(t *testing.T) {
+		w := get(t, "/tags/go/feed.json")
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		var feed jsonFeed
+		if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid JSON Feed: %v", err)
+		}
+		if feed.Version != "https://jsonfeed.org/version/1.1" {
+			t.Errorf("Version mismatch: got %s", feed.Version)
+		}
+		if len(feed.Items) != 2 || feed.Items[0].Title != "Second Post" || feed.Items[1].Title != "First Post" {
+			t.Errorf("Tag filter mismatch (case-insensitive): got %+v", feed.Items)
+		}
+		if feed.HomePageURL != "https://example.com/tags/go/" {
+			t.Errorf("HomePageURL mismatch: got %s", feed.HomePageURL)
+		}
+	})
+
+	t.Run("Site RSS feed", func(t *testing.T) {
+		w := get(t, "/rss.xml")
+		var feed rssFeed
+		if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid RSS: %v", err)
+		}
+		// about, sub/deep, t1/cococo and 3 blog posts (indexes excluded)
+		if len(feed.Channel.Items) != 6 {
+			t.Errorf("Item count mismatch: got %d", len(feed.Channel.Items))
+		}
+	})
+
+	t.Run("Post directories", func(t *testing.T) {
+		srv.config.Feed.PostDirs = []string{"/blog/"}
+		defer func() { srv.config.Feed.PostDirs = nil }()
+
+		var feed atomFeed
+		if err := xml.Unmarshal(get(t, "/feed.xml").Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid Atom: %v", err)
+		}
+		if len(feed.Entries) != 3 || feed.Entries[0].Title != "Third Post" {
+			t.Errorf("Expected the 3 blog posts, got %+v", feed.Entries)
+		}
+		var sub atomFeed
+		if w := get(t, "/sub/feed.xml"); w.Code != http.StatusOK {
+			t.Errorf("StatusCode mismatch: got %d", w.Code)
+		} else if err := xml.Unmarshal(w.Body.Bytes(), &sub); err != nil || len(sub.Entries) != 0 {
+			t.Errorf("Expected an empty feed outside post_dirs, got %d entries (%v)", len(sub.Entries), err)
+		}
+	})
+
+	t.Run("Max entries", func(t *testing.T) {
+		srv.config.Feed.MaxEntries = 1
+		defer func() { srv.config.Feed.MaxEntries = 0 }()
+
+		var feed jsonFeed
+		if err := json.Unmarshal(get(t, "/blog/feed.json").Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid JSON Feed: %v", err)
+		}
+		if len(feed.Items) != 1 {
+			t.Errorf("Expected 1 item, got %d", len(feed.Items))
+		}
+	})
+
+	t.Run("Pagination (RFC 5005)", func(t *testing.T) {
+		srv.config.Feed.MaxEntries = 2
+		srv.config.Feed.Paginate = true
+		defer func() {
+			srv.config.Feed.MaxEntries = 0
+			srv.config.Feed.Paginate = false
+		}()
+
+		// Page 1 of 2 (Atom)
+		var first atomFeed
+		if err := xml.Unmarshal(get(t, "/blog/feed.xml").Body.Bytes(), &first); err != nil {
+			t.Fatalf("Invalid Atom: %v", err)
+		}
+		if len(first.Entries) != 2 {
+			t.Errorf("Expected 2 entries on page 1, got %d", len(first.Entries))
+		}
+		links := map[string]string{}
+		for _, l := range first.Links {
+			links[l.Rel] = l.Href
+		}
+		wantLinks := map[string]string{
+			"self":  "https://example.com/blog/feed.xml",
+			"first": "https://example.com/blog/feed.xml",
+			"last":  "https://example.com/blog/feed.xml?page=2",
+			"next":  "https://example.com/blog/feed.xml?page=2",
+		}
+		for rel, href := range wantLinks {
+			if links[rel] != href {
+				t.Errorf("Link rel=%s mismatch: got %q, want %q", rel, links[rel], href)
+			}
+		}
+		if _, ok := links["previous"]; ok {
+			t.Error("First page must not have a previous link")
+		}
+
+		// Page 2 of 2 (JSON Feed)
+		var second jsonFeed
+		if err := json.Unmarshal(get(t, "/blog/feed.json?page=2").Body.Bytes(), &second); err != nil {
+			t.Fatalf("Invalid JSON Feed: %v", err)
+		}
+		if len(second.Items) != 1 || second.Items[0].Title != "First Post" {
+			t.Errorf("Unexpected page 2 items: %+v", second.Items)
+		}
+		if second.NextURL != "" {
+			t.Errorf("Last page must not have next_url, got %s", second.NextURL)
+		}
+
+		if w := get(t, "/blog/feed.xml?page=3"); w.Code != http.StatusNotFound {
+			t.Errorf("Out of range page: expected 404, got %d", w.Code)
+		}
+		if w := get(t, "/blog/feed.xml?page=x"); w.Code != http.StatusBadRequest {
+			t.Errorf("Invalid page: expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Protected pages", func(t *testing.T) {
+		hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := srv.config.HTML.MarkdownRootDir[0]
+		srv.auth = &basicAuth{paths: []string{"/blog/second"}}
+		createFile(t, dir, "sub/"+overlayFileName, "[auth]\nenabled = true\nusers = [\"carol:"+string(hash)+"\"]\n")
+		srv.purgeCache()
+		defer func() {
+			srv.auth = nil
+			_ = os.Remove(filepath.Join(dir, "sub", overlayFileName))
+			srv.purgeCache()
+		}()
+
+		var rss rssFeed
+		if err := xml.Unmarshal(get(t, "/rss.xml").Body.Bytes(), &rss); err != nil {
+			t.Fatalf("Invalid RSS: %v", err)
+		}
+		for _, item := range rss.Channel.Items {
+			if item.Title == "Second Post" || item.Title == "Deep Page" {
+				t.Errorf("Protected page in the feed: %+v", item)
+			}
+		}
+		if len(rss.Channel.Items) != 4 {
+			t.Errorf("Item count mismatch: got %d", len(rss.Channel.Items))
+		}
+		var feed jsonFeed
+		if err := json.Unmarshal(get(t, "/tags/go/feed.json").Body.Bytes(), &feed); err != nil {
+			t.Fatalf("Invalid JSON Feed: %v", err)
+		}
+		if len(feed.Items) != 1 || feed.Items[0].Title != "First Post" {
+			t.Errorf("Expected only the unprotected post, got %+v", feed.Items)
+		}
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		for _, p := range []string{"/nothing/feed.xml", "/tags/unknown/feed.xml"} {
+			if w := get(t, p); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected 404, got %d", p, w.Code)
+			}
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv.config.Feed.Enabled = false
+		defer func() { srv.config.Feed.Enabled = true }()
+		if w := get(t, "/feed.xml"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 when disabled, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package gomadore
+
+import "os"
+
+// flushSignal is nil: there is no SIGUSR1.
+var flushSignal os.Signal
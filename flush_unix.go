@@ -0,0 +1,11 @@
+//go:build unix
+
+package gomadore
+
+import (
+	"os"
+	"syscall"
+)
+
+// flushSignal makes the process drop its cached pages (see flushCaches).
+var flushSignal os.Signal = syscall.SIGUSR1
@@ -0,0 +1,180 @@
+package gomadore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Path of the pull webhook
+	gitPullPath = "/admin/git/pull"
+	// Default branch of [git]
+	defaultGitBranch = "main"
+	// Default timeout (seconds) of a git command
+	defaultGitTimeout = 120
+	// Upper bound of a webhook request body
+	gitWebhookMaxBytes = 1 << 20
+)
+
+// --- Git Content Backend ---
+
+// gitRepo keeps a clone of the content repository in [git] dir up to date:
+// it is cloned (or pulled, if it exists) when the server is built, and
+// pulled again on POST /admin/git/pull, e.g. from the push webhook of the
+// repository.
+type gitRepo struct {
+	url     string
+	branch  string
+	dir     string
+	token   string
+	timeout time.Duration
+	mu      sync.Mutex // one pull at a time
+}
+
+// newGitRepo clones or pulls the repository (nil if [git] is disabled). A
+// failed pull of an existing clone is logged, and the clone served as is.
+func newGitRepo(cfg Config) (*gitRepo, error) {
+	gc := cfg.Git
+	if !gc.Enabled {
+		return nil, nil
+	}
+	timeout := gc.Timeout
+	if timeout <= 0 {
+		timeout = defaultGitTimeout
+	}
+	g := &gitRepo{
+		url:     gc.URL,
+		branch:  gc.Branch,
+		dir:     gc.Dir,
+		token:   gc.Token,
+		timeout: time.Duration(timeout) * time.Second,
+	}
+	if g.branch == "" {
+		g.branch = defaultGitBranch
+	}
+
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); err != nil {
+		if err := g.clone(); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+	if _, _, err := g.pull(); err != nil {
+		slog.Warn("Failed to pull the content repository; serving the existing clone", "dir", g.dir, "err", err)
+	}
+	return g, nil
+}
+
+// git runs a git command and returns its trimmed output.
+func (g *gitRepo) git(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0") // fail instead of asking for credentials
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g *gitRepo) clone() error {
+	if _, err := g.git("clone", "--branch", g.branch, "--single-branch", "--", g.url, g.dir); err != nil {
+		return err
+	}
+	rev, _ := g.git("-C", g.dir, "rev-parse", "HEAD")
+	slog.Info("Cloned the content repository", "url", g.url, "branch", g.branch, "dir", g.dir, "revision", rev)
+	return nil
+}
+
+// pull fetches the branch and resets the clone to it, discarding local
+// changes. It returns the revision and whether it changed.
+func (g *gitRepo) pull() (string, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	before, err := g.git("-C", g.dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := g.git("-C", g.dir, "fetch", "origin", g.branch); err != nil {
+		return before, false, err
+	}
+	if _, err := g.git("-C", g.dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return before, false, err
+	}
+	after, err := g.git("-C", g.dir, "rev-parse", "HEAD")
+	if err != nil {
+		return before, false, err
+	}
+	if after != before {
+		slog.Info("Pulled the content repository", "dir", g.dir, "from", before, "to", after)
+	}
+	return after, after != before, nil
+}
+
+// authorized reports whether a webhook request carries the token: as a
+// bearer token, GitLab's X-Gitlab-Token, or the HMAC of the body in
+// GitHub's X-Hub-Signature-256.
+func (g *gitRepo) authorized(r *http.Request, body []byte) bool {
+	equal := func(a, b string) bool { return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1 }
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return equal(token, g.token)
+	}
+	if token := r.Header.Get("X-Gitlab-Token"); token != "" {
+		return equal(token, g.token)
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(g.token))
+		mac.Write(body)
+		return equal(sig, hex.EncodeToString(mac.Sum(nil)))
+	}
+	return false
+}
+
+// handleGitPull pulls the content repository and, if it changed, purges the
+// cache. It answers with the revision as JSON.
+func (s *Server) handleGitPull(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, gitWebhookMaxBytes))
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if !s.git.authorized(r, body) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	rev, changed, err := s.git.pull()
+	if err != nil {
+		slog.Error("Failed to pull the content repository", "err", err)
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return
+	}
+	if changed {
+		// Subsites may be served from the same clone
+		for _, srv := range s.servers() {
+			srv.purgeCache()
+		}
+	}
+	b, _ := json.Marshal(struct {
+		Revision string `json:"revision"`
+		Changed  bool   `json:"changed"`
+	}{rev, changed})
+	w.Header().Set("Content-Type", "application/json")
+	_ = writeBody(w, append(b, '\n'))
+}
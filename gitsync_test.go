@@ -0,0 +1,126 @@
+package gomadore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitWebhookAuth(t *testing.T) {
+	g := &gitRepo{token: "s3cret"}
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		header, value string
+		want          bool
+	}{
+		{"Authorization", "Bearer s3cret", true},
+		{"Authorization", "Bearer wrong", false},
+		{"X-Gitlab-Token", "s3cret", true},
+		{"X-Gitlab-Token", "wrong", false},
+		{"X-Hub-Signature-256", sig, true},
+		{"X-Hub-Signature-256", "sha256=00", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, gitPullPath, nil)
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		if got := g.authorized(r, body); got != tt.want {
+			t.Errorf("%s: %s = %v, want %v", tt.header, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGitPull(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	src := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	createFile(t, src, "index.md", "# First Version")
+	if err := os.Mkdir(filepath.Join(src, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, filepath.Join(src, "docs"), "index.md", "# First Docs")
+	run("add", "-A")
+	run("commit", "-q", "-m", "first")
+
+	srv, _ := setupTestServer(t)
+	clone := filepath.Join(t.TempDir(), "clone")
+	cfg := srv.config
+	cfg.HTML.MarkdownRootDir = RootDirs{clone}
+	cfg.Git.Enabled = true
+	cfg.Git.URL = src
+	cfg.Git.Dir = clone
+	cfg.Git.Token = "s3cret"
+	cfg.VHosts = []VHostConfig{{Hosts: []string{"docs.example.com"}, MarkdownRootDir: RootDirs{filepath.Join(clone, "docs")}}}
+	srv, err := newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+	do := func(method, p, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, p, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if body := do(http.MethodGet, "/", "").Body.String(); !strings.Contains(body, "First Version") {
+		t.Fatalf("Expected the cloned page, got %s", body)
+	}
+	docs := func() string {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = "docs.example.com"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if body := docs(); !strings.Contains(body, "First Docs") {
+		t.Fatalf("Expected the cloned subsite page, got %s", body)
+	}
+
+	createFile(t, src, "index.md", "# Second Version")
+	createFile(t, filepath.Join(src, "docs"), "index.md", "# Second Docs")
+	run("commit", "-q", "-am", "second")
+	if w := do(http.MethodPost, gitPullPath, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Wrong token: status %d", w.Code)
+	}
+	w := do(http.MethodPost, gitPullPath, "s3cret")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"changed":true`) {
+		t.Fatalf("Pull: %d %s", w.Code, w.Body.String())
+	}
+	if body := do(http.MethodGet, "/", "").Body.String(); !strings.Contains(body, "Second Version") {
+		t.Errorf("Expected the pulled page, got %s", body)
+	}
+	if body := docs(); !strings.Contains(body, "Second Docs") {
+		t.Errorf("Expected the pull to purge the subsite, got %s", body)
+	}
+	if w := do(http.MethodPost, gitPullPath, "s3cret"); !strings.Contains(w.Body.String(), `"changed":false`) {
+		t.Errorf("Second pull: %s", w.Body.String())
+	}
+}
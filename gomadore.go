@@ -0,0 +1,100 @@
+// Package gomadore renders a directory of Markdown files as HTML pages on
+// request, with caching, hot reload and the other features configured in
+// Config.
+//
+// The gomadore command (cmd/gomadore) runs it as a standalone server. To
+// serve the pages from another Go program, create a Server with New and
+// mount its Handler:
+//
+//	var cfg gomadore.Config
+//	cfg.HTML.MarkdownRootDir = gomadore.RootDirs{"./docs"}
+//	cfg.HTML.SiteTitle = "Docs"
+//	srv, err := gomadore.New(cfg)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.Start(ctx) // optional: file watcher, cache expiry, search index
+//	http.Handle("/docs/", http.StripPrefix("/docs", srv.Handler()))
+package gomadore
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// --- Library API ---
+
+// Option configures a Server created with New.
+type Option func(*options)
+
+type options struct {
+	tmpl        *template.Template
+	forcedTitle string
+}
+
+// WithTemplate renders the pages with t instead of the template of
+// html.template_filepath (or the default template).
+func WithTemplate(t *template.Template) Option {
+	return func(o *options) { o.tmpl = t }
+}
+
+// WithForcedTitle uses title as the title of every page (like -ft).
+func WithForcedTitle(title string) Option {
+	return func(o *options) { o.forcedTitle = title }
+}
+
+// New validates cfg, fills unset options with their defaults and returns a
+// Server for it. The listener options of [general] are not required: serving
+// the Handler is up to the caller.
+func New(cfg Config, opts ...Option) (*Server, error) {
+	if err := validateConfig(cfg, "General.ListenAddr", "General.ListenPort"); err != nil {
+		return nil, err
+	}
+	cfg.applyDefaults()
+	if err := cfg.useEmbeddedContent(); err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t := o.tmpl
+	if t == nil {
+		var tmplPath string
+		var err error
+		if t, _, tmplPath, err = loadTemplate("", cfg); err != nil {
+			return nil, fmt.Errorf("load HTML template (%s): %w", tmplPath, err)
+		}
+	}
+
+	srv, err := newServer(cfg, t)
+	if err != nil {
+		return nil, err
+	}
+	srv.forcedTitle = o.forcedTitle
+	return srv, nil
+}
+
+// Handler returns the HTTP handler serving the pages and the enabled
+// endpoints (feeds, search, sitemap, ...).
+func (s *Server) Handler() http.Handler {
+	return s.handler()
+}
+
+// Start runs the background work of the server until ctx is done: the file
+// watcher (with cache.hot_reload), the expiry of cached pages, the memory
+// monitor (with cache.max_memory_percent) and the initial build of the
+// navigation and search index. Without it, pages are still
+// served; changed files are picked up when their cache entries expire.
+func (s *Server) Start(ctx context.Context) {
+	for _, srv := range s.servers() {
+		srv.start(ctx)
+	}
+	// The caches of all sites share one memory budget
+	if s.config.Cache.MaxMemoryPercent > 0 {
+		go s.startMemoryMonitor(ctx)
+	}
+}
@@ -0,0 +1,60 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "index.md", "# Home\nWelcome")
+	createFile(t, dir, "guide.md", "# Guide")
+
+	var cfg Config
+	cfg.HTML.MarkdownRootDir = RootDirs{dir}
+	cfg.HTML.SiteTitle = "Lib"
+
+	t.Run("Default template", func(t *testing.T) {
+		srv, err := New(cfg)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if srv.config.Cache.MaxCacheItems != 1000 {
+			t.Errorf("Defaults not applied: %+v", srv.config.Cache)
+		}
+
+		ts := httptest.NewServer(http.StripPrefix("/docs", srv.Handler()))
+		defer ts.Close()
+		resp, err := http.Get(ts.URL + "/docs/guide")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		tmpl := template.Must(template.New("base").Parse(`lib:{{ .Title }}`))
+		srv, err := New(cfg, WithTemplate(tmpl), WithForcedTitle("Forced"))
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil))
+		if body := w.Body.String(); body != "lib:Forced" {
+			t.Errorf("Unexpected body %q", body)
+		}
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var bad Config
+		if _, err := New(bad); err == nil || !strings.Contains(err.Error(), "markdown_rootdir") {
+			t.Errorf("Expected validation error for markdown_rootdir, got %v", err)
+		}
+	})
+}
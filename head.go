@@ -0,0 +1,17 @@
+package gomadore
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// --- HEAD Requests ---
+
+// writeBody writes a complete response body with its Content-Length, so that
+// a HEAD request gets the same headers as a GET. net/http drops the body of
+// HEAD responses, after sniffing the Content-Type from it if none is set.
+func writeBody(w http.ResponseWriter, body []byte) error {
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	_, err := w.Write(body)
+	return err
+}
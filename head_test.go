@@ -0,0 +1,63 @@
+package gomadore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeadRequests(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	// Larger than the buffer net/http measures Content-Length with
+	createFile(t, dir, "long.md", "# Long\n\n"+strings.Repeat("Some text of a long page.\n\n", 400))
+	srv.config.Cache.HotReload = true
+	srv.config.Cache.LiveReload = true
+	srv.liveReload = newLiveReload(srv.config)
+
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	do := func(method, p string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(t.Context(), method, ts.URL+p, nil)
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	// A HEAD miss renders the page like a GET
+	head, body := do(http.MethodHead, "/long")
+	if head.StatusCode != http.StatusOK || body != "" || head.Header.Get("X-Cache") != "MISS" {
+		t.Fatalf("HEAD /long: %d %v %q", head.StatusCode, head.Header, body)
+	}
+	get, body := do(http.MethodGet, "/long")
+	if get.ContentLength != int64(len(body)) || head.ContentLength != get.ContentLength {
+		t.Errorf("Content-Length: HEAD %d, GET %d, body %d", head.ContentLength, get.ContentLength, len(body))
+	}
+	for _, h := range []string{"Content-Type", "ETag", "Cache-Control"} {
+		if head.Header.Get(h) != get.Header.Get(h) || get.Header.Get(h) == "" {
+			t.Errorf("%s: HEAD %q, GET %q", h, head.Header.Get(h), get.Header.Get(h))
+		}
+	}
+	if head, _ = do(http.MethodHead, "/long"); head.Header.Get("X-Cache") != "HIT" || head.ContentLength != get.ContentLength {
+		t.Errorf("HEAD hit: %v", head.Header)
+	}
+
+	if head, _ := do(http.MethodHead, "/missing"); head.StatusCode != http.StatusNotFound {
+		t.Errorf("HEAD /missing: %d", head.StatusCode)
+	}
+	// The live reload stream is not started
+	if head, _ := do(http.MethodHead, liveReloadPath); head.StatusCode != http.StatusOK || head.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("HEAD %s: %d %v", liveReloadPath, head.StatusCode, head.Header)
+	}
+	if resp, _ := do(http.MethodPost, "/long"); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /long: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,73 @@
+package gomadore
+
+import "net/http"
+
+// Default values of the [headers] options
+const (
+	defaultXContentTypeOptions     = "nosniff"
+	defaultXFrameOptions           = "SAMEORIGIN"
+	defaultReferrerPolicy          = "strict-origin-when-cross-origin"
+	defaultStrictTransportSecurity = "max-age=31536000"
+)
+
+// --- Security Headers ---
+
+// securityHeaders adds the configured security headers to every response.
+// Every method is safe to call on nil (headers disabled).
+type securityHeaders struct {
+	headers map[string]string // header name -> value (non-empty)
+	hsts    string            // Strict-Transport-Security, HTTPS requests only
+	proxies *trustedProxies   // for requests forwarded over HTTPS
+}
+
+// newSecurityHeaders returns nil if [headers] is disabled. Unset options get
+// their defaults; options set to "" are not sent.
+func newSecurityHeaders(cfg Config, proxies *trustedProxies) *securityHeaders {
+	hc := cfg.Headers
+	if !hc.Enabled {
+		return nil
+	}
+	sh := &securityHeaders{
+		headers: make(map[string]string),
+		hsts:    stringOr(hc.StrictTransportSecurity, defaultStrictTransportSecurity),
+		proxies: proxies,
+	}
+	for name, value := range map[string]string{
+		"Content-Security-Policy": stringOr(hc.ContentSecurityPolicy, ""),
+		"X-Content-Type-Options":  stringOr(hc.XContentTypeOptions, defaultXContentTypeOptions),
+		"X-Frame-Options":         stringOr(hc.XFrameOptions, defaultXFrameOptions),
+		"Referrer-Policy":         stringOr(hc.ReferrerPolicy, defaultReferrerPolicy),
+	} {
+		if value != "" {
+			sh.headers[name] = value
+		}
+	}
+	return sh
+}
+
+// wrap sets the headers before next handles the request, so they are part
+// of every response (including errors and redirects).
+func (sh *securityHeaders) wrap(next http.Handler) http.Handler {
+	if sh == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		for name, value := range sh.headers {
+			h.Set(name, value)
+		}
+		// Browsers ignore HSTS on plain HTTP; only send it over TLS
+		if sh.hsts != "" && sh.proxies.scheme(r) == "https" {
+			h.Set("Strict-Transport-Security", sh.hsts)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stringOr returns *p, or def if the option is not set.
+func stringOr(p *string, def string) string {
+	if p == nil {
+		return def
+	}
+	return *p
+}
@@ -0,0 +1,49 @@
+package gomadore
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	if newSecurityHeaders(Config{}, nil) != nil {
+		t.Error("Expected nil when disabled")
+	}
+
+	srv, _ := setupTestServer(t)
+	csp, none := "default-src 'self'", ""
+	srv.config.Headers.Enabled = true
+	srv.config.Headers.ContentSecurityPolicy = &csp
+	srv.config.Headers.XFrameOptions = &none
+	srv.headers = newSecurityHeaders(srv.config, nil)
+	h := srv.handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", w.Code)
+	}
+	for name, want := range map[string]string{
+		"Content-Security-Policy":   csp,
+		"X-Content-Type-Options":    defaultXContentTypeOptions,
+		"X-Frame-Options":           "",
+		"Referrer-Policy":           defaultReferrerPolicy,
+		"Strict-Transport-Security": "",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	t.Run("HSTS over TLS", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/about", nil)
+		r.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Strict-Transport-Security"); got != defaultStrictTransportSecurity {
+			t.Errorf("Strict-Transport-Security: got %q", got)
+		}
+	})
+}
@@ -0,0 +1,441 @@
+package gomadore
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// --- Syntax Highlighting ---
+
+// Token classes of the highlighter
+const (
+	tokPlain = iota
+	tokKeyword
+	tokType
+	tokString
+	tokComment
+	tokNumber
+	tokKey
+)
+
+// highlightStyle maps token classes to inline CSS.
+type highlightStyle struct {
+	pre    string
+	tokens map[int]string
+}
+
+// Built-in styles (selected by [html] highlight_style)
+var highlightStyles = map[string]highlightStyle{
+	"github": {
+		pre: "color:#24292f;background-color:#f6f8fa;",
+		tokens: map[int]string{
+			tokKeyword: "color:#cf222e",
+			tokType:    "color:#8250df",
+			tokString:  "color:#0a3069",
+			tokComment: "color:#6e7781;font-style:italic",
+			tokNumber:  "color:#0550ae",
+			tokKey:     "color:#116329",
+		},
+	},
+	"monokai": {
+		pre: "color:#f8f8f2;background-color:#272822;",
+		tokens: map[int]string{
+			tokKeyword: "color:#f92672",
+			tokType:    "color:#66d9ef",
+			tokString:  "color:#e6db74",
+			tokComment: "color:#75715e;font-style:italic",
+			tokNumber:  "color:#ae81ff",
+			tokKey:     "color:#a6e22e",
+		},
+	},
+	"dracula": {
+		pre: "color:#f8f8f2;background-color:#282a36;",
+		tokens: map[int]string{
+			tokKeyword: "color:#ff79c6",
+			tokType:    "color:#8be9fd",
+			tokString:  "color:#f1fa8c",
+			tokComment: "color:#6272a4;font-style:italic",
+			tokNumber:  "color:#bd93f9",
+			tokKey:     "color:#50fa7b",
+		},
+	},
+}
+
+// lexer describes the lexical rules of a language.
+type lexer struct {
+	keywords     []string
+	types        []string // built-in types, constants and functions
+	lineComments []string
+	blockComment [2]string
+	quotes       string // string delimiters
+	tripleQuotes bool   // python style """ strings
+	keySep       byte   // "key:" / "key =" is highlighted as key (data formats)
+	ignoreCase   bool   // keywords are case-insensitive
+}
+
+func words(s string) []string { return strings.Fields(s) }
+
+var (
+	cLikeTypes = words("bool char double float int long short signed unsigned void size_t true false NULL nullptr")
+
+	lexers = map[string]*lexer{
+		"go": {
+			keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+			types:        words("any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr true false iota nil append cap clear close copy delete len make max min new panic print println recover"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"c": {
+			keywords:     words("auto break case const continue default do else enum extern for goto if inline register restrict return sizeof static struct switch typedef union volatile while #include #define #ifdef #ifndef #endif #if #else #pragma"),
+			types:        cLikeTypes,
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+		},
+		"cpp": {
+			keywords:     words("auto break case catch class const constexpr continue default delete do else enum explicit export extern for friend goto if inline namespace new noexcept operator private protected public return sizeof static struct switch template this throw try typedef typename union using virtual volatile while #include #define #ifdef #ifndef #endif #if #else #pragma"),
+			types:        append(words("std string vector map"), cLikeTypes...),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+		},
+		"java": {
+			keywords:     words("abstract assert break case catch class const continue default do else enum extends final finally for goto if implements import instanceof interface native new package private protected public return static super switch synchronized this throw throws try var void volatile while record"),
+			types:        words("boolean byte char double float int long short String Object true false null"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'",
+		},
+		"javascript": {
+			keywords:     words("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while with yield"),
+			types:        words("true false null undefined NaN Infinity Array Object String Number Boolean Promise Map Set JSON Math console window document"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"typescript": {
+			keywords:     words("abstract as async await break case catch class const continue declare default delete do else enum export extends finally for from function if implements import in instanceof interface keyof let namespace new of private protected public readonly return static super switch this throw try type typeof var void while yield"),
+			types:        words("any boolean never number object string symbol unknown true false null undefined Array Object Promise Record Map Set"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"python": {
+			keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield match case"),
+			types:        words("True False None self bool bytes dict float int list set str tuple object print len range open super isinstance"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			tripleQuotes: true,
+		},
+		"ruby": {
+			keywords:     words("alias and begin break case class def do else elsif end ensure for if in module next not or redo rescue retry return self super then undef unless until when while yield require attr_accessor attr_reader"),
+			types:        words("true false nil puts print"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+		},
+		"rust": {
+			keywords:     words("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+			types:        words("bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Box Some None Ok Err true false"),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"",
+		},
+		"shell": {
+			keywords:     words("if then else elif fi case esac for while until do done in function return local export readonly unset shift exit break continue select"),
+			types:        words("echo printf cd pwd test read source eval exec set true false"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+		},
+		"sql": {
+			keywords:     words("select from where and or not insert into values update set delete create table drop alter index view join left right inner outer on as group by order having limit offset union all distinct primary key foreign references null is in like between case when then else end begin commit rollback"),
+			types:        words("int integer bigint text varchar char boolean date timestamp real numeric serial"),
+			lineComments: []string{"--"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "'\"",
+			ignoreCase:   true,
+		},
+		"json": {
+			types:  words("true false null"),
+			quotes: "\"",
+			keySep: ':',
+		},
+		"yaml": {
+			types:        words("true false null yes no on off"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			keySep:       ':',
+		},
+		"toml": {
+			types:        words("true false"),
+			lineComments: []string{"#"},
+			quotes:       "\"'",
+			keySep:       '=',
+		},
+	}
+
+	// Alternative names of fenced code block languages
+	lexerAliases = map[string]string{
+		"golang":  "go",
+		"h":       "c",
+		"c++":     "cpp",
+		"cc":      "cpp",
+		"hpp":     "cpp",
+		"js":      "javascript",
+		"jsx":     "javascript",
+		"mjs":     "javascript",
+		"ts":      "typescript",
+		"tsx":     "typescript",
+		"py":      "python",
+		"python3": "python",
+		"rb":      "ruby",
+		"rs":      "rust",
+		"sh":      "shell",
+		"bash":    "shell",
+		"zsh":     "shell",
+		"console": "shell",
+		"yml":     "yaml",
+	}
+)
+
+// lexerFor returns the lexer of a fenced code block language (nil if unknown).
+func lexerFor(lang string) *lexer {
+	lang = strings.ToLower(lang)
+	if alias, ok := lexerAliases[lang]; ok {
+		lang = alias
+	}
+	return lexers[lang]
+}
+
+type token struct {
+	class int
+	text  string
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize splits source code into highlighted tokens. It is a small
+// rule-based scanner (comments, strings, numbers, keywords), not a parser.
+func (lx *lexer) tokenize(src string) []token {
+	var tokens []token
+	plainStart := 0
+	emit := func(start, end, class int) {
+		if plainStart < start {
+			tokens = append(tokens, token{tokPlain, src[plainStart:start]})
+		}
+		tokens = append(tokens, token{class, src[start:end]})
+		plainStart = end
+	}
+	// "#" only starts a comment at the beginning of a word (e.g. not in "$#" or "a#b")
+	atWordStart := func(i int) bool {
+		if i == 0 {
+			return true
+		}
+		r, _ := utf8.DecodeLastRuneInString(src[:i])
+		return unicode.IsSpace(r)
+	}
+
+	i := 0
+scan:
+	for i < len(src) {
+		rest := src[i:]
+
+		// Comments
+		for _, lc := range lx.lineComments {
+			if strings.HasPrefix(rest, lc) && (lc != "#" || atWordStart(i)) {
+				end := strings.IndexByte(rest, '\n')
+				if end < 0 {
+					end = len(rest)
+				}
+				emit(i, i+end, tokComment)
+				i += end
+				continue scan
+			}
+		}
+		if bc := lx.blockComment; bc[0] != "" && strings.HasPrefix(rest, bc[0]) {
+			end := strings.Index(rest[len(bc[0]):], bc[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(bc[0]) + len(bc[1])
+			}
+			emit(i, i+end, tokComment)
+			i += end
+			continue
+		}
+
+		c := src[i]
+
+		// Strings
+		if strings.IndexByte(lx.quotes, c) >= 0 {
+			end := lx.stringEnd(rest)
+			class := tokString
+			if lx.keySep != 0 && lx.isKey(src[i+end:]) {
+				class = tokKey
+			}
+			emit(i, i+end, class)
+			i += end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		prevIdent := false
+		if i > 0 {
+			p, _ := utf8.DecodeLastRuneInString(src[:i])
+			prevIdent = isIdentRune(p)
+		}
+
+		// Numbers
+		if !prevIdent && (unicode.IsDigit(r) || (c == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9')) {
+			end := 1
+			for end < len(rest) && (isIdentRune(rune(rest[end])) || rest[end] == '.') {
+				end++
+			}
+			emit(i, i+end, tokNumber)
+			i += end
+			continue
+		}
+
+		// Identifiers and keywords (a leading "#" for preprocessor directives)
+		if !prevIdent && (isIdentRune(r) || (c == '#' && len(rest) > 1 && unicode.IsLetter(rune(rest[1])))) {
+			end := size
+			for end < len(rest) {
+				r, n := utf8.DecodeRuneInString(rest[end:])
+				if !isIdentRune(r) {
+					break
+				}
+				end += n
+			}
+			word := rest[:end]
+			if lx.ignoreCase {
+				word = strings.ToLower(word)
+			}
+			switch {
+			case lx.keySep != 0 && lx.isKey(rest[end:]):
+				emit(i, i+end, tokKey)
+			case slices.Contains(lx.keywords, word):
+				emit(i, i+end, tokKeyword)
+			case slices.Contains(lx.types, word):
+				emit(i, i+end, tokType)
+			}
+			i += end
+			continue
+		}
+
+		i += size
+	}
+	if plainStart < len(src) {
+		tokens = append(tokens, token{tokPlain, src[plainStart:]})
+	}
+	return tokens
+}
+
+// stringEnd returns the length of the string literal at the start of s.
+// Unterminated strings end at the line end (or at the end of s for
+// backquoted and triple-quoted strings, which may span lines).
+func (lx *lexer) stringEnd(s string) int {
+	q := s[:1]
+	if lx.tripleQuotes && len(s) >= 3 && s[:3] == strings.Repeat(q, 3) {
+		if end := strings.Index(s[3:], s[:3]); end >= 0 {
+			return end + 6
+		}
+		return len(s)
+	}
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q != "`":
+			i++
+		case s[i] == q[0]:
+			return i + 1
+		case s[i] == '\n' && q != "`":
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isKey reports whether the text after a word or string starts with the key separator.
+func (lx *lexer) isKey(after string) bool {
+	after = strings.TrimLeft(after, " \t")
+	return after != "" && after[0] == lx.keySep
+}
+
+// highlightCode renders code as HTML spans with the style's inline CSS.
+// Code of unknown languages is only escaped.
+func highlightCode(w util.BufWriter, code, lang string, style highlightStyle) {
+	lx := lexerFor(lang)
+	if lx == nil {
+		_, _ = w.WriteString(html.EscapeString(code))
+		return
+	}
+	for _, t := range lx.tokenize(code) {
+		css := style.tokens[t.class]
+		if css == "" {
+			_, _ = w.WriteString(html.EscapeString(t.text))
+			continue
+		}
+		_, _ = fmt.Fprintf(w, `<span style="%s">%s</span>`, css, html.EscapeString(t.text))
+	}
+}
+
+// highlighter renders fenced code blocks with syntax highlighting.
+type highlighter struct {
+	style highlightStyle
+}
+
+// newHighlighter returns a goldmark extension for the named style
+// (nil if the style is empty or "none").
+func newHighlighter(name string) goldmark.Extender {
+	style, ok := highlightStyles[name]
+	if !ok {
+		return nil
+	}
+	return &highlighter{style: style}
+}
+
+// Extend implements goldmark.Extender.
+func (h *highlighter) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(h, 100)))
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (h *highlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, h.renderFencedCodeBlock)
+}
+
+func (h *highlighter) renderFencedCodeBlock(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(src))
+	}
+	lang := string(n.Language(src))
+
+	_, _ = fmt.Fprintf(w, `<pre class="highlight" style="%s">`, h.style.pre)
+	if lang != "" {
+		_, _ = fmt.Fprintf(w, `<code class="language-%s">`, html.EscapeString(lang))
+	} else {
+		_, _ = w.WriteString("<code>")
+	}
+	highlightCode(w, code.String(), lang, h.style)
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
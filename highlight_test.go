@@ -0,0 +1,107 @@
+package gomadore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestLexerTokenize(t *testing.T) {
+	tests := []struct {
+		lang string
+		src  string
+		want []token // non-plain tokens only
+	}{
+		{"go", "func main() { // start\n\tx := \"a\\\"b\" + `raw` + 42\n}",
+			[]token{{tokKeyword, "func"}, {tokComment, "// start"}, {tokString, `"a\"b"`}, {tokString, "`raw`"}, {tokNumber, "42"}}},
+		{"golang", "var s string = nil /* c */",
+			[]token{{tokKeyword, "var"}, {tokType, "string"}, {tokType, "nil"}, {tokComment, "/* c */"}}},
+		{"py", "def f():\n    \"\"\"doc \"quoted\" \"\"\"\n    return None # done",
+			[]token{{tokKeyword, "def"}, {tokString, `"""doc "quoted" """`}, {tokKeyword, "return"}, {tokType, "None"}, {tokComment, "# done"}}},
+		{"bash", "echo $# x#y # comment",
+			[]token{{tokType, "echo"}, {tokComment, "# comment"}}},
+		{"SQL", "SELECT id FROM t WHERE n = 'x' -- q",
+			[]token{{tokKeyword, "SELECT"}, {tokKeyword, "FROM"}, {tokKeyword, "WHERE"}, {tokString, "'x'"}, {tokComment, "-- q"}}},
+		{"json", `{"key": "value", "n": 1.5, "b": true}`,
+			[]token{{tokKey, `"key"`}, {tokString, `"value"`}, {tokKey, `"n"`}, {tokNumber, "1.5"}, {tokKey, `"b"`}, {tokType, "true"}}},
+		{"toml", "[html]\nsite_title = \"x\" # c",
+			[]token{{tokKey, "site_title"}, {tokString, `"x"`}, {tokComment, "# c"}}},
+		{"c", "#include <stdio.h>\nint x2 = 0x1F;",
+			[]token{{tokKeyword, "#include"}, {tokType, "int"}, {tokNumber, "0x1F"}}},
+		{"go", "s := \"unterminated\nnext",
+			[]token{{tokString, `"unterminated`}}},
+	}
+	for _, tt := range tests {
+		tokens := lexerFor(tt.lang).tokenize(tt.src)
+
+		// The tokens cover the source exactly
+		var joined strings.Builder
+		var got []token
+		for _, tok := range tokens {
+			joined.WriteString(tok.text)
+			if tok.class != tokPlain {
+				got = append(got, tok)
+			}
+		}
+		if joined.String() != tt.src {
+			t.Errorf("%s: tokens do not cover the source: %q", tt.lang, joined.String())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: token mismatch:\n got: %v\nwant: %v", tt.lang, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: token %d mismatch: got %v, want %v", tt.lang, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	if lexerFor("brainfuck") != nil {
+		t.Error("Unknown language should have no lexer")
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	if newHighlighter("") != nil || newHighlighter("none") != nil {
+		t.Error("Empty or \"none\" style should disable highlighting")
+	}
+
+	md := goldmark.New(goldmark.WithExtensions(newHighlighter("github")))
+	render := func(src string) string {
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(src), &buf); err != nil {
+			t.Fatalf("Convert failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	out := render("```go\nif a < b { return \"<x>\" }\n```\n")
+	for _, want := range []string{
+		`<pre class="highlight" style="color:#24292f;background-color:#f6f8fa;"><code class="language-go">`,
+		`<span style="color:#cf222e">if</span> a &lt; b`,
+		`<span style="color:#0a3069">&#34;&lt;x&gt;&#34;</span>`,
+		"</code></pre>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output should contain %q: %s", want, out)
+		}
+	}
+
+	// Unknown and missing languages are escaped only
+	out = render("```unknown\n<b>if</b>\n```\n\n```\nplain & simple\n```\n")
+	if !strings.Contains(out, `<code class="language-unknown">&lt;b&gt;if&lt;/b&gt;`) || strings.Contains(out, "<span") {
+		t.Errorf("Unknown language output mismatch: %s", out)
+	}
+	if !strings.Contains(out, "<code>plain &amp; simple\n</code>") {
+		t.Errorf("Missing language output mismatch: %s", out)
+	}
+
+	// Indented code blocks are not affected
+	out = render("    if x\n")
+	if !strings.Contains(out, "<pre><code>if x\n</code></pre>") {
+		t.Errorf("Indented code block should be rendered as-is: %s", out)
+	}
+}
@@ -0,0 +1,108 @@
+package gomadore
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hook events
+const (
+	hookContentChanged = "content_changed"
+	hookCachePurged    = "cache_purged"
+	hookServerStarted  = "server_started"
+)
+
+// Default timeout (seconds) of a hook command
+const defaultHookTimeout = 30
+
+// HookConfig runs an external command when one of its events occurs.
+type HookConfig struct {
+	Events  []string `toml:"events" validate:"required,min=1,dive,oneof=content_changed cache_purged server_started"`
+	Command []string `toml:"command" validate:"required,min=1"`
+	Timeout int      `toml:"timeout" validate:"min=0"`
+}
+
+// --- External Hooks ---
+
+// hookRunner starts the configured commands in the background.
+type hookRunner struct {
+	hooks  []HookConfig
+	roots  RootDirs
+	strict bool
+	exts   []string       // suffixes of markdown files
+	base   string         // site URL without trailing slash ("" if not configured)
+	wg     sync.WaitGroup // running commands (used by tests)
+}
+
+// newHookRunner returns nil if no hooks are configured.
+func newHookRunner(cfg Config) *hookRunner {
+	if len(cfg.Hooks) == 0 {
+		return nil
+	}
+	return &hookRunner{
+		hooks:  cfg.Hooks,
+		roots:  cfg.HTML.MarkdownRootDir,
+		strict: cfg.HTML.StrictHtmlUrl,
+		exts:   markdownSuffixes(cfg),
+		base:   strings.TrimSuffix(cfg.HTML.SiteURL, "/"),
+	}
+}
+
+// contentChanged fires content_changed for a created, modified or removed file.
+// Safe to call on nil.
+func (h *hookRunner) contentChanged(file string) {
+	if h == nil {
+		return
+	}
+	env := []string{"GOMADORE_FILE=" + file}
+	if _, rel, ok := h.roots.relPath(file); ok && markdownExt(rel, h.exts) != "" {
+		urlPath := urlPathFor(strings.TrimSuffix(rel, markdownExt(rel, h.exts)), h.strict)
+		env = append(env, "GOMADORE_PATH="+urlPath)
+		if h.base != "" {
+			env = append(env, "GOMADORE_URL="+h.base+urlPath)
+		}
+	}
+	h.fire(hookContentChanged, env...)
+}
+
+// fire runs every hook registered for the event. The event name and the
+// given "KEY=value" pairs are added to the environment. Safe to call on nil.
+func (h *hookRunner) fire(event string, env ...string) {
+	if h == nil {
+		return
+	}
+	for _, hook := range h.hooks {
+		if !slices.Contains(hook.Events, event) {
+			continue
+		}
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.run(hook, append([]string{"GOMADORE_EVENT=" + event}, env...))
+		}()
+	}
+}
+
+func (h *hookRunner) run(hook HookConfig, env []string) {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		slog.Error("Hook command failed", "command", hook.Command, "env", env, "err", err, "output", string(out))
+		return
+	}
+	slog.Debug("Hook command finished", "command", hook.Command, "env", env, "output", string(out))
+}
@@ -0,0 +1,80 @@
+package gomadore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookRunner(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "hook.log")
+
+	var cfg Config
+	cfg.HTML.MarkdownRootDir = RootDirs{dir}
+	cfg.HTML.SiteURL = "https://example.com/"
+	cfg.Hooks = []HookConfig{
+		{
+			Events:  []string{hookContentChanged, hookCachePurged},
+			Command: []string{"sh", "-c", `echo "$GOMADORE_EVENT|$GOMADORE_PATH|$GOMADORE_URL|$GOMADORE_FILE" >> "$0"`, out},
+		},
+		{
+			Events:  []string{hookServerStarted},
+			Command: []string{"false"}, // failures are only logged
+		},
+	}
+	h := newHookRunner(cfg)
+
+	readLog := func(t *testing.T) string {
+		t.Helper()
+		h.wg.Wait()
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Hook did not run: %v", err)
+		}
+		_ = os.Remove(out)
+		return strings.TrimSpace(string(b))
+	}
+
+	t.Run("content_changed", func(t *testing.T) {
+		file := filepath.Join(dir, "sub", "index.md")
+		h.contentChanged(file)
+		want := "content_changed|/sub/|https://example.com/sub/|" + file
+		if got := readLog(t); got != want {
+			t.Errorf("Environment mismatch:\n got: %s\nwant: %s", got, want)
+		}
+
+		// Non-markdown files have no page path
+		h.contentChanged(filepath.Join(dir, "image.png"))
+		if got := readLog(t); got != "content_changed|||"+filepath.Join(dir, "image.png") {
+			t.Errorf("Environment mismatch: got %s", got)
+		}
+	})
+
+	t.Run("Event filter", func(t *testing.T) {
+		h.fire(hookServerStarted)
+		h.wg.Wait()
+		if _, err := os.Stat(out); err == nil {
+			t.Error("Hook must not run for unregistered events")
+		}
+	})
+
+	t.Run("cache_purged via purgeCache", func(t *testing.T) {
+		srv, _ := setupTestServer(t)
+		srv.hooks = h
+		srv.purgeCache()
+		if got := readLog(t); got != "cache_purged|||" {
+			t.Errorf("Environment mismatch: got %s", got)
+		}
+	})
+
+	t.Run("No hooks", func(t *testing.T) {
+		var nilRunner *hookRunner
+		if newHookRunner(Config{}) != nil {
+			t.Error("Expected nil runner without hooks")
+		}
+		nilRunner.fire(hookCachePurged) // must not panic
+		nilRunner.contentChanged(out)
+	})
+}
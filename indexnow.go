@@ -0,0 +1,240 @@
+package gomadore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default IndexNow submission endpoint (shared by all participating search engines)
+	defaultIndexNowEndpoint = "https://api.indexnow.org/indexnow"
+	// Default delay (seconds) to collect changes before notifying
+	defaultIndexNowDebounce = 60
+	// Default HTTP timeout (seconds) for notifications
+	defaultIndexNowTimeout = 10
+)
+
+// IndexNow keys: 8-128 characters of a-z, A-Z, 0-9 and "-"
+var indexNowKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9-]{8,128}$`)
+
+// --- IndexNow / Sitemap Ping ---
+
+// indexNowNotifier collects changed page URLs reported by the watcher and,
+// after a quiet period, submits them to IndexNow and pings the sitemap.
+type indexNowNotifier struct {
+	roots    RootDirs
+	strict   bool
+	base     string // site URL without trailing slash
+	key      string
+	endpoint string
+	pingURLs []string
+	sitemap  bool
+	debounce time.Duration
+	client   *http.Client
+
+	excluded func(root, rel string) bool // files not submitted (see Server.indexNowExcluded)
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	timer   *time.Timer
+}
+
+func newIndexNowNotifier(cfg Config, excluded func(root, rel string) bool) (*indexNowNotifier, error) {
+	ic := cfg.IndexNow
+	if cfg.HTML.SiteURL == "" {
+		return nil, errors.New("html.site_url is required")
+	}
+	if !indexNowKeyPattern.MatchString(ic.Key) {
+		return nil, errors.New("key must be 8-128 characters of a-z, A-Z, 0-9 and '-'")
+	}
+	if len(ic.PingURLs) > 0 && !cfg.Sitemap.Enabled {
+		return nil, errors.New("ping_urls requires [sitemap] to be enabled")
+	}
+
+	endpoint := ic.Endpoint
+	if endpoint == "" {
+		endpoint = defaultIndexNowEndpoint
+	}
+	debounce := ic.Debounce
+	if debounce <= 0 {
+		debounce = defaultIndexNowDebounce
+	}
+	timeout := ic.Timeout
+	if timeout <= 0 {
+		timeout = defaultIndexNowTimeout
+	}
+
+	return &indexNowNotifier{
+		roots:    cfg.HTML.MarkdownRootDir,
+		strict:   cfg.HTML.StrictHtmlUrl,
+		base:     strings.TrimSuffix(cfg.HTML.SiteURL, "/"),
+		key:      ic.Key,
+		endpoint: endpoint,
+		pingURLs: ic.PingURLs,
+		sitemap:  cfg.Sitemap.Enabled,
+		debounce: time.Duration(debounce) * time.Second,
+		client:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		pending:  make(map[string]struct{}),
+
+		excluded: excluded,
+	}, nil
+}
+
+// keyFile is the URL path of the key verification file.
+func (n *indexNowNotifier) keyFile() string {
+	return "/" + n.key + ".txt"
+}
+
+// handleKey serves the key verification file.
+func (n *indexNowNotifier) handleKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, n.key)
+}
+
+// notify records a changed markdown file (created, modified or removed) and
+// (re)starts the debounce timer. Pages left out of the sitemap and protected
+// pages are skipped. Safe to call on nil.
+func (n *indexNowNotifier) notify(file string) {
+	if n == nil {
+		return
+	}
+	root, rel, ok := n.roots.relPath(file)
+	if !ok || (n.excluded != nil && n.excluded(root, rel)) {
+		return
+	}
+	rel = strings.TrimSuffix(rel, path.Ext(rel)) // a markdown file
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending[n.base+urlPathFor(rel, n.strict)] = struct{}{}
+	if n.timer == nil {
+		n.timer = time.AfterFunc(n.debounce, n.flush)
+	} else {
+		n.timer.Reset(n.debounce)
+	}
+}
+
+// flush sends the pending URLs and pings the sitemap.
+func (n *indexNowNotifier) flush() {
+	n.mu.Lock()
+	urls := make([]string, 0, len(n.pending))
+	for u := range n.pending {
+		urls = append(urls, u)
+	}
+	clear(n.pending)
+	n.timer = nil
+	n.mu.Unlock()
+
+	if len(urls) == 0 {
+		return
+	}
+	slices.Sort(urls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.client.Timeout)
+	defer cancel()
+
+	if err := n.submit(ctx, urls); err != nil {
+		slog.Error("IndexNow submission failed", "endpoint", n.endpoint, "err", err)
+	} else {
+		slog.Info("IndexNow submitted", "endpoint", n.endpoint, "urls", len(urls))
+	}
+
+	if !n.sitemap {
+		return
+	}
+	sitemapURL := n.base + "/sitemap.xml"
+	for _, p := range n.pingURLs {
+		if err := n.ping(ctx, p, sitemapURL); err != nil {
+			slog.Error("Sitemap ping failed", "url", p, "err", err)
+		} else {
+			slog.Info("Sitemap pinged", "url", p)
+		}
+	}
+}
+
+// submit posts a URL list to the IndexNow endpoint.
+func (n *indexNowNotifier) submit(ctx context.Context, urls []string) error {
+	u, err := url.Parse(n.base)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]any{
+		"host":        u.Host,
+		"key":         n.key,
+		"keyLocation": n.base + n.keyFile(),
+		"urlList":     urls,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return n.do(req)
+}
+
+// ping requests a sitemap ping URL. A "%s" in the URL is replaced with the
+// escaped sitemap URL; otherwise it is appended.
+func (n *indexNowNotifier) ping(ctx context.Context, pingURL, sitemapURL string) error {
+	escaped := url.QueryEscape(sitemapURL)
+	if strings.Contains(pingURL, "%s") {
+		pingURL = strings.ReplaceAll(pingURL, "%s", escaped)
+	} else {
+		pingURL += escaped
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+	if err != nil {
+		return err
+	}
+	return n.do(req)
+}
+
+func (n *indexNowNotifier) do(req *http.Request) error {
+	req.Header.Set("User-Agent", "gomadore/"+Version)
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// indexNowExcluded reports whether IndexNow leaves out a changed markdown
+// file (rel from root): like the sitemap, drafts (unless show_drafts is set),
+// pages excluded from it ("noindex: true", "sitemap: false") and protected
+// pages. A removed file is submitted, so that its URL is dropped.
+func (s *Server) indexNowExcluded(root, rel string) bool {
+	if s.pageProtected("/" + strings.TrimSuffix(rel, path.Ext(rel))) {
+		return true
+	}
+	p, err := s.files.meta(root, rel)
+	if err != nil {
+		return false
+	}
+	if p.Draft && !s.config.HTML.ShowDrafts {
+		return true
+	}
+	_, listed := sitemapEntry(p, "")
+	return !listed
+}
@@ -0,0 +1,153 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// indexNowRecorder is a fake IndexNow endpoint / sitemap ping target
+type indexNowRecorder struct {
+	mu          sync.Mutex
+	submissions []map[string]any
+	pings       []string
+}
+
+func (rec *indexNowRecorder) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if r.Method == http.MethodPost {
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			rec.submissions = append(rec.submissions, body)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		rec.pings = append(rec.pings, r.URL.Query().Get("sitemap"))
+	})
+}
+
+func newTestIndexNowConfig(dir, endpoint string) Config {
+	var cfg Config
+	cfg.HTML.MarkdownRootDir = RootDirs{dir}
+	cfg.HTML.SiteURL = "https://example.com/"
+	cfg.Sitemap.Enabled = true
+	cfg.IndexNow.Enabled = true
+	cfg.IndexNow.Key = "0123456789abcdef"
+	cfg.IndexNow.Endpoint = endpoint
+	cfg.IndexNow.PingURLs = []string{endpoint + "/ping?sitemap=%s"}
+	return cfg
+}
+
+func TestNewIndexNowNotifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		ok     bool
+	}{
+		{"Valid", func(*Config) {}, true},
+		{"No site URL", func(c *Config) { c.HTML.SiteURL = "" }, false},
+		{"Short key", func(c *Config) { c.IndexNow.Key = "abc" }, false},
+		{"Invalid key", func(c *Config) { c.IndexNow.Key = "0123456789/abcdef" }, false},
+		{"Ping without sitemap", func(c *Config) { c.Sitemap.Enabled = false }, false},
+	}
+	for _, tt := range tests {
+		cfg := newTestIndexNowConfig(t.TempDir(), "https://api.example.com")
+		tt.modify(&cfg)
+		_, err := newIndexNowNotifier(cfg, nil)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: unexpected result: err=%v", tt.name, err)
+		}
+	}
+}
+
+func TestIndexNow(t *testing.T) {
+	rec := &indexNowRecorder{}
+	ts := httptest.NewServer(rec.handler())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	n, err := newIndexNowNotifier(newTestIndexNowConfig(dir, ts.URL), func(_, rel string) bool { return rel == "private.md" })
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	n.debounce = 50 * time.Millisecond
+
+	t.Run("Debounced submission", func(t *testing.T) {
+		n.notify(filepath.Join(dir, "about.md"))
+		n.notify(filepath.Join(dir, "sub", "index.md"))
+		n.notify(filepath.Join(dir, "about.md"))
+		n.notify(filepath.Join(dir, "..", "outside.md")) // ignored
+		n.notify(filepath.Join(dir, "private.md"))       // protected
+
+		time.Sleep(300 * time.Millisecond)
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if len(rec.submissions) != 1 {
+			t.Fatalf("Expected 1 submission, got %d", len(rec.submissions))
+		}
+		sub := rec.submissions[0]
+		if sub["host"] != "example.com" || sub["key"] != "0123456789abcdef" ||
+			sub["keyLocation"] != "https://example.com/0123456789abcdef.txt" {
+			t.Errorf("Unexpected payload: %v", sub)
+		}
+		var urls []string
+		for _, u := range sub["urlList"].([]any) {
+			urls = append(urls, u.(string))
+		}
+		if want := []string{"https://example.com/about", "https://example.com/sub/"}; !slices.Equal(urls, want) {
+			t.Errorf("urlList mismatch: got %v, want %v", urls, want)
+		}
+		if len(rec.pings) != 1 || rec.pings[0] != "https://example.com/sitemap.xml" {
+			t.Errorf("Sitemap ping mismatch: got %v", rec.pings)
+		}
+	})
+
+	t.Run("Key file", func(t *testing.T) {
+		srv, _ := setupTestServer(t)
+		srv.indexNow = n
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/0123456789abcdef.txt", nil)
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "0123456789abcdef" {
+			t.Errorf("Key file mismatch: %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Nil notifier", func(t *testing.T) {
+		var nilNotifier *indexNowNotifier
+		nilNotifier.notify(filepath.Join(dir, "about.md")) // must not panic
+	})
+}
+
+func TestIndexNowExcluded(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "draft.md", "---\ndraft: true\n---\n# Draft")
+	createFile(t, dir, "hidden.md", "---\nnoindex: true\n---\n# Hidden")
+	createFile(t, dir, "unlisted.md", "---\nsitemap: false\n---\n# Unlisted")
+	srv.auth = &basicAuth{paths: []string{"/sub/"}}
+	tests := map[string]bool{
+		"about.md":    false,
+		"removed.md":  false, // submitted so that it is dropped
+		"draft.md":    true,
+		"hidden.md":   true,
+		"unlisted.md": true,
+		"sub/deep.md": true,
+	}
+	for rel, want := range tests {
+		if got := srv.indexNowExcluded(dir, rel); got != want {
+			t.Errorf("indexNowExcluded(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	srv.config.HTML.ShowDrafts = true
+	if srv.indexNowExcluded(dir, "draft.md") {
+		t.Error("Drafts are published with show_drafts")
+	}
+}
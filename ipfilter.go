@@ -0,0 +1,59 @@
+package gomadore
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+)
+
+// --- IP Filter ---
+
+// ipFilter refuses requests by the address of the client. Every method is
+// safe to call on nil (filter disabled).
+type ipFilter struct {
+	allow   []netip.Prefix // empty: every address not denied
+	deny    []netip.Prefix
+	proxies *trustedProxies
+}
+
+// newIPFilter returns nil if [ip_filter] is disabled.
+func newIPFilter(cfg Config, proxies *trustedProxies) (*ipFilter, error) {
+	if !cfg.IPFilter.Enabled {
+		return nil, nil
+	}
+	allow, err := parsePrefixes(cfg.IPFilter.AllowCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("allow_cidrs: %w", err)
+	}
+	deny, err := parsePrefixes(cfg.IPFilter.DenyCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("deny_cidrs: %w", err)
+	}
+	return &ipFilter{allow: allow, deny: deny, proxies: proxies}, nil
+}
+
+// permits reports whether a client address may access the site: it must not
+// be in deny_cidrs and, if allow_cidrs is set, must be in it. An unknown
+// address is only permitted without allow_cidrs.
+func (f *ipFilter) permits(addr netip.Addr) bool {
+	if prefixesContain(f.deny, addr) {
+		return false
+	}
+	return len(f.allow) == 0 || prefixesContain(f.allow, addr)
+}
+
+// wrap answers 403 Forbidden to requests of clients that are not permitted.
+func (f *ipFilter) wrap(next http.Handler) http.Handler {
+	if f == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr := f.proxies.clientIP(r); !f.permits(addr) {
+			slog.Debug("Request refused by the IP filter", "client", addr, "path", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
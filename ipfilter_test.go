@@ -0,0 +1,52 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	cfg := srv.config
+	cfg.IPFilter.Enabled = true
+	cfg.IPFilter.AllowCIDRs = []string{"10.0.0.0/8", "2001:db8::/32"}
+	cfg.IPFilter.DenyCIDRs = []string{"10.6.6.0/24"}
+	cfg.Proxy.TrustedProxies = []string{"192.168.0.1"}
+	srv, err := newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+
+	tests := []struct {
+		remote, xff string
+		code        int
+	}{
+		{"10.1.2.3:1234", "", http.StatusOK},
+		{"[2001:db8::5]:1234", "", http.StatusOK},
+		{"10.6.6.6:1234", "", http.StatusForbidden},            // denied
+		{"203.0.113.5:1234", "", http.StatusForbidden},         // not allowed
+		{"203.0.113.5:1234", "10.1.2.3", http.StatusForbidden}, // forged header
+		{"192.168.0.1:1234", "10.1.2.3", http.StatusOK},        // via the proxy
+		{"192.168.0.1:1234", "203.0.113.5", http.StatusForbidden},
+		{"192.168.0.1:1234", "", http.StatusForbidden}, // the proxy itself
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/about", nil)
+		r.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			r.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s (X-Forwarded-For %q): status %d, want %d", tt.remote, tt.xff, w.Code, tt.code)
+		}
+	}
+
+	cfg.IPFilter.DenyCIDRs = []string{"not-an-ip"}
+	if _, err := newServer(cfg, srv.tmpl); err == nil {
+		t.Error("Expected an error for an invalid deny_cidrs entry")
+	}
+}
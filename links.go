@@ -0,0 +1,67 @@
+package gomadore
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// --- Markdown Link Rewriting ---
+
+// mdLinks rewrites relative links to markdown files ("./spec.md#usage") into
+// the URLs the files are served at ("./spec#usage", or "./spec.html#usage"
+// with strict_html_url), so content written for browsing on GitHub works
+// unchanged. Links with a scheme or host are left alone.
+type mdLinks struct {
+	exts   []string // markdown_extensions
+	strict bool
+}
+
+// Extend implements goldmark.Extender.
+func (e *mdLinks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(e, 100)))
+}
+
+// Transform implements parser.ASTTransformer.
+func (e *mdLinks) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*ast.Link); ok && entering {
+			if dest, ok := rewriteMarkdownLink(string(link.Destination), e.exts, e.strict); ok {
+				link.Destination = []byte(dest)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// rewriteMarkdownLink returns the served URL of a relative link to a markdown
+// file (with one of the suffixes exts), and false if dest is not one. The
+// query and fragment are kept.
+func rewriteMarkdownLink(dest string, exts []string, strict bool) (string, bool) {
+	p, suffix := dest, ""
+	if i := strings.IndexAny(dest, "?#"); i >= 0 {
+		p, suffix = dest[:i], dest[i:]
+	}
+	if strings.HasPrefix(p, "//") || strings.ContainsRune(strings.SplitN(p, "/", 2)[0], ':') {
+		return "", false // other host, or a scheme such as "https:"
+	}
+	ext := markdownExt(p, exts)
+	if ext == "" {
+		return "", false
+	}
+
+	p = p[:len(p)-len(ext)]
+	switch {
+	case strict:
+		p += ".html"
+	case p == "index":
+		p = "./"
+	case strings.HasSuffix(p, "/index"):
+		p = strings.TrimSuffix(p, "index")
+	}
+	return p + suffix, true
+}
@@ -0,0 +1,50 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRewriteMarkdownLink(t *testing.T) {
+	tests := []struct {
+		dest   string
+		strict bool
+		want   string
+		ok     bool
+	}{
+		{"./spec.md", false, "./spec", true},
+		{"./spec.md", true, "./spec.html", true},
+		{"../api/Ref.MD#usage", false, "../api/Ref#usage", true},
+		{"/guide/setup.md?x=1", true, "/guide/setup.html?x=1", true},
+		{"guide/index.md", false, "guide/", true},
+		{"guide/index.md", true, "guide/index.html", true},
+		{"index.md#top", false, "./#top", true},
+		{"https://example.com/README.md", false, "", false},
+		{"//example.com/a.md", false, "", false},
+		{"mailto:a.md", false, "", false},
+		{"#notes.md", false, "", false},
+		{"./image.png", false, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := rewriteMarkdownLink(tt.dest, []string{".md"}, tt.strict)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rewriteMarkdownLink(%q, %v) = %q, %v; want %q, %v", tt.dest, tt.strict, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "links.md", "# Links\n\n[spec](./spec.md#usage) [ref][r] [site](https://example.com/x.md)\n\n[r]: sub/deep.md\n")
+
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/links", nil))
+	body := w.Body.String()
+	for _, want := range []string{`href="./spec#usage"`, `href="sub/deep"`, `href="https://example.com/x.md"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Missing %s in %s", want, body)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package gomadore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Prefix of listen_addr for a Unix domain socket ("unix:/run/gomadore.sock")
+const unixAddrPrefix = "unix:"
+
+// Default permissions of the Unix domain socket (general.socket_mode)
+const defaultSocketMode = "0660"
+
+// --- Listener ---
+
+// unixSocketPath returns the socket path of a "unix:" listen_addr.
+func unixSocketPath(listenAddr string) (string, bool) {
+	p, ok := strings.CutPrefix(listenAddr, unixAddrPrefix)
+	return p, ok && p != ""
+}
+
+// listenAddress returns the address of the main listener for logs and hooks:
+// "host:port", or "unix:<path>".
+func listenAddress(cfg Config) string {
+	if _, ok := unixSocketPath(cfg.General.ListenAddr); ok {
+		return cfg.General.ListenAddr
+	}
+	return fmt.Sprintf("%s:%d", cfg.General.ListenAddr, cfg.General.ListenPort)
+}
+
+// checkListener validates the listener options that depend on each other:
+// listen_port is required unless listen_addr is a Unix domain socket, and
+// socket_mode must be an octal permission.
+func checkListener(cfg Config) error {
+	g := cfg.General
+	if _, ok := unixSocketPath(g.ListenAddr); !ok {
+		if g.ListenPort == 0 {
+			return errors.New("listen_port is required unless listen_addr is \"unix:<path>\"")
+		}
+		return nil
+	}
+	if _, err := parseSocketMode(g.SocketMode); err != nil {
+		return err
+	}
+	if cfg.TLS.Enabled && cfg.TLS.RedirectHTTP {
+		return errors.New("tls.redirect_http cannot be used with a Unix domain socket")
+	}
+	return nil
+}
+
+// parseSocketMode parses socket_mode ("0660"); "" is the default.
+func parseSocketMode(s string) (fs.FileMode, error) {
+	if s == "" {
+		s = defaultSocketMode
+	}
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("socket_mode %q: must be an octal permission like \"0660\"", s)
+	}
+	return fs.FileMode(m), nil
+}
+
+// listenTCP opens an additional TCP listener, or takes it over from the
+// process that started this one for an upgrade.
+func listenTCP(name, addr string) (net.Listener, error) {
+	if ln, err := inheritedListener(name); ln != nil || err != nil {
+		return ln, err
+	}
+	return net.Listen("tcp", addr)
+}
+
+// listen opens the main listener (or takes it over after an upgrade): a TCP port, or a Unix domain socket with
+// the permissions of socket_mode. A socket left behind by a previous process
+// is replaced; any other file at the path is an error. The socket file is
+// removed when the listener is closed.
+func listen(cfg Config) (net.Listener, error) {
+	if ln, err := inheritedListener("main"); ln != nil || err != nil {
+		return ln, err
+	}
+	sock, ok := unixSocketPath(cfg.General.ListenAddr)
+	if !ok {
+		return net.Listen("tcp", listenAddress(cfg))
+	}
+	mode, err := parseSocketMode(cfg.General.SocketMode)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(sock); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", sock)
+		}
+		if err := os.Remove(sock); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(sock, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
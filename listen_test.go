@@ -0,0 +1,106 @@
+package gomadore
+
+import (
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*Config)
+		wantErr string
+	}{
+		{"TCP", func(c *Config) { c.General.ListenPort = 8080 }, ""},
+		{"TCP without port", func(c *Config) {}, "listen_port"},
+		{"Unix socket", func(c *Config) { c.General.ListenAddr = "unix:/run/gomadore.sock" }, ""},
+		{"Empty socket path", func(c *Config) { c.General.ListenAddr = "unix:" }, "listen_port"},
+		{"Invalid mode", func(c *Config) {
+			c.General.ListenAddr = "unix:/run/gomadore.sock"
+			c.General.SocketMode = "rw-rw----"
+		}, "socket_mode"},
+		{"HTTP redirect", func(c *Config) {
+			c.General.ListenAddr = "unix:/run/gomadore.sock"
+			c.TLS.Enabled, c.TLS.RedirectHTTP = true, true
+		}, "redirect_http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cfg.General.ListenAddr = "127.0.0.1"
+			tt.edit(&cfg)
+			err := checkListener(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected an error about %s, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "g.sock")
+	var cfg Config
+	cfg.General.ListenAddr = "unix:" + sock
+	cfg.General.SocketMode = "0600"
+
+	open := func() net.Listener {
+		t.Helper()
+		ln, err := listen(cfg)
+		if err != nil {
+			t.Fatalf("listen failed: %v", err)
+		}
+		return ln
+	}
+
+	ln := open()
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected socket mode: %v", info.Mode())
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go srv.Serve(ln)
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", sock) },
+	}}
+	resp, err := client.Get("http://localhost/")
+	if err != nil {
+		t.Fatalf("Request over the socket failed: %v", err)
+	}
+	resp.Body.Close()
+	srv.Close()
+
+	t.Run("Stale socket is replaced", func(t *testing.T) {
+		// A socket file left behind by a killed process
+		l, err := net.Listen("unix", sock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+		open().Close()
+		if _, err := os.Stat(sock); !os.IsNotExist(err) {
+			t.Errorf("Expected the socket to be removed on close, got %v", err)
+		}
+	})
+
+	t.Run("Other files are kept", func(t *testing.T) {
+		createFile(t, filepath.Dir(sock), "g.sock", "data")
+		if _, err := listen(cfg); err == nil {
+			t.Error("Expected an error for a regular file at the socket path")
+		}
+	})
+}
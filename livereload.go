@@ -0,0 +1,159 @@
+package gomadore
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event stream of the live reload script. Hidden paths are never served as
+// pages, so it cannot shadow a markdown file.
+const liveReloadPath = "/.gomadore/livereload"
+
+// Script injected into rendered pages when live reload is enabled. The
+// browser reconnects on its own after a restart or a configuration reload.
+const liveReloadScript = `<script>new EventSource("` + liveReloadPath + `").addEventListener("reload", () => location.reload());</script>`
+
+// Interval of keep-alive comments, so proxies do not drop idle streams
+const liveReloadKeepAlive = 30 * time.Second
+
+// --- Live Reload (Server-Sent Events) ---
+
+// liveReload pushes a reload event to every open page when the file watcher
+// detects a change.
+type liveReload struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	closed  bool
+	reload  bool // send a reload event when closing the streams
+}
+
+// newLiveReload returns nil unless both hot_reload and live_reload are enabled.
+func newLiveReload(cfg Config) *liveReload {
+	if !cfg.Cache.HotReload || !cfg.Cache.LiveReload {
+		return nil
+	}
+	return &liveReload{clients: make(map[chan struct{}]struct{})}
+}
+
+// inject adds the live reload script before the closing body tag of a
+// rendered page (or at its end). Safe to call on nil.
+func (lr *liveReload) inject(page []byte) []byte {
+	if lr == nil {
+		return page
+	}
+	i := bytes.LastIndex(page, []byte("</body>"))
+	if i < 0 {
+		return append(page, liveReloadScript...)
+	}
+	out := make([]byte, 0, len(page)+len(liveReloadScript))
+	out = append(out, page[:i]...)
+	out = append(out, liveReloadScript...)
+	return append(out, page[i:]...)
+}
+
+// notify sends a reload event to all connected pages. Safe to call on nil.
+func (lr *liveReload) notify() {
+	if lr == nil {
+		return
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for ch := range lr.clients {
+		select {
+		case ch <- struct{}{}:
+		default: // a reload is already pending
+		}
+	}
+}
+
+// close ends all streams. With reload (the Server was replaced), pages get a
+// last reload event and reconnect to the new Server. Safe to call on nil.
+func (lr *liveReload) close(reload bool) {
+	if lr == nil {
+		return
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.closed = true
+	lr.reload = reload
+	for ch := range lr.clients {
+		close(ch)
+		delete(lr.clients, ch)
+	}
+}
+
+func (lr *liveReload) subscribe() (chan struct{}, bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	if lr.closed {
+		return nil, false
+	}
+	ch := make(chan struct{}, 1)
+	lr.clients[ch] = struct{}{}
+	return ch, true
+}
+
+func (lr *liveReload) unsubscribe(ch chan struct{}) {
+	lr.mu.Lock()
+	delete(lr.clients, ch)
+	lr.mu.Unlock()
+}
+
+func (lr *liveReload) handleEvents(w http.ResponseWriter, r *http.Request) {
+	ch, ok := lr.subscribe()
+	if !ok {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer lr.unsubscribe(ch)
+
+	// The stream outlives any write timeout of the listener
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return // no stream
+	}
+	send := func(msg string) bool {
+		if _, err := fmt.Fprint(w, msg); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !send(": connected\n\n") {
+		return
+	}
+
+	ticker := time.NewTicker(liveReloadKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case _, open := <-ch:
+			if !open {
+				// Closed: lr.reload was set before the channel was closed
+				if lr.reload {
+					send("event: reload\ndata: {}\n\n")
+				}
+				return
+			}
+			if !send("event: reload\ndata: {}\n\n") {
+				return
+			}
+			slog.Debug("Live reload sent", "remote_addr", r.RemoteAddr)
+		case <-ticker.C:
+			if !send(": keep-alive\n\n") {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gomadore
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiveReloadInject(t *testing.T) {
+	var off *liveReload
+	if got := string(off.inject([]byte("<body></body>"))); got != "<body></body>" {
+		t.Errorf("nil inject changed the page: %q", got)
+	}
+
+	lr := &liveReload{clients: make(map[chan struct{}]struct{})}
+	got := string(lr.inject([]byte("<html><body><p>x</p></body></html>")))
+	if want := "<html><body><p>x</p>" + liveReloadScript + "</body></html>"; got != want {
+		t.Errorf("inject = %q, want %q", got, want)
+	}
+	if got := string(lr.inject([]byte("<p>x</p>"))); got != "<p>x</p>"+liveReloadScript {
+		t.Errorf("inject without body = %q", got)
+	}
+}
+
+func TestLiveReload(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.HotReload = true
+	srv.config.Cache.LiveReload = true
+	srv.liveReload = newLiveReload(srv.config)
+
+	// Pages carry the script
+	req := httptest.NewRequestWithContext(t.Context(), "GET", "/index", nil)
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, req)
+	if !strings.Contains(w.Body.String(), liveReloadScript) {
+		t.Errorf("page does not contain the live reload script: %s", w.Body.String())
+	}
+
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	open := func() *bufio.Reader {
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+liveReloadPath, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		br := bufio.NewReader(resp.Body)
+		if line, _ := br.ReadString('\n'); line != ": connected\n" {
+			t.Fatalf("first line = %q", line)
+		}
+		_, _ = br.ReadString('\n')
+		return br
+	}
+	readEvent := func(br *bufio.Reader) string {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return ""
+		}
+		return line
+	}
+
+	// A change reaches the open stream
+	br := open()
+	createFile(t, dir, "about.md", "# About\nChanged")
+	srv.liveReload.notify()
+	if got := readEvent(br); got != "event: reload\n" {
+		t.Errorf("event after notify = %q", got)
+	}
+	_, _ = br.ReadString('\n') // data
+	_, _ = br.ReadString('\n') // blank line
+
+	// Closing for a replacement Server sends a last reload and ends the stream
+	srv.liveReload.close(true)
+	if got := readEvent(br); got != "event: reload\n" {
+		t.Errorf("event on close = %q", got)
+	}
+	_, _ = br.ReadString('\n')
+	_, _ = br.ReadString('\n')
+	if _, err := br.ReadString('\n'); err == nil {
+		t.Error("stream still open after close")
+	}
+
+	// A closed Server refuses new streams
+	resp, err := http.Get(ts.URL + liveReloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status after close = %d", resp.StatusCode)
+	}
+}
+
+func TestLiveReloadDisabled(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.Cache.HotReload = false
+	srv.config.Cache.LiveReload = true
+	if newLiveReload(srv.config) != nil {
+		t.Error("live reload enabled without hot_reload")
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), "GET", "/index", nil)
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, req)
+	if strings.Contains(w.Body.String(), "EventSource") {
+		t.Error("page contains the live reload script while disabled")
+	}
+}
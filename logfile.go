@@ -0,0 +1,189 @@
+package gomadore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Timestamp of rotated log files (e.g. access-20250102T150405.000.log)
+	logBackupLayout = "20060102T150405.000"
+	// Delay before a failed rotation is tried again
+	logRotateRetry = time.Minute
+)
+
+// --- Log Files ---
+
+// rotatingFile appends to a log file. With a maximum size, the file is
+// renamed with a timestamp before it would grow beyond it, and a new one is
+// started; rotated files beyond maxBackups or older than maxAge are removed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64         // bytes; 0: never rotate
+	maxAge     time.Duration // 0: keep
+	maxBackups int           // 0: keep all
+
+	mu     sync.Mutex
+	file   *os.File // nil if it could not be reopened after a rotation
+	size   int64
+	retry  time.Time // no rotation before (after a failed one)
+	closed bool
+}
+
+// openLogFile opens a log file with the rotation settings of [general].
+func openLogFile(path string, cfg Config) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.General.LogMaxSizeMB) << 20,
+		maxAge:     time.Duration(cfg.General.LogMaxAgeDays) * 24 * time.Hour,
+		maxBackups: cfg.General.LogMaxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.prune()
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file != nil && f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize && !time.Now().Before(f.retry) {
+		f.rotate()
+	}
+	if f.file == nil {
+		// Not reopened after a rotation; try again
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// backupName returns an unused name for the file rotated at t: with the
+// timestamp of t, or of the next millisecond without a rotated file.
+func (f *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	for ; ; t = t.Add(time.Millisecond) {
+		name := strings.TrimSuffix(f.path, ext) + "-" + t.Format(logBackupLayout) + ext
+		if _, err := os.Lstat(name); err != nil {
+			return name
+		}
+	}
+}
+
+// rotate renames the file and starts a new one. If that fails, writing goes
+// on at the end of the file (reopened by Write if need be), rotation is
+// retried after logRotateRetry, and the error is reported on stderr, since
+// the log may be this file. The caller holds f.mu.
+func (f *rotatingFile) rotate() {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = os.Rename(f.path, f.backupName(time.Now()))
+	}
+	if err != nil {
+		f.retry = time.Now().Add(logRotateRetry)
+		fmt.Fprintf(os.Stderr, "gomadore: failed to rotate log file %s: %v\n", f.path, err)
+	}
+	if f.open() == nil && err == nil {
+		go f.prune()
+	}
+}
+
+// prune removes the rotated files beyond maxBackups and older than maxAge.
+func (f *rotatingFile) prune() {
+	if f.maxBackups <= 0 && f.maxAge <= 0 {
+		return
+	}
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	files, _ := filepath.Glob(prefix + "*" + ext)
+	type backup struct {
+		file string
+		t    time.Time
+	}
+	var backups []backup
+	for _, file := range files {
+		t, err := time.ParseInLocation(logBackupLayout, strings.TrimSuffix(strings.TrimPrefix(file, prefix), ext), time.Local)
+		if err == nil {
+			backups = append(backups, backup{file, t})
+		}
+	}
+	// Newest first
+	slices.SortFunc(backups, func(a, b backup) int { return b.t.Compare(a.t) })
+	for i, b := range backups {
+		if f.maxBackups > 0 && i >= f.maxBackups || f.maxAge > 0 && time.Since(b.t) > f.maxAge {
+			_ = os.Remove(b.file)
+		}
+	}
+}
+
+var (
+	serverLogMu   sync.Mutex
+	serverLogFile *rotatingFile // nil when logging to stderr
+)
+
+// setupLogOutput sets up the server log: on stderr, or appended to log_file
+// (reopened on every call, so that a reload picks up a moved file).
+func setupLogOutput(cfg Config) error {
+	var w io.Writer = os.Stderr
+	var file *rotatingFile
+	if cfg.General.LogFile != "" {
+		var err error
+		if file, err = openLogFile(cfg.General.LogFile, cfg); err != nil {
+			return err
+		}
+		w = file
+	}
+	setupLogger(w, cfg.General.LogLevel, cfg.General.LogType)
+
+	serverLogMu.Lock()
+	prev := serverLogFile
+	serverLogFile = file
+	serverLogMu.Unlock()
+	if prev != nil {
+		_ = prev.Close()
+	}
+	return nil
+}
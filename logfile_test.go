@@ -0,0 +1,132 @@
+package gomadore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "server.log")
+	var cfg Config
+	cfg.General.LogMaxSizeMB = 1
+	cfg.General.LogMaxBackups = 2
+	f, err := openLogFile(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for range 1024 {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 1<<20 {
+		t.Fatalf("A full file should not be rotated yet: %v %v", info, err)
+	}
+	if _, err := f.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(len(line)) {
+		t.Fatalf("Expected a new file after rotation: %v %v", info, err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "logs", "server-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 rotated file, got %v", backups)
+	}
+
+	// Older backups beyond log_max_backups and log_max_age_days are removed
+	old := time.Now().Add(-48 * time.Hour)
+	for i := range 3 {
+		createFile(t, filepath.Join(dir, "logs"), "server-"+old.Add(time.Duration(i)*time.Minute).Format(logBackupLayout)+".log", "old")
+	}
+	createFile(t, filepath.Join(dir, "logs"), "server-notes.log", "kept")
+	f.prune()
+	if backups, _ := filepath.Glob(filepath.Join(dir, "logs", "server-2*.log")); len(backups) != 2 {
+		t.Errorf("Expected 2 backups to be kept, got %v", backups)
+	}
+	cfg.General.LogMaxBackups = 0
+	cfg.General.LogMaxAgeDays = 1
+	g, err := openLogFile(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if backups, _ := filepath.Glob(filepath.Join(dir, "logs", "server-2*.log")); len(backups) != 1 {
+		t.Errorf("Expected only the recent backup, got %v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logs", "server-notes.log")); err != nil {
+		t.Error("A file that is not a backup was removed")
+	}
+}
+
+func TestRotatingFileUnlimited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	createFile(t, filepath.Dir(path), "access.log", "before\n")
+	f, err := openLogFile(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("closed\n")); err == nil {
+		t.Error("Write after Close should fail")
+	}
+	if b, _ := os.ReadFile(path); string(b) != "before\nafter\n" {
+		t.Errorf("Expected the file to be appended to, got %q", b)
+	}
+}
+
+func TestRotatingFileFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	var cfg Config
+	cfg.General.LogMaxSizeMB = 1
+	f, err := openLogFile(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	big := []byte(strings.Repeat("x", 1<<20-1) + "\n")
+	if _, err := f.Write(big); err != nil {
+		t.Fatal(err)
+	}
+	// The rename fails if the file was removed behind our back
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Writing should go on after a failed rotation: %v", err)
+		}
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "first\nsecond\n" {
+		t.Errorf("Expected the log file to be reopened, got %q %v", data, err)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, "server-*.log")); len(backups) != 0 {
+		t.Errorf("Expected no rotated file, got %v", backups)
+	}
+	if f.retry.IsZero() {
+		t.Error("A failed rotation should be retried later")
+	}
+
+	// Rotations in the same millisecond do not overwrite each other
+	now := time.Now()
+	first := f.backupName(now)
+	createFile(t, dir, filepath.Base(first), "rotated")
+	if second := f.backupName(now); second == first {
+		t.Errorf("Backup name %s is already used", second)
+	} else if _, err := time.Parse(logBackupLayout, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(second), "server-"), ".log")); err != nil {
+		t.Errorf("Backup name %s has no timestamp: %v", second, err)
+	}
+}
@@ -1,10 +1,12 @@
-package main
+package gomadore
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
@@ -12,69 +14,389 @@ import (
 	"io/fs"
 	"log"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
-	"reflect"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/fsnotify/fsnotify"
-	"github.com/go-playground/validator/v10"
 	"github.com/yuin/goldmark"
-	"github.com/yuin/goldmark/ast"
-	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/sync/singleflight"
 )
 
+// Build information, set at link time (see version.go):
+//
+//	go build -ldflags "-X github.com/kumakaba/gomadore.Version=v1.2.0 -X github.com/kumakaba/gomadore.Revision=release ..." ./cmd/gomadore
 var (
-	Version    = "v1.1.0"  // VERSION_STR
-	Revision   = "release" // VERSION_STR
+	Version    = "" // Default: module version from build info, or "dev"
+	Revision   = "" // Default: short VCS revision from build info, or "unknown"
+	Commit     = "" // Default: VCS revision from build info
+	BuildDate  = "" // Default: VCS commit time from build info
 	Maintainer = "kumakaba"
 )
 
+// Errors of page rendering (see renderPage)
+var (
+	errOutsideRoot        = errors.New("path is outside of the markdown root")
+	errMarkdownConversion = errors.New("markdown conversion failed")
+	errTemplateExecution  = errors.New("template execution failed")
+	errDraft              = errors.New("page is a draft") // also wraps fs.ErrNotExist
+)
+
 // --- Configuration Struct ---
 type Config struct {
 	General struct {
-		ListenAddr string `toml:"listen_addr" validate:"required"`
-		ListenPort int    `toml:"listen_port" validate:"required"`
-		LogLevel   string `toml:"log_level" validate:"omitempty,oneof=debug info error"`
-		LogType    string `toml:"log_type" validate:"omitempty,oneof=text json"`
+		ListenAddr        string `toml:"listen_addr" validate:"required"`
+		ListenPort        int    `toml:"listen_port" validate:"min=0,max=65535"`
+		PIDFile           string `toml:"pid_file"`
+		SocketMode        string `toml:"socket_mode"`
+		LogLevel          string `toml:"log_level" validate:"omitempty,oneof=debug info error"`
+		LogType           string `toml:"log_type" validate:"omitempty,oneof=text json"`
+		ReadHeaderTimeout int    `toml:"read_header_timeout"`
+		ReadTimeout       int    `toml:"read_timeout"`
+		WriteTimeout      int    `toml:"write_timeout"`
+		IdleTimeout       int    `toml:"idle_timeout"`
+		MaxHeaderBytes    int    `toml:"max_header_bytes" validate:"min=0"`
+		WatchConfig       bool   `toml:"watch_config"`
+		LogFile           string `toml:"log_file"`
+		LogMaxSizeMB      int    `toml:"log_max_size_mb" validate:"min=0"`
+		LogMaxAgeDays     int    `toml:"log_max_age_days" validate:"min=0"`
+		LogMaxBackups     int    `toml:"log_max_backups" validate:"min=0"`
 	} `toml:"general"`
 	HTML struct {
-		MarkdownRootDir  string `toml:"markdown_rootdir" validate:"required"`
-		SiteTitle        string `toml:"site_title"`
-		SiteLang         string `toml:"site_lang"`
-		SiteAuthor       string `toml:"site_author"`
-		BaseCSSUrl       string `toml:"base_css_url"`
-		ScreenCSSUrl     string `toml:"screen_css_url"`
-		PrintCSSUrl      string `toml:"print_css_url"`
-		StrictHtmlUrl    bool   `toml:"strict_html_url"`
-		TemplateFilePath string `toml:"template_filepath"`
+		MarkdownRootDir  RootDirs `toml:"markdown_rootdir" validate:"required_unless=ContentSource embedded,dive,required"`
+		ContentSource    string   `toml:"content_source" validate:"omitempty,oneof=directory embedded"`
+		SiteURL          string   `toml:"site_url" validate:"omitempty,url"`
+		SiteTitle        string   `toml:"site_title"`
+		SiteLang         string   `toml:"site_lang"`
+		SiteAuthor       string   `toml:"site_author"`
+		BaseCSSUrl       string   `toml:"base_css_url"`
+		ScreenCSSUrl     string   `toml:"screen_css_url"`
+		PrintCSSUrl      string   `toml:"print_css_url"`
+		StrictHtmlUrl    bool     `toml:"strict_html_url"`
+		TemplateFilePath string   `toml:"template_filepath"`
+		StripFirstH1     bool     `toml:"strip_first_h1"`
+		HeadingOffset    int      `toml:"heading_offset" validate:"min=0,max=5"`
+		HighlightStyle   string   `toml:"highlight_style" validate:"omitempty,oneof=none github monokai dracula"`
+		AutoIndex        bool     `toml:"auto_index"`
+		NotFoundPage     string   `toml:"not_found_page"`
+		SanitizeHTML     bool     `toml:"sanitize_html"`
+		ServeRawMarkdown bool     `toml:"serve_raw_markdown"`
+		AcceptMarkdown   bool     `toml:"accept_markdown"`
+		ShowDrafts       bool     `toml:"show_drafts"`
+		MinifyHTML       bool     `toml:"minify_html"`
+		PageOrder        string   `toml:"page_order" validate:"omitempty,oneof=filename date"`
+		SourceEncoding   string   `toml:"source_encoding" validate:"omitempty,oneof=utf-8 shift_jis euc-jp auto"`
+		MarkdownExts     []string `toml:"markdown_extensions" validate:"dive,startswith=."`
+		Charset          string   `toml:"charset" validate:"omitempty,oneof=utf-8 shift_jis euc-jp"`
+		SlowRenderMs     int      `toml:"slow_render_ms" validate:"min=0"`
 	} `toml:"html"`
+	Markdown struct {
+		Tables         *bool `toml:"tables"`
+		Strikethrough  *bool `toml:"strikethrough"`
+		TaskList       *bool `toml:"tasklist"`
+		Linkify        *bool `toml:"linkify"`
+		Typographer    *bool `toml:"typographer"`
+		Footnote       *bool `toml:"footnote"`
+		DefinitionList *bool `toml:"definition_list"`
+		RewriteMDLinks *bool `toml:"rewrite_md_links"`
+		WikiLinks      *bool `toml:"wiki_links"`
+		PageAssets     *bool `toml:"page_assets"`
+	} `toml:"markdown"`
+	Sanitize struct {
+		Policy          string   `toml:"policy" validate:"omitempty,oneof=ugc strict"`
+		AllowElements   []string `toml:"allow_elements"`
+		AllowAttributes []string `toml:"allow_attributes"`
+	} `toml:"sanitize"`
 	Cache struct {
-		HotReload     bool `toml:"hot_reload"`
-		CacheLimit    int  `toml:"cache_limit"`
-		MaxCacheItems int  `toml:"max_cache_items"`
+		HotReload            bool     `toml:"hot_reload"`
+		CacheLimit           int      `toml:"cache_limit"`
+		MaxCacheItems        int      `toml:"max_cache_items"`
+		Gzip                 bool     `toml:"gzip"`
+		LiveReload           bool     `toml:"live_reload"`
+		StaleWhileRevalidate int      `toml:"stale_while_revalidate" validate:"min=0"`
+		WatchDebounceMs      int      `toml:"watch_debounce_ms" validate:"min=0"`
+		WatchMode            string   `toml:"watch_mode" validate:"omitempty,oneof=fsnotify poll"`
+		WatchPollIntervalMs  int      `toml:"watch_poll_interval_ms" validate:"min=0"`
+		WatchIgnore          []string `toml:"watch_ignore"`
+		WarmCache            bool     `toml:"warm_cache"`
+		RerenderOnChange     bool     `toml:"rerender_on_change"`
+		CacheDir             string   `toml:"cache_dir"`
+		StatsInterval        int      `toml:"stats_interval" validate:"min=0"`
+		MaxMemoryPercent     int      `toml:"max_memory_percent" validate:"min=0,max=100"`
+		Redis                struct {
+			Enabled   bool   `toml:"enabled"`
+			Address   string `toml:"address" validate:"required_if=Enabled true"`
+			Password  string `toml:"password"`
+			DB        int    `toml:"db" validate:"min=0"`
+			TTL       int    `toml:"ttl" validate:"min=0"`
+			KeyPrefix string `toml:"key_prefix"`
+		} `toml:"redis"`
+		Rules []CacheRuleConfig `toml:"rule" validate:"dive"`
 	} `toml:"cache"`
+	Static struct {
+		Enabled       bool   `toml:"enabled"`
+		Precompressed bool   `toml:"precompressed"`
+		AssetsDir     string `toml:"assets_dir" validate:"omitempty,dir"`
+	} `toml:"static"`
+	Favicon struct {
+		Path           string `toml:"path" validate:"omitempty,file"`
+		AppleTouchIcon string `toml:"apple_touch_icon" validate:"omitempty,file"`
+		MaxAge         int    `toml:"max_age" validate:"min=0"`
+	} `toml:"favicon"`
+	Manifest struct {
+		Enabled         bool   `toml:"enabled"`
+		Name            string `toml:"name"`
+		ShortName       string `toml:"short_name"`
+		Description     string `toml:"description"`
+		StartURL        string `toml:"start_url"`
+		Display         string `toml:"display" validate:"omitempty,oneof=fullscreen standalone minimal-ui browser"`
+		ThemeColor      string `toml:"theme_color"`
+		BackgroundColor string `toml:"background_color"`
+		IconSource      string `toml:"icon_source" validate:"required_if=Enabled true"`
+		IconSizes       []int  `toml:"icon_sizes" validate:"dive,min=16,max=1024"`
+	} `toml:"manifest"`
+	Offline struct {
+		Enabled      bool     `toml:"enabled"`
+		PrecacheURLs []string `toml:"precache_urls"`
+	} `toml:"offline"`
+	Feed struct {
+		Enabled    bool     `toml:"enabled"`
+		Title      string   `toml:"title"`
+		MaxEntries int      `toml:"max_entries" validate:"min=0"`
+		Paginate   bool     `toml:"paginate"`
+		PostDirs   []string `toml:"post_dirs"`
+	} `toml:"feed"`
+	Webmention struct {
+		Enabled             bool   `toml:"enabled"`
+		StoreFile           string `toml:"store_file" validate:"required_if=Enabled true"`
+		Timeout             int    `toml:"timeout"`
+		AllowPrivateSources bool   `toml:"allow_private_sources"`
+		MaxPerPage          int    `toml:"max_per_page" validate:"min=0"`
+		MaxMentions         int    `toml:"max_mentions" validate:"min=0"`
+	} `toml:"webmention"`
+	Search struct {
+		Enabled    bool `toml:"enabled"`
+		MaxResults int  `toml:"max_results" validate:"min=0"`
+	} `toml:"search"`
+	Sitemap struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"sitemap"`
+	Robots struct {
+		Enabled  bool     `toml:"enabled"`
+		Disallow []string `toml:"disallow"`
+		Allow    []string `toml:"allow"`
+		File     string   `toml:"file" validate:"omitempty,file"`
+	} `toml:"robots"`
+	Git struct {
+		Enabled bool   `toml:"enabled"`
+		URL     string `toml:"url" validate:"required_if=Enabled true"`
+		Branch  string `toml:"branch"`
+		Dir     string `toml:"dir" validate:"required_if=Enabled true"`
+		Token   string `toml:"token" validate:"required_if=Enabled true"`
+		Timeout int    `toml:"timeout" validate:"min=0"`
+	} `toml:"git"`
+	Edit struct {
+		Enabled      bool     `toml:"enabled"`
+		Realm        string   `toml:"realm"`
+		HtpasswdFile string   `toml:"htpasswd_file" validate:"omitempty,file"`
+		Users        []string `toml:"users"`
+	} `toml:"edit"`
+	Math struct {
+		Enabled  bool   `toml:"enabled"`
+		KatexURL string `toml:"katex_url"`
+	} `toml:"math"`
+	IndexNow struct {
+		Enabled  bool     `toml:"enabled"`
+		Key      string   `toml:"key" validate:"required_if=Enabled true"`
+		Endpoint string   `toml:"endpoint" validate:"omitempty,url"`
+		PingURLs []string `toml:"ping_urls" validate:"dive,url"`
+		Debounce int      `toml:"debounce"`
+		Timeout  int      `toml:"timeout"`
+	} `toml:"indexnow"`
+	VHosts    []VHostConfig    `toml:"vhost" validate:"dive"`
+	Hooks     []HookConfig     `toml:"hooks" validate:"dive"`
+	Redirects []RedirectConfig `toml:"redirect" validate:"dive"`
+	TOC       struct {
+		MinLevel int `toml:"min_level" validate:"min=0,max=6"`
+		MaxLevel int `toml:"max_level" validate:"min=0,max=6"`
+	} `toml:"toc"`
+	Template struct {
+		MissingKey     string `toml:"missing_key" validate:"omitempty,oneof=default zero error"`
+		RenderTimeout  int    `toml:"render_timeout"`
+		MaxOutputBytes int    `toml:"max_output_bytes"`
+	} `toml:"template"`
+	Metrics struct {
+		Enabled    bool   `toml:"enabled"`
+		ListenAddr string `toml:"listen_addr"`
+		ListenPort int    `toml:"listen_port" validate:"min=0,max=65535"`
+	} `toml:"metrics"`
+	Auth struct {
+		Enabled      bool     `toml:"enabled"`
+		Realm        string   `toml:"realm"`
+		HtpasswdFile string   `toml:"htpasswd_file" validate:"omitempty,file"`
+		Users        []string `toml:"users"`
+		Paths        []string `toml:"paths" validate:"dive,startswith=/"`
+		OIDC         struct {
+			Enabled       bool     `toml:"enabled"`
+			Issuer        string   `toml:"issuer" validate:"required_if=Enabled true,omitempty,url"`
+			ClientID      string   `toml:"client_id" validate:"required_if=Enabled true"`
+			ClientSecret  string   `toml:"client_secret"`
+			RedirectURL   string   `toml:"redirect_url" validate:"required_if=Enabled true,omitempty,url"`
+			Scopes        []string `toml:"scopes"`
+			AllowedEmails []string `toml:"allowed_emails"`
+			AllowedGroups []string `toml:"allowed_groups"`
+			GroupsClaim   string   `toml:"groups_claim"`
+			CookieSecret  string   `toml:"cookie_secret"`
+			SessionTTL    int      `toml:"session_ttl" validate:"min=0"`
+		} `toml:"oidc"`
+	} `toml:"auth"`
+	IPFilter struct {
+		Enabled    bool     `toml:"enabled"`
+		AllowCIDRs []string `toml:"allow_cidrs"`
+		DenyCIDRs  []string `toml:"deny_cidrs"`
+	} `toml:"ip_filter"`
+	Proxy struct {
+		TrustedProxies []string `toml:"trusted_proxies"`
+	} `toml:"proxy"`
+	AccessLog struct {
+		Enabled bool   `toml:"enabled"`
+		Format  string `toml:"format" validate:"omitempty,oneof=slog combined"`
+		File    string `toml:"file"`
+	} `toml:"access_log"`
+	Headers struct {
+		Enabled                 bool    `toml:"enabled"`
+		ContentSecurityPolicy   *string `toml:"content_security_policy"`
+		XContentTypeOptions     *string `toml:"x_content_type_options"`
+		XFrameOptions           *string `toml:"x_frame_options"`
+		ReferrerPolicy          *string `toml:"referrer_policy"`
+		StrictTransportSecurity *string `toml:"strict_transport_security"`
+	} `toml:"headers"`
+	Tags struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"tags"`
+	API struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"api"`
+	TLS struct {
+		Enabled           bool     `toml:"enabled"`
+		Mode              string   `toml:"mode" validate:"omitempty,oneof=file acme"`
+		CertFile          string   `toml:"cert_file" validate:"required_if=Enabled true Mode '',required_if=Enabled true Mode file"`
+		KeyFile           string   `toml:"key_file" validate:"required_if=Enabled true Mode '',required_if=Enabled true Mode file"`
+		ACMEDomains       []string `toml:"acme_domains" validate:"required_if=Enabled true Mode acme,dive,fqdn"`
+		ACMEEmail         string   `toml:"acme_email" validate:"omitempty,email"`
+		ACMECacheDir      string   `toml:"acme_cache_dir"`
+		ACMEDirectoryURL  string   `toml:"acme_directory_url" validate:"omitempty,url"`
+		MinVersion        string   `toml:"min_version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+		RedirectHTTP      bool     `toml:"redirect_http"`
+		HTTPPort          int      `toml:"http_port" validate:"min=0,max=65535"`
+		ClientCAFile      string   `toml:"client_ca_file" validate:"required_if=RequireClientCert true"`
+		RequireClientCert bool     `toml:"require_client_cert"`
+	} `toml:"tls"`
 }
 
 // --- Cache Structs ---
 type CacheItem struct {
-	Content []byte
-	Expires time.Time
+	Content  []byte    // nil if only Gzip is kept
+	Size     int       // length of the plain content
+	ETag     string    // strong entity tag of the plain content
+	Gzip     []byte    // gzip compressed content (nil if disabled or not worth it)
+	ModTime  time.Time // modification time of the markdown file (zero if none)
+	Expires  time.Time
+	NoExpiry bool          // kept until invalidated (cache lifetime 0)
+	used     *atomic.Int64 // last request (Unix nanoseconds), for eviction under memory pressure
+}
+
+// size returns the memory held by the page.
+func (item CacheItem) size() int64 {
+	return int64(len(item.Content) + len(item.Gzip))
+}
+
+// touch records a request for the page.
+func (item CacheItem) touch() {
+	if item.used != nil {
+		item.used.Store(time.Now().UnixNano())
+	}
+}
+
+// lastUsed returns the time of the last request for the page (Unix
+// nanoseconds).
+func (item CacheItem) lastUsed() int64 {
+	if item.used == nil {
+		return 0
+	}
+	return item.used.Load()
+}
+
+// plain returns the plain content, decompressing it if only the gzip bytes
+// are kept.
+func (item CacheItem) plain() ([]byte, error) {
+	if item.Content != nil || item.Gzip == nil {
+		return item.Content, nil
+	}
+	return gunzipPage(item.Gzip, item.Size)
 }
 
 type Cache struct {
 	sync.RWMutex
 	items map[string]CacheItem
+
+	// Statistics, updated without the lock
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64 // pages dropped for max_cache_items or on expiry
+	bytes     atomic.Int64  // size of the cached pages
+}
+
+// len returns the number of cached pages.
+func (c *Cache) len() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.items)
+}
+
+// set stores a page. The caller holds the lock.
+func (c *Cache) set(key string, item CacheItem) {
+	if old, ok := c.items[key]; ok {
+		c.bytes.Add(-old.size())
+	}
+	c.items[key] = item
+	c.bytes.Add(item.size())
+}
+
+// remove drops a page. The caller holds the lock.
+func (c *Cache) remove(key string) {
+	if old, ok := c.items[key]; ok {
+		delete(c.items, key)
+		c.bytes.Add(-old.size())
+	}
+}
+
+// reset drops every page. The caller holds the lock.
+func (c *Cache) reset() {
+	clear(c.items)
+	c.bytes.Store(0)
+}
+
+// cacheItems returns the number of cached pages of the server and its subsites.
+func (s *Server) cacheItems() int {
+	n := 0
+	for _, srv := range s.servers() {
+		n += srv.cache.len()
+	}
+	return n
 }
 
 // --- Server Struct ---
@@ -86,20 +408,63 @@ type Server struct {
 	forcedTitle string
 	version     string
 	revision    string
+	icons       *siteIcons
+	robots      *robotsTxt // nil unless [robots] is enabled
+	git         *gitRepo   // nil unless [git] is enabled
+	edit        *editAPI   // nil unless [edit] is enabled
+	oidc        *oidcAuth  // nil unless [auth.oidc] is enabled
+	redirects   *redirectRules
+	manifest    *webManifest
+	offline     *serviceWorker
+	files       *docCache // parsed markdown files (metadata, plain text)
+	overlays    *dirOverlays
+	wiki        *wikiLinks // nil unless wiki_links is set
+	pages       *pageIndex
+	webmentions *webmentionReceiver
+	search      *searchIndex
+	indexNow    *indexNowNotifier
+	vhosts      map[string]*vhost  // host name -> virtual host
+	subsites    []*Server          // virtual hosts with their own markdown_rootdir
+	subsiteFor  map[string]*Server // host name -> subsite
+	hooks       *hookRunner
+	metrics     *metrics
+	renders     singleflight.Group // in-flight renders by cache key
+	accessLog   *accessLogger
+	nav         *navTree
+	sanitizer   *sanitizer
+	store       pageStore // nil unless cache_dir or [cache.redis] is set
+	auth        *basicAuth
+	cacheRules  cacheRules
+	proxies     *trustedProxies // nil unless trusted_proxies is set
+	ipFilter    *ipFilter       // nil unless [ip_filter] is enabled
+	headers     *securityHeaders
+	liveReload  *liveReload
 }
 
 // Default HTML Template
 const defaultHtmlTmpl = `<!DOCTYPE html>
 <html lang="{{ .Language }}">
 <head>
-    <meta charset="UTF-8">
+    <meta charset="{{ .Charset }}">
     <title>{{ .Title }}</title>
     <meta name="viewport" content="width=device-width, initial-scale=1">
     <meta name="generator" content="gomadore {{ .GomadoreFullVersion }}">
     <meta name="x-document-hash" content="{{ .DocumentHash }}">
+    {{- if .NoIndex }}
+    <meta name="robots" content="noindex">
+    {{- end }}
     <link rel="stylesheet" href="{{ .BaseCSS }}">
     <link rel="stylesheet" href="{{ .ScreenCSS }}" media="screen">
     <link rel="stylesheet" href="{{ .PrintCSS }}" media="print">
+    {{- with .ManifestTags }}
+    {{ . }}
+    {{- end }}
+    {{- with .ServiceWorkerScript }}
+    {{ . }}
+    {{- end }}
+    {{- with .MathTags }}
+    {{ . }}
+    {{- end }}
 </head>
 <body id="{{ .Filename }}">
     <div class="container markdown-body">
@@ -111,53 +476,55 @@ const defaultHtmlTmpl = `<!DOCTYPE html>
 
 // MAIN =========================================
 
-func main() {
+// Main runs the gomadore command (see cmd/gomadore) with the command line
+// arguments of the process. It exits the process when done.
+func Main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
 	configPath := flag.String("c", "config.toml", "Path to configuration file")
 	tmplPath := flag.String("t", "", "Path to HTML template file (optional)")
 	forcedTitleFlag := flag.String("ft", "", "Force a specific title for all pages (overrides Markdown H1)")
 	listMode := flag.Bool("l", false, "List available URLs and exit")
 	listModeWithHash := flag.Bool("lh", false, "List available URLs with sha256sum and exit (TAB separation)")
 	printTmplFlag := flag.Bool("pt", false, "print the current HTML template and exit")
+	exportDir := flag.String("export", "", "Render every page as static HTML into the directory and exit")
+	checkMode := flag.Bool("check", false, "Check every markdown file (front matter, rendering, internal links) and exit (status 1 on problems)")
 	versionFlag := flag.Bool("v", false, "print the version and exit")
+	jsonFlag := flag.Bool("json", false, "print the version info as JSON (with -v)")
 	flag.Parse()
 
 	isPrintExitMode := *listMode || *listModeWithHash || *printTmplFlag || *versionFlag
 
 	// Return Version and exit
 	if *versionFlag {
-		fmt.Printf("%s/gomadore (%s-%s)\n", Maintainer, Version, Revision)
+		if err := printVersion(os.Stdout, *jsonFlag); err != nil {
+			log.Fatalf("Failed to print version: %v", err)
+		}
 		os.Exit(0)
 	}
 
-	// Load configuration
-	var cfg Config
-	if _, err := toml.DecodeFile(*configPath, &cfg); err != nil {
+	// Load configuration (validated, defaults applied)
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
 		log.Fatalf("Failed to load configuration file (%s): %v", *configPath, err)
 	}
 
 	// Setup Logger(slog)
-	setupLogger(os.Stderr, cfg.General.LogLevel, cfg.General.LogType)
+	if err := setupLogOutput(cfg); err != nil {
+		log.Fatalf("Failed to open log file (%s): %v", cfg.General.LogFile, err)
+	}
 
 	if !isPrintExitMode {
 		slog.Info("Setup gomadore", "version", Version, "revision", Revision)
 	}
 
-	// Validation
-	validate := validator.New()
-	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		// get toml-tag
-		name := strings.SplitN(fld.Tag.Get("toml"), ",", 2)[0]
-		if name == "-" {
-			return ""
-		}
-		return name
-	})
-	verr := validate.Struct(cfg)
-	if verr != nil {
-		slog.Error("Configuration validation failed", "config_path", *configPath, "err", verr)
-		os.Exit(1)
-	}
-
 	// URL list mode
 	if *listMode {
 		if err := printURLList(cfg, false); err != nil {
@@ -176,50 +543,18 @@ func main() {
 		os.Exit(0)
 	}
 
-	if cfg.Cache.CacheLimit < 0 {
-		cfg.Cache.CacheLimit = 0
-	}
-	if cfg.Cache.MaxCacheItems < 1 {
-		cfg.Cache.MaxCacheItems = 1000
-	}
-
 	// Load Template
-	var currentTmplFilePath string
-	var currentTmpl string
-	if *tmplPath != "" {
-		// Load from file if -t is provided
-		currentTmplFilePath = *tmplPath
-	} else if cfg.HTML.TemplateFilePath != "" {
-		// from config
-		currentTmplFilePath = cfg.HTML.TemplateFilePath
-	}
-
-	if currentTmplFilePath != "" {
-		if !isPrintExitMode {
+	t, currentTmpl, currentTmplFilePath, err := loadTemplate(*tmplPath, cfg)
+	if err != nil {
+		slog.Error("Failed to load HTML template", "tmpl_path", currentTmplFilePath, "err", err)
+		os.Exit(1)
+	}
+	if !isPrintExitMode {
+		if currentTmplFilePath != "" {
 			slog.Info("Use the provided HTML template file", "tmpl_path", currentTmplFilePath)
-		}
-		tmplBytes, readErr := os.ReadFile(currentTmplFilePath)
-		if readErr != nil {
-			slog.Error("Failed to read HTML template file", "tmpl_path", currentTmplFilePath, "err", readErr)
-			os.Exit(1)
-		}
-		currentTmpl = string(tmplBytes)
-	} else {
-		// Use default embedded template if not provided
-		if !isPrintExitMode {
+		} else {
 			slog.Info("Use default HTML template")
 		}
-		currentTmpl = defaultHtmlTmpl
-	}
-
-	// Parse template (and check)
-	var t *template.Template
-	var err error
-
-	t, err = template.New("base").Parse(currentTmpl)
-	if err != nil {
-		slog.Error("Failed to parse template", "err", err)
-		os.Exit(1)
 	}
 
 	// Print HTML Template and Exit
@@ -229,76 +564,233 @@ func main() {
 	}
 
 	// Initialize server
-	srv := &Server{
-		config: cfg,
-		cache:  &Cache{items: make(map[string]CacheItem)},
-		md: goldmark.New(
-			goldmark.WithExtensions(extension.GFM), // Enable GitHub Flavored Markdown
-			goldmark.WithParserOptions(
-				parser.WithAutoHeadingID(),
-			),
-		),
-		version:     Version,
-		revision:    Revision,
-		tmpl:        t,
-		forcedTitle: *forcedTitleFlag,
+	srv, err := newServer(cfg, t)
+	if err != nil {
+		slog.Error("Failed to initialize server", "err", err)
+		os.Exit(1)
 	}
+	srv.forcedTitle = *forcedTitleFlag
 
-	// Context for managing lifecycle of background goroutines (watcher, cleaner)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Start background cache cleaner (Garbage Collection)
-	// Only start if CacheLimit is positive.
-	// If CacheLimit <= 0, cache is treated as indefinite (never expires), so GC is not needed.
-	if cfg.Cache.CacheLimit > 0 {
-		// Set cleanup interval to half of the cache limit.
-		// Enforce a minimum interval of 60 seconds to prevent excessive locking overhead.
-		cleanupInterval := time.Duration(cfg.Cache.CacheLimit) * time.Second / 2
-		if cleanupInterval < 60*time.Second {
-			cleanupInterval = 60 * time.Second
+	// Content check mode (for CI)
+	if *checkMode {
+		n, err := srv.checkSite(os.Stdout)
+		if err != nil {
+			slog.Error("Failed to check site", "err", err)
+			os.Exit(1)
 		}
-		go srv.startCacheCleaner(ctx, cleanupInterval)
+		if n > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	// Setup Hot Reload if enabled
-	if cfg.Cache.HotReload {
-		go srv.watchFiles(ctx)
+	// Static site export mode
+	if *exportDir != "" {
+		srv.liveReload = nil // exported pages have no server to listen to
+		n, err := srv.exportSite(*exportDir)
+		if err != nil {
+			slog.Error("Failed to export site", "dir", *exportDir, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("Exported site", "dir", *exportDir, "pages", n)
+		os.Exit(0)
 	}
 
+	// The active server (and its background goroutines) is replaced on SIGHUP
+	live := newLiveServer(srv)
+	defer live.stop()
+
 	// HTTP Server setup
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /favicon.ico", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNoContent)
+	addr := listenAddress(cfg)
+
+	httpSrv := newHTTPServer(addr, live, cfg)
+	// Live reload streams never end on their own
+	httpSrv.RegisterOnShutdown(func() {
+		for _, s := range live.server().servers() {
+			s.liveReload.close(false)
+		}
 	})
-	mux.HandleFunc("GET /", srv.handleRequest)
-	addr := fmt.Sprintf("%s:%d", cfg.General.ListenAddr, cfg.General.ListenPort)
 
-	httpSrv := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+	// HTTPS (certificates are loaded now to fail before listening)
+	var redirectSrv *http.Server
+	if cfg.TLS.Enabled {
+		var acmeMgr *autocert.Manager
+		if cfg.TLS.Mode == tlsModeACME {
+			acmeMgr, err = newACMEManager(cfg)
+			if err != nil {
+				slog.Error("Failed to set up ACME", "err", err)
+				os.Exit(1)
+			}
+			httpSrv.TLSConfig = acmeTLSConfig(acmeMgr, cfg)
+		} else {
+			httpSrv.TLSConfig, err = newTLSConfig(cfg)
+			if err != nil {
+				slog.Error("Failed to load TLS certificate", "err", err)
+				os.Exit(1)
+			}
+		}
+		if err := setClientAuth(httpSrv.TLSConfig, cfg); err != nil {
+			slog.Error("Failed to load client CA", "err", err)
+			os.Exit(1)
+		}
+		if cfg.TLS.RedirectHTTP {
+			redirect := httpsRedirectHandler(cfg.General.ListenPort)
+			if acmeMgr != nil {
+				// Also answer HTTP-01 challenges
+				redirect = acmeMgr.HTTPHandler(redirect)
+			}
+			redirectSrv = newHTTPServer(fmt.Sprintf("%s:%d", cfg.General.ListenAddr, cfg.TLS.HTTPPort), redirect, cfg)
+		}
+	}
+
+	// Listeners (passed on to the new process on upgrade)
+	var lns []namedListener
+
+	// Metrics endpoint on its own (admin) listener
+	var metricsSrv *http.Server
+	if srv.metrics != nil {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /metrics", srv.metrics.handleMetrics)
+		metricsSrv = newHTTPServer(fmt.Sprintf("%s:%d", cfg.Metrics.ListenAddr, cfg.Metrics.ListenPort), mux, cfg)
+		mln, err := listenTCP("metrics", metricsSrv.Addr)
+		if err != nil {
+			slog.Error("Metrics server launch failed", "err", err)
+			os.Exit(1)
+		}
+		lns = append(lns, namedListener{"metrics", mln})
+		go func() {
+			slog.Info("Metrics server starting", "addr", metricsSrv.Addr)
+			if err := metricsSrv.Serve(mln); err != nil && err != http.ErrServerClosed {
+				slog.Error("Metrics server launch failed", "err", err)
+				os.Exit(1)
+			}
+		}()
 	}
 
 	// Start server
+	ln, err := listen(cfg)
+	if err != nil {
+		slog.Error("Server launch failed", "err", err)
+		os.Exit(1)
+	}
+	lns = append(lns, namedListener{"main", ln})
 	go func() {
-		slog.Info("Server starting", "addr", addr)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Info("Server starting", "addr", addr, "tls", cfg.TLS.Enabled)
+		if cfg.TLS.Enabled {
+			err = httpSrv.ServeTLS(ln, "", "")
+		} else {
+			err = httpSrv.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("Server launch failed", "err", err)
 			os.Exit(1)
 		}
 	}()
+	if redirectSrv != nil {
+		rln, err := listenTCP("redirect", redirectSrv.Addr)
+		if err != nil {
+			slog.Error("Redirect server launch failed", "err", err)
+			os.Exit(1)
+		}
+		lns = append(lns, namedListener{"redirect", rln})
+		go func() {
+			slog.Info("HTTP to HTTPS redirect starting", "addr", redirectSrv.Addr)
+			if err := redirectSrv.Serve(rln); err != nil && err != http.ErrServerClosed {
+				slog.Error("Redirect server launch failed", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	if err := writePIDFile(cfg.General.PIDFile); err != nil {
+		slog.Error("Failed to write PID file", "file", cfg.General.PIDFile, "err", err)
+	}
+	defer removePIDFile(cfg.General.PIDFile)
+	notifyUpgraded()
+	srv.hooks.fire(hookServerStarted, "GOMADORE_ADDR="+addr)
+
+	// Theme development (hot_reload) and watch_config: a changed template or
+	// configuration file is read again and replaces the running one like a
+	// reload with SIGHUP (clearing the page cache)
+	var reloadPaths []string
+	if cfg.Cache.HotReload {
+		reloadPaths = templatePaths(*tmplPath, cfg)
+	}
+	if cfg.General.WatchConfig {
+		reloadPaths = append(reloadPaths, *configPath)
+	}
+	if len(reloadPaths) > 0 {
+		tctx, tcancel := context.WithCancel(context.Background())
+		defer tcancel()
+		debounce := time.Duration(cfg.Cache.WatchDebounceMs) * time.Millisecond
+		go watchReloadPaths(tctx, reloadPaths, debounce, func() {
+			slog.Info("Template or configuration changed; reloading...")
+			if err := live.reload(*configPath, *tmplPath, *forcedTitleFlag); err != nil {
+				slog.Error("Reload failed; keeping the running configuration and template", "err", err)
+				return
+			}
+			slog.Info("Reload complete")
+		})
+	}
 
 	// Wait for signals
 	quit := make(chan os.Signal, 1)
-	// Monitor SIGINT (Ctrl+C) and SIGTERM (kill)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit // Block until signal received
+	// Monitor SIGINT (Ctrl+C) and SIGTERM (kill), SIGHUP (reload), and
+	// SIGUSR1 (cache flush) and SIGUSR2 (binary upgrade) where supported
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+	for _, sig := range []os.Signal{flushSignal, upgradeSignal} {
+		if sig != nil {
+			signals = append(signals, sig)
+		}
+	}
+	signal.Notify(quit, signals...)
+	for sig := range quit { // Block until signal received
+		if flushSignal != nil && sig == flushSignal {
+			live.server().flushCaches()
+			continue
+		}
+		if upgradeSignal != nil && sig == upgradeSignal {
+			slog.Info("Upgrading: starting the new binary...")
+			exe, err := os.Executable()
+			if err == nil {
+				err = upgrade(exe, os.Args[1:], lns)
+			}
+			if err != nil {
+				slog.Error("Upgrade failed; keeping the running process", "err", err)
+				continue
+			}
+			// The socket file now belongs to the new process
+			if ul, ok := ln.(*net.UnixListener); ok {
+				ul.SetUnlinkOnClose(false)
+			}
+			slog.Info("New process is serving; draining connections")
+			break
+		}
+		if sig != syscall.SIGHUP {
+			break
+		}
+		slog.Info("Reloading configuration and template...")
+		if err := live.reload(*configPath, *tmplPath, *forcedTitleFlag); err != nil {
+			slog.Error("Reload failed; keeping the running configuration", "err", err)
+			continue
+		}
+		slog.Info("Reload complete")
+	}
 	slog.Info("Shutting down server...")
 
 	// Shutdown with 5-second timeout
 	sctx, scancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer scancel()
 
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(sctx); err != nil {
+			slog.Error("Redirect server forced to shutdown", "err", err)
+		}
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(sctx); err != nil {
+			slog.Error("Metrics server forced to shutdown", "err", err)
+		}
+	}
 	if err := httpSrv.Shutdown(sctx); err != nil {
 		slog.Error("Server forced to shutdown", "err", err)
 		os.Exit(1)
@@ -307,101 +799,318 @@ func main() {
 	slog.Info("Server exiting")
 }
 
-// --- Logic to print available URLs ---
-func printURLList(cfg Config, with_hash bool) error {
-	root := cfg.HTML.MarkdownRootDir
+// --- Server Setup ---
+
+// loadTemplate reads and parses the HTML template: the -t file or directory
+// if given, else the configured template_filepath, else the default template.
+// It returns the template, its source and its path ("" for the default template).
+func loadTemplate(flagPath string, cfg Config) (*template.Template, string, string, error) {
+	tmplPath := flagPath
+	if tmplPath == "" {
+		tmplPath = cfg.HTML.TemplateFilePath
+	}
+
+	if tmplPath != "" {
+		t, src, err := parseTemplatePath(tmplPath)
+		return t, src, tmplPath, err
+	}
 
-	// Check if root directory exists and is a directory
-	info, err := os.Stat(root)
+	t, err := template.New("base").Funcs(templateFuncs).Parse(defaultHtmlTmpl)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("markdown root directory does not exist: %s", root)
-		}
-		return fmt.Errorf("accessing Markdown root directory: %v", err)
+		return nil, "", "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	return t, defaultHtmlTmpl, "", nil
+}
+
+// newServer builds a Server from a validated configuration and a parsed template.
+func newServer(cfg Config, t *template.Template) (*Server, error) {
+	cfg.HTML.MarkdownExts = markdownSuffixes(cfg)
+	if err := checkMarkdownExts(cfg.HTML.MarkdownExts); err != nil {
+		return nil, err
+	}
+	// The clone must exist before the content is read
+	git, err := newGitRepo(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("git: %w", err)
+	}
+	extensions := markdownExtensions(cfg) // GitHub Flavored Markdown unless configured
+	if hl := newHighlighter(cfg.HTML.HighlightStyle); hl != nil {
+		extensions = append(extensions, hl)
+	}
+	if cfg.Math.Enabled {
+		extensions = append(extensions, &mathExtension{})
+	}
+	if boolOr(cfg.Markdown.RewriteMDLinks, true) {
+		extensions = append(extensions, &mdLinks{exts: cfg.HTML.MarkdownExts, strict: cfg.HTML.StrictHtmlUrl})
+	}
+	if boolOr(cfg.Markdown.PageAssets, false) {
+		extensions = append(extensions, &pageAssets{})
+	}
+	var wiki *wikiLinks
+	if boolOr(cfg.Markdown.WikiLinks, false) {
+		wiki = &wikiLinks{}
+		extensions = append(extensions, wiki)
+	}
+	srv := &Server{
+		config: cfg,
+		cache:  &Cache{items: make(map[string]CacheItem)},
+		md: goldmark.New(
+			goldmark.WithExtensions(extensions...),
+			goldmark.WithParserOptions(
+				parser.WithAutoHeadingID(),
+			),
+		),
+		version:  Version,
+		revision: Revision,
+		tmpl:     t,
+		git:      git,
+	}
+	srv.files = newDocCache(srv.md, cfg.HTML.StrictHtmlUrl, cfg.HTML.SourceEncoding, cfg.HTML.MarkdownExts)
+	srv.pages = newPageIndex(srv.files, cfg.HTML.MarkdownRootDir, cfg.HTML.ShowDrafts)
+	if wiki != nil {
+		wiki.pages = srv.pages
+		srv.wiki = wiki
+	}
+	srv.nav = newNavTree(cfg, srv.pages, srv.pageProtected)
+	overlays, err := newDirOverlays(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("directory configuration: %w", err)
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("markdown root is not a directory: %s", root)
+	srv.overlays = overlays
+	srv.sanitizer = newSanitizer(cfg)
+	if err := checkWatchIgnore(cfg.Cache.WatchIgnore); err != nil {
+		return nil, err
 	}
+	srv.liveReload = newLiveReload(cfg)
+	applyTemplateOptions(t, cfg)
 
-	host := cfg.General.ListenAddr
-	if host == "0.0.0.0" || host == "" {
-		host = "127.0.0.1"
+	vhosts, err := newVHosts(cfg.VHosts)
+	if err != nil {
+		return nil, err
 	}
-	baseURL := fmt.Sprintf("http://%s:%d", host, cfg.General.ListenPort)
+	for _, vh := range vhosts {
+		applyTemplateOptions(vh.tmpl, cfg)
+	}
+	srv.vhosts = vhosts
+	templates := []*template.Template{t}
+	for _, vh := range vhosts {
+		templates = append(templates, vh.tmpl)
+	}
+	if srv.store, err = newPageStore(cfg, templates...); err != nil {
+		return nil, err
+	}
+	srv.hooks = newHookRunner(cfg)
+	srv.metrics = newMetrics(cfg, srv.cacheStats)
 
-	// Slice to store URLs
-	var urls []string
+	srv.subsites, srv.subsiteFor, err = newSubsites(cfg, t, vhosts)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range srv.subsites {
+		sub.metrics = srv.metrics
+	}
 
-	// Walk through directory
-	err = filepath.WalkDir(root, func(pathStr string, d fs.DirEntry, err error) error {
+	if srv.proxies, err = newTrustedProxies(cfg); err != nil {
+		return nil, fmt.Errorf("proxy: trusted_proxies: %w", err)
+	}
+	if srv.ipFilter, err = newIPFilter(cfg, srv.proxies); err != nil {
+		return nil, fmt.Errorf("ip_filter: %w", err)
+	}
+
+	al, err := newAccessLogger(cfg, srv.proxies)
+	if err != nil {
+		return nil, fmt.Errorf("access log: %w", err)
+	}
+	srv.accessLog = al
+
+	auth, err := newBasicAuth(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	srv.auth = auth
+	if srv.oidc, err = newOIDCAuth(cfg); err != nil {
+		return nil, fmt.Errorf("auth.oidc: %w", err)
+	}
+	for _, sub := range srv.subsites {
+		// [auth] applies to the requests of every host (see pageProtected)
+		sub.auth, sub.oidc = srv.auth, srv.oidc
+	}
+	if srv.edit, err = newEditAPI(cfg); err != nil {
+		return nil, fmt.Errorf("edit: %w", err)
+	}
+	srv.headers = newSecurityHeaders(cfg, srv.proxies)
+
+	if srv.cacheRules, err = newCacheRules(cfg.Cache.Rules); err != nil {
+		return nil, fmt.Errorf("cache.rule: %w", err)
+	}
+
+	srv.redirects, err = newRedirectRules(cfg.Redirects)
+	if err != nil {
+		return nil, fmt.Errorf("redirect: %w", err)
+	}
+
+	if srv.robots, err = newRobotsTxt(cfg); err != nil {
+		return nil, fmt.Errorf("robots: %w", err)
+	}
+	srv.icons, err = newSiteIcons(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("favicon: %w", err)
+	}
+
+	if cfg.Manifest.Enabled {
+		m, err := newWebManifest(cfg)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("web app manifest: %w", err)
 		}
-		// Process only files with .md extension
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
-			// Get relative path
-			rel, err := filepath.Rel(root, pathStr)
-			if err != nil {
-				return nil
-			}
+		srv.manifest = m
+	}
 
-			var docHash string
-			if with_hash {
-				// Check if file exists
-				mdContent, err := os.ReadFile(pathStr)
-				if err != nil {
-					return err
-				}
-				// Calculate SHA256 hash of the markdown content
-				hashBytes := sha256.Sum256(mdContent)
-				docHash = hex.EncodeToString(hashBytes[:])
-			}
+	if cfg.Offline.Enabled {
+		// Template and binary version changes must also roll the offline cache
+		salt := fmt.Sprintf("%s-%s", Version, Revision)
+		if t != nil && t.Tree != nil {
+			salt += t.Tree.Root.String()
+		}
+		srv.offline = newServiceWorker(cfg, salt)
+	}
 
-			// Convert path separators
-			urlPath := filepath.ToSlash(rel)
+	if cfg.Webmention.Enabled {
+		wr, err := newWebmentionReceiver(srv)
+		if err != nil {
+			return nil, fmt.Errorf("webmention: %w", err)
+		}
+		srv.webmentions = wr
+	}
 
-			// Remove extension
-			urlPath = strings.TrimSuffix(urlPath, ".md")
+	if cfg.Search.Enabled {
+		srv.search = newSearchIndex(srv.pages, srv.pageProtected)
+	}
 
-			// Handle index files
-			if !cfg.HTML.StrictHtmlUrl {
-				if urlPath == "index" {
-					urlPath = ""
-				} else if strings.HasSuffix(urlPath, "/index") {
-					urlPath = strings.TrimSuffix(urlPath, "index")
-				}
-			}
+	if cfg.IndexNow.Enabled {
+		n, err := newIndexNowNotifier(cfg, srv.indexNowExcluded)
+		if err != nil {
+			return nil, fmt.Errorf("indexnow: %w", err)
+		}
+		srv.indexNow = n
+	}
 
-			// Construct full URL
-			var fullURL string
-			if urlPath == "" {
-				fullURL = fmt.Sprintf("%s/", baseURL)
-			} else {
-				prefix := "/"
-				if strings.HasPrefix(urlPath, "/") {
-					prefix = ""
-				}
-				if cfg.HTML.StrictHtmlUrl {
-					fullURL = fmt.Sprintf("%s%s%s.html", baseURL, prefix, urlPath)
-				} else {
-					fullURL = fmt.Sprintf("%s%s%s", baseURL, prefix, urlPath)
-				}
-			}
+	return srv, nil
+}
 
-			if docHash != "" {
-				fullURL = fmt.Sprintf("%s\t%s", fullURL, docHash)
+// handler returns the HTTP handler of the server: the routes wrapped in the
+// request middlewares.
+func (s *Server) handler() http.Handler {
+	return s.accessLog.wrap(s.metrics.instrument(s.ipFilter.wrap(s.headers.wrap(s.authenticate(s.dirAuth(s.hostRoutes()))))))
+}
+
+// routes registers all HTTP handlers of the server.
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	if s.icons != nil {
+		for name := range s.icons.files {
+			mux.HandleFunc("GET "+name, s.icons.handleIcon)
+		}
+	}
+	if !s.icons.has("/favicon.ico") {
+		mux.HandleFunc("GET /favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	if s.manifest != nil {
+		mux.HandleFunc("GET /site.webmanifest", s.manifest.handleManifest)
+		for name := range s.manifest.icons {
+			if s.icons.has("/" + name) {
+				continue // the configured apple_touch_icon takes precedence
 			}
+			mux.HandleFunc("GET /"+name, s.manifest.handleIcon)
+		}
+	}
+	if s.offline != nil {
+		mux.HandleFunc("GET /sw.js", s.offline.handleScript)
+	}
+	if s.webmentions != nil {
+		mux.HandleFunc("POST /webmention", s.webmentions.handleWebmention)
+	}
+	if s.search != nil {
+		mux.HandleFunc("GET /search", s.handleSearch)
+		mux.HandleFunc("GET /search.json", s.handleSearchJSON)
+	}
+	if s.config.Sitemap.Enabled {
+		mux.HandleFunc("GET /sitemap.xml", s.handleSitemap)
+	}
+	if s.robots != nil {
+		mux.HandleFunc("GET /robots.txt", s.handleRobots)
+	}
+	if s.git != nil {
+		mux.HandleFunc("POST "+gitPullPath, s.handleGitPull)
+	}
+	if s.edit != nil {
+		mux.HandleFunc("PUT /", s.handleEdit)
+		mux.HandleFunc("DELETE /", s.handleEdit)
+	}
+	if s.config.API.Enabled {
+		mux.HandleFunc("GET "+apiPagesPath+"{path...}", s.handlePageAPI)
+	}
+	if s.indexNow != nil {
+		mux.HandleFunc("GET "+s.indexNow.keyFile(), s.indexNow.handleKey)
+	}
+	if s.liveReload != nil {
+		mux.HandleFunc("GET "+liveReloadPath, s.liveReload.handleEvents)
+	}
+	mux.HandleFunc("GET /", s.handleRequest)
+	return mux
+}
 
-			// Add to list (do not print yet)
-			urls = append(urls, fullURL)
+// --- Logic to print available URLs ---
+func printURLList(cfg Config, with_hash bool) error {
+	roots := cfg.HTML.MarkdownRootDir
+
+	// Check if the root directories exist and are directories
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("markdown root directory does not exist: %s", root)
+			}
+			return fmt.Errorf("accessing Markdown root directory: %v", err)
 		}
-		return nil
-	})
+		if !info.IsDir() {
+			return fmt.Errorf("markdown root is not a directory: %s", root)
+		}
+	}
+
+	host := cfg.General.ListenAddr
+	if host == "0.0.0.0" || host == "" {
+		host = "127.0.0.1"
+	}
+	baseURL := fmt.Sprintf("http://%s:%d", host, cfg.General.ListenPort)
+	if _, ok := unixSocketPath(cfg.General.ListenAddr); ok {
+		// Served through a proxy; list the public URLs
+		baseURL = cmp.Or(strings.TrimSuffix(cfg.HTML.SiteURL, "/"), "http://localhost")
+	}
 
+	// The page index leaves out drafts, which are not served
+	pages, err := newPageIndex(newDocCache(goldmark.New(), cfg.HTML.StrictHtmlUrl, cfg.HTML.SourceEncoding, markdownSuffixes(cfg)), roots, cfg.HTML.ShowDrafts).all()
 	if err != nil {
 		return fmt.Errorf("directory walk error: %v", err)
 	}
 
+	// Slice to store URLs
+	urls := make([]string, 0, len(pages))
+	for _, p := range pages {
+		fullURL := baseURL + p.URL
+		if with_hash {
+			mdContent, err := os.ReadFile(p.File)
+			if err != nil {
+				return err
+			}
+			// Calculate SHA256 hash of the markdown content
+			hashBytes := sha256.Sum256(mdContent)
+			fullURL = fmt.Sprintf("%s\t%s", fullURL, hex.EncodeToString(hashBytes[:]))
+		}
+		urls = append(urls, fullURL)
+	}
+
 	// Sort and print
 	// Simple string sort ensures shorter paths (parent dir/index) come first
 	slices.Sort(urls)
@@ -431,134 +1140,435 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Moved pages ([[redirect]]), before anything is looked up
+	if s.serveRedirect(w, r) {
+		return
+	}
+
+	// Feeds (/feed.xml, /docs/feed.json, /tags/go/rss.xml, ...)
+	if s.serveFeed(w, r) {
+		return
+	}
+
+	// Static files (images, CSS, ...) under the content root
+	if s.serveStatic(w, r) {
+		return
+	}
+
+	// Markdown sources ("/about.md")
+	if s.serveRawMarkdown(w, r) {
+		return
+	}
+
+	// Webmention endpoint discovery
+	if s.webmentions != nil {
+		w.Header().Add("Link", `</webmention>; rel="webmention"`)
+	}
+
 	rawPath := r.URL.Path
 
+	// Site settings of the requested host (virtual hosts have their own cache keys)
+	st := s.siteFor(r)
+
 	// If StrictHtmlUrl mode is enabled, only accept URLs ending in ".html"
 	if s.config.HTML.StrictHtmlUrl {
 		if !strings.HasSuffix(rawPath, ".html") {
-			http.NotFound(w, r)
+			s.notFound(w, r, st)
 			return
 		}
 	}
 
-	// If URL ends with slash, append "index" (directory support)
-	if strings.HasSuffix(rawPath, "/") {
-		rawPath += "index"
-	}
-
-	// Remove ".html" suffix if present
-	rawPath = strings.TrimSuffix(rawPath, ".html")
-
 	// Normalize path again for internal processing
-	reqPath := path.Clean(rawPath)
-	if reqPath == "." {
-		reqPath = "/index"
-	}
-	filename := path.Base(reqPath)
-	if filename == "" || filename == "." {
-		filename = "default"
-	}
-
-	// Check cache
-	s.cache.RLock()
-	item, found := s.cache.items[reqPath]
-	s.cache.RUnlock()
+	reqPath := pageKey(rawPath)
+	cacheKey := st.cacheKey(reqPath)
 
-	// Determine if the cached item is valid.
-	// If CacheLimit > 0, check the expiration time.
-	// If CacheLimit <= 0, the cache never expires (valid until restart).
-	isCacheValid := found
-	if s.config.Cache.CacheLimit > 0 {
-		isCacheValid = found && time.Now().Before(item.Expires)
+	// The markdown source or plain text for "Accept: text/markdown"
+	if s.config.HTML.AcceptMarkdown {
+		w.Header().Add("Vary", "Accept")
+		if s.serveNegotiated(w, r, st, reqPath) {
+			return
+		}
 	}
 
 	// Return cached content if hit and valid
-	if isCacheValid {
-		w.Header().Set("X-Cache", "HIT")
+	item, ok := s.cachedPage(cacheKey)
+	status := "HIT"
+	if !ok {
+		// Expired but within stale_while_revalidate: answer immediately and
+		// let a single background render replace the page
+		if item, ok = s.stalePage(cacheKey); ok {
+			status = "STALE"
+			s.revalidate(st, reqPath, cacheKey)
+		}
+	}
+	if ok {
+		s.cache.hits.Add(1)
+		s.metrics.cacheHit()
+		item.touch()
+		w.Header().Set("X-Cache", status)
 
 		// Set browser cache (max-age)
-		if s.config.Cache.CacheLimit > 0 {
-			w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", s.config.Cache.CacheLimit))
+		if ttl, _ := s.pageTTL(st, reqPath); ttl > 0 {
+			w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", ttl))
 		} else {
 			// For indefinite server-side cache, instruct the browser to cache for a long duration (e.g., 1 day).
 			w.Header().Set("Cache-Control", "max-age=86400")
 		}
 
-		if _, err := w.Write(item.Content); err != nil {
-			slog.Debug("Failed to write response (cache hit)", "err", err)
+		w.Header().Set("Content-Type", s.htmlContentType())
+		body, etag, err := negotiatePage(w, r, item, item.ETag)
+		if err != nil {
+			slog.Error("Failed to decompress a cached page", "path", reqPath, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if etag == "" {
+			etag = pageETag(body)
 		}
+		servePage(w, r, body, etag, item.ModTime)
 		return
 	}
 
 	// --- Markdown File Processing ---
 
-	// Construct file system path
-	// Use filepath.FromSlash to ensure compatibility with Windows if needed (though running in container usually implies Linux)
-	staticPath := filepath.Join(s.config.HTML.MarkdownRootDir, filepath.FromSlash(reqPath))
-	fullPath := staticPath + ".md"
+	s.cache.misses.Add(1)
+	s.metrics.cacheMiss()
+	// Concurrent misses of the same page wait for a single render
+	v, err, _ := s.renders.Do(cacheKey, func() (any, error) {
+		return s.renderAndCache(st, reqPath, cacheKey)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errOutsideRoot):
+			slog.Info("Attack attempt detected", "path", r.URL.Path, "remote_addr", s.proxies.clientAddr(r))
+			s.notFound(w, r, st)
+		case errors.Is(err, fs.ErrNotExist):
+			s.notFound(w, r, st)
+		case errors.Is(err, errMarkdownConversion):
+			http.Error(w, "Markdown conversion failed", http.StatusInternalServerError)
+		case errors.Is(err, errTemplateExecution):
+			slog.Error("Template execution failed", "path", r.URL.Path, "err", err)
+			http.Error(w, "Template execution failed", http.StatusInternalServerError)
+		default:
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+		return
+	}
 
-	absRoot, err := filepath.Abs(s.config.HTML.MarkdownRootDir)
+	item = v.(CacheItem)
+
+	w.Header().Set("X-Cache", "MISS")
+	if ttl, cached := s.pageTTL(st, reqPath); cached {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", ttl))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("Content-Type", s.htmlContentType())
+	body, etag, err := negotiatePage(w, r, item, item.ETag)
 	if err != nil {
+		slog.Error("Failed to decompress a rendered page", "path", reqPath, "err", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	servePage(w, r, body, etag, item.ModTime)
+}
+
+// pageModTime returns the modification time of a page's markdown file, or
+// the zero time if there is none (e.g. a generated directory listing).
+func (s *Server) pageModTime(reqPath string) time.Time {
+	absPath, err := s.markdownFile(reqPath)
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// cachedPage returns the cached page of a cache key unless it has expired.
+func (s *Server) cachedPage(cacheKey string) (CacheItem, bool) {
+	s.cache.RLock()
+	item, found := s.cache.items[cacheKey]
+	s.cache.RUnlock()
+
+	// Determine if the cached item is valid.
+	// If it was cached with a positive lifetime, check the expiration time.
+	// Otherwise it never expires (valid until invalidated or restart).
+	if found && !item.NoExpiry {
+		return item, time.Now().Before(item.Expires)
+	}
+	return item, found
+}
+
+// stalePage returns an expired cached page that may still be served for
+// stale_while_revalidate seconds after its expiry.
+func (s *Server) stalePage(cacheKey string) (CacheItem, bool) {
+	if s.config.Cache.StaleWhileRevalidate <= 0 {
+		return CacheItem{}, false
+	}
+	s.cache.RLock()
+	item, found := s.cache.items[cacheKey]
+	s.cache.RUnlock()
+	return item, found && !item.NoExpiry && time.Now().Before(item.Expires.Add(s.staleDuration()))
+}
+
+// staleDuration returns how long expired pages are kept for stalePage.
+func (s *Server) staleDuration() time.Duration {
+	return time.Duration(s.config.Cache.StaleWhileRevalidate) * time.Second
+}
+
+// revalidate renders a stale page in the background. Concurrent requests
+// share the render of the page. A page that cannot be rendered any more
+// (e.g. removed) is dropped from the cache.
+func (s *Server) revalidate(st *site, reqPath, cacheKey string) {
+	go func() {
+		_, err, _ := s.renders.Do(cacheKey, func() (any, error) {
+			if item, ok := s.cachedPage(cacheKey); ok {
+				return item, nil // revalidated by an earlier request
+			}
+			return s.renderAndCache(st, reqPath, cacheKey)
+		})
+		if err != nil {
+			slog.Debug("Failed to revalidate page", "path", reqPath, "err", err)
+			s.cache.Lock()
+			s.cache.remove(cacheKey)
+			s.cache.Unlock()
+		}
+	}()
+}
+
+// renderAndCache renders a page and stores it in the cache, unless a
+// [[cache.rule]] says it must not be cached.
+func (s *Server) renderAndCache(st *site, reqPath, cacheKey string) (CacheItem, error) {
+	ttl, cached := s.pageTTL(st, reqPath)
+	// Taken before reading the file, so that a concurrent edit cannot get
+	// an older Last-Modified than the content it produces
+	modTime := s.pageModTime(reqPath)
+	// After a restart, the first render of a page may be found on disk
+	var respBody []byte
+	ok := false
+	if cached && s.store != nil {
+		respBody, ok = s.store.get(cacheKey, modTime)
+	}
+	if !ok {
+		renderStart := time.Now()
+		var err error
+		respBody, err = s.renderPage(st, reqPath)
+		s.metrics.observeRender(time.Since(renderStart))
+		if err != nil {
+			return CacheItem{}, err
+		}
+		if cached && s.store != nil {
+			s.store.put(cacheKey, modTime, respBody)
+		}
+	}
+
+	etag := pageETag(respBody)
+	// Compressed once here, so cache hits are served without recompressing
+	var gz []byte
+	if s.config.Cache.Gzip {
+		gz = gzipPage(respBody)
+	}
+
+	item := CacheItem{
+		Content:  respBody,
+		Size:     len(respBody),
+		ETag:     etag,
+		Gzip:     gz,
+		ModTime:  modTime,
+		Expires:  time.Now().Add(time.Duration(ttl) * time.Second),
+		NoExpiry: ttl <= 0,
+		used:     new(atomic.Int64),
+	}
+	item.touch()
+	if !cached {
+		return item, nil
+	}
+	// Only the compressed page is kept; it is decompressed for the few
+	// clients that do not accept gzip
+	if gz != nil {
+		item.Content = nil
+	}
+
+	// Save to cache
+	s.cache.Lock()
+
+	// Enforce Maximum Cache Items limit.
+	// If the cache is full and we are adding a new item, evict one item to make space.
+	// Note: We use random eviction (Go's map iteration is random) which is simple and effective enough.
+	if s.config.Cache.MaxCacheItems > 0 && len(s.cache.items) >= s.config.Cache.MaxCacheItems {
+		if _, exists := s.cache.items[cacheKey]; !exists {
+			for k := range s.cache.items {
+				s.cache.remove(k)
+				s.cache.evictions.Add(1)
+				break // Delete one item and exit
+			}
+		}
+	}
+
+	s.cache.set(cacheKey, item)
+	s.cache.Unlock()
+
+	return item, nil
+}
+
+// markdownFile resolves an internal page path (e.g. "/sub/index") to the
+// absolute path of its markdown file, in the first content root that has
+// one (or else in the first root). It returns errOutsideRoot if the path
+// escapes the content root.
+func (s *Server) markdownFile(reqPath string) (string, error) {
+	roots := s.config.HTML.MarkdownRootDir
+	root, rel, ok := roots.source(reqPath, s.config.HTML.MarkdownExts)
+	if !ok {
+		root, rel = roots.primary(), reqPath+s.config.HTML.MarkdownExts[0]
+	}
+	// Use filepath.FromSlash to ensure compatibility with Windows if needed (though running in container usually implies Linux)
+	fullPath := filepath.Join(root, filepath.FromSlash(rel))
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
 	absPath, err := filepath.Abs(fullPath)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return "", err
 	}
 
-	rel, err := filepath.Rel(absRoot, absPath)
+	rel, err = filepath.Rel(absRoot, absPath)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return "", err
 	}
 	if rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
-		slog.Info("Attack attempt detected", "path", r.URL.Path, "remote_addr", r.RemoteAddr)
-		http.NotFound(w, r)
-		return
+		return "", errOutsideRoot
+	}
+	return absPath, nil
+}
+
+// pageDocument is a markdown page rendered to HTML, before it is put into
+// the template.
+type pageDocument struct {
+	Title      string         // front matter "title" or first H1 ("" if none)
+	Meta       map[string]any // front matter (empty if none)
+	Body       template.HTML  // rendered body (sanitized if configured, with the TOC)
+	TOC        template.HTML
+	TOCEntries []*tocEntry
+	Hash       string    // SHA256 of the markdown file
+	ModTime    time.Time // modification time of the markdown file
+	WordCount  int
+	HasMath    bool
+
+	timings renderTimings // for the slow_render_ms warning
+}
+
+// renderDocument reads and renders the markdown file of an internal page
+// path. Errors wrap fs.ErrNotExist for missing files and drafts, and
+// errOutsideRoot or errMarkdownConversion.
+func (s *Server) renderDocument(reqPath string) (*pageDocument, error) {
+	absPath, err := s.markdownFile(reqPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if file exists
-	mdContent, err := os.ReadFile(absPath)
+	mdContent, err := readSource(absPath, s.config.HTML.SourceEncoding)
 	if err != nil {
-		if os.IsNotExist(err) {
-			http.NotFound(w, r)
-			return
-		}
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
 	// Calculate SHA256 hash of the markdown content
 	hashBytes := sha256.Sum256(mdContent)
-	docHash := hex.EncodeToString(hashBytes[:])
+	pd := &pageDocument{Hash: hex.EncodeToString(hashBytes[:])}
+
+	// Markdown Processing: Front Matter -> Parse -> Extract H1 -> Render
 
-	// Markdown Processing: Parse -> Extract H1 -> Render
+	// Separate the front matter (YAML "---" or TOML "+++") from the markdown body
+	meta, body, err := splitFrontMatter(mdContent)
+	if err != nil {
+		slog.Warn("Ignore front matter", "path", reqPath, "err", err)
+	}
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	if metaBool(meta, "draft") && !s.config.HTML.ShowDrafts {
+		return nil, fmt.Errorf("%s: %w: %w", reqPath, errDraft, fs.ErrNotExist)
+	}
+	pd.Meta = meta
+
+	pd.timings.size = len(mdContent)
 
 	// Parse to AST
-	reader := text.NewReader(mdContent)
-	doc := s.md.Parser().Parse(reader)
+	start := time.Now()
+	reader := text.NewReader(body)
+	pc := parser.NewContext()
+	pc.Set(pagePathKey, reqPath)
+	doc := s.md.Parser().Parse(reader, parser.WithContext(pc))
+	pd.timings.parse = time.Since(start)
 
 	// Get markdown file info for DocumentDate
 	fileInfo, err := os.Stat(absPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			http.NotFound(w, r)
-			return
-		}
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
+	pd.ModTime = fileInfo.ModTime()
 
-	// Prepare time strings (RFC3339 is compatible with JS Date constructor)
-	now := time.Now()
-	genDate := now.Format("2006-01-02")
-	genDateTime := now.Format(time.RFC3339)
+	// Front matter "title", else the first H1
+	pd.Title = metaString(meta, "title")
+	if pd.Title == "" {
+		pd.Title = extractTitle(doc, body)
+	}
+
+	pd.WordCount = wordCount(nodeText(doc, body))
+
+	// The title is rendered by the template; avoid duplicate H1s if configured
+	adjustHeadings(doc, s.config.HTML.StripFirstH1, s.config.HTML.HeadingOffset)
+
+	// Render to HTML
+	start = time.Now()
+	var buf bytes.Buffer
+	if err := s.md.Renderer().Render(&buf, body, doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMarkdownConversion, err)
+	}
+
+	// Untrusted content: filter the rendered markdown (the TOC is generated and added afterwards)
+	bodyHTML := s.sanitizer.sanitize(buf.String())
+
+	// Table of contents (also replaces "[TOC]" markers in the body)
+	pd.TOCEntries = buildTOC(doc, body, s.config.TOC.MinLevel, s.config.TOC.MaxLevel)
+	pd.TOC = renderTOC(pd.TOCEntries)
+	pd.Body = template.HTML(injectTOC(bodyHTML, pd.TOC))
+	pd.HasMath = hasMath(doc)
+	pd.timings.render = time.Since(start)
+	return pd, nil
+}
 
-	docModTime := fileInfo.ModTime()
-	docDate := docModTime.Format("2006-01-02")
-	docDateTime := docModTime.Format(time.RFC3339)
+// renderPage renders the markdown page at an internal page path through the
+// site's template. Errors wrap fs.ErrNotExist for missing pages, and
+// errOutsideRoot, errMarkdownConversion or errTemplateExecution.
+func (s *Server) renderPage(st *site, reqPath string) ([]byte, error) {
+	begin := time.Now()
+	st = s.overlays.site(st, reqPath)
+	pd, err := s.renderDocument(reqPath)
+	if errors.Is(err, fs.ErrNotExist) && !errors.Is(err, errDraft) {
+		if s.config.Tags.Enabled {
+			// Generated tag pages, unless the content has the page
+			if tag, ok := tagPagePath(reqPath); ok {
+				return s.renderTagPage(st, tag)
+			}
+		}
+		if s.config.HTML.AutoIndex && isDirIndexPath(reqPath) {
+			// Directory without index.md
+			return s.renderDirIndex(st, reqPath)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	filename := path.Base(reqPath)
+	if filename == "" || filename == "." {
+		filename = "default"
+	}
 
 	// Determine final page title
 	var finalTitle string
@@ -567,87 +1577,103 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		slog.Debug("Override title by forced option", "string", s.forcedTitle)
 		finalTitle = s.forcedTitle
 	} else {
-		// Priority 2: Extract H1 from Markdown
-		var pageTitle string
-		for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
-			if h, ok := n.(*ast.Heading); ok && h.Level == 1 {
-				pageTitle = string(h.Lines().Value(mdContent))
-				break
-			}
-		}
-
-		finalTitle = s.config.HTML.SiteTitle
-		if pageTitle != "" {
-			finalTitle = fmt.Sprintf("%s - %s", pageTitle, finalTitle)
+		// Priority 2: Front matter "title", Priority 3: Extract H1 from Markdown
+		finalTitle = st.title
+		if pd.Title != "" {
+			finalTitle = fmt.Sprintf("%s - %s", pd.Title, finalTitle)
 		}
 	}
 
-	// Render to HTML
-	var buf bytes.Buffer
-	if err := s.md.Renderer().Render(&buf, mdContent, doc); err != nil {
-		http.Error(w, "Markdown conversion failed", http.StatusInternalServerError)
-		return
+	// Assemble HTML (RFC3339 is compatible with JS Date constructor)
+	meta := pd.Meta
+	data := s.templateData(st, finalTitle, pd.Body, filename)
+	data["TOC"] = pd.TOC
+	data["TOCEntries"] = pd.TOCEntries
+	data["DocumentHash"] = pd.Hash
+	data["DocumentDate"] = pd.ModTime.Format("2006-01-02")                    // modified:YYYY-MM-DD
+	data["DocumentDateTime"] = template.HTML(pd.ModTime.Format(time.RFC3339)) // modified:RFC3339
+	data["WordCount"] = pd.WordCount
+	data["Webmentions"] = s.webmentions.mentionsFor(reqPath)
+	data["Meta"] = meta
+	data["Description"] = metaString(meta, "description")
+	data["NoIndex"] = metaBool(meta, "noindex")
+	if s.config.Math.Enabled && pd.HasMath {
+		data["MathTags"] = mathTags(s.config.Math.KatexURL)
+	}
+	if author := metaString(meta, "author"); author != "" {
+		data["Author"] = author
+	}
+	data["Tags"] = s.pageTags(metaStrings(meta, "tags"))
+	data["Nav"] = s.nav.get(reqPath)
+	data["Breadcrumbs"] = s.nav.breadcrumbs(reqPath)
+	data["Backlinks"] = s.backlinks(reqPath)
+	links := s.nav.siblings(reqPath)
+	data["Prev"] = links.Prev
+	data["Next"] = links.Next
+
+	tmpl := pageTemplate(st.tmpl, metaString(meta, "template"), reqPath)
+	start := time.Now()
+	respBody, err := s.executeTemplate(tmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errTemplateExecution, err)
 	}
+	pd.timings.template = time.Since(start)
+	pd.timings.total = time.Since(begin)
+	s.warnSlowRender(reqPath, pd.timings)
+	return respBody, nil
+}
 
-	// Assemble HTML
-	var finalHTML bytes.Buffer
-	err = s.tmpl.Execute(&finalHTML, map[string]interface{}{
-		"Title":               finalTitle,
-		"Language":            s.config.HTML.SiteLang,
-		"Author":              s.config.HTML.SiteAuthor,
+// templateData returns the template variables shared by every page
+// (markdown documents and generated pages such as search results).
+// Document specific variables are left empty.
+func (s *Server) templateData(st *site, title string, body template.HTML, filename string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"Title":               title,
+		"Language":            st.lang,
+		"Charset":             charsetLabel(s.config.HTML.Charset),
+		"Author":              st.author,
 		"Filename":            filename,
-		"BaseCSS":             s.config.HTML.BaseCSSUrl,
-		"ScreenCSS":           s.config.HTML.ScreenCSSUrl,
-		"PrintCSS":            s.config.HTML.PrintCSSUrl,
-		"Body":                template.HTML(buf.String()),
-		"DocumentHash":        docHash,
-		"DocumentDate":        docDate,                    // modified:YYYY-MM-DD
-		"DocumentDateTime":    template.HTML(docDateTime), // modified:RFC3339
-		"GeneratedDate":       genDate,                    // generated:YYYY-MM-DD
-		"GeneratedDateTime":   template.HTML(genDateTime), // generated:RFC3339
+		"BaseCSS":             st.baseCSS,
+		"ScreenCSS":           st.screenCSS,
+		"PrintCSS":            st.printCSS,
+		"Body":                body,
+		"DocumentHash":        "",
+		"DocumentDate":        "",
+		"DocumentDateTime":    template.HTML(""),
+		"GeneratedDate":       now.Format("2006-01-02"),                // generated:YYYY-MM-DD
+		"GeneratedDateTime":   template.HTML(now.Format(time.RFC3339)), // generated:RFC3339
 		"GomadoreVersion":     s.version,
 		"GomadoreFullVersion": fmt.Sprintf("%s-%s", s.version, s.revision),
-	})
-	if err != nil {
-		http.Error(w, "Template execution failed", http.StatusInternalServerError)
-		return
-	}
-
-	respBody := finalHTML.Bytes()
-
-	// Save to cache
-	s.cache.Lock()
-
-	// Enforce Maximum Cache Items limit.
-	// If the cache is full and we are adding a new item, evict one item to make space.
-	// Note: We use random eviction (Go's map iteration is random) which is simple and effective enough.
-	if s.config.Cache.MaxCacheItems > 0 && len(s.cache.items) >= s.config.Cache.MaxCacheItems {
-		if _, exists := s.cache.items[reqPath]; !exists {
-			for k := range s.cache.items {
-				delete(s.cache.items, k)
-				break // Delete one item and exit
-			}
-		}
-	}
-
-	s.cache.items[reqPath] = CacheItem{
-		Content: respBody,
-		Expires: time.Now().Add(time.Duration(s.config.Cache.CacheLimit) * time.Second),
-	}
-	s.cache.Unlock()
-
-	w.Header().Set("X-Cache", "MISS")
-	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", s.config.Cache.CacheLimit))
-
-	// Check for write errors
-	if _, err := w.Write(respBody); err != nil {
-		slog.Info("Failed to write response (fresh)", "err", err)
+		"ManifestTags":        s.manifest.linkTags(),
+		"ServiceWorkerScript": s.offline.registerScript(),
+		"MathTags":            template.HTML(""),
+		"Webmentions":         []Webmention(nil),
+		"Description":         "",
+		"NoIndex":             false,
+		"Meta":                map[string]any{},
+		"TOC":                 template.HTML(""),
+		"TOCEntries":          []*tocEntry(nil),
+		"Nav":                 s.nav.get(""),
+		"Tags":                []tagLink(nil),
+		"Tag":                 "",
+		"TagEntries":          []tagLink(nil),
+		"Breadcrumbs":         []*navNode(nil),
+		"Backlinks":           []dirIndexEntry(nil),
+		"Prev":                (*navNode)(nil),
+		"Next":                (*navNode)(nil),
 	}
 }
 
 // --- File Watcher (Hot Reload) ---
 
 func (s *Server) watchFiles(ctx context.Context) {
+	if s.config.Cache.WatchMode == watchModePoll {
+		slog.Info("Hot Reload enabled: Polling for changes...", "interval_ms", s.config.Cache.WatchPollIntervalMs)
+		s.pollFiles(ctx, func() []string { return s.config.HTML.MarkdownRootDir })
+		slog.Info("Stopping file watcher...")
+		return
+	}
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		slog.Error("Watcher error", "err", err)
@@ -659,32 +1685,26 @@ func (s *Server) watchFiles(ctx context.Context) {
 		}
 	}()
 
-	// Function to add subdirectories recursively
+	// Directories beyond the watch limit of the OS are polled
+	var unwatched unwatchedDirs
 	addWatchRecursive := func(root string) {
-		err := filepath.WalkDir(root, func(pathStr string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			pathStr = filepath.ToSlash(filepath.Clean(pathStr))
-			if d.IsDir() {
-				if err := watcher.Add(pathStr); err != nil {
-					slog.Error("Failed to add to watcher", "path", pathStr, "err", err)
-				} else {
-					slog.Debug("Watching dir", "path", pathStr)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			slog.Error("Directory walk error", "err", err)
+		if s.addWatches(root, watcher.Add, &unwatched) {
+			go s.pollFiles(ctx, unwatched.list)
 		}
 	}
 
 	slog.Info("Hot Reload enabled: Initializing watcher...")
-	addWatchRecursive(s.config.HTML.MarkdownRootDir)
+	for _, root := range s.config.HTML.MarkdownRootDir {
+		addWatchRecursive(root)
+	}
+	unwatched.warn(s.config.Cache.WatchPollIntervalMs)
 
 	var debounceTimer *time.Timer
-	const debounceDuration = 100 * time.Millisecond
+	debounceDuration := time.Duration(s.config.Cache.WatchDebounceMs) * time.Millisecond
+
+	// Files changed within the debounce period (reported to hooks)
+	var changedMu sync.Mutex
+	changed := make(map[string]struct{})
 
 	for {
 		select {
@@ -697,38 +1717,54 @@ func (s *Server) watchFiles(ctx context.Context) {
 				return
 			}
 
+			s.metrics.watcherEvent(event.Op)
+
 			filename := filepath.Base(event.Name)
 			if strings.HasPrefix(filename, ".") || strings.HasSuffix(filename, "~") {
 				continue
 			}
+			if s.ignoredByWatcher(event.Name) {
+				continue
+			}
 
 			if event.Has(fsnotify.Create) {
 				info, err := os.Stat(event.Name)
 				if err == nil && info.IsDir() {
 					slog.Debug("New directory detected", "path", event.Name)
 					addWatchRecursive(event.Name)
+					unwatched.warn(s.config.Cache.WatchPollIntervalMs)
 				}
 			}
 
 			shouldClear := false
 
-			if strings.HasSuffix(event.Name, ".md") {
+			if markdownExt(event.Name, s.config.HTML.MarkdownExts) != "" {
 				shouldClear = true
+				if event.Op != fsnotify.Chmod {
+					// Tell search engines about the changed page
+					s.indexNow.notify(event.Name)
+				}
 			} else if event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
 				shouldClear = true
 			}
 
 			if shouldClear {
+				changedMu.Lock()
+				changed[event.Name] = struct{}{}
+				changedMu.Unlock()
 
 				if debounceTimer != nil {
 					debounceTimer.Stop()
 				}
 
 				debounceTimer = time.AfterFunc(debounceDuration, func() {
-					slog.Debug("File/Dir change detected. Clearing cache.", "path", event.Name, "event", event.Op)
-					s.cache.Lock()
-					clear(s.cache.items)
-					s.cache.Unlock()
+					changedMu.Lock()
+					files := slices.Sorted(maps.Keys(changed))
+					clear(changed)
+					changedMu.Unlock()
+
+					slog.Debug("File/Dir change detected. Invalidating cache.", "files", files)
+					s.filesChanged(files)
 				})
 			}
 
@@ -741,6 +1777,104 @@ func (s *Server) watchFiles(ctx context.Context) {
 	}
 }
 
+// filesChanged updates the server after files under the content roots were
+// created, modified or removed: cached pages are invalidated (and rendered
+// again in the background with rerender_on_change), hooks run and live
+// reload clients notified.
+func (s *Server) filesChanged(files []string) {
+	var cached []string
+	if s.config.Cache.RerenderOnChange {
+		cached = s.cachedKeys()
+	}
+	s.invalidateFiles(files)
+	if len(cached) > 0 {
+		go s.rerender(cached)
+	}
+	for _, f := range files {
+		s.hooks.contentChanged(f)
+	}
+	s.liveReload.notify()
+}
+
+// invalidateFiles drops the cached pages of changed markdown files (for every
+// site). Any other change, such as a renamed or removed directory, purges the
+// whole cache.
+func (s *Server) invalidateFiles(files []string) {
+	var keys, rels []string
+	for _, f := range files {
+		_, rel, ok := s.config.HTML.MarkdownRootDir.relPath(f)
+		ext := markdownExt(rel, s.config.HTML.MarkdownExts)
+		if !ok || ext == "" {
+			s.purgeCache()
+			return
+		}
+		rels = append(rels, rel)
+		key := "/" + strings.TrimSuffix(rel, ext)
+		keys = append(keys, key)
+		if s.config.HTML.AutoIndex {
+			// The generated listing of the directory shows the page
+			keys = append(keys, path.Join(path.Dir(key), "index"))
+		}
+	}
+	s.files.drop(files...)
+	rels = slices.Compact(slices.Sorted(slices.Values(rels)))
+	// Pages that gained or lost a link from the changed pages show it
+	keys = append(keys, s.pages.update(rels)...)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	for _, key := range keys {
+		s.dropCachedPage(key)
+	}
+	if s.config.Tags.Enabled {
+		s.dropCachedTagPages()
+	}
+	s.offline.invalidate()
+	s.search.update(rels)
+	if s.store != nil {
+		s.store.refresh()
+	}
+	slog.Debug("Invalidated cached pages", "keys", keys)
+
+	if s.nav.refresh() {
+		// Every page shows the navigation (titles or the set of pages changed)
+		s.cache.Lock()
+		s.cache.reset()
+		s.cache.Unlock()
+		s.hooks.fire(hookCachePurged)
+		slog.Debug("Navigation changed; purged the cache")
+	}
+}
+
+// flushCaches purges the caches of the server and its subsites (SIGUSR1),
+// e.g. after the content was synced by other means than the watcher.
+func (s *Server) flushCaches() {
+	items := s.cacheItems()
+	for _, srv := range s.servers() {
+		srv.purgeCache()
+	}
+	s.liveReload.notify()
+	slog.Info("Cache flushed", "removed_count", items)
+}
+
+// purgeCache drops every cached page and any state derived from content.
+func (s *Server) purgeCache() {
+	s.cache.Lock()
+	s.cache.reset()
+	s.cache.Unlock()
+
+	s.offline.invalidate()
+	s.overlays.reload()
+	s.files.reset()
+	s.pages.invalidate()
+	s.search.invalidate()
+	s.nav.refresh()
+	if s.store != nil {
+		s.store.refresh()
+	}
+	s.hooks.fire(hookCachePurged)
+}
+
 // --- Cache Cleanup (Garbage Collection) ---
 
 // startCacheCleaner runs a background ticker to remove expired cache items.
@@ -776,7 +1910,7 @@ func (s *Server) cleanup() {
 	now := time.Now()
 	keysToDelete := make([]string, 0, 10)
 	for key, item := range s.cache.items {
-		if now.After(item.Expires) {
+		if !item.NoExpiry && now.After(item.Expires.Add(s.staleDuration())) {
 			keysToDelete = append(keysToDelete, key)
 		}
 	}
@@ -787,9 +1921,10 @@ func (s *Server) cleanup() {
 		s.cache.Lock()
 		count := 0
 		for _, key := range keysToDelete {
-			delete(s.cache.items, key)
+			s.cache.remove(key)
 			count++
 		}
+		s.cache.evictions.Add(uint64(count))
 		s.cache.Unlock()
 
 		if count > 0 {
@@ -800,6 +1935,11 @@ func (s *Server) cleanup() {
 
 // --- Logger Setup ---
 func setupLogger(w io.Writer, levelStr, typeStr string) {
+	slog.SetDefault(slog.New(newLogHandler(w, levelStr, typeStr)))
+}
+
+// newLogHandler returns a text or JSON handler (log_type) writing to w.
+func newLogHandler(w io.Writer, levelStr, typeStr string) slog.Handler {
 	var level slog.Level
 	switch strings.ToLower(levelStr) {
 	case "debug":
@@ -823,7 +1963,5 @@ func setupLogger(w io.Writer, levelStr, typeStr string) {
 	default:
 		handler = slog.NewTextHandler(w, opts)
 	}
-
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
+	return handler
 }
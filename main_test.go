@@ -1,4 +1,4 @@
-package main
+package gomadore
 
 import (
 	"bytes"
@@ -7,19 +7,18 @@ import (
 	"html/template"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
-
-	"github.com/yuin/goldmark"
-	"github.com/yuin/goldmark/extension"
-	"github.com/yuin/goldmark/parser"
 )
 
 // Helper to create a server instance for testing
@@ -51,20 +50,15 @@ func setupTestServer(t *testing.T) (*Server, string) {
 
 	// Initialize Server struct
 	cfg := Config{}
-	cfg.HTML.MarkdownRootDir = tempDir
+	cfg.HTML.MarkdownRootDir = RootDirs{tempDir}
 	cfg.Cache.CacheLimit = 60
 	cfg.HTML.StrictHtmlUrl = false // Set to false for testing (default behavior)
 
 	tmpl, _ := template.New("base").Parse(`{{.Body}}`) // Simple template
 
-	srv := &Server{
-		config: cfg,
-		cache:  &Cache{items: make(map[string]CacheItem)},
-		md: goldmark.New(
-			goldmark.WithExtensions(extension.GFM),
-			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
-		),
-		tmpl: tmpl,
+	srv, err := newServer(cfg, tmpl)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
 	}
 
 	return srv, tempDir
@@ -205,6 +199,45 @@ func TestCacheLogic(t *testing.T) {
 	})
 }
 
+func TestConcurrentCacheMiss(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	// The template blocks until every request has missed the cache
+	var renders atomic.Int32
+	release := make(chan struct{})
+	srv.tmpl = template.Must(template.New("base").Funcs(template.FuncMap{
+		"wait": func() string {
+			renders.Add(1)
+			<-release
+			return ""
+		},
+	}).Parse(`{{wait}}{{.Body}}`))
+
+	const n = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	for i := range n {
+		wg.Go(func() {
+			req := httptest.NewRequest(http.MethodGet, "/about", nil)
+			w := httptest.NewRecorder()
+			srv.handleRequest(w, req)
+			bodies[i] = w.Body.String()
+		})
+	}
+	time.Sleep(100 * time.Millisecond) // let all requests reach the render
+	close(release)
+	wg.Wait()
+
+	if got := renders.Load(); got != 1 {
+		t.Errorf("Expected 1 render, got %d", got)
+	}
+	for i, b := range bodies {
+		if !strings.Contains(b, "This is about page") {
+			t.Errorf("Request %d: unexpected body %q", i, b)
+		}
+	}
+}
+
 func TestPrintURLList(t *testing.T) {
 	// Create directories and files for testing
 	tempDir := t.TempDir()
@@ -221,7 +254,7 @@ func TestPrintURLList(t *testing.T) {
 	cfg := Config{}
 	cfg.General.ListenAddr = "127.0.0.1"
 	cfg.General.ListenPort = 8080
-	cfg.HTML.MarkdownRootDir = tempDir
+	cfg.HTML.MarkdownRootDir = RootDirs{tempDir}
 
 	// Subtest: StrictHtmlUrl = false (Default)
 	t.Run("StrictHtmlUrl=false", func(t *testing.T) {
@@ -465,6 +498,102 @@ func TestHotReload(t *testing.T) {
 	}
 }
 
+func TestInvalidateFiles(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.vhosts = map[string]*vhost{"docs.example.com": {key: "docs.example.com"}}
+
+	fill := func() {
+		srv.cache.Lock()
+		for _, k := range []string{"/index", "/about", "/sub/deep", "/sub/index", "docs.example.com/about"} {
+			srv.cache.items[k] = CacheItem{Content: []byte("cached"), Expires: time.Now().Add(time.Hour)}
+		}
+		srv.cache.Unlock()
+	}
+	cached := func() []string {
+		srv.cache.RLock()
+		defer srv.cache.RUnlock()
+		return slices.Sorted(maps.Keys(srv.cache.items))
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"Single page (all sites)", []string{filepath.Join(dir, "about.md")},
+			[]string{"/index", "/sub/deep", "/sub/index"}},
+		{"Directory index", []string{filepath.Join(dir, "sub", "index.md"), filepath.Join(dir, "index.md")},
+			[]string{"/about", "/sub/deep", "docs.example.com/about"}},
+		{"Non-markdown change purges everything", []string{filepath.Join(dir, "about.md"), filepath.Join(dir, "sub")},
+			nil},
+	}
+	for _, tt := range tests {
+		fill()
+		srv.invalidateFiles(tt.files)
+		if got := cached(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: remaining cache mismatch:\n got: %v\nwant: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	srv, rootDir := setupTestServer(t)
+	srv.config.Cache.StaleWhileRevalidate = 60
+	createFile(t, rootDir, "stale.md", "# New Version")
+
+	expire := func(content string, ago time.Duration) {
+		srv.cache.Lock()
+		srv.cache.items["/stale"] = CacheItem{Content: []byte(content), Expires: time.Now().Add(-ago)}
+		srv.cache.Unlock()
+	}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/stale", nil))
+		return w
+	}
+
+	t.Run("Within the window", func(t *testing.T) {
+		expire("old version", 10*time.Second)
+		w := get()
+		if got := w.Header().Get("X-Cache"); got != "STALE" {
+			t.Errorf("Expected X-Cache: STALE, got %s", got)
+		}
+		if w.Body.String() != "old version" {
+			t.Errorf("Expected the stale copy, got %q", w.Body.String())
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if _, ok := srv.cachedPage("/stale"); ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("Page was not revalidated in the background")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		w = get()
+		if got := w.Header().Get("X-Cache"); got != "HIT" || !strings.Contains(w.Body.String(), "New Version") {
+			t.Errorf("Expected the new page from the cache, got %s: %q", got, w.Body.String())
+		}
+	})
+
+	t.Run("Beyond the window", func(t *testing.T) {
+		expire("old version", 2*time.Minute)
+		if got := get().Header().Get("X-Cache"); got != "MISS" {
+			t.Errorf("Expected X-Cache: MISS, got %s", got)
+		}
+	})
+
+	t.Run("Cleanup keeps stale pages", func(t *testing.T) {
+		expire("old version", 10*time.Second)
+		srv.cleanup()
+		if srv.cache.len() != 1 {
+			t.Error("Expected the stale page to stay until the end of the window")
+		}
+	})
+}
+
 func TestCacheCleanup(t *testing.T) {
 	srv, _ := setupTestServer(t)
 
@@ -576,7 +705,7 @@ func TestPrintURLList_Error(t *testing.T) {
 	// Case 1: Directory does not exist
 	t.Run("Root Not Exist", func(t *testing.T) {
 		cfg := Config{}
-		cfg.HTML.MarkdownRootDir = filepath.Join(tempDir, "non_existent")
+		cfg.HTML.MarkdownRootDir = RootDirs{filepath.Join(tempDir, "non_existent")}
 
 		err := printURLList(cfg, false)
 		if err == nil {
@@ -593,7 +722,7 @@ func TestPrintURLList_Error(t *testing.T) {
 		createFile(t, tempDir, "file.txt", "content")
 
 		cfg := Config{}
-		cfg.HTML.MarkdownRootDir = filePath
+		cfg.HTML.MarkdownRootDir = RootDirs{filePath}
 
 		err := printURLList(cfg, false)
 		if err == nil {
@@ -987,3 +1116,51 @@ func TestTemplateVersionVariables(t *testing.T) {
 	}
 
 }
+
+func TestTemplateFrontMatter(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SiteTitle = "Site"
+	srv.config.HTML.SiteAuthor = "Site Author"
+
+	const fmTmpl = `[Title:{{.Title}}]
+[Description:{{.Description}}]
+[Author:{{.Author}}]
+[Custom:{{.Meta.custom}}]
+[Tags:{{range .Meta.tags}}{{.}},{{end}}]
+{{.Body}}`
+	srv.tmpl, _ = template.New("base").Parse(fmTmpl)
+
+	createFile(t, dir, "yaml.md", "---\ntitle: YAML Title\ndescription: About YAML\nauthor: Alice\ncustom: value\ntags: [a, b]\n---\n# H1 Title\nBody")
+	createFile(t, dir, "toml.md", "+++\ntitle = \"TOML Title\"\ncustom = 42\n+++\n# H1 Title\nBody")
+	createFile(t, dir, "none.md", "# H1 Title\nBody")
+	createFile(t, dir, "broken.md", "---\ntitle: [unclosed\n---\n# H1 Title\nBody")
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/yaml", []string{"[Title:YAML Title - Site]", "[Description:About YAML]", "[Author:Alice]", "[Custom:value]", "[Tags:a,b,]"}},
+		{"/toml", []string{"[Title:TOML Title - Site]", "[Description:]", "[Author:Site Author]", "[Custom:42]"}},
+		{"/none", []string{"[Title:H1 Title - Site]", "[Author:Site Author]", "[Custom:]"}},
+		{"/broken", []string{"[Title:H1 Title - Site]", "[Custom:]"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequestWithContext(t.Context(), "GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+
+		respBody := w.Body.String()
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: StatusCode mismatch: got %d", tt.path, w.Code)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(respBody, s) {
+				t.Errorf("%s: body should contain %q. Got body: %s", tt.path, s, respBody)
+			}
+		}
+		// The front matter block itself is not rendered
+		if tt.path != "/broken" && (strings.Contains(respBody, "<hr") || strings.Contains(respBody, "custom")) {
+			t.Errorf("%s: front matter rendered into body: %s", tt.path, respBody)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package gomadore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/gif"  // register GIF decoder for icon_source
+	_ "image/jpeg" // register JPEG decoder for icon_source
+	"image/png"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Default icon sizes used when manifest.icon_sizes is empty.
+// 192 and 512 are the sizes required by Chrome for installable web apps.
+var defaultIconSizes = []int{192, 512}
+
+// appleTouchIconSize is the size iOS expects for /apple-touch-icon.png
+const appleTouchIconSize = 180
+
+// --- Web App Manifest ---
+
+// webManifest holds the pre-generated manifest JSON, icons and link tags.
+// Everything is generated once at startup, so requests are served from memory.
+type webManifest struct {
+	body  []byte            // site.webmanifest (JSON)
+	icons map[string][]byte // URL path (without leading slash) -> PNG bytes
+	tags  template.HTML     // <link>/<meta> tags for <head>
+}
+
+type manifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+type manifestJSON struct {
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name,omitempty"`
+	Description     string         `json:"description,omitempty"`
+	Lang            string         `json:"lang,omitempty"`
+	StartURL        string         `json:"start_url"`
+	Scope           string         `json:"scope"`
+	Display         string         `json:"display"`
+	ThemeColor      string         `json:"theme_color,omitempty"`
+	BackgroundColor string         `json:"background_color,omitempty"`
+	Icons           []manifestIcon `json:"icons"`
+}
+
+// newWebManifest decodes the configured source image, renders every icon size
+// and builds the manifest document.
+func newWebManifest(cfg Config) (*webManifest, error) {
+	mc := cfg.Manifest
+
+	f, err := os.Open(mc.IconSource)
+	if err != nil {
+		return nil, fmt.Errorf("opening icon source: %w", err)
+	}
+	src, _, err := image.Decode(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("decoding icon source (%s): %w", mc.IconSource, err)
+	}
+
+	sizes := slices.Clone(mc.IconSizes)
+	if len(sizes) == 0 {
+		sizes = slices.Clone(defaultIconSizes)
+	}
+	slices.Sort(sizes)
+	sizes = slices.Compact(sizes)
+
+	m := &webManifest{icons: make(map[string][]byte)}
+
+	doc := manifestJSON{
+		Name:            mc.Name,
+		ShortName:       mc.ShortName,
+		Description:     mc.Description,
+		Lang:            cfg.HTML.SiteLang,
+		StartURL:        mc.StartURL,
+		Scope:           "/",
+		Display:         mc.Display,
+		ThemeColor:      mc.ThemeColor,
+		BackgroundColor: mc.BackgroundColor,
+	}
+	if doc.Name == "" {
+		doc.Name = cfg.HTML.SiteTitle
+	}
+	if doc.StartURL == "" {
+		doc.StartURL = "/"
+	}
+	if doc.Display == "" {
+		doc.Display = "standalone"
+	}
+
+	var tags strings.Builder
+	tags.WriteString(`<link rel="manifest" href="/site.webmanifest">`)
+
+	for _, size := range sizes {
+		name := fmt.Sprintf("icons/icon-%dx%d.png", size, size)
+		pngBytes, err := renderIcon(src, size)
+		if err != nil {
+			return nil, err
+		}
+		m.icons[name] = pngBytes
+		doc.Icons = append(doc.Icons, manifestIcon{
+			Src:   "/" + name,
+			Sizes: fmt.Sprintf("%dx%d", size, size),
+			Type:  "image/png",
+		})
+		fmt.Fprintf(&tags, "\n    <link rel=\"icon\" type=\"image/png\" sizes=\"%dx%d\" href=\"/%s\">", size, size, name)
+	}
+
+	// Apple devices ignore the manifest icons and look for apple-touch-icon
+	appleIcon, err := renderIcon(src, appleTouchIconSize)
+	if err != nil {
+		return nil, err
+	}
+	m.icons["apple-touch-icon.png"] = appleIcon
+	tags.WriteString("\n    <link rel=\"apple-touch-icon\" href=\"/apple-touch-icon.png\">")
+
+	if mc.ThemeColor != "" {
+		fmt.Fprintf(&tags, "\n    <meta name=\"theme-color\" content=\"%s\">", template.HTMLEscapeString(mc.ThemeColor))
+	}
+
+	m.body, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	m.tags = template.HTML(tags.String())
+
+	return m, nil
+}
+
+// renderIcon scales src into a square PNG of the given size.
+// Non-square sources are fitted and centered on a transparent canvas.
+func renderIcon(src image.Image, size int) ([]byte, error) {
+	sb := src.Bounds()
+	w, h := sb.Dx(), sb.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("icon source has no pixels")
+	}
+
+	// Fit into size x size keeping the aspect ratio
+	dw, dh := size, size
+	if w > h {
+		dh = size * h / w
+	} else if h > w {
+		dw = size * w / h
+	}
+	offX, offY := (size-dw)/2, (size-dh)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, image.Rect(offX, offY, offX+dw, offY+dh), src, sb, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("encoding %dx%d icon: %w", size, size, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// linkTags returns the HTML tags to embed in <head>. Safe to call on nil.
+func (m *webManifest) linkTags() template.HTML {
+	if m == nil {
+		return ""
+	}
+	return m.tags
+}
+
+func (m *webManifest) handleManifest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Cache-Control", "max-age=86400")
+	_ = writeBody(w, m.body)
+}
+
+func (m *webManifest) handleIcon(w http.ResponseWriter, r *http.Request) {
+	icon, ok := m.icons[strings.TrimPrefix(path.Clean(r.URL.Path), "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "max-age=604800")
+	_ = writeBody(w, icon)
+}
@@ -0,0 +1,151 @@
+package gomadore
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createIconSource writes a w x h PNG to dir and returns its path
+func createIconSource(t *testing.T, dir string, w, h int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	p := filepath.Join(dir, "icon-source.png")
+	if err := os.WriteFile(p, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write icon source: %v", err)
+	}
+	return p
+}
+
+func TestWebManifest(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	srv.config.HTML.SiteTitle = "Manifest Site"
+	srv.config.Manifest.Enabled = true
+	srv.config.Manifest.IconSource = createIconSource(t, dir, 300, 200)
+	srv.config.Manifest.IconSizes = []int{512, 192, 192}
+	srv.config.Manifest.ThemeColor = "#123456"
+
+	m, err := newWebManifest(srv.config)
+	if err != nil {
+		t.Fatalf("newWebManifest failed: %v", err)
+	}
+	srv.manifest = m
+	mux := srv.routes()
+
+	t.Run("Manifest JSON", func(t *testing.T) {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/site.webmanifest", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/manifest+json" {
+			t.Errorf("Content-Type mismatch: got %s", ct)
+		}
+
+		var doc manifestJSON
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("Invalid manifest JSON: %v", err)
+		}
+		if doc.Name != "Manifest Site" {
+			t.Errorf("Name should fall back to site_title, got %q", doc.Name)
+		}
+		if doc.Display != "standalone" || doc.StartURL != "/" {
+			t.Errorf("Unexpected defaults: display=%q start_url=%q", doc.Display, doc.StartURL)
+		}
+		if len(doc.Icons) != 2 {
+			t.Fatalf("Expected 2 icons (deduplicated), got %d", len(doc.Icons))
+		}
+		if doc.Icons[0].Sizes != "192x192" || doc.Icons[1].Sizes != "512x512" {
+			t.Errorf("Icons should be sorted by size, got %+v", doc.Icons)
+		}
+	})
+
+	t.Run("Icons", func(t *testing.T) {
+		for path, size := range map[string]int{
+			"/icons/icon-192x192.png": 192,
+			"/icons/icon-512x512.png": 512,
+			"/apple-touch-icon.png":   appleTouchIconSize,
+		} {
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: StatusCode mismatch: got %d", path, w.Code)
+				continue
+			}
+			img, err := png.Decode(w.Body)
+			if err != nil {
+				t.Errorf("%s: invalid PNG: %v", path, err)
+				continue
+			}
+			if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+				t.Errorf("%s: size mismatch: got %dx%d, want %dx%d", path, b.Dx(), b.Dy(), size, size)
+			}
+		}
+	})
+
+	t.Run("Link tags in page", func(t *testing.T) {
+		srv.tmpl, _ = srv.tmpl.New("base").Parse(`{{ .ManifestTags }}`)
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		for _, want := range []string{
+			`<link rel="manifest" href="/site.webmanifest">`,
+			`<link rel="icon" type="image/png" sizes="192x192" href="/icons/icon-192x192.png">`,
+			`<link rel="apple-touch-icon" href="/apple-touch-icon.png">`,
+			`<meta name="theme-color" content="#123456">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Response missing %q\nGot: %s", want, body)
+			}
+		}
+	})
+}
+
+func TestWebManifest_Disabled(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/site.webmanifest", nil)
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Manifest should not be served when disabled, got %d", w.Code)
+	}
+}
+
+func TestWebManifest_InvalidSource(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "broken.png", "not an image")
+
+	cfg := Config{}
+	cfg.Manifest.Enabled = true
+	cfg.Manifest.IconSource = filepath.Join(dir, "broken.png")
+
+	if _, err := newWebManifest(cfg); err == nil {
+		t.Error("Expected error for undecodable icon source, got nil")
+	}
+}
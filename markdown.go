@@ -0,0 +1,42 @@
+package gomadore
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// --- Markdown Extensions ---
+
+// markdownExtensions returns the goldmark extensions enabled in [markdown].
+// Unset options keep GitHub Flavored Markdown: tables, strikethrough, task
+// lists and linkify are on; typographer, footnotes and definition lists are off.
+func markdownExtensions(cfg Config) []goldmark.Extender {
+	mc := cfg.Markdown
+	var exts []goldmark.Extender
+	for _, e := range []struct {
+		opt *bool
+		def bool
+		ext goldmark.Extender
+	}{
+		{mc.Tables, true, extension.Table},
+		{mc.Strikethrough, true, extension.Strikethrough},
+		{mc.TaskList, true, extension.TaskList},
+		{mc.Linkify, true, extension.Linkify},
+		{mc.Typographer, false, extension.Typographer},
+		{mc.Footnote, false, extension.Footnote},
+		{mc.DefinitionList, false, extension.DefinitionList},
+	} {
+		if boolOr(e.opt, e.def) {
+			exts = append(exts, e.ext)
+		}
+	}
+	return exts
+}
+
+// boolOr returns *p, or def if the option is not set.
+func boolOr(p *bool, def bool) bool {
+	if p == nil {
+		return def
+	}
+	return *p
+}
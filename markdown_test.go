@@ -0,0 +1,56 @@
+package gomadore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestMarkdownExtensions(t *testing.T) {
+	src := "| a |\n|---|\n| 1 |\n\n~~old~~ https://example.com \"quote\"\n\n- [x] done\n\nText[^1]\n\n[^1]: Note\n\nTerm\n: Definition\n"
+	render := func(cfg Config) string {
+		var buf bytes.Buffer
+		md := goldmark.New(goldmark.WithExtensions(markdownExtensions(cfg)...))
+		if err := md.Convert([]byte(src), &buf); err != nil {
+			t.Fatalf("Convert failed: %v", err)
+		}
+		return buf.String()
+	}
+	on, off := true, false
+
+	// Unset: GitHub Flavored Markdown
+	var cfg Config
+	html := render(cfg)
+	for _, want := range []string{"<table>", "<del>old</del>", `<a href="https://example.com">`, `type="checkbox"`, "&quot;quote&quot;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("default: missing %q in %s", want, html)
+		}
+	}
+	for _, unwanted := range []string{"footnote", "<dl>", "&ldquo;"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("default: unexpected %q in %s", unwanted, html)
+		}
+	}
+
+	// Everything toggled
+	cfg.Markdown.Tables = &off
+	cfg.Markdown.Strikethrough = &off
+	cfg.Markdown.TaskList = &off
+	cfg.Markdown.Linkify = &off
+	cfg.Markdown.Typographer = &on
+	cfg.Markdown.Footnote = &on
+	cfg.Markdown.DefinitionList = &on
+	html = render(cfg)
+	for _, want := range []string{`class="footnote-ref"`, "<dl>", "&ldquo;quote&rdquo;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("toggled: missing %q in %s", want, html)
+		}
+	}
+	for _, unwanted := range []string{"<table>", "<del>", "<a href=\"https://example.com\">", "checkbox"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("toggled: unexpected %q in %s", unwanted, html)
+		}
+	}
+}
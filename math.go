@@ -0,0 +1,200 @@
+package gomadore
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KaTeX tags added to the head of pages with math. %[1]s: base URL of the
+// KaTeX distribution (katex.min.css, katex.min.js, contrib/auto-render.min.js).
+const katexTagsTmpl = `<link rel="stylesheet" href="%[1]s/katex.min.css">
+    <script defer src="%[1]s/katex.min.js"></script>
+    <script defer src="%[1]s/contrib/auto-render.min.js" onload="renderMathInElement(document.body)"></script>`
+
+// --- Math ($...$ and $$...$$) ---
+
+// Math nodes are emitted as spans and divs with the TeX source between the
+// \( \) and \[ \] delimiters, which KaTeX (auto-render) and MathJax both
+// typeset in the browser.
+
+var (
+	kindMathInline = ast.NewNodeKind("MathInline")
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline is $...$ (or $$...$$ within a paragraph, rendered as display math).
+type mathInline struct {
+	ast.BaseInline
+	tex     []byte
+	display bool
+}
+
+func (n *mathInline) Kind() ast.NodeKind { return kindMathInline }
+
+func (n *mathInline) Dump(src []byte, level int) {
+	ast.DumpHelper(n, src, level, map[string]string{"TeX": string(n.tex)}, nil)
+}
+
+// mathBlock is a display math block between lines of "$$".
+type mathBlock struct {
+	ast.BaseBlock
+}
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(src []byte, level int) {
+	ast.DumpHelper(n, src, level, nil, nil)
+}
+
+// hasMath reports whether a document contains math.
+func hasMath(doc ast.Node) bool {
+	found := false
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && (n.Kind() == kindMathInline || n.Kind() == kindMathBlock) {
+			found = true
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte { return []byte{'$'} }
+
+// Parse follows the Pandoc rules, so prices such as "$5 and $10" stay text:
+// the opening $ must not be followed by a space, and the closing $ must not
+// be preceded by a space nor followed by a digit. Math ends with its line.
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	delim := 1
+	if len(line) > 1 && line[1] == '$' {
+		delim = 2
+	}
+	if len(line) <= delim || util.IsSpace(line[delim]) {
+		return nil
+	}
+	for i := delim; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // escaped character, e.g. \$
+		case '$':
+			if delim == 2 {
+				if i+1 < len(line) && line[i+1] == '$' {
+					block.Advance(i + 2)
+					return &mathInline{tex: bytes.Clone(line[2:i]), display: true}
+				}
+				continue
+			}
+			if util.IsSpace(line[i-1]) || (i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9') {
+				continue
+			}
+			block.Advance(i + 1)
+			return &mathInline{tex: bytes.Clone(line[1:i])}
+		}
+	}
+	return nil
+}
+
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	if !isMathFence(line[pc.BlockIndent():]) {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceToEOL()
+	return &mathBlock{}, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if isMathFence(util.TrimLeftSpace(line)) {
+		reader.AdvanceToEOL()
+		return parser.Close
+	}
+	seg := segment
+	seg.ForceNewline = true
+	node.Lines().Append(seg)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// isMathFence reports whether a line (without indentation) is "$$".
+func isMathFence(line []byte) bool {
+	return bytes.Equal(util.TrimRightSpace(line), []byte("$$"))
+}
+
+// mathExtension adds the math parsers and renderers.
+type mathExtension struct{}
+
+// Extend implements goldmark.Extender.
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 150)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(e, 100)))
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (e *mathExtension) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, e.renderInline)
+	reg.Register(kindMathBlock, e.renderBlock)
+}
+
+func (e *mathExtension) renderInline(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathInline)
+	if n.display {
+		_, _ = fmt.Fprintf(w, `<span class="math display">\[%s\]</span>`, html.EscapeString(string(n.tex)))
+	} else {
+		_, _ = fmt.Fprintf(w, `<span class="math inline">\(%s\)</span>`, html.EscapeString(string(n.tex)))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (e *mathExtension) renderBlock(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	var tex bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		tex.Write(seg.Value(src))
+	}
+	_, _ = fmt.Fprintf(w, "<div class=\"math display\">\\[\n%s\\]</div>\n", html.EscapeString(tex.String()))
+	return ast.WalkSkipChildren, nil
+}
+
+// mathTags returns the KaTeX tags for the page head (empty if katex_url is not set).
+func mathTags(katexURL string) template.HTML {
+	if katexURL == "" {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(katexTagsTmpl, html.EscapeString(strings.TrimSuffix(katexURL, "/"))))
+}
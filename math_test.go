@@ -0,0 +1,73 @@
+package gomadore
+
+import (
+	"bytes"
+	"html/template"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestMathExtension(t *testing.T) {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM, &mathExtension{}))
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"Energy $E = mc^2$ here", `<p>Energy <span class="math inline">\(E = mc^2\)</span> here</p>`},
+		{"Sum $$\\sum_{i=1}^n i$$ inline", `<p>Sum <span class="math display">\[\sum_{i=1}^n i\]</span> inline</p>`},
+		{"$$\na < b\n\\frac{1}{2}\n$$", "<div class=\"math display\">\\[\na &lt; b\n\\frac{1}{2}\n\\]</div>"},
+		{"Costs $5 and $10", "<p>Costs $5 and $10</p>"},
+		{"A $ sign $ alone", "<p>A $ sign $ alone</p>"},
+		{"Escaped \\$x$", "<p>Escaped $x$</p>"},
+		{"Code `$x$`", "<p>Code <code>$x$</code></p>"},
+		{"Text *$a_1$* and $a_2$", `<p>Text <em><span class="math inline">\(a_1\)</span></em> and <span class="math inline">\(a_2\)</span></p>`},
+		{"Unclosed $x", "<p>Unclosed $x</p>"},
+		{"Escape $\\$1$", `<p>Escape <span class="math inline">\(\$1\)</span></p>`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(tt.src), &buf); err != nil {
+			t.Fatalf("%q: %v", tt.src, err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestMathTags(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Math.Enabled = true
+	srv.config.Math.KatexURL = "https://cdn.example.com/katex/"
+	srv.config.HTML.SanitizeHTML = true
+	srv.sanitizer = newSanitizer(srv.config)
+	srv.md = goldmark.New(goldmark.WithExtensions(extension.GFM, &mathExtension{}))
+	srv.tmpl = template.Must(template.New("base").Parse(`<head>{{ .MathTags }}</head>{{ .Body }}`))
+
+	createFile(t, dir, "formula.md", "# Formula\n$x^2$\n")
+
+	get := func(p string) string {
+		req := httptest.NewRequestWithContext(t.Context(), "GET", p, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w.Body.String()
+	}
+
+	body := get("/formula")
+	if !strings.Contains(body, `<link rel="stylesheet" href="https://cdn.example.com/katex/katex.min.css">`) {
+		t.Errorf("KaTeX stylesheet missing: %s", body)
+	}
+	if !strings.Contains(body, `<span class="math inline">\(x^2\)</span>`) {
+		t.Errorf("math span removed by the sanitizer: %s", body)
+	}
+
+	// Pages without math get no tags
+	if body := get("/about"); strings.Contains(body, "katex") {
+		t.Errorf("KaTeX tags on a page without math: %s", body)
+	}
+}
@@ -0,0 +1,107 @@
+package gomadore
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math"
+	"runtime/debug"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Interval of the memory monitor
+const memoryCheckInterval = 10 * time.Second
+
+// --- Memory Pressure ---
+
+// memoryLimit returns the memory available to the process: the Go memory
+// limit (GOMEMLIMIT) if set, otherwise the physical memory or the cgroup
+// limit, whichever is lower (0 if unknown).
+func memoryLimit() int64 {
+	if l := debug.SetMemoryLimit(-1); l != math.MaxInt64 {
+		return l
+	}
+	return physicalMemory()
+}
+
+// cacheMemoryBudget returns the bytes the caches may hold with
+// max_memory_percent (0: no limit).
+func (s *Server) cacheMemoryBudget() int64 {
+	pct := s.config.Cache.MaxMemoryPercent
+	if pct <= 0 {
+		return 0
+	}
+	return memoryLimit() * int64(pct) / 100
+}
+
+// startMemoryMonitor evicts the least recently used pages while the caches
+// hold more than their memory budget.
+func (s *Server) startMemoryMonitor(ctx context.Context) {
+	budget := s.cacheMemoryBudget()
+	if budget <= 0 {
+		slog.Warn("max_memory_percent ignored; the memory limit is unknown (set GOMEMLIMIT)")
+		return
+	}
+	slog.Info("Cache memory monitor started", "budget_bytes", budget)
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// GOMEMLIMIT may be changed at run time
+			if budget := s.cacheMemoryBudget(); budget > 0 {
+				s.evictForMemory(budget)
+			}
+		}
+	}
+}
+
+// evictForMemory drops the least recently used pages of the server and its
+// subsites until they hold less than 90% of budget, if they hold more than
+// budget. It returns the number of pages dropped.
+func (s *Server) evictForMemory(budget int64) int {
+	held := s.cacheStats().Bytes
+	if held <= budget {
+		return 0
+	}
+
+	type entry struct {
+		cache *Cache
+		key   string
+		used  *atomic.Int64 // tells the page from a newer one with the same key
+		last  int64
+		size  int64
+	}
+	var entries []entry
+	for _, srv := range s.servers() {
+		srv.cache.RLock()
+		for key, item := range srv.cache.items {
+			entries = append(entries, entry{srv.cache, key, item.used, item.lastUsed(), item.size()})
+		}
+		srv.cache.RUnlock()
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.last, b.last) })
+
+	target := budget / 10 * 9
+	removed := 0
+	for _, e := range entries {
+		if held <= target {
+			break
+		}
+		e.cache.Lock()
+		if item, ok := e.cache.items[e.key]; ok && item.used == e.used {
+			e.cache.remove(e.key)
+			e.cache.evictions.Add(1)
+			held -= e.size
+			removed++
+		}
+		e.cache.Unlock()
+	}
+	slog.Info("Evicted cached pages under memory pressure", "removed_count", removed, "held_bytes", held, "budget_bytes", budget)
+	return removed
+}
@@ -0,0 +1,27 @@
+//go:build linux
+
+package gomadore
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// physicalMemory returns the total memory of the machine, or the limit of
+// the cgroup (v2) of the process if lower.
+func physicalMemory() int64 {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return 0
+	}
+	total := int64(info.Totalram) * int64(info.Unit)
+	if b, err := os.ReadFile("/sys/fs/cgroup/memory.max"); err == nil {
+		// "max" if there is no limit
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err == nil && n < total {
+			total = n
+		}
+	}
+	return total
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package gomadore
+
+// physicalMemory is unknown: only GOMEMLIMIT bounds the cache.
+func physicalMemory() int64 { return 0 }
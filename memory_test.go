@@ -0,0 +1,113 @@
+package gomadore
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	old := debug.SetMemoryLimit(512 << 20)
+	t.Cleanup(func() { debug.SetMemoryLimit(old) })
+	if l := memoryLimit(); l != 512<<20 {
+		t.Errorf("Expected GOMEMLIMIT, got %d", l)
+	}
+
+	srv, _ := setupTestServer(t)
+	if b := srv.cacheMemoryBudget(); b != 0 {
+		t.Errorf("Expected no budget by default, got %d", b)
+	}
+	srv.config.Cache.MaxMemoryPercent = 25
+	if b := srv.cacheMemoryBudget(); b != 128<<20 {
+		t.Errorf("Expected a quarter of GOMEMLIMIT, got %d", b)
+	}
+
+	debug.SetMemoryLimit(math.MaxInt64)
+	if l := memoryLimit(); l < 0 {
+		t.Errorf("Unexpected physical memory %d", l)
+	}
+}
+
+func TestEvictForMemory(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	get := func(p string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, p, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", p, w.Code)
+		}
+	}
+	// Requested in this order, then /about again: /sub/deep is the least
+	// recently used page
+	for _, p := range []string{"/about", "/sub/deep", "/", "/about"} {
+		get(p)
+	}
+	held := srv.cacheStats().Bytes
+	if n := srv.evictForMemory(held); n != 0 {
+		t.Errorf("Expected no eviction within the budget, got %d", n)
+	}
+
+	deep, _ := srv.cachedPage("/sub/deep")
+	if n := srv.evictForMemory(held - 1); n != 1 {
+		t.Fatalf("Expected one eviction, got %d", n)
+	}
+	if _, ok := srv.cachedPage("/sub/deep"); ok {
+		t.Error("The least recently used page should be evicted")
+	}
+	cs := srv.cacheStats()
+	if cs.Items != 2 || cs.Bytes != held-deep.size() || cs.Evictions != 1 {
+		t.Errorf("After the eviction: %+v", cs)
+	}
+
+	if n := srv.evictForMemory(1); n != 2 || srv.cacheItems() != 0 {
+		t.Errorf("Expected every page to be evicted, got %d", n)
+	}
+}
+
+func TestEvictForMemorySubsites(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	docsDir := t.TempDir()
+	createFile(t, docsDir, "guide.md", "# Guide\n\n"+strings.Repeat("Read me. ", 1000))
+	cfg := srv.config
+	cfg.VHosts = []VHostConfig{{Hosts: []string{"docs.example.com"}, MarkdownRootDir: RootDirs{docsDir}}}
+	multi, err := newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := multi.handler()
+	get := func(host, p string) {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, p, nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s%s: status %d", host, p, w.Code)
+		}
+	}
+	// The subsite page is the least recently used one of all sites
+	get("docs.example.com", "/guide")
+	get("example.com", "/about")
+	sub := multi.subsiteFor["docs.example.com"]
+	guide, _ := sub.cachedPage("/guide")
+	about, _ := multi.cachedPage("/about")
+
+	// One budget for both caches, which the main site alone does not fill
+	budget := about.size() * 2
+	if guide.size()+about.size() <= budget {
+		t.Fatalf("The caches should hold more than the budget: %d + %d", guide.size(), about.size())
+	}
+	if n := multi.evictForMemory(budget); n != 1 {
+		t.Fatalf("Expected one eviction, got %d", n)
+	}
+	if _, ok := sub.cachedPage("/guide"); ok {
+		t.Error("The least recently used page of the subsite should be evicted")
+	}
+	if _, ok := multi.cachedPage("/about"); !ok {
+		t.Error("The main site page should be kept")
+	}
+}
@@ -0,0 +1,271 @@
+package gomadore
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// Default address of the metrics listener
+	defaultMetricsListenAddr = "127.0.0.1"
+	defaultMetricsListenPort = 9464
+)
+
+// Histogram buckets (seconds), the Prometheus client defaults
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Request methods used as label values (others are counted as "other")
+var metricsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Watcher operations used as label values
+var metricsWatcherOps = []struct {
+	op   fsnotify.Op
+	name string
+}{
+	{fsnotify.Create, "create"},
+	{fsnotify.Write, "write"},
+	{fsnotify.Remove, "remove"},
+	{fsnotify.Rename, "rename"},
+	{fsnotify.Chmod, "chmod"},
+}
+
+// --- Metrics (Prometheus text exposition format) ---
+
+// histogram is a cumulative histogram over metricsBuckets.
+type histogram struct {
+	counts []uint64 // per bucket (not cumulative)
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(metricsBuckets))
+	}
+	for i, b := range metricsBuckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// metrics collects server metrics. Every method is safe to call on nil
+// (metrics disabled).
+type metrics struct {
+	mu              sync.Mutex
+	requests        map[[2]string]uint64 // {method, code} -> count
+	requestDuration histogram
+	cacheHits       uint64
+	cacheMisses     uint64
+	renderDuration  histogram
+	watcherEvents   map[string]uint64 // op -> count
+	cacheStats      func() cacheStats
+}
+
+// newMetrics returns nil if metrics are disabled.
+func newMetrics(cfg Config, cacheStats func() cacheStats) *metrics {
+	if !cfg.Metrics.Enabled {
+		return nil
+	}
+	return &metrics{
+		requests:      make(map[[2]string]uint64),
+		watcherEvents: make(map[string]uint64),
+		cacheStats:    cacheStats,
+	}
+}
+
+// statusRecorder captures the status code and body size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument counts the requests handled by next and their latency.
+func (m *metrics) instrument(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		method := "other"
+		if slices.Contains(metricsMethods, r.Method) {
+			method = r.Method
+		}
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.mu.Lock()
+		m.requests[[2]string{method, strconv.Itoa(status)}]++
+		m.requestDuration.observe(time.Since(start).Seconds())
+		m.mu.Unlock()
+	})
+}
+
+// setCacheStats replaces the cache statistics source (after a reload).
+func (m *metrics) setCacheStats(cacheStats func() cacheStats) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.cacheStats = cacheStats
+	m.mu.Unlock()
+}
+
+func (m *metrics) cacheHit() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.cacheHits++
+	m.mu.Unlock()
+}
+
+func (m *metrics) cacheMiss() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.cacheMisses++
+	m.mu.Unlock()
+}
+
+func (m *metrics) observeRender(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.renderDuration.observe(d.Seconds())
+	m.mu.Unlock()
+}
+
+func (m *metrics) watcherEvent(op fsnotify.Op) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	for _, o := range metricsWatcherOps {
+		if op.Has(o.op) {
+			m.watcherEvents[o.name]++
+		}
+	}
+	m.mu.Unlock()
+}
+
+// handleMetrics serves the metrics in the Prometheus text format.
+func (m *metrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	m.write(&buf)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = writeBody(w, buf.Bytes())
+}
+
+func (m *metrics) write(w *bytes.Buffer) {
+	m.mu.Lock()
+	statsFn := m.cacheStats
+	m.mu.Unlock()
+	var cs cacheStats
+	if statsFn != nil {
+		cs = statsFn() // takes the cache lock, not m.mu
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP gomadore_build_info Build information.")
+	fmt.Fprintln(w, "# TYPE gomadore_build_info gauge")
+	fmt.Fprintf(w, "gomadore_build_info{version=%q,revision=%q,goversion=%q} 1\n", Version, Revision, runtime.Version())
+
+	fmt.Fprintln(w, "# HELP gomadore_http_requests_total Number of HTTP requests by method and status code.")
+	fmt.Fprintln(w, "# TYPE gomadore_http_requests_total counter")
+	keys := slices.SortedFunc(maps.Keys(m.requests), func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "gomadore_http_requests_total{method=%q,code=%q} %d\n", k[0], k[1], m.requests[k])
+	}
+
+	writeHistogram(w, "gomadore_http_request_duration_seconds", "HTTP request latency.", &m.requestDuration)
+
+	fmt.Fprintln(w, "# HELP gomadore_cache_hits_total Number of page requests served from the cache.")
+	fmt.Fprintln(w, "# TYPE gomadore_cache_hits_total counter")
+	fmt.Fprintf(w, "gomadore_cache_hits_total %d\n", m.cacheHits)
+	fmt.Fprintln(w, "# HELP gomadore_cache_misses_total Number of page requests that were rendered.")
+	fmt.Fprintln(w, "# TYPE gomadore_cache_misses_total counter")
+	fmt.Fprintf(w, "gomadore_cache_misses_total %d\n", m.cacheMisses)
+	fmt.Fprintln(w, "# HELP gomadore_cache_items Number of cached pages.")
+	fmt.Fprintln(w, "# TYPE gomadore_cache_items gauge")
+	fmt.Fprintf(w, "gomadore_cache_items %d\n", cs.Items)
+	fmt.Fprintln(w, "# HELP gomadore_cache_bytes Size of the cached pages as stored (compressed with gzip enabled).")
+	fmt.Fprintln(w, "# TYPE gomadore_cache_bytes gauge")
+	fmt.Fprintf(w, "gomadore_cache_bytes %d\n", cs.Bytes)
+	fmt.Fprintln(w, "# HELP gomadore_cache_evictions_total Number of pages dropped for max_cache_items or on expiry (since the last reload).")
+	fmt.Fprintln(w, "# TYPE gomadore_cache_evictions_total counter")
+	fmt.Fprintf(w, "gomadore_cache_evictions_total %d\n", cs.Evictions)
+
+	writeHistogram(w, "gomadore_render_duration_seconds", "Page rendering time (markdown and template).", &m.renderDuration)
+
+	fmt.Fprintln(w, "# HELP gomadore_watcher_events_total Number of file watcher events by operation.")
+	fmt.Fprintln(w, "# TYPE gomadore_watcher_events_total counter")
+	for _, o := range metricsWatcherOps {
+		fmt.Fprintf(w, "gomadore_watcher_events_total{op=%q} %d\n", o.name, m.watcherEvents[o.name])
+	}
+}
+
+func writeHistogram(w *bytes.Buffer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	var cumulative uint64
+	for i, b := range metricsBuckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(b, 'f', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'f', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
@@ -0,0 +1,84 @@
+package gomadore
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestMetrics(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	if srv.metrics != nil {
+		t.Fatal("Metrics should be disabled by default")
+	}
+	// Disabled metrics are no-ops
+	srv.metrics.cacheHit()
+	srv.metrics.watcherEvent(fsnotify.Write)
+
+	srv.config.Metrics.Enabled = true
+	srv.metrics = newMetrics(srv.config, srv.cacheStats)
+	h := srv.handler()
+
+	for _, target := range []string{"/about", "/about", "/missing"} {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, target, nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req := httptest.NewRequestWithContext(t.Context(), "PROPFIND", "/about", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	srv.metrics.watcherEvent(fsnotify.Create | fsnotify.Write)
+
+	w := httptest.NewRecorder()
+	srv.metrics.handleMetrics(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type mismatch: got %q", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`gomadore_http_requests_total{method="GET",code="200"} 2`,
+		`gomadore_http_requests_total{method="GET",code="404"} 1`,
+		`gomadore_http_requests_total{method="other",code="405"} 1`,
+		`gomadore_http_request_duration_seconds_bucket{le="+Inf"} 4`,
+		`gomadore_http_request_duration_seconds_count 4`,
+		"gomadore_cache_hits_total 1",
+		"gomadore_cache_misses_total 2",
+		"gomadore_cache_items 1",
+		"gomadore_cache_evictions_total 0",
+		"# TYPE gomadore_cache_bytes gauge",
+		`gomadore_render_duration_seconds_count 2`,
+		`gomadore_watcher_events_total{op="create"} 1`,
+		`gomadore_watcher_events_total{op="write"} 1`,
+		`gomadore_watcher_events_total{op="remove"} 0`,
+		"# TYPE gomadore_render_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics should contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, v := range []float64{0.001, 0.02, 0.02, 30} {
+		h.observe(v)
+	}
+	var buf bytes.Buffer
+	writeHistogram(&buf, "x", "help", &h)
+	for _, want := range []string{
+		`x_bucket{le="0.005"} 1`,
+		`x_bucket{le="0.01"} 1`,
+		`x_bucket{le="0.025"} 3`,
+		`x_bucket{le="10"} 3`,
+		`x_bucket{le="+Inf"} 4`,
+		"x_sum 30.041",
+		"x_count 4",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Histogram should contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package gomadore
+
+import (
+	"bytes"
+
+	"golang.org/x/net/html"
+)
+
+// --- HTML Minification ---
+
+// Elements whose content is kept as is
+var minifyPreserved = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+}
+
+// Elements around which whitespace between tags is not rendered, so that
+// whitespace-only text next to them can be dropped
+var minifyBlocks = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true, "link": true,
+	"script": true, "style": true, "base": true, "noscript": true, "template": true,
+	"header": true, "footer": true, "main": true, "nav": true, "aside": true,
+	"section": true, "article": true, "address": true, "div": true, "p": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"th": true, "td": true, "caption": true, "colgroup": true, "col": true,
+	"blockquote": true, "figure": true, "figcaption": true, "hr": true, "br": true,
+	"pre": true, "form": true, "fieldset": true, "legend": true, "details": true,
+	"summary": true, "option": true, "optgroup": true, "select": true,
+}
+
+// minifyHTML collapses runs of whitespace in text to one character, drops
+// whitespace between block-level tags and strips comments (except
+// conditional comments). Tags and attributes are copied unchanged, and the
+// content of pre, textarea, script and style is left alone.
+func minifyHTML(src []byte) []byte {
+	type token struct {
+		typ  html.TokenType
+		name string
+		raw  []byte
+	}
+	var tokens []token
+	z := html.NewTokenizer(bytes.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break // io.EOF: the input is a byte slice
+		}
+		t := token{typ: tt, raw: bytes.Clone(z.Raw())}
+		if tt == html.StartTagToken || tt == html.EndTagToken || tt == html.SelfClosingTagToken {
+			name, _ := z.TagName()
+			t.name = string(name)
+		}
+		tokens = append(tokens, t)
+	}
+
+	isBlock := func(i int) bool {
+		return i < 0 || i >= len(tokens) || tokens[i].typ == html.DoctypeToken || minifyBlocks[tokens[i].name]
+	}
+
+	out := make([]byte, 0, len(src))
+	preserve := 0 // depth of preserved elements
+	for i, t := range tokens {
+		switch t.typ {
+		case html.CommentToken:
+			if preserve > 0 || bytes.HasPrefix(t.raw, []byte("<!--[if")) {
+				out = append(out, t.raw...)
+			}
+			continue
+		case html.StartTagToken:
+			if minifyPreserved[t.name] {
+				preserve++
+			}
+		case html.EndTagToken:
+			if minifyPreserved[t.name] && preserve > 0 {
+				preserve--
+			}
+		case html.TextToken:
+			if preserve > 0 {
+				break
+			}
+			text := collapseSpace(t.raw)
+			if len(bytes.TrimSpace(text)) == 0 && (isBlock(i-1) || isBlock(i+1)) {
+				continue
+			}
+			out = append(out, text...)
+			continue
+		}
+		out = append(out, t.raw...)
+	}
+	return out
+}
+
+// collapseSpace replaces every run of HTML whitespace with a single space
+// (a newline if the run contains one, so that line structure survives).
+func collapseSpace(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != '\f' {
+			out = append(out, c)
+			i++
+			continue
+		}
+		sep := byte(' ')
+		for ; i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r' || b[i] == '\f'); i++ {
+			if b[i] == '\n' {
+				sep = '\n'
+			}
+		}
+		out = append(out, sep)
+	}
+	return out
+}
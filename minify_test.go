@@ -0,0 +1,61 @@
+package gomadore
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"blocks", "<!DOCTYPE html>\n<html>\n  <body>\n    <p>Hello   <b>big</b>\n    world</p>\n  </body>\n</html>\n",
+			"<!DOCTYPE html><html><body><p>Hello <b>big</b>\nworld</p></body></html>"},
+		{"comments", "<p>a<!-- note -->b</p><!--[if IE]><p>old</p><![endif]-->",
+			"<p>ab</p><!--[if IE]><p>old</p><![endif]-->"},
+		{"inline space", "<p><a href=\"/x\">one</a> <a href=\"/y\">two</a></p>",
+			"<p><a href=\"/x\">one</a> <a href=\"/y\">two</a></p>"},
+		{"preserved", "<pre><code>a\n    b  <!-- kept --></code></pre>\n<script>\n  if (a  <  b) {}\n</script>",
+			"<pre><code>a\n    b  <!-- kept --></code></pre><script>\n  if (a  <  b) {}\n</script>"},
+		{"attributes", "<div  class=\"a  b\"\n  id=x>  </div>",
+			"<div  class=\"a  b\"\n  id=x></div>"},
+		{"entities", "<p>&lt;tag&gt;  &amp;</p>", "<p>&lt;tag&gt; &amp;</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(minifyHTML([]byte(tt.in))); got != tt.want {
+				t.Errorf("minifyHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinifiedPages(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.tmpl = template.Must(template.New("base").Parse("<html>\n  <body>\n    {{ .Body }}\n  </body>\n</html>\n"))
+	createFile(t, dir, "code.md", "# Code\n\n```\nkeep   this\n```\n")
+
+	st := srv.siteForHost("")
+	out, err := srv.renderPage(st, "/code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\n  </body>") {
+		t.Fatalf("Expected unminified output by default, got %q", out)
+	}
+
+	srv.config.HTML.MinifyHTML = true
+	out, err = srv.renderPage(st, "/code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<html><body><h1 id=\"code\">Code</h1><pre>"; !strings.HasPrefix(string(out), want) || strings.Contains(string(out), "\n  ") {
+		t.Errorf("Expected minified output, got %q", out)
+	}
+	if !strings.Contains(string(out), "keep   this") {
+		t.Errorf("Expected code blocks to be kept, got %q", out)
+	}
+}
@@ -0,0 +1,299 @@
+package gomadore
+
+import (
+	"cmp"
+	"log/slog"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// Orders of pages for the previous/next links (html.page_order)
+	pageOrderFilename = "filename"
+	pageOrderDate     = "date"
+)
+
+// --- Navigation Tree ---
+
+// navNode is a page or directory of the content root, available in
+// templates as {{ .Nav }}.
+type navNode struct {
+	Title    string
+	URL      string // "" for a directory without index page (unless auto_index)
+	Path     string // internal page path ("/guide/intro"); "/guide/" for a directory
+	IsDir    bool
+	Children []*navNode
+}
+
+// pageLinks are the neighbours of a page within its directory, available
+// in templates as {{ .Prev }} and {{ .Next }} (nil at either end).
+type pageLinks struct {
+	Prev *navNode
+	Next *navNode
+}
+
+// navTree holds the navigation of the content root, built from the page
+// index on first use. Every method is safe for concurrent use.
+//
+// Protected pages (see Server.pageProtected) are only shown on protected
+// pages, whose readers are signed in: the navigation and the neighbours of
+// other pages are built without them.
+type navTree struct {
+	mu           sync.Mutex
+	ix           *pageIndex
+	protected    func(pagePath string) bool
+	nodes        []*navNode
+	links        map[string]pageLinks // by page path
+	visible      []*navNode           // nodes without the protected pages
+	visibleLinks map[string]pageLinks
+	built        bool
+	strict       bool
+	autoIndex    bool
+	order        string
+}
+
+func newNavTree(cfg Config, ix *pageIndex, protected func(pagePath string) bool) *navTree {
+	return &navTree{ix: ix, protected: protected, strict: cfg.HTML.StrictHtmlUrl, autoIndex: cfg.HTML.AutoIndex, order: cfg.HTML.PageOrder}
+}
+
+// get returns the top level nodes shown on a page path ("" for a page that
+// is not a document). They must not be modified.
+func (n *navTree) get(p string) []*navNode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.built {
+		n.build()
+	}
+	if n.shows(p) {
+		return n.nodes
+	}
+	return n.visible
+}
+
+// siblings returns the previous and next pages of a page path.
+func (n *navTree) siblings(p string) pageLinks {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.built {
+		n.build()
+	}
+	if n.shows(p) {
+		return n.links[p]
+	}
+	return n.visibleLinks[p]
+}
+
+// shows reports whether protected pages are shown on a page path.
+func (n *navTree) shows(p string) bool {
+	return p != "" && n.protected != nil && n.protected(p)
+}
+
+// breadcrumbs returns the trail from the top page to a page path, available
+// in templates as {{ .Breadcrumbs }}: the root index page (if any), every
+// ancestor directory and the page itself (an index page is represented by
+// its directory). The nodes must not be modified. The ancestors of a page
+// are protected only if the page is, so the trail comes from every node.
+func (n *navTree) breadcrumbs(p string) []*navNode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.built {
+		n.build()
+	}
+
+	var trail []*navNode
+	find := func(nodes []*navNode, p string) *navNode {
+		if i := slices.IndexFunc(nodes, func(c *navNode) bool { return c.Path == p }); i >= 0 {
+			return nodes[i]
+		}
+		return nil
+	}
+	if top := find(n.nodes, "/index"); top != nil {
+		trail = append(trail, top)
+	}
+	if p == "/index" {
+		return trail
+	}
+
+	var dirs []string
+	for d := navDir(p); d != "/"; d = navDir(d) {
+		dirs = append(dirs, d)
+	}
+	slices.Reverse(dirs)
+
+	nodes := n.nodes
+	for _, d := range dirs {
+		node := find(nodes, d)
+		if node == nil {
+			return trail
+		}
+		trail = append(trail, node)
+		nodes = node.Children
+	}
+	if path.Base(p) != "index" { // an index page is its directory
+		if node := find(nodes, p); node != nil {
+			trail = append(trail, node)
+		}
+	}
+	return trail
+}
+
+// refresh rebuilds the tree (if it has been built) and reports whether it
+// or the order of pages changed.
+func (n *navTree) refresh() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.built {
+		return false
+	}
+	prev, prevLinks, prevVisible := n.nodes, n.links, n.visible
+	n.build()
+	return !reflect.DeepEqual(prev, n.nodes) || !reflect.DeepEqual(prevLinks, n.links) || !reflect.DeepEqual(prevVisible, n.visible)
+}
+
+func (n *navTree) build() {
+	pages, err := n.ix.all()
+	if err != nil {
+		slog.Warn("Failed to build navigation", "err", err)
+		return
+	}
+	n.nodes = buildNav(pages, n.strict, n.autoIndex)
+	n.links = buildPageLinks(pages, n.order)
+	n.visible, n.visibleLinks = n.nodes, n.links
+	if n.protected != nil && slices.ContainsFunc(pages, func(p *pageMeta) bool { return n.protected(p.Path) }) {
+		pages = slices.DeleteFunc(slices.Clone(pages), func(p *pageMeta) bool { return n.protected(p.Path) })
+		n.visible = buildNav(pages, n.strict, n.autoIndex)
+		n.visibleLinks = buildPageLinks(pages, n.order)
+	}
+	n.built = true
+}
+
+// buildNav arranges pages into a tree of directories. A directory is titled
+// and linked by its index page (whose node is not repeated as a child).
+// Hidden files and directories are skipped. On each level the root index
+// page comes first, then directories, then pages; both sorted by title.
+func buildNav(pages []*pageMeta, strict, autoIndex bool) []*navNode {
+	root := &navNode{Path: "/", IsDir: true}
+	dirs := map[string]*navNode{"/": root}
+
+	var dirFor func(dir string) *navNode
+	dirFor = func(dir string) *navNode {
+		if d, ok := dirs[dir]; ok {
+			return d
+		}
+		rel := strings.Trim(dir, "/")
+		d := &navNode{Title: path.Base(rel), Path: dir, IsDir: true}
+		if autoIndex {
+			d.URL = urlPathFor(rel+"/index", strict)
+		}
+		dirs[dir] = d
+		parent := dirFor(navDir(dir))
+		parent.Children = append(parent.Children, d)
+		return d
+	}
+
+	for _, p := range pages {
+		if strings.Contains(p.Path, "/.") {
+			continue
+		}
+		dir := navDir(p.Path)
+		title := cmp.Or(p.Title, path.Base(p.Path))
+		if p.IsIndex() && dir != "/" {
+			d := dirFor(dir)
+			if p.Title != "" {
+				d.Title = p.Title
+			}
+			d.URL = p.URL
+			continue
+		}
+		parent := dirFor(dir)
+		parent.Children = append(parent.Children, &navNode{Title: title, URL: p.URL, Path: p.Path})
+	}
+
+	for _, d := range dirs {
+		slices.SortFunc(d.Children, func(a, b *navNode) int {
+			if ra, rb := navRank(a), navRank(b); ra != rb {
+				return ra - rb
+			}
+			return cmp.Or(
+				strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+				strings.Compare(a.Path, b.Path),
+			)
+		})
+	}
+	return root.Children
+}
+
+// buildPageLinks links every page to its neighbours in the same directory
+// (index and hidden pages excluded). Pages with a weight come first, by
+// ascending weight; the others follow by file name or, with the "date"
+// order, by ascending date.
+func buildPageLinks(pages []*pageMeta, order string) map[string]pageLinks {
+	dirs := make(map[string][]*pageMeta)
+	for _, p := range pages {
+		if p.IsIndex() || strings.Contains(p.Path, "/.") {
+			continue
+		}
+		dir := navDir(p.Path)
+		dirs[dir] = append(dirs[dir], p)
+	}
+
+	links := make(map[string]pageLinks)
+	for _, list := range dirs {
+		slices.SortFunc(list, func(a, b *pageMeta) int {
+			if (a.Weight != 0) != (b.Weight != 0) {
+				if a.Weight != 0 {
+					return -1
+				}
+				return 1
+			}
+			if c := cmp.Compare(a.Weight, b.Weight); c != 0 {
+				return c
+			}
+			if order == pageOrderDate {
+				if c := a.Date.Compare(b.Date); c != 0 {
+					return c
+				}
+			}
+			return strings.Compare(a.Path, b.Path)
+		})
+		node := func(p *pageMeta) *navNode {
+			return &navNode{Title: cmp.Or(p.Title, path.Base(p.Path)), URL: p.URL, Path: p.Path}
+		}
+		for i, p := range list {
+			var l pageLinks
+			if i > 0 {
+				l.Prev = node(list[i-1])
+			}
+			if i < len(list)-1 {
+				l.Next = node(list[i+1])
+			}
+			links[p.Path] = l
+		}
+	}
+	return links
+}
+
+// navDir returns the directory of a page or directory path ("/a/b" -> "/a/",
+// "/a/b/" -> "/a/", "/b" -> "/").
+func navDir(p string) string {
+	dir := path.Dir(strings.TrimSuffix(p, "/"))
+	if dir == "/" {
+		return dir
+	}
+	return dir + "/"
+}
+
+// navRank orders navigation nodes: the root index page, directories, pages.
+func navRank(n *navNode) int {
+	switch {
+	case n.Path == "/index":
+		return 0
+	case n.IsDir:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,203 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// navString renders nodes as "title(url)[children]" for comparison
+func navString(nodes []*navNode) string {
+	var parts []string
+	for _, n := range nodes {
+		s := n.Title + "(" + n.URL + ")"
+		if n.IsDir {
+			s += "[" + navString(n.Children) + "]"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildNav(t *testing.T) {
+	pages := []*pageMeta{
+		{Path: "/about", URL: "/about", Title: "About"},
+		{Path: "/index", URL: "/", Title: "Home"},
+		{Path: "/guide/index", URL: "/guide/", Title: "User Guide"},
+		{Path: "/guide/setup", URL: "/guide/setup", Title: "Setup"},
+		{Path: "/guide/adv/tuning", URL: "/guide/adv/tuning", Title: ""},
+		{Path: "/blog/post", URL: "/blog/post", Title: "A Post"},
+		{Path: "/.drafts/x", URL: "/.drafts/x", Title: "Hidden"},
+	}
+
+	got := navString(buildNav(pages, false, false))
+	want := "Home(/) blog()[A Post(/blog/post)] User Guide(/guide/)[adv()[tuning(/guide/adv/tuning)] Setup(/guide/setup)] About(/about)"
+	if got != want {
+		t.Errorf("Nav mismatch\n got: %s\nwant: %s", got, want)
+	}
+
+	got = navString(buildNav(pages, true, true))
+	if !strings.Contains(got, "blog(/blog/index.html)[") || !strings.Contains(got, "adv(/guide/adv/index.html)[") {
+		t.Errorf("Expected generated listing URLs with auto_index, got %s", got)
+	}
+}
+
+func TestNavTemplate(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.HotReload = true
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`{{define "nav"}}<ul>{{range .}}<li>{{.Title}}{{if .IsDir}}{{template "nav" .Children}}{{end}}</li>{{end}}</ul>{{end}}` +
+			`{{template "nav" .Nav}}{{.Body}}`))
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/about", nil))
+		return w.Body.String()
+	}
+
+	body := get()
+	for _, want := range []string{"<li>Top Page</li>", "<li>sub<ul><li>Deep Page</li></ul></li>", "<li>About</li>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Nav lacks %q: %s", want, body)
+		}
+	}
+
+	t.Run("Unchanged navigation keeps other pages cached", func(t *testing.T) {
+		file := filepath.Join(dir, "sub", "deep.md")
+		createFile(t, dir, "sub/deep.md", "# Deep Page\nEdited")
+		srv.invalidateFiles([]string{file})
+		if _, ok := srv.cachedPage("/about"); !ok {
+			t.Error("Expected /about to stay cached")
+		}
+	})
+
+	t.Run("New page purges the cache", func(t *testing.T) {
+		createFile(t, dir, "new.md", "# Brand New")
+		srv.invalidateFiles([]string{filepath.Join(dir, "new.md")})
+		if _, ok := srv.cachedPage("/about"); ok {
+			t.Error("Expected /about to be dropped")
+		}
+		if body := get(); !strings.Contains(body, "<li>Brand New</li>") {
+			t.Errorf("Nav not refreshed: %s", body)
+		}
+	})
+
+	t.Run("Removed page", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(dir, "new.md")); err != nil {
+			t.Fatal(err)
+		}
+		srv.invalidateFiles([]string{filepath.Join(dir, "new.md")})
+		if body := get(); strings.Contains(body, "Brand New") {
+			t.Errorf("Nav still lists the removed page: %s", body)
+		}
+	})
+}
+
+func TestBuildPageLinks(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	pages := []*pageMeta{
+		{Path: "/book/index", URL: "/book/", Title: "Book"},
+		{Path: "/book/a", URL: "/book/a", Title: "A", Date: day(3)},
+		{Path: "/book/b", URL: "/book/b", Title: "B", Date: day(1)},
+		{Path: "/book/c", URL: "/book/c", Title: "C", Date: day(2)},
+		{Path: "/book/intro", URL: "/book/intro", Title: "Intro", Weight: 1, Date: day(9)},
+		{Path: "/other", URL: "/other", Title: "Other"},
+	}
+
+	order := func(links map[string]pageLinks, first string) string {
+		var seq []string
+		for p := first; p != ""; {
+			seq = append(seq, path.Base(p))
+			next := links[p].Next
+			if next == nil {
+				break
+			}
+			if prev := links[next.Path].Prev; prev == nil || prev.Path != p {
+				t.Errorf("Prev of %s is not %s", next.Path, p)
+			}
+			p = next.Path
+		}
+		return strings.Join(seq, " ")
+	}
+
+	links := buildPageLinks(pages, pageOrderFilename)
+	if got := order(links, "/book/intro"); got != "intro a b c" {
+		t.Errorf("Filename order: got %q", got)
+	}
+	if links["/book/intro"].Prev != nil || links["/book/c"].Next != nil {
+		t.Error("Expected no links beyond the ends")
+	}
+	if l, ok := links["/book/index"]; ok {
+		t.Errorf("Index page should not be linked: %+v", l)
+	}
+	if l := links["/other"]; l.Prev != nil || l.Next != nil {
+		t.Errorf("Pages of other directories should not be linked: %+v", l)
+	}
+
+	links = buildPageLinks(pages, pageOrderDate)
+	if got := order(links, "/book/intro"); got != "intro b c a" {
+		t.Errorf("Date order: got %q", got)
+	}
+}
+
+func TestPrevNextTemplate(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.HotReload = true
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`{{with .Prev}}prev={{.Title}}({{.URL}}){{end}} {{with .Next}}next={{.Title}}({{.URL}}){{end}}`))
+	createFile(t, dir, "sub/first.md", "---\nweight: 1\n---\n# First Page")
+
+	get := func(p string) string {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return strings.TrimSpace(w.Body.String())
+	}
+	if got := get("/sub/first"); got != "next=Deep Page(/sub/deep)" {
+		t.Errorf("Unexpected links of the first page: %q", got)
+	}
+	if got := get("/sub/deep"); got != "prev=First Page(/sub/first)" {
+		t.Errorf("Unexpected links of the last page: %q", got)
+	}
+
+	t.Run("Reordered pages purge the cache", func(t *testing.T) {
+		createFile(t, dir, "sub/first.md", "---\nweight: 0\n---\n# First Page")
+		srv.invalidateFiles([]string{filepath.Join(dir, "sub", "first.md")})
+		if got := get("/sub/deep"); got != "next=First Page(/sub/first)" {
+			t.Errorf("Links not refreshed: %q", got)
+		}
+	})
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	if err := os.MkdirAll(filepath.Join(dir, "guide", "adv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "guide/index.md", "# User Guide")
+	createFile(t, dir, "guide/adv/tuning.md", "# Tuning")
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`{{range .Breadcrumbs}}/{{.Title}}({{.URL}}){{end}}`))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "/Top Page(/)"},
+		{"/about", "/Top Page(/)/About(/about)"},
+		{"/guide/", "/Top Page(/)/User Guide(/guide/)"},
+		{"/guide/adv/tuning", "/Top Page(/)/User Guide(/guide/)/adv()/Tuning(/guide/adv/tuning)"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
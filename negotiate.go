@@ -0,0 +1,261 @@
+package gomadore
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Media types of page sources served by content negotiation
+const (
+	mediaTypeMarkdown = "text/markdown"
+	mediaTypePlain    = "text/plain"
+)
+
+// --- Content Negotiation ---
+
+// negotiatedType returns the media type of the page source an Accept header
+// prefers over HTML: text/markdown or text/plain, or "" for HTML. Sources
+// must be asked for by name with a higher quality than HTML, so browsers
+// and "*/*" clients always get HTML.
+func negotiatedType(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	qHTML := max(acceptQuality(accept, "text/html"), acceptQuality(accept, "application/xhtml+xml"))
+	qMarkdown, explicitMarkdown := acceptExplicit(accept, mediaTypeMarkdown)
+	qPlain, explicitPlain := acceptExplicit(accept, mediaTypePlain)
+	switch {
+	case explicitMarkdown && qMarkdown > qHTML && qMarkdown >= qPlain:
+		return mediaTypeMarkdown
+	case explicitPlain && qPlain > qHTML:
+		return mediaTypePlain
+	}
+	return ""
+}
+
+// acceptQuality returns the quality an Accept header gives a media type,
+// from its most specific matching range (0 if none matches).
+func acceptQuality(accept, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	major, _, _ := strings.Cut(mediaType, "/")
+	for part := range strings.SplitSeq(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		s := -1
+		switch name {
+		case mediaType:
+			s = 2
+		case major + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1.0
+		for p := range strings.SplitSeq(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+	}
+	return q
+}
+
+// acceptExplicit returns the quality of a media type named in an Accept
+// header, and whether it is named at all.
+func acceptExplicit(accept, mediaType string) (float64, bool) {
+	for part := range strings.SplitSeq(accept, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(name), mediaType) {
+			return acceptQuality(accept, mediaType), true
+		}
+	}
+	return 0, false
+}
+
+// serveNegotiated answers a page request whose Accept header prefers the
+// markdown source or plain text with that instead of the HTML page. It
+// returns false if the request prefers HTML, or the page has no markdown
+// file (e.g. a generated directory listing).
+func (s *Server) serveNegotiated(w http.ResponseWriter, r *http.Request, st *site, reqPath string) bool {
+	mediaType := negotiatedType(r.Header.Get("Accept"))
+	if mediaType == "" {
+		return false
+	}
+	file, err := s.markdownFile(reqPath)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if !s.config.HTML.ShowDrafts && isDraftFile(file, s.config.HTML.SourceEncoding) {
+		s.notFound(w, r, st)
+		return true
+	}
+
+	content, err := readSource(file, s.config.HTML.SourceEncoding)
+	if err != nil {
+		s.notFound(w, r, st)
+		return true
+	}
+	if mediaType == mediaTypePlain {
+		content = s.plainTextPage(content, reqPath)
+	}
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if ttl, cached := s.pageTTL(st, reqPath); cached {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", ttl))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	http.ServeContent(w, r, "", info.ModTime(), bytes.NewReader(content))
+	return true
+}
+
+// plainTextPage renders the markdown source of a page as plain text, with
+// links as rewritten for the HTML page.
+func (s *Server) plainTextPage(src []byte, reqPath string) []byte {
+	_, body, _ := splitFrontMatter(src)
+	if body == nil {
+		body = src
+	}
+	pc := parser.NewContext()
+	pc.Set(pagePathKey, reqPath)
+	doc := s.md.Parser().Parse(text.NewReader(body), parser.WithContext(pc))
+	return []byte(plainText(doc, body))
+}
+
+// plainText renders a markdown document as plain text: blocks separated by
+// blank lines, list items with "-" or their number, quotes with ">", code
+// blocks as is, and the URL of a link in angle brackets after its text.
+func plainText(doc ast.Node, src []byte) string {
+	var b strings.Builder
+	writePlainBlocks(&b, doc, src)
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writePlainBlocks(b *strings.Builder, parent ast.Node, src []byte) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.List:
+			num := n.Start
+			for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+				marker := "- "
+				if n.IsOrdered() {
+					marker = strconv.Itoa(num) + ". "
+					num++
+				}
+				var ib strings.Builder
+				writePlainBlocks(&ib, item, src)
+				writeIndented(b, strings.TrimRight(ib.String(), "\n"), marker, strings.Repeat(" ", len(marker)))
+				if !n.IsTight {
+					b.WriteByte('\n')
+				}
+			}
+			if n.IsTight {
+				b.WriteByte('\n')
+			}
+		case *ast.Blockquote:
+			var qb strings.Builder
+			writePlainBlocks(&qb, n, src)
+			writeIndented(b, strings.TrimRight(qb.String(), "\n"), "> ", "> ")
+			b.WriteByte('\n')
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				b.Write(seg.Value(src))
+			}
+			b.WriteByte('\n')
+		case *ast.ThematicBreak:
+			b.WriteString("---\n\n")
+		case *ast.HTMLBlock:
+			// Markup only
+		case *east.Table:
+			for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+				var cells []string
+				for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+					cells = append(cells, plainInline(cell, src))
+				}
+				b.WriteString(strings.Join(cells, " | ") + "\n")
+			}
+			b.WriteByte('\n')
+		case *ast.TextBlock:
+			// The text of a tight list item
+			b.WriteString(plainInline(n, src) + "\n")
+		default:
+			if n.HasChildren() && n.FirstChild().Type() == ast.TypeInline {
+				b.WriteString(plainInline(n, src) + "\n\n")
+			} else {
+				writePlainBlocks(b, n, src)
+			}
+		}
+	}
+}
+
+// writeIndented writes lines with a prefix for the first one and another for
+// the rest (empty lines are not indented).
+func writeIndented(b *strings.Builder, s, first, rest string) {
+	for i, line := range strings.Split(s, "\n") {
+		switch {
+		case i == 0:
+			b.WriteString(first + line)
+		case line == "":
+			b.WriteString(strings.TrimRight(rest, " "))
+		default:
+			b.WriteString(rest + line)
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// plainInline returns the text of the inline children of a block.
+func plainInline(block ast.Node, src []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(block, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch n := n.(type) {
+		case *ast.Text:
+			if entering {
+				b.Write(n.Segment.Value(src))
+				if n.SoftLineBreak() || n.HardLineBreak() {
+					b.WriteByte('\n')
+				}
+			}
+		case *ast.String:
+			if entering {
+				b.Write(n.Value)
+			}
+		case *ast.AutoLink:
+			if entering {
+				b.Write(n.URL(src))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			dest := string(n.Destination)
+			if !entering && dest != "" && !strings.HasPrefix(dest, "#") {
+				b.WriteString(" <" + dest + ">")
+			}
+		case *ast.Image:
+			// The alt text, without the URL
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
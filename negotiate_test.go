@@ -0,0 +1,84 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiatedType(t *testing.T) {
+	tests := map[string]string{
+		"":    "",
+		"*/*": "",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": "",
+		"text/markdown":                   mediaTypeMarkdown,
+		"text/markdown, text/html;q=0.9":  mediaTypeMarkdown,
+		"text/markdown;q=0.5, text/html":  "",
+		"text/plain":                      mediaTypePlain,
+		"text/plain, text/markdown":       mediaTypeMarkdown,
+		"text/plain, text/markdown;q=0.5": mediaTypePlain,
+		"text/*":                          "",
+		"text/markdown;q=0":               "",
+		"TEXT/Markdown, */*;q=0.1":        mediaTypeMarkdown,
+	}
+	for accept, want := range tests {
+		if got := negotiatedType(accept); got != want {
+			t.Errorf("negotiatedType(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	src := "# Title\n\nSome *text* with a [link](./spec.md) and\na [fragment](#x).\n\n" +
+		"- one\n- two\n  - nested\n\n1. first\n2. second\n\n> quoted\n> text\n\n" +
+		"```go\nfunc main() {}\n```\n\n<div>raw</div>\n\n![alt](a.png)\n\n---\n\n| A | B |\n|---|---|\n| 1 | 2 |\n"
+	want := "Title\n\nSome text with a link <./spec> and\na fragment.\n\n" +
+		"- one\n- two\n  - nested\n\n1. first\n2. second\n\n> quoted\n> text\n\n" +
+		"func main() {}\n\nalt\n\n---\n\nA | B\n1 | 2\n"
+	if got := string(srv.plainTextPage([]byte(src), "/page")); got != want {
+		t.Errorf("plainTextPage:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestAcceptMarkdown(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "draft.md", "---\ndraft: true\n---\n# Draft\n")
+
+	get := func(p, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, p, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	// Disabled: always HTML
+	if w := get("/about", "text/markdown"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || w.Header().Get("Vary") != "" {
+		t.Errorf("Expected HTML without negotiation, got %v", w.Header())
+	}
+
+	srv.config.HTML.AcceptMarkdown = true
+	w := get("/about", "text/markdown")
+	if w.Header().Get("Content-Type") != "text/markdown; charset=utf-8" || w.Body.String() != "# About\nThis is about page" {
+		t.Errorf("Expected the source, got %v %q", w.Header(), w.Body.String())
+	}
+	if w.Header().Get("Vary") != "Accept" || w.Header().Get("Last-Modified") == "" {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+	w = get("/about", "text/plain")
+	if w.Header().Get("Content-Type") != "text/plain; charset=utf-8" || w.Body.String() != "About\n\nThis is about page\n" {
+		t.Errorf("Expected plain text, got %v %q", w.Header(), w.Body.String())
+	}
+	w = get("/about", "text/html,*/*;q=0.8")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "<h1") || w.Header().Get("Vary") != "Accept" {
+		t.Errorf("Expected HTML, got %v", w.Header())
+	}
+	if w := get("/draft", "text/markdown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a draft, got %d", w.Code)
+	}
+	if w := get("/missing", "text/markdown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+}
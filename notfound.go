@@ -0,0 +1,59 @@
+package gomadore
+
+import (
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// --- Custom 404 Page ---
+
+// notFoundPagePath returns the internal page path of not_found_page
+// ("404.md" -> "/404"), or "" if it is not configured.
+func (s *Server) notFoundPagePath() string {
+	page := s.config.HTML.NotFoundPage
+	if page == "" {
+		return ""
+	}
+	page = path.Clean("/" + strings.ReplaceAll(page, "\\", "/"))
+	return strings.TrimSuffix(page, markdownExt(page, s.config.HTML.MarkdownExts))
+}
+
+// notFound answers 404 Not Found with the rendered not_found_page, or with
+// the plain text response if it is not configured or cannot be rendered.
+// The rendered page is cached like any other page.
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request, st *site) {
+	reqPath := s.notFoundPagePath()
+	if reqPath == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	cacheKey := st.cacheKey(reqPath)
+	item, ok := s.cachedPage(cacheKey)
+	if !ok {
+		v, err, _ := s.renders.Do(cacheKey, func() (any, error) {
+			return s.renderAndCache(st, reqPath, cacheKey)
+		})
+		if err != nil {
+			slog.Warn("Failed to render not_found_page", "page", s.config.HTML.NotFoundPage, "err", err)
+			http.NotFound(w, r)
+			return
+		}
+		item = v.(CacheItem)
+	}
+
+	body, _, err := negotiatePage(w, r, item, "")
+	if err != nil {
+		slog.Error("Failed to decompress not_found_page", "err", err)
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", s.htmlContentType())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusNotFound)
+	if err := writeBody(w, body); err != nil {
+		slog.Debug("Failed to write response (not found)", "err", err)
+	}
+}
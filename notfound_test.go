@@ -0,0 +1,67 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotFoundPage(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	get := func(t *testing.T, p string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	t.Run("Not configured", func(t *testing.T) {
+		w := get(t, "/missing")
+		if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Body.String(), "404 page not found") {
+			t.Errorf("Expected plain 404, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	srv.config.HTML.NotFoundPage = "404.md"
+
+	t.Run("Missing not_found_page", func(t *testing.T) {
+		w := get(t, "/missing")
+		if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Body.String(), "404 page not found") {
+			t.Errorf("Expected plain 404, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	createFile(t, dir, "404.md", "# Not Found\nThe page does not exist.")
+
+	for _, p := range []string{"/missing", "/sub/missing", "/sub/"} {
+		t.Run("Rendered "+p, func(t *testing.T) {
+			w := get(t, p)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("Expected 404, got %d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+				t.Errorf("Unexpected Content-Type %q", ct)
+			}
+			if !strings.Contains(w.Body.String(), "The page does not exist.") {
+				t.Errorf("Unexpected body %q", w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Cached", func(t *testing.T) {
+		if _, ok := srv.cachedPage("/404"); !ok {
+			t.Error("Expected the rendered 404 page in the cache")
+		}
+	})
+
+	t.Run("Strict HTML URL", func(t *testing.T) {
+		srv.config.HTML.StrictHtmlUrl = true
+		defer func() { srv.config.HTML.StrictHtmlUrl = false }()
+		w := get(t, "/about")
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "The page does not exist.") {
+			t.Errorf("Expected rendered 404, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
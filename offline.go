@@ -0,0 +1,187 @@
+package gomadore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Registration snippet embedded into pages when [offline] is enabled.
+const serviceWorkerRegisterScript = `<script>if ("serviceWorker" in navigator) { navigator.serviceWorker.register("/sw.js"); }</script>`
+
+// Service worker body. %s placeholders: cache name, precache URL list (JSON).
+// Strategy: network-first for same-origin GET requests, falling back to the
+// cache (and finally to the cached top page) when offline.
+const serviceWorkerTmpl = `// gomadore service worker
+const CACHE_NAME = %s;
+const PRECACHE_URLS = %s;
+
+self.addEventListener("install", (event) => {
+  event.waitUntil(
+    caches.open(CACHE_NAME)
+      .then((cache) => cache.addAll(PRECACHE_URLS))
+      .then(() => self.skipWaiting())
+  );
+});
+
+self.addEventListener("activate", (event) => {
+  event.waitUntil(
+    caches.keys()
+      .then((keys) => Promise.all(keys
+        .filter((key) => key.startsWith("gomadore-") && key !== CACHE_NAME)
+        .map((key) => caches.delete(key))))
+      .then(() => self.clients.claim())
+  );
+});
+
+self.addEventListener("fetch", (event) => {
+  const req = event.request;
+  if (req.method !== "GET") {
+    return;
+  }
+  const url = new URL(req.url);
+  const sameOrigin = url.origin === self.location.origin;
+  if (!sameOrigin && req.destination !== "style" && req.destination !== "font") {
+    return;
+  }
+  event.respondWith(
+    fetch(req)
+      .then((res) => {
+        if (res.ok || res.type === "opaque") {
+          const copy = res.clone();
+          caches.open(CACHE_NAME).then((cache) => cache.put(req, copy));
+        }
+        return res;
+      })
+      .catch(() => caches.match(req)
+        .then((cached) => cached || (req.mode === "navigate" ? caches.match("/") : undefined)))
+  );
+});
+`
+
+// --- Offline Support (Service Worker) ---
+
+// serviceWorker generates /sw.js. The cache name contains a version derived
+// from content hashes, so any content change makes browsers install the new
+// worker and drop the outdated cache.
+type serviceWorker struct {
+	mu       sync.Mutex
+	roots    RootDirs
+	salt     string   // mixed into the version (gomadore version, template)
+	precache []string // URLs cached at install time
+	version  string   // empty when it needs to be recomputed
+}
+
+func newServiceWorker(cfg Config, salt string) *serviceWorker {
+	precache := []string{"/"}
+	for _, u := range []string{cfg.HTML.BaseCSSUrl, cfg.HTML.ScreenCSSUrl, cfg.HTML.PrintCSSUrl} {
+		// Only same-origin assets can be precached reliably
+		if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+			precache = append(precache, u)
+		}
+	}
+	precache = append(precache, cfg.Offline.PrecacheURLs...)
+	slices.Sort(precache)
+
+	return &serviceWorker{
+		roots:    cfg.HTML.MarkdownRootDir,
+		salt:     salt,
+		precache: slices.Compact(precache),
+	}
+}
+
+// invalidate forces the version to be recomputed on the next request.
+func (sw *serviceWorker) invalidate() {
+	if sw == nil {
+		return
+	}
+	sw.mu.Lock()
+	sw.version = ""
+	sw.mu.Unlock()
+}
+
+// currentVersion returns the cached content version, computing it if needed.
+func (sw *serviceWorker) currentVersion() (string, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.version == "" {
+		v, err := contentVersion(sw.roots, sw.salt)
+		if err != nil {
+			return "", err
+		}
+		sw.version = v
+	}
+	return sw.version, nil
+}
+
+// registerScript returns the registration snippet. Safe to call on nil.
+func (sw *serviceWorker) registerScript() template.HTML {
+	if sw == nil {
+		return ""
+	}
+	return serviceWorkerRegisterScript
+}
+
+func (sw *serviceWorker) handleScript(w http.ResponseWriter, r *http.Request) {
+	version, err := sw.currentVersion()
+	if err != nil {
+		slog.Error("Failed to compute service worker version", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	cacheName, _ := json.Marshal("gomadore-" + version)
+	precache, _ := json.Marshal(sw.precache)
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	// Browsers must always revalidate the worker script to pick up new versions
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Service-Worker-Allowed", "/")
+	_ = writeBody(w, fmt.Appendf(nil, serviceWorkerTmpl, cacheName, precache))
+}
+
+// contentVersion hashes the relative path and content of every regular file
+// under roots (in walk order, which is lexical) into a short version string.
+func contentVersion(roots RootDirs, salt string) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, salt)
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(pathStr string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			rel, err := filepath.Rel(root, pathStr)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(pathStr)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(content)
+			_, _ = fmt.Fprintf(h, "%s\x00%x\n", filepath.ToSlash(rel), sum)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
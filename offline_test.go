@@ -0,0 +1,78 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServiceWorker(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.BaseCSSUrl = "/css/base.css"
+	srv.config.HTML.ScreenCSSUrl = "https://cdn.example.com/screen.css"
+	srv.config.Offline.Enabled = true
+	srv.config.Offline.PrecacheURLs = []string{"/about"}
+	srv.offline = newServiceWorker(srv.config, "test")
+	mux := srv.routes()
+
+	fetchScript := func(t *testing.T) string {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sw.js", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+			t.Errorf("Cache-Control mismatch: got %s", got)
+		}
+		return w.Body.String()
+	}
+
+	first := fetchScript(t)
+	if !strings.Contains(first, `const PRECACHE_URLS = ["/","/about","/css/base.css"];`) {
+		t.Errorf("Unexpected precache list (cross-origin URLs must be excluded):\n%s", first)
+	}
+
+	t.Run("Version is stable without changes", func(t *testing.T) {
+		if again := fetchScript(t); again != first {
+			t.Error("Service worker changed without content changes")
+		}
+	})
+
+	t.Run("Version changes with content", func(t *testing.T) {
+		createFile(t, dir, "about.md", "# About\nUpdated")
+
+		// Still cached until invalidated
+		if got := fetchScript(t); got != first {
+			t.Error("Version should be cached until invalidation")
+		}
+
+		srv.purgeCache()
+		if got := fetchScript(t); got == first {
+			t.Error("Version should change after content update")
+		}
+	})
+
+	t.Run("Registration snippet", func(t *testing.T) {
+		srv.tmpl, _ = srv.tmpl.New("base").Parse(`{{ .ServiceWorkerScript }}`)
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if !strings.Contains(w.Body.String(), `navigator.serviceWorker.register("/sw.js")`) {
+			t.Errorf("Page does not contain registration snippet: %s", w.Body.String())
+		}
+	})
+}
+
+func TestServiceWorker_Disabled(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sw.js", nil)
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("sw.js should not be served when disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,654 @@
+package gomadore
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384, ES512, ...
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Path that clears the session
+	oidcLogoutPath = "/auth/logout"
+	// Cookie holding the signed session
+	oidcSessionCookie = "gomadore_session"
+	// Cookie holding the state of a sign-in in progress
+	oidcStateCookie = "gomadore_oidc_state"
+	// Default session lifetime (seconds)
+	defaultOIDCSessionTTL = 8 * 60 * 60
+	// Default claim listing the groups of a user
+	defaultOIDCGroupsClaim = "groups"
+	// Time to complete a sign-in at the provider
+	oidcStateTTL = 10 * time.Minute
+	// Timeout of a request to the provider
+	oidcTimeout = 10 * time.Second
+	// Minimum interval between two fetches of the provider keys
+	oidcKeysRefresh = time.Minute
+	// Accepted clock difference to the provider
+	oidcClockSkew = time.Minute
+	// Upper bound of a provider response
+	oidcMaxResponseBytes = 1 << 20
+)
+
+// --- OpenID Connect Authentication ---
+
+// oidcAuth requires a sign-in at an OpenID Connect provider for the [auth]
+// paths (authorization code flow with PKCE). The session is kept in a
+// signed cookie, so nothing is stored on the server.
+type oidcAuth struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	callbackPath string // path of redirectURL
+	scopes       []string
+	emails       []string // allowed addresses (lower case), "@example.com" for a domain
+	groups       []string
+	groupsClaim  string
+	paths        []string // protected path prefixes (empty: every path)
+	secret       []byte   // HMAC key of the cookies
+	ttl          time.Duration
+	secure       bool // cookies only over HTTPS
+	client       *http.Client
+
+	mu          sync.Mutex
+	provider    *oidcProvider // discovered on first use
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// oidcProvider is the discovery document of the issuer.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcSession is the content of the session cookie.
+type oidcSession struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Expires int64  `json:"exp"`
+}
+
+// oidcLogin is the content of the state cookie of a sign-in in progress.
+type oidcLogin struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE code verifier
+	Return   string `json:"return"`   // path to go back to
+	Expires  int64  `json:"exp"`
+}
+
+// newOIDCAuth returns nil if [auth.oidc] is disabled. The provider is
+// contacted on the first sign-in, so the server starts while it is down.
+func newOIDCAuth(cfg Config) (*oidcAuth, error) {
+	oc := cfg.Auth.OIDC
+	if !oc.Enabled {
+		return nil, nil
+	}
+	redirect, err := url.Parse(oc.RedirectURL)
+	if err != nil || redirect.Path == "" || redirect.Path == "/" {
+		return nil, fmt.Errorf("redirect_url %q: expected an absolute URL with a path, e.g. https://docs.example.com/auth/callback", oc.RedirectURL)
+	}
+	o := &oidcAuth{
+		issuer:       strings.TrimSuffix(oc.Issuer, "/"),
+		clientID:     oc.ClientID,
+		clientSecret: oc.ClientSecret,
+		redirectURL:  oc.RedirectURL,
+		callbackPath: redirect.Path,
+		scopes:       []string{"openid", "email", "profile"},
+		groups:       oc.AllowedGroups,
+		groupsClaim:  oc.GroupsClaim,
+		paths:        cfg.Auth.Paths,
+		secret:       []byte(oc.CookieSecret),
+		ttl:          time.Duration(oc.SessionTTL) * time.Second,
+		secure:       redirect.Scheme == "https",
+		client:       &http.Client{Timeout: oidcTimeout},
+	}
+	for _, s := range oc.Scopes {
+		if !slices.Contains(o.scopes, s) {
+			o.scopes = append(o.scopes, s)
+		}
+	}
+	for _, e := range oc.AllowedEmails {
+		o.emails = append(o.emails, strings.ToLower(e))
+	}
+	if o.groupsClaim == "" {
+		o.groupsClaim = defaultOIDCGroupsClaim
+	}
+	if o.ttl <= 0 {
+		o.ttl = defaultOIDCSessionTTL * time.Second
+	}
+	if len(o.secret) == 0 {
+		// Sessions do not survive a restart
+		o.secret = make([]byte, 32)
+		_, _ = rand.Read(o.secret)
+	}
+	return o, nil
+}
+
+// protectsPage reports whether one of the URL paths of a page (internal
+// page path) needs a session, unless the whole site does. Safe to call on nil.
+func (o *oidcAuth) protectsPage(pagePath string) bool {
+	return o != nil && coversPage(o.paths, pagePath)
+}
+
+// authenticate applies [auth]: an OIDC session (or, if [auth] is enabled
+// as well, Basic credentials), or Basic credentials alone.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.oidc == nil {
+		return s.auth.wrap(next)
+	}
+	return s.oidc.wrap(next, s.auth)
+}
+
+// wrap serves the callback and logout paths, and sends browsers without a
+// session to the provider. Requests with Basic credentials are checked by
+// basic instead, if it is not nil.
+func (o *oidcAuth) wrap(next http.Handler, basic *basicAuth) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case o.callbackPath:
+			o.handleCallback(w, r)
+			return
+		case oidcLogoutPath:
+			o.handleLogout(w, r)
+			return
+		}
+		if !pathsCover(o.paths, r.URL.Path) || o.session(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, _, ok := r.BasicAuth(); ok && basic != nil {
+			if basic.authorize(w, r) {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		o.login(w, r)
+	})
+}
+
+// session returns the valid session of a request, or nil.
+func (o *oidcAuth) session(r *http.Request) *oidcSession {
+	c, err := r.Cookie(oidcSessionCookie)
+	if err != nil {
+		return nil
+	}
+	var sess oidcSession
+	if !o.open(c.Value, &sess) || time.Now().Unix() >= sess.Expires {
+		return nil
+	}
+	return &sess
+}
+
+// login redirects to the authorization endpoint of the provider.
+func (o *oidcAuth) login(w http.ResponseWriter, r *http.Request) {
+	p, err := o.discover(r.Context())
+	if err != nil {
+		slog.Error("OIDC discovery failed", "issuer", o.issuer, "err", err)
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return
+	}
+	login := oidcLogin{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: randomToken(),
+		Return:   r.URL.RequestURI(),
+		Expires:  time.Now().Add(oidcStateTTL).Unix(),
+	}
+	o.setCookie(w, oidcStateCookie, o.seal(login), o.callbackPath, oidcStateTTL)
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.clientID},
+		"redirect_uri":          {o.redirectURL},
+		"scope":                 {strings.Join(o.scopes, " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, p.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+}
+
+// handleCallback completes a sign-in: it exchanges the code for an ID
+// token, verifies it, and starts a session if the user is allowed.
+func (o *oidcAuth) handleCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		slog.Warn("OIDC sign-in failed at the provider", "error", e, "description", q.Get("error_description"))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var login oidcLogin
+	c, err := r.Cookie(oidcStateCookie)
+	if err != nil || !o.open(c.Value, &login) || time.Now().Unix() >= login.Expires ||
+		subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(login.State)) != 1 {
+		http.Error(w, "Bad Request: sign-in expired or invalid, please try again", http.StatusBadRequest)
+		return
+	}
+	o.setCookie(w, oidcStateCookie, "", o.callbackPath, -1)
+
+	claims, err := o.exchange(r.Context(), q.Get("code"), login)
+	if err != nil {
+		slog.Warn("OIDC sign-in failed", "err", err)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if !o.allowed(claims) {
+		slog.Info("OIDC sign-in refused", "sub", claims.Subject, "email", claims.Email)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	sess := oidcSession{Subject: claims.Subject, Email: claims.Email, Expires: time.Now().Add(o.ttl).Unix()}
+	o.setCookie(w, oidcSessionCookie, o.seal(sess), "/", o.ttl)
+	slog.Info("OIDC sign-in", "sub", claims.Subject, "email", claims.Email)
+
+	target := login.Return
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		target = "/"
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// handleLogout ends the session. The session at the provider is kept.
+func (o *oidcAuth) handleLogout(w http.ResponseWriter, r *http.Request) {
+	o.setCookie(w, oidcSessionCookie, "", "/", -1)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// setCookie sets a cookie for maxAge, or deletes it if maxAge is negative.
+func (o *oidcAuth) setCookie(w http.ResponseWriter, name, value, path string, maxAge time.Duration) {
+	age := int(maxAge / time.Second)
+	if maxAge < 0 {
+		age = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   age,
+		Secure:   o.secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// seal encodes v as a signed cookie value.
+func (o *oidcAuth) seal(v any) string {
+	b, _ := json.Marshal(v)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	mac := hmac.New(sha256.New, o.secret)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// open decodes a cookie value made by seal, and reports whether its
+// signature is valid.
+func (o *oidcAuth) open(value string, v any) bool {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, o.secret)
+	mac.Write([]byte(payload))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	return err == nil && json.Unmarshal(b, v) == nil
+}
+
+// randomToken returns 32 random bytes, base64url-encoded.
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// --- Provider ---
+
+// discover fetches the discovery document of the issuer once it succeeds.
+func (o *oidcAuth) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	var p oidcProvider
+	if err := o.getJSON(ctx, o.issuer+"/.well-known/openid-configuration", &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != o.issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q", p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, errors.New("discovery document lacks endpoints")
+	}
+	o.provider = &p
+	return o.provider, nil
+}
+
+func (o *oidcAuth) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return o.doJSON(req, v)
+}
+
+func (o *oidcAuth) doJSON(req *http.Request, v any) error {
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s: %s", req.URL, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.Unmarshal(b, v)
+}
+
+// exchange redeems an authorization code at the token endpoint and returns
+// the verified claims of the ID token.
+func (o *oidcAuth) exchange(ctx context.Context, code string, login oidcLogin) (*idClaims, error) {
+	p, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.redirectURL},
+		"code_verifier": {login.Verifier},
+	}
+	if o.clientSecret == "" {
+		form.Set("client_id", o.clientID) // public client
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.clientID), url.QueryEscape(o.clientSecret))
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := o.doJSON(req, &tok); err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return o.verify(ctx, tok.IDToken, login.Nonce)
+}
+
+// --- ID Tokens ---
+
+// idClaims are the claims of an ID token used by gomadore.
+type idClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expires       int64    `json:"exp"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified"`
+	Groups        []string `json:"-"` // from the groups claim
+}
+
+// audience is the "aud" claim: a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*a = audience{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// Hashes of the supported JWS algorithms
+var jwsHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verify checks the signature and claims of an ID token.
+func (o *oidcAuth) verify(ctx context.Context, raw, nonce string) (*idClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("ID token header: %w", err)
+	}
+	hash, ok := jwsHashes[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Alg)
+	}
+	key, err := o.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed ID token signature")
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	digest := h.Sum(nil)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(header.Alg, "RS") || rsa.VerifyPKCS1v15(key, hash, digest, sig) != nil {
+			return nil, errors.New("invalid ID token signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(header.Alg, "ES") || len(sig) != 2*size ||
+			!ecdsa.Verify(key, digest, new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])) {
+			return nil, errors.New("invalid ID token signature")
+		}
+	}
+
+	var claims idClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("ID token claims: %w", err)
+	}
+	var all map[string]json.RawMessage
+	_ = decodeSegment(parts[1], &all)
+	if g, ok := all[o.groupsClaim]; ok {
+		var one string
+		if json.Unmarshal(g, &claims.Groups) != nil && json.Unmarshal(g, &one) == nil {
+			claims.Groups = []string{one}
+		}
+	}
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != o.issuer:
+		return nil, fmt.Errorf("ID token issuer %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, o.clientID):
+		return nil, fmt.Errorf("ID token audience %v", claims.Audience)
+	case time.Now().Add(-oidcClockSkew).Unix() >= claims.Expires:
+		return nil, errors.New("ID token expired")
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return nil, errors.New("ID token nonce mismatch")
+	case claims.Subject == "":
+		return nil, errors.New("ID token has no subject")
+	}
+	return &claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// key returns the provider key with the ID kid, fetching the key set again
+// if it is unknown (the provider rotated its keys).
+func (o *oidcAuth) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if k, ok := o.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(o.keysFetched) < oidcKeysRefresh {
+		return nil, fmt.Errorf("unknown ID token key %q", kid)
+	}
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := o.getJSON(ctx, p.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	o.keysFetched = time.Now()
+	o.keys = make(map[string]crypto.PublicKey)
+	for _, raw := range set.Keys {
+		id, k, err := parseJWK(raw)
+		if err != nil {
+			continue // unsupported key types and uses
+		}
+		o.keys[id] = k
+	}
+	if k, ok := o.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown ID token key %q", kid)
+}
+
+// parseJWK decodes an RSA or EC public signing key of a JSON Web Key Set.
+func parseJWK(raw []byte) (string, crypto.PublicKey, error) {
+	var k struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &k); err != nil {
+		return "", nil, err
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return "", nil, errors.New("not a signing key")
+	}
+	num := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil, errors.New("malformed key")
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := num(k.N)
+		if err != nil {
+			return "", nil, err
+		}
+		e, err := num(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return "", nil, errors.New("malformed key")
+		}
+		return k.Kid, &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return "", nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := num(k.X)
+		if err != nil {
+			return "", nil, err
+		}
+		y, err := num(k.Y)
+		if err != nil {
+			return "", nil, err
+		}
+		return k.Kid, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// allowed reports whether a signed-in user may access the site: anyone if
+// neither allowed_emails nor allowed_groups is set, else a user matching
+// either. Unverified email addresses do not match.
+func (o *oidcAuth) allowed(c *idClaims) bool {
+	if len(o.emails) == 0 && len(o.groups) == 0 {
+		return true
+	}
+	if email := strings.ToLower(c.Email); email != "" && (c.EmailVerified == nil || *c.EmailVerified) {
+		for _, e := range o.emails {
+			if email == e || (strings.HasPrefix(e, "@") && strings.HasSuffix(email, e)) {
+				return true
+			}
+		}
+	}
+	for _, g := range c.Groups {
+		if slices.Contains(o.groups, g) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package gomadore
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider is a minimal OpenID Connect provider issuing ID tokens for
+// the claims set by the test.
+type fakeProvider struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+	nonce  string // of the last authorization request
+	verify string // PKCE challenge of the last authorization request
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeProvider{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.srv.URL,
+			"authorization_endpoint": p.srv.URL + "/authorize",
+			"token_endpoint":         p.srv.URL + "/token",
+			"jwks_uri":               p.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": enc(key.N.Bytes()), "e": enc(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "docs" || secret != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.verify {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": p.token(p.claims)})
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// token signs claims (with iss, aud, exp and nonce filled in) as RS256.
+func (p *fakeProvider) token(claims map[string]any) string {
+	all := map[string]any{"iss": p.srv.URL, "aud": "docs", "exp": time.Now().Add(time.Hour).Unix(), "nonce": p.nonce}
+	for k, v := range claims {
+		all[k] = v
+	}
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": "RS256", "kid": "k1"}) + "." + enc(all)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCAuth(t *testing.T) {
+	p := newFakeProvider(t)
+	srv, _ := setupTestServer(t)
+	cfg := srv.config
+	cfg.Auth.Paths = []string{"/sub/"}
+	cfg.Auth.OIDC.Enabled = true
+	cfg.Auth.OIDC.Issuer = p.srv.URL
+	cfg.Auth.OIDC.ClientID = "docs"
+	cfg.Auth.OIDC.ClientSecret = "s3cret"
+	cfg.Auth.OIDC.RedirectURL = "http://docs.example.com/auth/callback"
+	cfg.Auth.OIDC.AllowedEmails = []string{"@example.com"}
+	cfg.Auth.OIDC.AllowedGroups = []string{"docs-readers"}
+	srv, err := newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := srv.handler()
+	do := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	cookie := func(w *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range w.Result().Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("No %s cookie set", name)
+		return nil
+	}
+	// signIn starts at /sub/deep and returns the callback response
+	signIn := func(code string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := do("/sub/deep")
+		if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), p.srv.URL+"/authorize?") {
+			t.Fatalf("Expected a redirect to the provider, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+		u, _ := url.Parse(w.Header().Get("Location"))
+		q := u.Query()
+		if q.Get("client_id") != "docs" || q.Get("redirect_uri") != cfg.Auth.OIDC.RedirectURL || q.Get("code_challenge_method") != "S256" {
+			t.Fatalf("Unexpected authorization request %s", u)
+		}
+		p.nonce, p.verify = q.Get("nonce"), q.Get("code_challenge")
+		return do("/auth/callback?code="+code+"&state="+url.QueryEscape(q.Get("state")), cookie(w, oidcStateCookie))
+	}
+
+	if w := do("/about"); w.Code != http.StatusOK {
+		t.Errorf("Unprotected path: status %d", w.Code)
+	}
+	if !srv.pageProtected("/sub/deep") || srv.pageProtected("/about") {
+		t.Error("Only the pages under the OIDC paths should count as protected")
+	}
+
+	p.claims = map[string]any{"sub": "u1", "email": "alice@example.com", "email_verified": true}
+	w := signIn("good-code")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/sub/deep" {
+		t.Fatalf("Callback: %d %s %s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+	session := cookie(w, oidcSessionCookie)
+	if w := do("/sub/deep", session); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Deep Page") {
+		t.Errorf("With a session: %d", w.Code)
+	}
+	tampered := *session
+	tampered.Value = strings.Replace(session.Value, ".", "x.", 1)
+	if w := do("/sub/deep", &tampered); w.Code != http.StatusFound {
+		t.Errorf("Tampered session: status %d", w.Code)
+	}
+	if w := do("/auth/logout", session); cookie(w, oidcSessionCookie).MaxAge >= 0 {
+		t.Error("Logout should clear the session")
+	}
+
+	// Group membership, unverified or other addresses, bad codes and states
+	p.claims = map[string]any{"sub": "u2", "email": "bob@other.org", "groups": []string{"docs-readers"}}
+	if w := signIn("good-code"); w.Code != http.StatusSeeOther {
+		t.Errorf("Group member: status %d", w.Code)
+	}
+	p.claims = map[string]any{"sub": "u3", "email": "eve@example.com", "email_verified": false}
+	if w := signIn("good-code"); w.Code != http.StatusForbidden {
+		t.Errorf("Unverified address: status %d", w.Code)
+	}
+	p.claims = map[string]any{"sub": "u4", "email": "mallory@other.org"}
+	if w := signIn("good-code"); w.Code != http.StatusForbidden {
+		t.Errorf("Other address: status %d", w.Code)
+	}
+	if w := signIn("bad-code"); w.Code != http.StatusForbidden {
+		t.Errorf("Bad code: status %d", w.Code)
+	}
+	if w := do("/auth/callback?code=good-code&state=forged"); w.Code != http.StatusBadRequest {
+		t.Errorf("Missing state cookie: status %d", w.Code)
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	p := newFakeProvider(t)
+	var cfg Config
+	cfg.Auth.OIDC.Enabled = true
+	cfg.Auth.OIDC.Issuer = p.srv.URL
+	cfg.Auth.OIDC.ClientID = "docs"
+	cfg.Auth.OIDC.RedirectURL = "https://docs.example.com/auth/callback"
+	o, err := newOIDCAuth(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.nonce = "n1"
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		ok     bool
+	}{
+		{"valid", map[string]any{"sub": "u1"}, true},
+		{"audience array", map[string]any{"sub": "u1", "aud": []string{"other", "docs"}}, true},
+		{"other audience", map[string]any{"sub": "u1", "aud": "other"}, false},
+		{"other issuer", map[string]any{"sub": "u1", "iss": "https://evil.example.com"}, false},
+		{"expired", map[string]any{"sub": "u1", "exp": time.Now().Add(-time.Hour).Unix()}, false},
+		{"other nonce", map[string]any{"sub": "u1", "nonce": "n2"}, false},
+		{"no subject", map[string]any{}, false},
+	}
+	for _, tt := range tests {
+		_, err := o.verify(t.Context(), p.token(tt.claims), "n1")
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+
+	tok := p.token(map[string]any{"sub": "u1"})
+	parts := strings.Split(tok, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + parts[2]
+	if _, err := o.verify(t.Context(), forged, "n1"); err == nil {
+		t.Error("A token with a forged payload was accepted")
+	}
+}
@@ -0,0 +1,243 @@
+package gomadore
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Name of the per-directory configuration file in the content tree
+const overlayFileName = "_gomadore.toml"
+
+// --- Directory Overlays ---
+
+// OverlayConfig is a _gomadore.toml file. The options that are set override
+// the site settings for the pages of its directory and subdirectories.
+type OverlayConfig struct {
+	SiteTitle        string `toml:"site_title"`
+	BaseCSSUrl       string `toml:"base_css_url"`
+	ScreenCSSUrl     string `toml:"screen_css_url"`
+	PrintCSSUrl      string `toml:"print_css_url"`
+	TemplateFilePath string `toml:"template_filepath"` // relative to the directory of the file
+	Auth             struct {
+		Enabled      bool     `toml:"enabled"`
+		Realm        string   `toml:"realm"`
+		HtpasswdFile string   `toml:"htpasswd_file"` // relative to the directory of the file
+		Users        []string `toml:"users"`
+	} `toml:"auth"`
+}
+
+// overlay is a loaded _gomadore.toml file.
+type overlay struct {
+	dir  string // page path of the directory ("/team-a", "" for the root)
+	cfg  OverlayConfig
+	tmpl *template.Template // nil: inherited
+	auth *basicAuth         // nil: inherited
+}
+
+// covers reports whether a page or URL path is inside the directory.
+func (o *overlay) covers(p string) bool {
+	return o.dir == "" || strings.HasPrefix(p, o.dir+"/")
+}
+
+// dirOverlays holds the _gomadore.toml files of the content root. They are
+// read when the server is built, and again whenever the cache is purged
+// (with hot_reload, after any change other than to a markdown file).
+type dirOverlays struct {
+	roots RootDirs
+	cfg   Config // for template options
+
+	mu   sync.RWMutex
+	list []*overlay // sorted by directory, parents first
+}
+
+// newDirOverlays loads the overlays of the content roots.
+func newDirOverlays(cfg Config) (*dirOverlays, error) {
+	o := &dirOverlays{roots: cfg.HTML.MarkdownRootDir, cfg: cfg}
+	list, err := o.load()
+	if err != nil {
+		return nil, err
+	}
+	o.list = list
+	return o, nil
+}
+
+// reload reads the overlays again. On error, the previous ones are kept.
+func (o *dirOverlays) reload() {
+	list, err := o.load()
+	if err != nil {
+		slog.Error("Failed to reload directory configuration; keeping the previous one", "err", err)
+		return
+	}
+	o.mu.Lock()
+	o.list = list
+	o.mu.Unlock()
+}
+
+// load walks the content roots for overlay files. A directory of an earlier
+// root hides the overlay file of the same directory in later ones.
+func (o *dirOverlays) load() ([]*overlay, error) {
+	var list []*overlay
+	seen := make(map[string]bool)
+	for _, root := range o.roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && errors.Is(err, fs.ErrNotExist) {
+					return nil // reported where the pages are read
+				}
+				return err
+			}
+			if d.IsDir() || d.Name() != overlayFileName {
+				return nil
+			}
+			ov, err := o.loadFile(root, p)
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			if !seen[ov.dir] {
+				seen[ov.dir] = true
+				list = append(list, ov)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(list, func(a, b *overlay) int { return strings.Compare(a.dir, b.dir) })
+	return list, nil
+}
+
+func (o *dirOverlays) loadFile(root, file string) (*overlay, error) {
+	var oc OverlayConfig
+	md, err := toml.DecodeFile(file, &oc)
+	if err != nil {
+		return nil, err
+	}
+	if keys := md.Undecoded(); len(keys) > 0 {
+		return nil, fmt.Errorf("unknown option %q", keys[0].String())
+	}
+
+	dir := filepath.Dir(file)
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return nil, err
+	}
+	ov := &overlay{cfg: oc}
+	if rel != "." {
+		ov.dir = "/" + filepath.ToSlash(rel)
+	}
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	if oc.TemplateFilePath != "" {
+		t, _, err := parseTemplatePath(resolve(oc.TemplateFilePath))
+		if err != nil {
+			return nil, fmt.Errorf("template_filepath: %w", err)
+		}
+		applyTemplateOptions(t, o.cfg)
+		ov.tmpl = t
+	}
+	if oc.Auth.Enabled {
+		var ac Config
+		ac.Auth.Enabled = true
+		ac.Auth.Realm = cmp.Or(oc.Auth.Realm, defaultAuthRealm)
+		ac.Auth.HtpasswdFile = resolve(oc.Auth.HtpasswdFile)
+		ac.Auth.Users = oc.Auth.Users
+		ac.Auth.Paths = []string{ov.dir + "/"}
+		if ov.auth, err = newBasicAuth(ac); err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+	return ov, nil
+}
+
+// site returns the site settings of a page: st with the overlays of the
+// page's directories applied, parents first. Safe to call on nil.
+func (o *dirOverlays) site(st *site, reqPath string) *site {
+	if o == nil {
+		return st
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var out *site
+	for _, ov := range o.list {
+		if !ov.covers(reqPath) {
+			continue
+		}
+		if out == nil {
+			c := *st
+			out = &c
+		}
+		override := func(dst *string, v string) {
+			if v != "" {
+				*dst = v
+			}
+		}
+		override(&out.title, ov.cfg.SiteTitle)
+		override(&out.baseCSS, ov.cfg.BaseCSSUrl)
+		override(&out.screenCSS, ov.cfg.ScreenCSSUrl)
+		override(&out.printCSS, ov.cfg.PrintCSSUrl)
+		if ov.tmpl != nil {
+			out.tmpl = ov.tmpl
+		}
+	}
+	return cmp.Or(out, st)
+}
+
+// authFor returns the authentication of the nearest directory of a URL path
+// that requires it (nil if none). Safe to call on nil.
+func (o *dirOverlays) authFor(urlPath string) *basicAuth {
+	if o == nil {
+		return nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, ov := range slices.Backward(o.list) {
+		// "/team-a" is the directory (redirected to "/team-a/") or a sibling page
+		if p := path.Clean(urlPath); ov.auth != nil && (ov.covers(p) || p == ov.dir) {
+			return ov.auth
+		}
+	}
+	return nil
+}
+
+// protectsPage reports whether a _gomadore.toml requires authentication for
+// one of the URL paths of a page (internal page path) other than that of
+// the whole content root. Safe to call on nil.
+func (o *dirOverlays) protectsPage(pagePath string) bool {
+	root := o.authFor("/")
+	return slices.ContainsFunc(pageURLPaths(pagePath), func(p string) bool {
+		a := o.authFor(p)
+		return a != nil && a != root
+	})
+}
+
+// dirAuth answers 401 Unauthorized to unauthenticated requests under a
+// directory whose _gomadore.toml requires authentication (of the subsite of
+// the request's host). [auth] applies independently.
+func (s *Server) dirAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv := cmp.Or(s.subsiteFor[normalizeHost(r.Host)], s)
+		if a := srv.overlays.authFor(r.URL.Path); a != nil && !a.authorize(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,124 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDirOverlays(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SiteTitle = "Site"
+	if err := os.MkdirAll(filepath.Join(dir, "sub", "inner", ".theme"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "sub/inner/page.md", "# Inner")
+	createFile(t, dir, "sub/_gomadore.toml", "site_title = \"Team\"\nbase_css_url = \"/team.css\"\n")
+	createFile(t, dir, "sub/inner/.theme/layout.html", `<title>{{ .Title }}</title><link href="{{ .BaseCSS }}">{{ .Body }}`)
+	createFile(t, dir, "sub/inner/_gomadore.toml", "site_title = \"Inner Team\"\ntemplate_filepath = \".theme/layout.html\"\n")
+	srv.purgeCache()
+
+	render := func(p string) string {
+		t.Helper()
+		out, err := srv.renderPage(srv.siteForHost(""), p)
+		if err != nil {
+			t.Fatalf("renderPage(%s): %v", p, err)
+		}
+		return string(out)
+	}
+	if got := render("/about"); strings.Contains(got, "<title>") {
+		t.Errorf("Expected the site template outside the overlays, got %q", got)
+	}
+	if got := render("/sub/inner/page"); !strings.Contains(got, "<title>Inner - Inner Team</title>") || !strings.Contains(got, `href="/team.css"`) {
+		t.Errorf("Expected the nested overlays to apply, got %q", got)
+	}
+
+	st := srv.overlays.site(srv.siteForHost(""), "/sub/deep")
+	if st.title != "Team" || st.baseCSS != "/team.css" || st.tmpl != srv.tmpl {
+		t.Errorf("Unexpected settings of /sub: %+v", st)
+	}
+	// A sibling page with the same prefix is not inside the directory
+	if st := srv.overlays.site(srv.siteForHost(""), "/subway"); st.title != "Site" {
+		t.Errorf("Expected /subway to keep the site title, got %q", st.title)
+	}
+
+	// Never served
+	createFile(t, dir, "sub/asset.txt", "asset")
+	srv.config.Static.Enabled = true
+	for p, want := range map[string]int{"/sub/asset.txt": http.StatusOK, "/sub/_gomadore.toml": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		srv.handler().ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), "GET", p, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: expected %d, got %d", p, want, w.Code)
+		}
+	}
+
+	// Errors keep the previous overlays on reload, and fail the startup
+	createFile(t, dir, "sub/_gomadore.toml", "site_titel = \"Typo\"\n")
+	srv.purgeCache()
+	if st := srv.overlays.site(srv.siteForHost(""), "/sub/deep"); st.title != "Team" {
+		t.Errorf("Expected the previous overlay to be kept, got %q", st.title)
+	}
+	if _, err := newDirOverlays(srv.config); err == nil || !strings.Contains(err.Error(), "site_titel") {
+		t.Errorf("Expected an unknown option error, got %v", err)
+	}
+}
+
+func TestDirOverlayAuth(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "sub/.htpasswd", "carol:"+string(hash)+"\n")
+	createFile(t, dir, "sub/_gomadore.toml", "[auth]\nenabled = true\nrealm = \"Team\"\nhtpasswd_file = \".htpasswd\"\n")
+	srv.purgeCache()
+	h := srv.handler()
+
+	tests := []struct {
+		name       string
+		path       string
+		user, pass string
+		wantStatus int
+	}{
+		{"Outside", "/about", "", "", http.StatusOK},
+		{"Sibling", "/t1/cococo", "", "", http.StatusOK},
+		{"No credentials", "/sub/deep", "", "", http.StatusUnauthorized},
+		{"Directory", "/sub", "", "", http.StatusUnauthorized},
+		{"Wrong password", "/sub/deep", "carol", "wrong", http.StatusUnauthorized},
+		{"Valid", "/sub/deep", "carol", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(t.Context(), "GET", tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d", tt.wantStatus, w.Code)
+			}
+			if w.Code == http.StatusUnauthorized && !strings.Contains(w.Header().Get("WWW-Authenticate"), `realm="Team"`) {
+				t.Errorf("Unexpected challenge: %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+	for page, want := range map[string]bool{"/sub/deep": true, "/sub/index": true, "/about": false, "/index": false} {
+		if got := srv.pageProtected(page); got != want {
+			t.Errorf("pageProtected(%q) = %v, want %v", page, got, want)
+		}
+	}
+
+	// An overlay of the content root protects the listing paths as well
+	createFile(t, dir, "_gomadore.toml", "[auth]\nenabled = true\nusers = [\"dave:"+string(hash)+"\"]\n")
+	srv.purgeCache()
+	if srv.pageProtected("/about") || !srv.pageProtected("/sub/deep") {
+		t.Error("Only the pages needing another login than the root should count as protected")
+	}
+}
@@ -0,0 +1,104 @@
+package gomadore
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Suffix of the attachment directory of a page ("page.md" + "page.assets/")
+const pageAssetsSuffix = ".assets"
+
+// pagePathKey passes the page path ("/guide/page") of the document being
+// parsed to AST transformers.
+var pagePathKey = parser.NewContextKey()
+
+// --- Page Assets ---
+
+// pageAssets rewrites relative image and link URLs into the attachment
+// directory of the page ("page.assets/shot.png", as Typora and other editors
+// store pasted images) to absolute URLs ("/guide/page.assets/shot.png"), so
+// they work wherever the page is shown: at "/guide/" for an index page, and
+// in feeds and the API. Documents parsed without a page path are left alone.
+type pageAssets struct{}
+
+// Extend implements goldmark.Extender.
+func (e *pageAssets) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(e, 100)))
+}
+
+// Transform implements parser.ASTTransformer.
+func (e *pageAssets) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	reqPath, _ := pc.Get(pagePathKey).(string)
+	if reqPath == "" {
+		return
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Image:
+			if dest, ok := rewritePageAsset(string(n.Destination), reqPath); ok {
+				n.Destination = []byte(dest)
+			}
+		case *ast.Link:
+			if dest, ok := rewritePageAsset(string(n.Destination), reqPath); ok {
+				n.Destination = []byte(dest)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// rewritePageAsset returns the absolute URL of a relative URL into the
+// attachment directory of the page at reqPath, and false if dest is not one.
+// The rest of the URL is kept as written.
+func rewritePageAsset(dest, reqPath string) (string, bool) {
+	rest := strings.TrimPrefix(dest, "./")
+	dir, file, ok := strings.Cut(rest, "/")
+	if !ok || file == "" {
+		return "", false
+	}
+	if name, err := url.PathUnescape(dir); err != nil || name != path.Base(reqPath)+pageAssetsSuffix {
+		return "", false
+	}
+	base := (&url.URL{Path: strings.TrimSuffix(path.Dir(reqPath), "/") + "/"}).EscapedPath()
+	return base + rest, true
+}
+
+// pageAssetOwner returns the page path and the file of the markdown page
+// whose attachment directory contains a URL path
+// ("/guide/page.assets/shot.png" next to guide/page.md, or a file with
+// another of the markdown_extensions), or "" if there is none.
+func (s *Server) pageAssetOwner(urlPath string) (pagePath, file string) {
+	segs := strings.Split(strings.Trim(path.Clean("/"+urlPath), "/"), "/")
+	for i, seg := range segs[:len(segs)-1] {
+		name, ok := strings.CutSuffix(seg, pageAssetsSuffix)
+		if !ok || name == "" {
+			continue
+		}
+		pagePath = "/" + path.Join(append(segs[:i:i], name)...)
+		root, rel, ok := s.config.HTML.MarkdownRootDir.source(pagePath[1:], s.config.HTML.MarkdownExts)
+		if !ok {
+			continue
+		}
+		return pagePath, filepath.Join(root, filepath.FromSlash(rel))
+	}
+	return "", ""
+}
+
+// isPageAsset reports whether a URL path is inside the attachment directory
+// of a markdown page (see pageAssetOwner). The attachments of drafts are
+// hidden unless show_drafts is set.
+func (s *Server) isPageAsset(urlPath string) bool {
+	_, file := s.pageAssetOwner(urlPath)
+	return file != "" && (s.config.HTML.ShowDrafts || !isDraftFile(file, s.config.HTML.SourceEncoding))
+}
@@ -0,0 +1,89 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewritePageAsset(t *testing.T) {
+	tests := []struct {
+		dest    string
+		reqPath string
+		want    string
+		ok      bool
+	}{
+		{"page.assets/shot.png", "/guide/page", "/guide/page.assets/shot.png", true},
+		{"./page.assets/a/b.png?v=1", "/guide/page", "/guide/page.assets/a/b.png?v=1", true},
+		{"index.assets/shot.png", "/guide/index", "/guide/index.assets/shot.png", true},
+		{"index.assets/shot.png", "/index", "/index.assets/shot.png", true},
+		{"My%20Page.assets/x%201.png", "/My Docs/My Page", "/My%20Docs/My%20Page.assets/x%201.png", true},
+		{"other.assets/shot.png", "/guide/page", "", false},
+		{"page.assets/", "/guide/page", "", false},
+		{"../page.assets/shot.png", "/guide/page", "", false},
+		{"/guide/page.assets/shot.png", "/guide/page", "", false},
+		{"shot.png", "/guide/page", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := rewritePageAsset(tt.dest, tt.reqPath)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rewritePageAsset(%q, %q) = %q, %v; want %q, %v", tt.dest, tt.reqPath, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPageAssets(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	enabled := true
+	srv.config.Markdown.PageAssets = &enabled
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"guide/index.assets", "guide/page.assets", "guide/draft.assets", "guide/other"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFile(t, dir, "guide/index.md", "# Guide\n\n![shot](index.assets/shot.png)\n")
+	createFile(t, dir, "guide/page.md", "# Page\n\n![shot](./page.assets/shot.png) [log](page.assets/log.txt) ![x](other/x.png)\n")
+	createFile(t, dir, "guide/draft.md", "---\ndraft: true\n---\n# Draft\n")
+	createFile(t, dir, "guide/index.assets/shot.png", "png")
+	createFile(t, dir, "guide/page.assets/shot.png", "png")
+	createFile(t, dir, "guide/draft.assets/shot.png", "png")
+	createFile(t, dir, "guide/other/x.png", "png")
+
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	body := get("/guide/").Body.String()
+	if !strings.Contains(body, `src="/guide/index.assets/shot.png"`) {
+		t.Errorf("Expected an absolute image URL in %s", body)
+	}
+	body = get("/guide/page").Body.String()
+	for _, want := range []string{`src="/guide/page.assets/shot.png"`, `href="/guide/page.assets/log.txt"`, `src="other/x.png"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Missing %s in %s", want, body)
+		}
+	}
+
+	// Only attachment directories of visible pages are served without [static]
+	tests := map[string]int{
+		"/guide/index.assets/shot.png": http.StatusOK,
+		"/guide/page.assets/shot.png":  http.StatusOK,
+		"/guide/draft.assets/shot.png": http.StatusNotFound,
+		"/guide/other/x.png":           http.StatusNotFound,
+		"/guide/none.assets/shot.png":  http.StatusNotFound,
+	}
+	for p, want := range tests {
+		if w := get(p); w.Code != want {
+			t.Errorf("GET %s: status %d, want %d", p, w.Code, want)
+		}
+	}
+}
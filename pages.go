@@ -0,0 +1,639 @@
+package gomadore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// Default suffix of markdown files (html.markdown_extensions)
+const defaultMarkdownExt = ".md"
+
+// --- Front Matter ---
+
+// splitFrontMatter separates a leading front matter block from markdown content.
+// YAML is delimited by "---" lines and TOML by "+++" lines.
+// If content has no (complete) front matter block, meta is nil and body is content.
+func splitFrontMatter(content []byte) (map[string]any, []byte, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+
+	firstEnd := bytes.IndexByte(content, '\n')
+	if firstEnd < 0 {
+		return nil, content, nil
+	}
+	delim := string(bytes.TrimRight(content[:firstEnd], " \t\r"))
+	if delim != "---" && delim != "+++" {
+		return nil, content, nil
+	}
+
+	// Find the closing delimiter line
+	pos := firstEnd + 1
+	for pos <= len(content) {
+		lineEnd := bytes.IndexByte(content[pos:], '\n')
+		var line []byte
+		next := len(content) + 1
+		if lineEnd < 0 {
+			line = content[pos:]
+		} else {
+			line = content[pos : pos+lineEnd]
+			next = pos + lineEnd + 1
+		}
+
+		if string(bytes.TrimRight(line, " \t\r")) == delim {
+			raw := content[firstEnd+1 : pos]
+			body := content[min(next, len(content)):]
+
+			meta := make(map[string]any)
+			var err error
+			if delim == "---" {
+				err = yaml.Unmarshal(raw, &meta)
+			} else {
+				_, err = toml.Decode(string(raw), &meta)
+			}
+			if err != nil {
+				return nil, content, fmt.Errorf("invalid front matter: %w", err)
+			}
+			return meta, body, nil
+		}
+		pos = next
+	}
+
+	return nil, content, nil
+}
+
+// metaString returns a front matter value as string ("" if missing).
+func metaString(meta map[string]any, key string) string {
+	switch v := meta[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// metaBool returns a front matter value as bool (false if missing or not a boolean).
+func metaBool(meta map[string]any, key string) bool {
+	switch v := meta[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return b
+	}
+	return false
+}
+
+// metaInt returns a front matter value as int (0 if missing or not a number).
+func metaInt(meta map[string]any, key string) int {
+	switch v := meta[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+// isDraftFile reports whether a markdown file (in encoding enc) is marked "draft: true".
+func isDraftFile(file, enc string) bool {
+	content, err := readSource(file, enc)
+	if err != nil {
+		return false
+	}
+	meta, _, _ := splitFrontMatter(content)
+	return metaBool(meta, "draft")
+}
+
+// metaStrings returns a front matter list (or comma separated string) as strings.
+func metaStrings(meta map[string]any, key string) []string {
+	var out []string
+	switch v := meta[key].(type) {
+	case []any:
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				out = append(out, s)
+			}
+		}
+	case []string:
+		for _, s := range v {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		for s := range strings.SplitSeq(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// Accepted layouts for date strings in front matter
+var metaTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// metaTime returns a front matter value as time.
+// YAML/TOML native dates and the string layouts above are accepted.
+func metaTime(meta map[string]any, key string) (time.Time, bool) {
+	switch v := meta[key].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range metaTimeLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// --- Page Metadata ---
+
+// pageMeta describes a markdown document (without its rendered body).
+type pageMeta struct {
+	Path        string         // internal request path (e.g. "/sub/deep", "/index")
+	URL         string         // public URL path (e.g. "/sub/deep", "/", "/about.html")
+	File        string         // file system path
+	Title       string         // front matter "title" or first H1
+	Description string         // front matter "description" or first paragraph
+	Author      string         // front matter "author"
+	Date        time.Time      // front matter "date" or file modification time
+	ModTime     time.Time      // file modification time
+	Tags        []string       // front matter "tags"
+	Draft       bool           // front matter "draft"
+	Weight      int            // front matter "weight" (0: none)
+	Aliases     []string       // front matter "aliases" (old URL paths)
+	WordCount   int            // number of words of the body (see wordCount)
+	Meta        map[string]any // raw front matter
+
+	links     []string // URL paths of the links to this site
+	wikiLinks []string // targets of the wiki links
+}
+
+// IsIndex reports whether the page is a directory index (index.md).
+func (p *pageMeta) IsIndex() bool {
+	return path.Base(p.Path) == "index"
+}
+
+// HasTag reports whether the page has the tag (case-insensitive).
+func (p *pageMeta) HasTag(tag string) bool {
+	return slices.ContainsFunc(p.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// urlPathFor converts a markdown path relative to the root (slash separated,
+// without its suffix such as ".md") into its public URL path.
+func urlPathFor(rel string, strict bool) string {
+	if strict {
+		return "/" + rel + ".html"
+	}
+	if rel == "index" {
+		return "/"
+	}
+	if strings.HasSuffix(rel, "/index") {
+		return "/" + strings.TrimSuffix(rel, "index")
+	}
+	return "/" + rel
+}
+
+// pageKey converts a URL path into the internal page path used as cache key
+// ("/" -> "/index", "/sub/" -> "/sub/index", "/about.html" -> "/about").
+func pageKey(urlPath string) string {
+	// If URL ends with slash, append "index" (directory support)
+	if strings.HasSuffix(urlPath, "/") {
+		urlPath += "index"
+	}
+
+	// Remove ".html" suffix if present
+	urlPath = strings.TrimSuffix(urlPath, ".html")
+
+	key := path.Clean(urlPath)
+	if key == "." {
+		key = "/index"
+	}
+	return key
+}
+
+// readPage reads a markdown file (in encoding enc) and parses it. rel is the
+// path relative to root, slash separated (e.g. "sub/deep.md"). Besides the
+// metadata it returns the parsed document and the markdown body (without
+// front matter) it refers to.
+func readPage(md goldmark.Markdown, root, rel string, strict bool, enc string) (*pageMeta, ast.Node, []byte, error) {
+	file := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	content, err := readSource(file, enc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	meta, body, err := splitFrontMatter(content)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", rel, err)
+	}
+
+	doc := md.Parser().Parse(text.NewReader(body))
+
+	relNoExt := strings.TrimSuffix(rel, path.Ext(rel))
+	p := &pageMeta{
+		Path:        "/" + relNoExt,
+		URL:         urlPathFor(relNoExt, strict),
+		File:        file,
+		Title:       metaString(meta, "title"),
+		Description: metaString(meta, "description"),
+		Author:      metaString(meta, "author"),
+		ModTime:     info.ModTime(),
+		Tags:        metaStrings(meta, "tags"),
+		Draft:       metaBool(meta, "draft"),
+		Weight:      metaInt(meta, "weight"),
+		Aliases:     metaStrings(meta, "aliases"),
+		Meta:        meta,
+	}
+	if p.Title == "" {
+		p.Title = extractTitle(doc, body)
+	}
+	for _, dest := range linkDestinations(doc) {
+		if target, ok := internalLink(p.URL, dest); ok {
+			p.links = append(p.links, target)
+		}
+	}
+	p.wikiLinks = wikiTargets(doc)
+	if p.Description == "" {
+		p.Description = firstParagraph(doc, body)
+	}
+	if d, ok := metaTime(meta, "date"); ok {
+		p.Date = d
+	} else {
+		p.Date = p.ModTime
+	}
+
+	return p, doc, body, nil
+}
+
+// extractTitle returns the text of the first top-level H1 ("" if none).
+func extractTitle(doc ast.Node, src []byte) string {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if h, ok := n.(*ast.Heading); ok && h.Level == 1 {
+			return string(h.Lines().Value(src))
+		}
+	}
+	return ""
+}
+
+// firstParagraph returns the plain text of the first top-level paragraph.
+func firstParagraph(doc ast.Node, src []byte) string {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if _, ok := n.(*ast.Paragraph); ok {
+			return nodeText(n, src)
+		}
+	}
+	return ""
+}
+
+// nodeText collects the plain text of a node and its descendants.
+func nodeText(n ast.Node, src []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if c.Type() == ast.TypeBlock && buf.Len() > 0 {
+				buf.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			lines := c.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				buf.Write(seg.Value(src))
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// scanPages walks roots and loads the metadata of every markdown file.
+// Pages are sorted by Path.
+func scanPages(files *docCache, roots RootDirs) ([]*pageMeta, error) {
+	var pages []*pageMeta
+	err := walkMarkdown(roots, files.exts, func(root, rel string) error {
+		p, err := files.meta(root, rel)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(pages, func(a, b *pageMeta) int { return strings.Compare(a.Path, b.Path) })
+	return pages, nil
+}
+
+// markdownSuffixes returns markdown_extensions, or [".md"] if it is not set.
+func markdownSuffixes(cfg Config) []string {
+	if len(cfg.HTML.MarkdownExts) == 0 {
+		return []string{defaultMarkdownExt}
+	}
+	return cfg.HTML.MarkdownExts
+}
+
+// checkMarkdownExts validates markdown_extensions: each is "." and a name
+// without dots or slashes, so that path.Ext finds it.
+func checkMarkdownExts(exts []string) error {
+	for _, ext := range exts {
+		name, ok := strings.CutPrefix(ext, ".")
+		if !ok || name == "" || strings.ContainsAny(name, "./\\") {
+			return fmt.Errorf("markdown_extensions %q: must be a dot and a name without dots or slashes (\".md\")", ext)
+		}
+	}
+	return nil
+}
+
+// markdownExt returns the suffix of exts a file name ends with (compared
+// case-insensitively, as written in the name), or "" if the file is not a
+// markdown file.
+func markdownExt(name string, exts []string) string {
+	for _, ext := range exts {
+		if len(name) > len(ext) && strings.EqualFold(name[len(name)-len(ext):], ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+	return ""
+}
+
+// findMarkdownFile returns the markdown file of a path without suffix: the
+// first of exts that exists, also in another case ("a.MD"), and false if
+// there is none.
+func findMarkdownFile(stem string, exts []string) (string, bool) {
+	for _, ext := range exts {
+		if info, err := os.Stat(stem + ext); err == nil && info.Mode().IsRegular() {
+			return stem + ext, true
+		}
+	}
+	if entries, err := os.ReadDir(filepath.Dir(stem)); err == nil {
+		base := filepath.Base(stem)
+		for _, ext := range exts {
+			for _, e := range entries {
+				name := e.Name()
+				if e.Type().IsRegular() && len(name) == len(base)+len(ext) && strings.HasPrefix(name, base) && strings.EqualFold(name[len(base):], ext) {
+					return filepath.Join(filepath.Dir(stem), name), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// walkMarkdown calls fn with the directory and the slash separated relative
+// path of every markdown file under roots (with one of the suffixes exts)
+// that a page is served from: files shadowed by one with an earlier suffix
+// ("a.markdown" by "a.md") or in an earlier directory are skipped.
+func walkMarkdown(roots RootDirs, exts []string, fn func(root, rel string) error) error {
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(pathStr string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := markdownExt(d.Name(), exts)
+			if d.IsDir() || ext == "" {
+				return nil
+			}
+			rel, err := filepath.Rel(root, pathStr)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if r, s, ok := roots.source(strings.TrimSuffix(rel, ext), exts); !ok || r != root || s != rel {
+				return nil
+			}
+			return fn(root, rel)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wordCount counts the words of plain text. Text without spaces between
+// words (Chinese, Japanese) counts each character as a word.
+func wordCount(text string) int {
+	n, inWord := 0, false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+			}
+			inWord = true
+		case r == '\'' || r == '-' || r == '_':
+			// Part of a word ("don't", "well-known")
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+// --- Page Index ---
+
+// pageIndex is the metadata of every page, shared by list mode, the
+// sitemap, feeds, tag pages, the navigation and search instead of each
+// walking the tree. It is built at startup (or on first use), updated file by
+// file by the watcher, and rescanned after invalidation.
+type pageIndex struct {
+	mu     sync.Mutex
+	files  *docCache
+	roots  RootDirs
+	drafts bool // include draft pages
+	pages  []*pageMeta
+	alias  map[string]*pageMeta   // page path of an alias -> page
+	linked map[string][]*pageMeta // page path -> pages linking to it
+	valid  bool
+}
+
+func newPageIndex(files *docCache, roots RootDirs, drafts bool) *pageIndex {
+	return &pageIndex{files: files, roots: roots, drafts: drafts}
+}
+
+// all returns the metadata of every page (without drafts unless show_drafts
+// is set), sorted by Path. The returned slice must not be modified.
+func (ix *pageIndex) all() ([]*pageMeta, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.valid {
+		pages, err := scanPages(ix.files, ix.roots)
+		if err != nil {
+			return nil, err
+		}
+		if !ix.drafts {
+			pages = slices.DeleteFunc(pages, func(p *pageMeta) bool { return p.Draft })
+		}
+		ix.pages = pages
+		ix.alias = aliasTable(pages)
+		ix.linked = backlinkTable(pages, ix.alias)
+		ix.valid = true
+	}
+	return ix.pages, nil
+}
+
+// update reloads the pages of changed markdown files (slash separated paths
+// relative to their content root); pages without a file any more are
+// dropped from the index. If the index has not been built
+// yet, it is left to be built on first use. It returns the paths of the
+// pages whose backlinks changed.
+func (ix *pageIndex) update(rels []string) []string {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.valid {
+		return nil
+	}
+	pages := slices.Clone(ix.pages) // callers of all() may still hold the old slice
+	for _, rel := range rels {
+		// Another file may now be served for the page (or none)
+		stem := strings.TrimSuffix(rel, path.Ext(rel))
+		pages = slices.DeleteFunc(pages, func(p *pageMeta) bool { return p.Path == "/"+stem })
+		root, src, ok := ix.source(stem)
+		if !ok {
+			continue
+		}
+		p, err := ix.files.meta(root, src)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			// Fall back to a full rescan on next use
+			slog.Warn("Failed to update page index", "file", rel, "err", err)
+			changed := slices.Collect(maps.Keys(ix.linked))
+			ix.valid, ix.pages, ix.alias, ix.linked = false, nil, nil, nil
+			return changed
+		}
+		if p.Draft && !ix.drafts {
+			continue
+		}
+		pages = append(pages, p)
+	}
+	slices.SortFunc(pages, func(a, b *pageMeta) int { return strings.Compare(a.Path, b.Path) })
+	ix.pages = pages
+	ix.alias = aliasTable(pages)
+	linked := backlinkTable(pages, ix.alias)
+	var changed []string
+	for key := range ix.linked {
+		if !slices.Equal(ix.linked[key], linked[key]) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range linked {
+		if _, ok := ix.linked[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	ix.linked = linked
+	return changed
+}
+
+// source returns the directory and relative path of the markdown file of a
+// page ("sub/deep" -> "sub/deep.md"), and false if it has none.
+func (ix *pageIndex) source(stem string) (root, rel string, ok bool) {
+	return ix.roots.source(stem, ix.files.exts)
+}
+
+// invalidate forces a rescan on the next call of all().
+func (ix *pageIndex) invalidate() {
+	ix.mu.Lock()
+	ix.valid = false
+	ix.pages = nil
+	ix.alias = nil
+	ix.linked = nil
+	ix.mu.Unlock()
+}
+
+// aliased returns the page that has the URL path among its aliases.
+func (ix *pageIndex) aliased(urlPath string) (*pageMeta, error) {
+	if _, err := ix.all(); err != nil {
+		return nil, err
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.alias[aliasKey(urlPath)], nil
+}
+
+// aliasKey normalizes a URL path for aliases, so that "/legacy",
+// "/legacy/" and "/legacy.html" are the same alias.
+func aliasKey(urlPath string) string {
+	key := pageKey("/" + strings.TrimPrefix(urlPath, "/"))
+	if dir, ok := strings.CutSuffix(key, "/index"); ok && dir != "" {
+		return dir
+	}
+	return key
+}
+
+// aliasTable maps the front matter aliases of pages to the pages. An alias
+// of an existing page or of an earlier page (by path) is ignored.
+func aliasTable(pages []*pageMeta) map[string]*pageMeta {
+	table := make(map[string]*pageMeta)
+	exists := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		exists[aliasKey(p.Path)] = true
+	}
+	for _, p := range pages {
+		for _, a := range p.Aliases {
+			key := aliasKey(a)
+			if exists[key] || table[key] != nil {
+				slog.Warn("Ignore conflicting alias", "alias", a, "file", p.File)
+				continue
+			}
+			table[key] = p
+		}
+	}
+	return table
+}
@@ -0,0 +1,329 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantTitle string
+		wantBody  string
+		wantErr   bool
+	}{
+		{
+			name:      "YAML",
+			content:   "---\ntitle: Hello\n---\n# Body",
+			wantTitle: "Hello",
+			wantBody:  "# Body",
+		},
+		{
+			name:      "TOML",
+			content:   "+++\ntitle = \"Hello\"\n+++\n# Body",
+			wantTitle: "Hello",
+			wantBody:  "# Body",
+		},
+		{
+			name:      "CRLF and BOM",
+			content:   "\xef\xbb\xbf---\r\ntitle: Hello\r\n---\r\n# Body",
+			wantTitle: "Hello",
+			wantBody:  "# Body",
+		},
+		{
+			name:     "No front matter",
+			content:  "# Body\n---\n",
+			wantBody: "# Body\n---\n",
+		},
+		{
+			name:     "Unclosed block is content",
+			content:  "---\ntitle: Hello\n",
+			wantBody: "---\ntitle: Hello\n",
+		},
+		{
+			name:    "Invalid YAML",
+			content: "---\ntitle: [unclosed\n---\nBody",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := splitFrontMatter([]byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := metaString(meta, "title"); got != tt.wantTitle {
+				t.Errorf("Title mismatch: got %q, want %q", got, tt.wantTitle)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("Body mismatch: got %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMetaValues(t *testing.T) {
+	meta, _, err := splitFrontMatter([]byte("---\ntags: [a, b]\ncsv: \"x, y\"\ndate: 2025-01-02\nstr: \"2025-01-02 03:04\"\n---\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := metaStrings(meta, "tags"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("List mismatch: got %v", got)
+	}
+	if got := metaStrings(meta, "csv"); len(got) != 2 || got[1] != "y" {
+		t.Errorf("Comma separated mismatch: got %v", got)
+	}
+	if d, ok := metaTime(meta, "date"); !ok || d.Format("2006-01-02") != "2025-01-02" {
+		t.Errorf("Native date mismatch: got %v (%v)", d, ok)
+	}
+	if d, ok := metaTime(meta, "str"); !ok || d.Format(time.DateTime) != "2025-01-02 03:04:00" {
+		t.Errorf("String date mismatch: got %v (%v)", d, ok)
+	}
+	if _, ok := metaTime(meta, "missing"); ok {
+		t.Error("Missing date should not be ok")
+	}
+	if !metaBool(map[string]any{"draft": true}, "draft") || !metaBool(map[string]any{"draft": "true"}, "draft") || metaBool(meta, "missing") {
+		t.Error("Bool mismatch")
+	}
+}
+
+func TestUrlPathFor(t *testing.T) {
+	tests := []struct {
+		rel    string
+		strict bool
+		want   string
+	}{
+		{"index", false, "/"},
+		{"about", false, "/about"},
+		{"sub/index", false, "/sub/"},
+		{"index", true, "/index.html"},
+		{"sub/deep", true, "/sub/deep.html"},
+	}
+	for _, tt := range tests {
+		if got := urlPathFor(tt.rel, tt.strict); got != tt.want {
+			t.Errorf("urlPathFor(%q, %v): got %q, want %q", tt.rel, tt.strict, got, tt.want)
+		}
+	}
+}
+
+func TestPageIndex(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	pages, err := srv.pages.all()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pages) != 4 || pages[0].Path != "/about" || pages[0].Title != "About" {
+		t.Fatalf("Unexpected pages: %+v", pages[0])
+	}
+
+	// Cached until invalidated
+	createFile(t, dir, "new.md", "# New")
+	if pages, _ = srv.pages.all(); len(pages) != 4 {
+		t.Errorf("Index should be cached, got %d pages", len(pages))
+	}
+	srv.purgeCache()
+	if pages, _ = srv.pages.all(); len(pages) != 5 {
+		t.Errorf("Index should be rescanned after purge, got %d pages", len(pages))
+	}
+}
+
+func TestPageIndexUpdate(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	before, err := srv.pages.all()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Only the changed files are read again
+	createFile(t, dir, "about.md", "# About Us\nOne two three")
+	createFile(t, dir, "new.md", "---\naliases: [/old]\n---\n# New")
+	createFile(t, dir, "wip.md", "---\ndraft: true\n---\n# WIP")
+	if err := os.Remove(filepath.Join(dir, "sub", "deep.md")); err != nil {
+		t.Fatal(err)
+	}
+	srv.invalidateFiles([]string{
+		filepath.Join(dir, "about.md"),
+		filepath.Join(dir, "new.md"),
+		filepath.Join(dir, "wip.md"),
+		filepath.Join(dir, "sub", "deep.md"),
+	})
+
+	pages, _ := srv.pages.all()
+	var paths []string
+	for _, p := range pages {
+		paths = append(paths, p.Path)
+	}
+	if want := []string{"/about", "/index", "/new", "/t1/cococo"}; !slices.Equal(paths, want) {
+		t.Fatalf("Expected pages %v, got %v", want, paths)
+	}
+	if pages[0].Title != "About Us" || pages[0].WordCount != 5 {
+		t.Errorf("Expected the changed page to be reloaded, got %q (%d words)", pages[0].Title, pages[0].WordCount)
+	}
+	if pages[1] != before[1] {
+		t.Error("Expected the unchanged page to be kept")
+	}
+	if len(before) != 4 || before[0].Title != "About" {
+		t.Error("Expected the previous slice to be left unmodified")
+	}
+	if p, _ := srv.pages.aliased("/old"); p == nil || p.Path != "/new" {
+		t.Errorf("Expected the alias of the new page, got %+v", p)
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 2},
+		{"Don't use well-known words 3 times.", 6},
+		{"日本語の文章", 6},
+		{"Go言語 1.25", 5},
+	}
+	for _, tt := range tests {
+		if got := wordCount(tt.text); got != tt.want {
+			t.Errorf("wordCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "wip.md", "---\ndraft: true\n---\n# Work In Progress\nunfinishedword")
+	createFile(t, dir, "done.md", "---\ndraft: false\n---\n# Done\nfinishedword")
+	srv.search = newSearchIndex(srv.pages, srv.pageProtected)
+
+	get := func(p string) int {
+		req := httptest.NewRequestWithContext(t.Context(), "GET", p, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w.Code
+	}
+	if code := get("/wip"); code != http.StatusNotFound {
+		t.Errorf("Draft should be 404, got %d", code)
+	}
+	if code := get("/done"); code != http.StatusOK {
+		t.Errorf("Published page should be 200, got %d", code)
+	}
+
+	pages, _ := srv.pages.all()
+	for _, p := range pages {
+		if p.Path == "/wip" {
+			t.Error("Draft listed in the page index")
+		}
+	}
+	for _, n := range srv.nav.get("") {
+		if n.Path == "/wip" {
+			t.Error("Draft listed in the navigation")
+		}
+	}
+	if res, _ := srv.search.search("unfinishedword", searchFilter{}, 10); len(res) != 0 {
+		t.Errorf("Draft found by search: %+v", res)
+	}
+
+	cfg := srv.config
+	output, _ := captureOutput(t, func() { _ = printURLList(cfg, false) })
+	if strings.Contains(output, "/wip") || !strings.Contains(output, "/done") {
+		t.Errorf("Unexpected URL list:\n%s", output)
+	}
+
+	// show_drafts serves and lists them
+	srv.config.HTML.ShowDrafts = true
+	srv.pages = newPageIndex(srv.files, RootDirs{dir}, true)
+	srv.search = newSearchIndex(srv.pages, srv.pageProtected)
+	if code := get("/wip"); code != http.StatusOK {
+		t.Errorf("Draft should be served with show_drafts, got %d", code)
+	}
+	pages, _ = srv.pages.all()
+	if !slices.ContainsFunc(pages, func(p *pageMeta) bool { return p.Path == "/wip" && p.Draft }) {
+		t.Error("Draft missing from the page index with show_drafts")
+	}
+	if res, _ := srv.search.search("unfinishedword", searchFilter{}, 10); len(res) != 1 {
+		t.Errorf("Draft not found by search with show_drafts: %+v", res)
+	}
+}
+
+func TestMarkdownFileSuffixes(t *testing.T) {
+	if err := checkMarkdownExts([]string{".md", ".markdown", ".txt"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, ext := range []string{"md", ".", ".tar.gz", "./md", ""} {
+		if err := checkMarkdownExts([]string{ext}); err == nil {
+			t.Errorf("Expected an error for %q", ext)
+		}
+	}
+
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.MarkdownExts = []string{".md", ".markdown", ".mdown", ".txt"}
+	srv.config.HTML.ServeRawMarkdown = true
+	srv.config.Static.Enabled = true
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "notes/index.mdown", "# Notes\n\n[first](first.markdown) [shadow](shadow.txt)\n")
+	createFile(t, dir, "notes/first.markdown", "# First")
+	createFile(t, dir, "notes/plain.TXT", "# Plain")
+	createFile(t, dir, "notes/shadow.md", "# Shadow MD")
+	createFile(t, dir, "notes/shadow.txt", "# Shadow TXT")
+
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/notes/", http.StatusOK, `href="first"`},
+		{"/notes/first", http.StatusOK, "First"},
+		{"/notes/plain", http.StatusOK, "Plain"},
+		{"/notes/shadow", http.StatusOK, "Shadow MD"},
+		{"/notes/first.markdown", http.StatusOK, "# First"},
+		{"/notes/shadow.txt", http.StatusNotFound, ""},
+		{"/notes/plain.TXT", http.StatusOK, "# Plain"},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GET %s: status %d, body %q; want %d with %q", tt.path, w.Code, w.Body.String(), tt.code, tt.want)
+		}
+	}
+
+	pages, err := srv.pages.all()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, p := range pages {
+		if strings.HasPrefix(p.Path, "/notes/") {
+			paths = append(paths, p.Path)
+		}
+	}
+	if want := []string{"/notes/first", "/notes/index", "/notes/plain", "/notes/shadow"}; !slices.Equal(paths, want) {
+		t.Errorf("Pages = %v, want %v", paths, want)
+	}
+}
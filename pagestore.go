@@ -0,0 +1,136 @@
+package gomadore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// --- Page Stores ---
+
+// pageStore keeps rendered pages outside the memory cache, so that they
+// survive a restart (cache_dir) or are shared by several replicas
+// ([cache.redis]). A page is looked up there before it is rendered, and
+// stored after.
+type pageStore interface {
+	// get returns the stored page of a cache key, rendered from a markdown
+	// file with the given modification time.
+	get(cacheKey string, modTime time.Time) ([]byte, bool)
+	// put stores a rendered page. Errors are logged.
+	put(cacheKey string, modTime time.Time, content []byte)
+	// refresh is called when the content may have changed: pages stored
+	// for older content must not be returned any more.
+	refresh()
+}
+
+// newPageStore opens the page store of a site (nil if neither cache_dir nor
+// [cache.redis] is set). templates are the templates the pages are rendered
+// with, parsed but not yet executed.
+func newPageStore(cfg Config, templates ...*template.Template) (pageStore, error) {
+	switch {
+	case cfg.Cache.CacheDir != "" && cfg.Cache.Redis.Enabled:
+		return nil, errors.New("cache_dir and [cache.redis] cannot be used together")
+	case cfg.Cache.CacheDir != "":
+		d, err := newDiskCache(cfg, templates...)
+		if err != nil {
+			return nil, fmt.Errorf("cache_dir: %w", err)
+		}
+		return d, nil
+	case cfg.Cache.Redis.Enabled:
+		r, err := newRedisCache(cfg, templates...)
+		if err != nil {
+			return nil, fmt.Errorf("cache.redis: %w", err)
+		}
+		return r, nil
+	}
+	return nil, nil
+}
+
+// siteHash returns a hash of what the pages of a site are rendered with
+// besides the content: the version, the content roots, the configuration
+// and the templates.
+func siteHash(cfg Config, roots []string, templates ...*template.Template) (string, error) {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00", Version, Revision, strings.Join(roots, "\x00"))
+	conf, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	_, _ = h.Write(conf)
+	for _, t := range templates {
+		if t == nil {
+			continue
+		}
+		list := t.Templates()
+		slices.SortFunc(list, func(a, b *template.Template) int { return strings.Compare(a.Name(), b.Name()) })
+		for _, tt := range list {
+			_, _ = fmt.Fprintf(h, "\x00%s\x00", tt.Name())
+			if tt.Tree != nil && tt.Tree.Root != nil {
+				_, _ = io.WriteString(h, tt.Tree.Root.String())
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// contentHash returns a hash of the files under the content roots (hidden
+// ones and the directory skip excepted). With byContent, it hashes their
+// relative names and contents, which are the same on every replica;
+// otherwise their paths, sizes and modification times, which is cheaper.
+func contentHash(roots []string, skip string, byContent bool) (string, error) {
+	h := sha256.New()
+	for i, root := range roots {
+		err := filepath.WalkDir(root, func(p string, e fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == skip || p != root && strings.HasPrefix(e.Name(), ".") {
+				// Hidden files are never served (editor swap files, VCS data)
+				if e.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !byContent {
+				info, err := e.Info()
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d\n", p, info.Size(), info.ModTime().UnixNano())
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "%d\x00%s\x00", i, filepath.ToSlash(rel))
+			if e.Type().IsRegular() {
+				f, err := os.Open(p)
+				if err != nil {
+					return err
+				}
+				_, err = io.Copy(h, f)
+				_ = f.Close()
+				if err != nil {
+					return err
+				}
+			}
+			_, _ = io.WriteString(h, "\n")
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
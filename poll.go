@@ -0,0 +1,208 @@
+package gomadore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// Values of watch_mode
+	watchModeFsnotify = "fsnotify"
+	watchModePoll     = "poll"
+
+	// Default interval of the polling watcher (ms)
+	defaultWatchPollIntervalMs = 2000
+)
+
+// --- Polling Watcher ---
+
+// fileState is what the polling watcher compares between two scans.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// scanFiles records the state of the files and directories under dirs (the
+// content roots or some of their subdirectories), skipping hidden, backup
+// and watch_ignore'd ones like the fsnotify watcher does. Missing
+// directories are empty.
+func (s *Server) scanFiles(dirs []string) map[string]fileState {
+	files := make(map[string]fileState)
+	for _, root := range dirs {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				return nil // removed (during the scan)
+			}
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || s.ignoredByWatcher(p)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			files[p] = fileState{size: info.Size(), modTime: info.ModTime(), dir: d.IsDir()}
+			return nil
+		})
+		if err != nil {
+			slog.Error("Directory walk error", "err", err)
+		}
+	}
+	return files
+}
+
+// changedFiles compares two scans the way the fsnotify watcher reports
+// changes: created, modified and removed markdown files, and removed other
+// files and directories (which purge the whole cache).
+func (s *Server) changedFiles(before, after map[string]fileState) []string {
+	var changed []string
+	for p, st := range after {
+		old, existed := before[p]
+		if st.dir || markdownExt(p, s.config.HTML.MarkdownExts) == "" {
+			continue
+		}
+		switch {
+		case !existed:
+			s.metrics.watcherEvent(fsnotify.Create)
+		case st.size != old.size || !st.modTime.Equal(old.modTime):
+			s.metrics.watcherEvent(fsnotify.Write)
+		default:
+			continue
+		}
+		changed = append(changed, p)
+	}
+	for p := range before {
+		if _, ok := after[p]; !ok {
+			s.metrics.watcherEvent(fsnotify.Remove)
+			changed = append(changed, p)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// pollFiles scans dirs every watch_poll_interval_ms and handles the changes
+// like the fsnotify watcher. It is the watcher of watch_mode = "poll", for
+// file systems without change notifications (NFS, SMB and other network
+// shares), and covers the directories fsnotify cannot watch. dirs is called
+// before each scan.
+func (s *Server) pollFiles(ctx context.Context, dirs func() []string) {
+	interval := time.Duration(cmp.Or(s.config.Cache.WatchPollIntervalMs, defaultWatchPollIntervalMs)) * time.Millisecond
+	files := s.scanFiles(dirs())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			scan := s.scanFiles(dirs())
+			changed := s.changedFiles(files, scan)
+			files = scan
+			if len(changed) == 0 {
+				continue
+			}
+			for _, f := range changed {
+				if markdownExt(f, s.config.HTML.MarkdownExts) != "" {
+					// Tell search engines about the changed page
+					s.indexNow.notify(f)
+				}
+			}
+			slog.Debug("File/Dir change detected. Invalidating cache.", "files", changed)
+			s.filesChanged(changed)
+		}
+	}
+}
+
+// unwatchedDirs are the subtrees of the content roots that the fsnotify
+// watcher could not watch because the watch limit of the OS was reached
+// (fs.inotify.max_user_watches on Linux). They are polled instead.
+type unwatchedDirs struct {
+	mu     sync.Mutex
+	roots  []string // topmost unwatched directories
+	count  int      // unwatched directories
+	warned int      // count when warn last logged
+	full   bool     // the watch limit was reached
+}
+
+// addWatches adds dir and its subdirectories to the watcher with add (the
+// watcher's Add method), unless they are ignored. Once the watch limit is
+// reached, the remaining directories are recorded in u instead. It reports
+// whether the limit was reached by this call.
+func (s *Server) addWatches(dir string, add func(string) error, u *unwatchedDirs) bool {
+	reached := false
+	err := filepath.WalkDir(dir, func(pathStr string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		pathStr = filepath.ToSlash(filepath.Clean(pathStr))
+		if s.ignoredByWatcher(pathStr) {
+			slog.Debug("Ignore dir", "path", pathStr)
+			return filepath.SkipDir
+		}
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		if !u.full {
+			err := add(pathStr)
+			if err == nil {
+				slog.Debug("Watching dir", "path", pathStr)
+				return nil
+			}
+			if !errors.Is(err, syscall.ENOSPC) {
+				slog.Error("Failed to add to watcher", "path", pathStr, "err", err)
+				return nil
+			}
+			u.full, reached = true, true
+		}
+		u.count++
+		if !slices.ContainsFunc(u.roots, func(r string) bool { return strings.HasPrefix(pathStr, r+"/") }) {
+			u.roots = append(u.roots, pathStr)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Directory walk error", "err", err)
+	}
+	return reached
+}
+
+// list returns the topmost unwatched directories.
+func (u *unwatchedDirs) list() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.roots)
+}
+
+// warn logs the number of unwatched directories, if it has grown.
+func (u *unwatchedDirs) warn(intervalMs int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.count == u.warned {
+		return
+	}
+	u.warned = u.count
+	slog.Warn("File watch limit reached; polling the directories that cannot be watched (raise fs.inotify.max_user_watches to watch them)",
+		"unwatched_count", u.count, "polled_subtrees", u.roots, "interval_ms", intervalMs)
+}
@@ -0,0 +1,111 @@
+package gomadore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestChangedFiles(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.WatchIgnore = []string{"drafts/**"}
+	before := srv.scanFiles(srv.config.HTML.MarkdownRootDir)
+
+	createFile(t, dir, "about.md", "# About\nA longer about page")
+	createFile(t, dir, "new.md", "# New")
+	createFile(t, dir, "style.css", "body {}")
+	createFile(t, dir, ".draft.md", "# Hidden")
+	if err := os.Mkdir(filepath.Join(dir, "drafts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "drafts/ignored.md", "# Ignored")
+	if err := os.RemoveAll(filepath.Join(dir, "t1")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := srv.changedFiles(before, srv.scanFiles(srv.config.HTML.MarkdownRootDir))
+	want := []string{
+		filepath.Join(dir, "about.md"),
+		filepath.Join(dir, "new.md"),
+		filepath.Join(dir, "t1"),
+		filepath.Join(dir, "t1", "cococo.md"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Changed files mismatch:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestPollFiles(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.WatchMode = watchModePoll
+	srv.config.Cache.WatchPollIntervalMs = 20
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go srv.watchFiles(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/about", nil))
+		return w.Body.String()
+	}
+	if body := get(); !strings.Contains(body, "This is about page") {
+		t.Fatalf("Unexpected page %s", body)
+	}
+	createFile(t, dir, "about.md", "# About\nPolled change")
+	for range 50 {
+		if strings.Contains(get(), "Polled change") {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("The change was not picked up by polling")
+}
+
+func TestAddWatches(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Cache.WatchIgnore = []string{"build"}
+	for _, d := range []string{"t1/a", "t1/a/b", "u", "build"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slash := filepath.ToSlash
+
+	// The OS accepts two watches
+	var watched []string
+	add := func(p string) error {
+		if len(watched) == 2 {
+			return syscall.ENOSPC
+		}
+		watched = append(watched, p)
+		return nil
+	}
+	var u unwatchedDirs
+	if !srv.addWatches(dir, add, &u) {
+		t.Fatal("Expected the watch limit to be reported")
+	}
+	if want := []string{slash(dir), slash(filepath.Join(dir, "sub"))}; !slices.Equal(watched, want) {
+		t.Errorf("Watched %v, want %v", watched, want)
+	}
+	wantRoots := []string{slash(filepath.Join(dir, "t1")), slash(filepath.Join(dir, "u"))}
+	if !slices.Equal(u.list(), wantRoots) || u.count != 4 {
+		t.Errorf("Unwatched %v (%d dirs), want %v (4 dirs)", u.list(), u.count, wantRoots)
+	}
+
+	// Directories created later are polled as well, without a new poller
+	if err := os.Mkdir(filepath.Join(dir, "v"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if srv.addWatches(filepath.Join(dir, "v"), add, &u) || len(u.list()) != 3 {
+		t.Errorf("Unexpected unwatched directories %v", u.list())
+	}
+}
@@ -0,0 +1,140 @@
+package gomadore
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// --- Reverse Proxies ---
+
+// trustedProxies are the reverse proxies whose X-Forwarded-For,
+// X-Real-IP and X-Forwarded-Proto headers are believed. Every method is
+// safe to call on nil (no trusted proxies).
+type trustedProxies struct {
+	nets []netip.Prefix
+	unix bool // peers on a Unix domain socket
+}
+
+// newTrustedProxies returns nil if [proxy] trusted_proxies is empty. The
+// entry "unix" trusts the peers of a Unix domain socket listener.
+func newTrustedProxies(cfg Config) (*trustedProxies, error) {
+	list := slices.DeleteFunc(slices.Clone(cfg.Proxy.TrustedProxies), func(s string) bool { return s == "unix" })
+	nets, err := parsePrefixes(list)
+	if err != nil {
+		return nil, err
+	}
+	unix := len(list) < len(cfg.Proxy.TrustedProxies)
+	if len(nets) == 0 && !unix {
+		return nil, nil
+	}
+	return &trustedProxies{nets: nets, unix: unix}, nil
+}
+
+// trusts reports whether a peer address is a trusted proxy. Peers on a
+// Unix domain socket have no address.
+func (p *trustedProxies) trusts(addr netip.Addr) bool {
+	if p == nil {
+		return false
+	}
+	if !addr.IsValid() {
+		return p.unix
+	}
+	return prefixesContain(p.nets, addr)
+}
+
+// clientIP returns the address of the client of a request: the peer, or,
+// if the peer is a trusted proxy, the last address in X-Forwarded-For that
+// is not one (the others may have been sent by the client), or else
+// X-Real-IP. The zero Addr is returned if the peer address cannot be parsed.
+func (p *trustedProxies) clientIP(r *http.Request) netip.Addr {
+	addr := remoteAddr(r)
+	if !p.trusts(addr) {
+		return addr
+	}
+	if r.Header.Get("X-Forwarded-For") == "" {
+		if real, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return real.Unmap()
+		}
+		return addr
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // malformed: stop at the last address known to be right
+		}
+		addr = hop.Unmap()
+		if !p.trusts(addr) {
+			break
+		}
+	}
+	return addr
+}
+
+// clientAddr returns the client of a request for logs: the peer's
+// "host:port", or the client address if the peer is a trusted proxy.
+func (p *trustedProxies) clientAddr(r *http.Request) string {
+	if !p.trusts(remoteAddr(r)) {
+		return r.RemoteAddr
+	}
+	return p.clientIP(r).String()
+}
+
+// scheme returns the scheme the client used: "https" over TLS, or, if the
+// peer is a trusted proxy, the first X-Forwarded-Proto value.
+func (p *trustedProxies) scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if p.trusts(remoteAddr(r)) {
+		proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+		if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "https" || proto == "http" {
+			return proto
+		}
+	}
+	return "http"
+}
+
+// remoteAddr returns the address of the peer of a request.
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.Unmap()
+}
+
+// parsePrefixes parses CIDR ranges; a single address is a range of its own.
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	var nets []netip.Prefix
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("%q: not an address or CIDR range", s)
+			}
+			nets = append(nets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q: not an address or CIDR range", s)
+		}
+		nets = append(nets, prefix.Masked())
+	}
+	return nets, nil
+}
+
+func prefixesContain(nets []netip.Prefix, addr netip.Addr) bool {
+	for _, n := range nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	var cfg Config
+	cfg.Proxy.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1"}
+	p, err := newTrustedProxies(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"203.0.113.5:1234", nil, "203.0.113.5"},
+		{"203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"}, // untrusted peer
+		{"10.1.2.3:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"6.6.6.6, 198.51.100.1, 10.0.0.9"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"6.6.6.6", "198.51.100.1"}, "198.51.100.1"},
+		{"192.0.2.1:1234", []string{"10.0.0.1, 10.0.0.2"}, "10.0.0.1"}, // all trusted
+		{"10.1.2.3:1234", []string{"garbage, 198.51.100.1"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"198.51.100.1, garbage"}, "10.1.2.3"},
+		{"[::ffff:10.1.2.3]:1234", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		for _, v := range tt.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := p.clientIP(r).String(); got != tt.want {
+			t.Errorf("%s %v: clientIP = %s, want %s", tt.remote, tt.xff, got, tt.want)
+		}
+	}
+
+	// Without trusted proxies, X-Forwarded-For is ignored
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.1.2.3:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := (*trustedProxies)(nil).clientIP(r).String(); got != "10.1.2.3" {
+		t.Errorf("clientIP = %s without trusted proxies", got)
+	}
+
+	// Peers of a Unix domain socket have no address
+	r.RemoteAddr = "@"
+	if got := p.clientIP(r); got.IsValid() {
+		t.Errorf("clientIP = %s for an untrusted socket peer", got)
+	}
+	cfg.Proxy.TrustedProxies = []string{"unix"}
+	if p, err = newTrustedProxies(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.clientIP(r).String(); got != "198.51.100.1" {
+		t.Errorf("clientIP = %s for a trusted socket peer", got)
+	}
+
+	cfg.Proxy.TrustedProxies = []string{"10.0.0.0/33"}
+	if _, err := newTrustedProxies(cfg); err == nil {
+		t.Error("Expected an error for an invalid range")
+	}
+}
+
+func TestProxyHeaders(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.Proxy.TrustedProxies = []string{"127.0.0.1"}
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote     string
+		header     map[string]string
+		addr, site string
+	}{
+		{"127.0.0.1:5000", map[string]string{"X-Real-IP": "198.51.100.7", "X-Forwarded-Proto": "https"}, "198.51.100.7", "https://docs.example.com"},
+		{"127.0.0.1:5000", map[string]string{"X-Forwarded-For": "198.51.100.8", "X-Real-IP": "198.51.100.7"}, "198.51.100.8", "http://docs.example.com"},
+		{"127.0.0.1:5000", map[string]string{"X-Forwarded-Proto": "https, http"}, "127.0.0.1", "https://docs.example.com"},
+		{"203.0.113.5:5000", map[string]string{"X-Real-IP": "198.51.100.7", "X-Forwarded-Proto": "https"}, "203.0.113.5:5000", "http://docs.example.com"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://docs.example.com/", nil)
+		r.RemoteAddr = tt.remote
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		if got := srv.proxies.clientAddr(r); got != tt.addr {
+			t.Errorf("%s %v: clientAddr = %s, want %s", tt.remote, tt.header, got, tt.addr)
+		}
+		if got := srv.siteURL(r); got != tt.site {
+			t.Errorf("%s %v: siteURL = %s, want %s", tt.remote, tt.header, got, tt.site)
+		}
+	}
+}
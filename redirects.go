@@ -0,0 +1,88 @@
+package gomadore
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// RedirectConfig redirects requests for a moved page or directory.
+type RedirectConfig struct {
+	From   string `toml:"from" validate:"required,startswith=/"`
+	To     string `toml:"to" validate:"required"`
+	Status int    `toml:"status" validate:"omitempty,oneof=301 302 303 307 308"`
+}
+
+// --- Redirect Rules ---
+
+// redirectRules matches request paths against the [[redirect]] rules.
+// Every method is safe to call on nil (no rules).
+type redirectRules struct {
+	exact  map[string]RedirectConfig
+	prefix []RedirectConfig // "/old/*" rules, longest From first
+}
+
+// newRedirectRules returns nil if no rules are configured. A From ending in
+// "*" matches every path with that prefix; a "*" in To is replaced by the
+// rest of the path.
+func newRedirectRules(rules []RedirectConfig) (*redirectRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	rr := &redirectRules{exact: make(map[string]RedirectConfig)}
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if seen[rule.From] {
+			return nil, fmt.Errorf("%s: redirected twice", rule.From)
+		}
+		seen[rule.From] = true
+		if rule.From == rule.To {
+			return nil, fmt.Errorf("%s: redirects to itself", rule.From)
+		}
+		rule.Status = cmp.Or(rule.Status, http.StatusMovedPermanently)
+		if strings.HasSuffix(rule.From, "*") {
+			rr.prefix = append(rr.prefix, rule)
+		} else {
+			rr.exact[rule.From] = rule
+		}
+	}
+	slices.SortFunc(rr.prefix, func(a, b RedirectConfig) int { return len(b.From) - len(a.From) })
+	return rr, nil
+}
+
+// match returns the target and status code of the rule for a request path.
+func (rr *redirectRules) match(urlPath string) (string, int, bool) {
+	if rr == nil {
+		return "", 0, false
+	}
+	if rule, ok := rr.exact[urlPath]; ok {
+		return rule.To, rule.Status, true
+	}
+	for _, rule := range rr.prefix {
+		if rest, ok := strings.CutPrefix(urlPath, strings.TrimSuffix(rule.From, "*")); ok {
+			return strings.Replace(rule.To, "*", rest, 1), rule.Status, true
+		}
+	}
+	return "", 0, false
+}
+
+// serveRedirect redirects a request matching a [[redirect]] rule, or else
+// the front matter alias of a page to the page, keeping the query string
+// unless the target has one. It reports whether it did.
+func (s *Server) serveRedirect(w http.ResponseWriter, r *http.Request) bool {
+	to, status, ok := s.redirects.match(r.URL.Path)
+	if !ok {
+		p, err := s.pages.aliased(r.URL.Path)
+		if err != nil || p == nil {
+			return false
+		}
+		to, status = p.URL, http.StatusMovedPermanently
+	}
+	if r.URL.RawQuery != "" && !strings.Contains(to, "?") {
+		to += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, to, status)
+	return true
+}
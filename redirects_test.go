@@ -0,0 +1,84 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectRules(t *testing.T) {
+	rr, err := newRedirectRules([]RedirectConfig{
+		{From: "/install", To: "/guide/setup"},
+		{From: "/old/*", To: "/new/*", Status: http.StatusFound},
+		{From: "/old/api/*", To: "https://api.example.com/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		to     string
+		status int
+	}{
+		{"/install", "/guide/setup", http.StatusMovedPermanently},
+		{"/install/", "", 0},
+		{"/old/a/b", "/new/a/b", http.StatusFound},
+		{"/old/", "/new/", http.StatusFound},
+		{"/old/api/v1", "https://api.example.com/", http.StatusMovedPermanently},
+		{"/other", "", 0},
+	}
+	for _, tt := range tests {
+		to, status, ok := rr.match(tt.path)
+		if to != tt.to || status != tt.status || ok != (tt.to != "") {
+			t.Errorf("match(%q) = %q, %d, %v; want %q, %d", tt.path, to, status, ok, tt.to, tt.status)
+		}
+	}
+
+	for _, rules := range [][]RedirectConfig{
+		{{From: "/a", To: "/b"}, {From: "/a", To: "/c"}},
+		{{From: "/a", To: "/a"}},
+	} {
+		if _, err := newRedirectRules(rules); err == nil {
+			t.Errorf("Expected an error for %v", rules)
+		}
+	}
+}
+
+func TestRedirectRequest(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	rr, err := newRedirectRules([]RedirectConfig{{From: "/about", To: "/sub/deep"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.redirects = rr
+
+	// about.md exists, but the rule is applied first
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/about?ref=x", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/sub/deep?ref=x" {
+		t.Errorf("Unexpected redirect: %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestAliasRedirect(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "moved.md", "---\naliases: [\"/old/path\", legacy, /about]\n---\n# Moved\n")
+
+	tests := []struct {
+		path string
+		code int
+		loc  string
+	}{
+		{"/old/path", http.StatusMovedPermanently, "/moved"},
+		{"/legacy/?x=1", http.StatusMovedPermanently, "/moved?x=1"},
+		{"/legacy.html", http.StatusMovedPermanently, "/moved"},
+		{"/about", http.StatusOK, ""}, // existing pages win
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.loc {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Header().Get("Location"), tt.code, tt.loc)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package gomadore
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Defaults of [cache.redis]
+	defaultRedisTTL       = 24 * 60 * 60 // seconds
+	defaultRedisKeyPrefix = "gomadore:"
+
+	// Timeout of connecting to Redis and of each command
+	redisTimeout = 2 * time.Second
+	// Idle connections kept open
+	redisMaxIdle = 8
+)
+
+// --- Redis Cache ---
+
+// redisCache keeps rendered pages in Redis, so that several replicas
+// behind a load balancer render each page once. A page is stored under a
+// key made of the key prefix, a hash of the site (version, configuration
+// and templates), a hash of the content (names and contents of the files
+// under the content roots) and a hash of its cache key. Replicas with the
+// same configuration and content therefore share pages, a change to either
+// starts over with new keys, and pages of older content expire after ttl.
+type redisCache struct {
+	client *redisClient
+	prefix string // key prefix and site hash
+	ttl    int    // seconds
+	roots  []string
+
+	mu      sync.RWMutex
+	gen     string // "": the content root cannot be read
+	failing atomic.Bool
+}
+
+// newRedisCache connects to the Redis server of [cache.redis]. An
+// unreachable server is logged, not an error: pages are then rendered as
+// without a shared cache until it is back.
+func newRedisCache(cfg Config, templates ...*template.Template) (*redisCache, error) {
+	rc := cfg.Cache.Redis
+	var roots []string
+	for _, dir := range cfg.HTML.MarkdownRootDir {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	// Content roots may be at other paths on other replicas
+	cfg.HTML.MarkdownRootDir = nil
+	site, err := siteHash(cfg, nil, templates...)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisCache{
+		client: &redisClient{addr: rc.Address, password: rc.Password, db: rc.DB, idle: make(chan *redisConn, redisMaxIdle)},
+		prefix: cmp.Or(rc.KeyPrefix, defaultRedisKeyPrefix) + site + ":",
+		ttl:    cmp.Or(rc.TTL, defaultRedisTTL),
+		roots:  roots,
+	}
+	if _, err := c.client.do("PING"); err != nil {
+		c.failing.Store(true)
+		slog.Warn("Redis cache unavailable", "addr", rc.Address, "err", err)
+	}
+	c.refresh()
+	return c, nil
+}
+
+// key returns the key of a page. Modification times differ between
+// replicas, so pages are only told apart by the content hash.
+func (c *redisCache) key(cacheKey string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gen == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(cacheKey))
+	return c.prefix + c.gen + ":" + hex.EncodeToString(sum[:16]), true
+}
+
+func (c *redisCache) get(cacheKey string, _ time.Time) ([]byte, bool) {
+	key, ok := c.key(cacheKey)
+	if !ok {
+		return nil, false
+	}
+	v, err := c.client.do("GET", key)
+	c.result(err)
+	b, ok := v.([]byte)
+	return b, ok
+}
+
+func (c *redisCache) put(cacheKey string, _ time.Time, content []byte) {
+	key, ok := c.key(cacheKey)
+	if !ok {
+		return
+	}
+	_, err := c.client.do("SET", key, content, "EX", strconv.Itoa(c.ttl))
+	c.result(err)
+}
+
+// result logs the first failure of a series, and the recovery.
+func (c *redisCache) result(err error) {
+	if err != nil {
+		if !c.failing.Swap(true) {
+			slog.Warn("Redis cache unavailable", "addr", c.client.addr, "err", err)
+		}
+		return
+	}
+	if c.failing.Swap(false) {
+		slog.Info("Redis cache available again", "addr", c.client.addr)
+	}
+}
+
+// refresh hashes the content again (pages of other content are left to
+// expire).
+func (c *redisCache) refresh() {
+	gen, err := contentHash(c.roots, "", true)
+	if err != nil {
+		slog.Warn("Redis cache disabled; cannot read the content", "err", err)
+		gen = ""
+	}
+	c.mu.Lock()
+	c.gen = gen
+	c.mu.Unlock()
+}
+
+// --- Redis Client (RESP2) ---
+
+// redisError is an error reply of the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisClient is a minimal Redis client for the few commands the cache
+// needs, keeping up to redisMaxIdle connections open. Addresses starting
+// with "/" are Unix sockets.
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *redisClient) dial() (*redisConn, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.addr, "/") {
+		network = "unix"
+	}
+	nc, err := net.DialTimeout(network, c.addr, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.password != "" {
+		if _, err := conn.do("AUTH", c.password); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(c.db)); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// do sends a command (string or []byte arguments) and returns its reply:
+// a string, an int64, a []byte, a []any, or nil.
+func (c *redisClient) do(args ...any) (any, error) {
+	var conn *redisConn
+	select {
+	case conn = <-c.idle:
+	default:
+		var err error
+		if conn, err = c.dial(); err != nil {
+			return nil, err
+		}
+	}
+	v, err := conn.do(args...)
+	if err != nil && !errors.As(err, new(redisError)) {
+		_ = conn.Close() // the connection may be out of sync
+		return nil, err
+	}
+	select {
+	case c.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+	return v, err
+}
+
+func (conn *redisConn) do(args ...any) (any, error) {
+	if err := conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		return nil, err
+	}
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, a := range args {
+		var b []byte
+		switch a := a.(type) {
+		case string:
+			b = []byte(a)
+		case []byte:
+			b = a
+		default:
+			return nil, fmt.Errorf("redis: unsupported argument type %T", a)
+		}
+		buf = fmt.Appendf(buf, "$%d\r\n", len(b))
+		buf = append(buf, b...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRedisReply(conn.r)
+}
+
+func readRedisReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // $-1: no value
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		list := make([]any, n)
+		for i := range list {
+			if list[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,156 @@
+package gomadore
+
+import (
+	"bufio"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is an in-memory Redis server answering PING, AUTH, GET and SET.
+type fakeRedis struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	data map[string][]byte
+	sets int
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, data: make(map[string][]byte)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := readRedisReply(r) // commands are arrays of bulk strings
+		if err != nil {
+			return
+		}
+		args, _ := v.([]any)
+		if len(args) == 0 {
+			return
+		}
+		cmd, _ := args[0].([]byte)
+		f.mu.Lock()
+		reply := "-ERR unknown command\r\n"
+		switch strings.ToUpper(string(cmd)) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "AUTH":
+			reply = "-WRONGPASS invalid password\r\n"
+			if string(args[1].([]byte)) == "s3cret" {
+				reply = "+OK\r\n"
+			}
+		case "GET":
+			reply = "$-1\r\n"
+			if b, ok := f.data[string(args[1].([]byte))]; ok {
+				reply = "$" + strconv.Itoa(len(b)) + "\r\n" + string(b) + "\r\n"
+			}
+		case "SET":
+			f.data[string(args[1].([]byte))] = args[2].([]byte)
+			f.sets++
+			reply = "+OK\r\n"
+		}
+		f.mu.Unlock()
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	f := newFakeRedis(t)
+	// replica starts a server for a content root like another replica would
+	replica := func() (*Server, string) {
+		t.Helper()
+		srv, dir := setupTestServer(t)
+		cfg := srv.config
+		cfg.Cache.Redis.Enabled = true
+		cfg.Cache.Redis.Address = f.ln.Addr().String()
+		cfg.Cache.Redis.Password = "s3cret"
+		tmpl, _ := template.New("base").Parse(`{{.Body}}`)
+		srv, err := newServer(cfg, tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return srv, dir
+	}
+	get := func(srv *Server, p string) string {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w.Body.String()
+	}
+	sets := func() int {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.sets
+	}
+
+	a, _ := replica()
+	if body := get(a, "/about"); !strings.Contains(body, "About") {
+		t.Fatalf("Unexpected page %s", body)
+	}
+	if sets() != 1 {
+		t.Fatalf("Expected the rendered page to be stored, got %d SETs", sets())
+	}
+
+	// Another replica (other path, other modification times) reads it
+	b, dir := replica()
+	if body := get(b, "/about"); !strings.Contains(body, "About") || sets() != 1 {
+		t.Errorf("Expected the shared page, got %d SETs: %s", sets(), body)
+	}
+
+	// Changed content uses new keys
+	createFile(t, dir, "about.md", "# Changed About")
+	b.purgeCache()
+	if body := get(b, "/about"); !strings.Contains(body, "Changed About") || sets() != 2 {
+		t.Errorf("Expected the changed page to be rendered, got %d SETs: %s", sets(), body)
+	}
+
+	// A wrong password or an unreachable server only disables sharing
+	b.config.Cache.Redis.Password = "wrong"
+	tmpl, _ := template.New("base").Parse(`{{.Body}}`)
+	c, err := newServer(b.config, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := get(c, "/about"); !strings.Contains(body, "Changed About") {
+		t.Errorf("Expected a rendered page without Redis, got %s", body)
+	}
+	if !c.store.(*redisCache).failing.Load() {
+		t.Error("Expected the failure to be noticed")
+	}
+}
+
+func TestNewPageStore(t *testing.T) {
+	var cfg Config
+	if s, err := newPageStore(cfg); s != nil || err != nil {
+		t.Errorf("Expected no store, got %v, %v", s, err)
+	}
+	cfg.Cache.CacheDir = t.TempDir()
+	cfg.Cache.Redis.Enabled = true
+	if _, err := newPageStore(cfg); err == nil {
+		t.Error("cache_dir and [cache.redis] should not be accepted together")
+	}
+}
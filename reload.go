@@ -0,0 +1,184 @@
+package gomadore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- Configuration Reload (SIGHUP) ---
+
+// liveState is a running Server with its handler and the cancel function of
+// its background goroutines (cache cleaner, file watcher).
+type liveState struct {
+	srv     *Server
+	handler http.Handler
+	cancel  context.CancelFunc
+}
+
+// liveServer serves requests with the current Server. On reload the Server is
+// replaced as a whole, so requests in flight finish with the old one and the
+// new one starts with an empty cache.
+type liveServer struct {
+	mu      sync.Mutex // serializes reloads
+	current atomic.Pointer[liveState]
+}
+
+// newLiveServer starts the background goroutines of srv and serves it.
+func newLiveServer(srv *Server) *liveServer {
+	l := &liveServer{}
+	l.current.Store(startServer(srv))
+	return l
+}
+
+func startServer(srv *Server) *liveState {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.Start(ctx)
+	return &liveState{srv: srv, handler: srv.handler(), cancel: cancel}
+}
+
+// start starts the background goroutines of a site until ctx is done.
+func (s *Server) start(ctx context.Context) {
+	// Build the page index and the navigation tree before the first
+	// request, and the search index in the background
+	if pages, err := s.pages.all(); err != nil {
+		slog.Warn("Failed to build page index", "err", err)
+	} else {
+		slog.Debug("Page index built", "pages", len(pages))
+	}
+	s.nav.get("")
+	go s.search.prepare()
+
+	// Render every page before the listener accepts requests (or before a
+	// reloaded server replaces the running one)
+	if s.config.Cache.WarmCache {
+		s.warmCache()
+	}
+
+	// Start background cache cleaner (Garbage Collection)
+	// Only start if a CacheLimit (of any site) is positive.
+	// If CacheLimit <= 0, cache is treated as indefinite (never expires), so GC is not needed.
+	// The interval is half of the cache limit, with a minimum of 60 seconds
+	// to prevent excessive locking overhead.
+	if cleanupInterval := s.cacheGCInterval(); cleanupInterval > 0 {
+		go s.startCacheCleaner(ctx, cleanupInterval)
+	}
+
+	if s.config.Cache.StatsInterval > 0 {
+		go s.startCacheStatsLogger(ctx, time.Duration(s.config.Cache.StatsInterval)*time.Second)
+	}
+
+	s.webmentions.start(ctx)
+
+	// Setup Hot Reload if enabled
+	if s.config.Cache.HotReload {
+		go s.watchFiles(ctx)
+	}
+}
+
+// server returns the Server currently handling requests.
+func (l *liveServer) server() *Server {
+	return l.current.Load().srv
+}
+
+func (l *liveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.current.Load().handler.ServeHTTP(w, r)
+}
+
+// swap starts next and makes it handle all new requests, then stops the
+// background goroutines of the previous Server.
+func (l *liveServer) swap(next *Server) {
+	next.webmentions.takeOver(l.server().webmentions)
+	prev := l.current.Swap(startServer(next))
+	prev.cancel()
+	for _, s := range prev.srv.servers() {
+		s.liveReload.close(true)
+	}
+	if err := prev.srv.accessLog.close(); err != nil {
+		slog.Warn("Failed to close access log", "err", err)
+	}
+}
+
+// stop stops the background goroutines of the current Server.
+func (l *liveServer) stop() {
+	cur := l.current.Load()
+	cur.cancel()
+	for _, s := range cur.srv.servers() {
+		s.liveReload.close(false)
+	}
+	_ = cur.srv.accessLog.close()
+}
+
+// restartSettings returns the settings that differ between the running
+// and the new configuration but cannot be applied by a reload: the
+// listeners and the watchers are only set up at startup.
+func restartSettings(prev, next Config) []string {
+	var changed []string
+	for _, c := range []struct {
+		name string
+		diff bool
+	}{
+		{"listen_addr", next.General.ListenAddr != prev.General.ListenAddr},
+		{"listen_port", next.General.ListenPort != prev.General.ListenPort},
+		{"socket_mode", next.General.SocketMode != prev.General.SocketMode},
+		{"pid_file", next.General.PIDFile != prev.General.PIDFile},
+		{"read_header_timeout", next.General.ReadHeaderTimeout != prev.General.ReadHeaderTimeout},
+		{"read_timeout", next.General.ReadTimeout != prev.General.ReadTimeout},
+		{"write_timeout", next.General.WriteTimeout != prev.General.WriteTimeout},
+		{"idle_timeout", next.General.IdleTimeout != prev.General.IdleTimeout},
+		{"max_header_bytes", next.General.MaxHeaderBytes != prev.General.MaxHeaderBytes},
+		{"watch_config", next.General.WatchConfig != prev.General.WatchConfig},
+		{"[tls]", !reflect.DeepEqual(next.TLS, prev.TLS)},
+		{"[metrics]", next.Metrics != prev.Metrics},
+	} {
+		if c.diff {
+			changed = append(changed, c.name)
+		}
+	}
+	return changed
+}
+
+// reload re-reads the configuration file and the template, and swaps in a new
+// Server built from them. On any error the running Server is kept.
+// Listener settings ([general] listen address/port and timeouts, [tls],
+// [metrics]) only take effect on restart.
+func (l *liveServer) reload(configPath, tmplFlag, forcedTitle string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("load configuration file (%s): %w", configPath, err)
+	}
+	t, _, tmplPath, err := loadTemplate(tmplFlag, cfg)
+	if err != nil {
+		return fmt.Errorf("load HTML template (%s): %w", tmplPath, err)
+	}
+	next, err := newServer(cfg, t)
+	if err != nil {
+		return fmt.Errorf("initialize server: %w", err)
+	}
+	next.forcedTitle = forcedTitle
+
+	prev := l.server()
+	if changed := restartSettings(prev.config, cfg); len(changed) > 0 {
+		slog.Warn("Settings changed that only take effect after a restart; keeping the running values", "settings", changed)
+	}
+
+	// The metrics listener keeps serving the same collector
+	for _, s := range next.servers() {
+		s.metrics = prev.metrics
+	}
+	next.metrics.setCacheStats(next.cacheStats)
+
+	if err := setupLogOutput(cfg); err != nil {
+		slog.Error("Failed to open log file; keeping the previous one", "file", cfg.General.LogFile, "err", err)
+	}
+	l.swap(next)
+	return nil
+}
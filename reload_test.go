@@ -0,0 +1,124 @@
+package gomadore
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLiveServerReload(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "docs")
+	createFile(t, dir, "base.html", "v1:{{.Title}}")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, root, "index.md", "# Top")
+
+	writeConfig := func(title string) string {
+		createFile(t, dir, "config.toml", fmt.Sprintf(
+			"[general]\nlisten_addr = \"127.0.0.1\"\nlisten_port = 8080\n[html]\nmarkdown_rootdir = %q\nsite_title = %q\ntemplate_filepath = %q\n",
+			root, title, filepath.Join(dir, "base.html")))
+		return filepath.Join(dir, "config.toml")
+	}
+	configPath := writeConfig("Site A")
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, _, _, err := loadTemplate("", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := newServer(cfg, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := newLiveServer(srv)
+	defer live.stop()
+
+	get := func() string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		live.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		body, _ := io.ReadAll(w.Result().Body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		return string(body)
+	}
+
+	if body := get(); body != "v1:Top - Site A" {
+		t.Fatalf("Unexpected body before reload: %q", body)
+	}
+
+	t.Run("Applies config and template", func(t *testing.T) {
+		writeConfig("Site B")
+		createFile(t, dir, "base.html", "v2:{{.Title}}")
+		if err := live.reload(configPath, "", ""); err != nil {
+			t.Fatalf("reload failed: %v", err)
+		}
+		if live.server() == srv {
+			t.Fatal("Server was not swapped")
+		}
+		if n := live.server().cache.len(); n != 0 {
+			t.Errorf("Expected an empty cache after reload, got %d items", n)
+		}
+		if body := get(); body != "v2:Top - Site B" {
+			t.Errorf("Unexpected body after reload: %q", body)
+		}
+	})
+
+	t.Run("Keeps the running server on error", func(t *testing.T) {
+		prev := live.server()
+		createFile(t, dir, "config.toml", "[general]\nlog_level = \"verbose\"\n")
+		if err := live.reload(configPath, "", ""); err == nil || !strings.Contains(err.Error(), "log_level") {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+		writeConfig("Site C")
+		createFile(t, dir, "base.html", "{{.Title")
+		if err := live.reload(configPath, "", ""); err == nil {
+			t.Error("Expected template parse error")
+		}
+		if live.server() != prev {
+			t.Error("Server was swapped despite errors")
+		}
+		if body := get(); body != "v2:Top - Site B" {
+			t.Errorf("Unexpected body: %q", body)
+		}
+	})
+
+	t.Run("Forced title", func(t *testing.T) {
+		createFile(t, dir, "base.html", "v3:{{.Title}}")
+		if err := live.reload(configPath, "", "Forced"); err != nil {
+			t.Fatalf("reload failed: %v", err)
+		}
+		if body := get(); body != "v3:Forced" {
+			t.Errorf("Unexpected body: %q", body)
+		}
+	})
+}
+
+func TestRestartSettings(t *testing.T) {
+	var prev Config
+	prev.General.ListenAddr = "127.0.0.1"
+	prev.HTML.SiteTitle = "Old"
+	next := prev
+	next.HTML.SiteTitle = "New"
+	next.Cache.CacheLimit = 600
+	next.General.LogLevel = "debug"
+	if changed := restartSettings(prev, next); len(changed) != 0 {
+		t.Errorf("Expected no restart for runtime settings, got %v", changed)
+	}
+	next.General.ListenAddr = "0.0.0.0"
+	next.TLS.Enabled = true
+	if changed := restartSettings(prev, next); strings.Join(changed, ",") != "listen_addr,[tls]" {
+		t.Errorf("Unexpected restart settings %v", changed)
+	}
+}
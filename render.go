@@ -0,0 +1,118 @@
+package gomadore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	// Default limit of a single template execution (seconds)
+	defaultRenderTimeout = 10
+	// Default maximum size of a rendered page (bytes)
+	defaultMaxOutputBytes = 16 << 20
+)
+
+var (
+	errRenderTimeout  = errors.New("template execution timed out")
+	errOutputTooLarge = errors.New("template output exceeds max_output_bytes")
+)
+
+// --- Template Execution Safety ---
+
+// applyTemplateOptions sets the configured "missingkey" behaviour on a template.
+func applyTemplateOptions(t *template.Template, cfg Config) {
+	if t != nil && cfg.Template.MissingKey != "" {
+		t.Option("missingkey=" + cfg.Template.MissingKey)
+	}
+}
+
+// guardedWriter buffers template output and aborts the execution once the
+// size limit is exceeded or the context is done.
+type guardedWriter struct {
+	ctx   context.Context
+	buf   bytes.Buffer
+	limit int // <= 0: unlimited
+}
+
+func (w *guardedWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, errRenderTimeout
+	}
+	if w.limit > 0 && w.buf.Len()+len(p) > w.limit {
+		return 0, errOutputTooLarge
+	}
+	return w.buf.Write(p)
+}
+
+// executeTemplate renders a template with the configured render timeout and
+// output size cap. The result is minified and gets the live reload script if
+// enabled.
+func (s *Server) executeTemplate(t *template.Template, data any) ([]byte, error) {
+	timeout := s.config.Template.RenderTimeout
+	if timeout <= 0 {
+		w := &guardedWriter{ctx: context.Background(), limit: s.config.Template.MaxOutputBytes}
+		if err := t.Execute(w, data); err != nil {
+			return nil, fmt.Errorf("template %q: %w", t.Name(), err)
+		}
+		return s.finishPage(w.buf.Bytes()), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	w := &guardedWriter{ctx: ctx, limit: s.config.Template.MaxOutputBytes}
+
+	// A template that loops without writing cannot be interrupted; the
+	// request is answered when the timeout expires and the execution is
+	// abandoned (it stops at its next write).
+	done := make(chan error, 1)
+	go func() {
+		done <- t.Execute(w, data)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", t.Name(), err)
+		}
+		return s.finishPage(w.buf.Bytes()), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("template %q: %w", t.Name(), errRenderTimeout)
+	}
+}
+
+// finishPage applies the configured post-processing to template output.
+func (s *Server) finishPage(b []byte) []byte {
+	if s.config.HTML.MinifyHTML {
+		b = minifyHTML(b)
+	}
+	return encodePage(s.liveReload.inject(b), s.config.HTML.Charset)
+}
+
+// --- Heading Adjustment ---
+
+// adjustHeadings removes the first top-level H1 of a document (if strip) and
+// then moves every heading down by offset levels (at most to H6).
+func adjustHeadings(doc ast.Node, strip bool, offset int) {
+	if strip {
+		for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+			if h, ok := n.(*ast.Heading); ok && h.Level == 1 {
+				doc.RemoveChild(doc, n)
+				break
+			}
+		}
+	}
+	if offset <= 0 {
+		return
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			h.Level = min(h.Level+offset, 6)
+		}
+		return ast.WalkContinue, nil
+	})
+}
@@ -0,0 +1,133 @@
+package gomadore
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowData blocks template execution (for render timeout tests)
+type slowData struct{}
+
+func (slowData) Slow() string {
+	time.Sleep(2 * time.Second)
+	return "done"
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	t.Run("Success", func(t *testing.T) {
+		out, err := srv.executeTemplate(template.Must(template.New("t").Parse(`<p>{{.}}</p>`)), "hello")
+		if err != nil || string(out) != "<p>hello</p>" {
+			t.Errorf("Unexpected result: %q, %v", out, err)
+		}
+	})
+
+	t.Run("Output size cap", func(t *testing.T) {
+		srv.config.Template.MaxOutputBytes = 100
+		defer func() { srv.config.Template.MaxOutputBytes = 0 }()
+
+		tmpl := template.Must(template.New("t").Parse(`{{range .}}0123456789{{end}}`))
+		if _, err := srv.executeTemplate(tmpl, make([]int, 10)); err != nil {
+			t.Errorf("100 bytes should be allowed: %v", err)
+		}
+		if _, err := srv.executeTemplate(tmpl, make([]int, 11)); !errors.Is(err, errOutputTooLarge) {
+			t.Errorf("Expected errOutputTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("Render timeout", func(t *testing.T) {
+		srv.config.Template.RenderTimeout = 1
+		defer func() { srv.config.Template.RenderTimeout = 0 }()
+
+		start := time.Now()
+		_, err := srv.executeTemplate(template.Must(template.New("t").Parse(`{{.Slow}}`)), slowData{})
+		if !errors.Is(err, errRenderTimeout) {
+			t.Errorf("Expected errRenderTimeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+			t.Errorf("Timeout not enforced: took %v", elapsed)
+		}
+	})
+}
+
+func TestTemplateMissingKey(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	render := func(t *testing.T, missingKey string) *httptest.ResponseRecorder {
+		t.Helper()
+		srv.config.Template.MissingKey = missingKey
+		srv.tmpl = template.Must(template.New("base").Parse(`[{{.NoSuchKey}}]{{.Body}}`))
+		applyTemplateOptions(srv.tmpl, srv.config)
+		srv.purgeCache()
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	if w := render(t, "zero"); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "[]") {
+		t.Errorf("missing_key=zero: got %d %q", w.Code, w.Body.String())
+	}
+	if w := render(t, "error"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "[") {
+		t.Errorf("missing_key=error: expected 500 without partial output, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestAdjustHeadings(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "headings.md", "# Title\n\nIntro\n\n## Section\n\n# Second H1\n\n###### Deep")
+
+	render := func(t *testing.T, strip bool, offset int) string {
+		t.Helper()
+		srv.config.HTML.StripFirstH1 = strip
+		srv.config.HTML.HeadingOffset = offset
+		srv.tmpl = template.Must(template.New("base").Parse(`<title>{{.Title}}</title>{{.Body}}`))
+		srv.purgeCache()
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/headings", nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w.Body.String()
+	}
+
+	tests := []struct {
+		name    string
+		strip   bool
+		offset  int
+		want    []string
+		notWant []string
+	}{
+		{"Default", false, 0,
+			[]string{`<h1 id="title">Title</h1>`, `<h2 id="section">`, `<h1 id="second-h1">`, `<h6 id="deep">`}, nil},
+		{"Strip", true, 0,
+			[]string{`<h2 id="section">`, `<h1 id="second-h1">`}, []string{`<h1 id="title">`}},
+		{"Offset", false, 1,
+			[]string{`<h2 id="title">Title</h2>`, `<h3 id="section">`, `<h2 id="second-h1">`, `<h6 id="deep">`}, []string{"<h1", "<h7"}},
+		{"Strip and offset", true, 1,
+			[]string{`<h3 id="section">`, `<h2 id="second-h1">`}, []string{`id="title"`, "<h1"}},
+	}
+	for _, tt := range tests {
+		body := render(t, tt.strip, tt.offset)
+		// The title is always taken from the first H1
+		if !strings.Contains(body, "<title>Title - </title>") {
+			t.Errorf("%s: title mismatch: %s", tt.name, body)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s: body should contain %q: %s", tt.name, s, body)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(body, s) {
+				t.Errorf("%s: body should not contain %q: %s", tt.name, s, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gomadore
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// --- robots.txt ---
+
+// robotsTxt serves /robots.txt: the configured file, or rules generated from
+// the disallow and allow lists.
+type robotsTxt struct {
+	body    []byte // the configured file (nil: generated)
+	rules   string // generated User-agent group
+	sitemap bool   // add the sitemap URL to generated rules
+}
+
+// newRobotsTxt builds /robots.txt from the configuration (nil if disabled).
+func newRobotsTxt(cfg Config) (*robotsTxt, error) {
+	if !cfg.Robots.Enabled {
+		return nil, nil
+	}
+	if cfg.Robots.File != "" {
+		b, err := os.ReadFile(cfg.Robots.File)
+		if err != nil {
+			return nil, err
+		}
+		return &robotsTxt{body: b}, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range cfg.Robots.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", p)
+	}
+	for _, p := range cfg.Robots.Allow {
+		fmt.Fprintf(&b, "Allow: %s\n", p)
+	}
+	if len(cfg.Robots.Disallow) == 0 && len(cfg.Robots.Allow) == 0 {
+		b.WriteString("Disallow:\n") // everything is allowed
+	}
+	return &robotsTxt{rules: b.String(), sitemap: cfg.Sitemap.Enabled}, nil
+}
+
+// handleRobots serves /robots.txt. The sitemap is given by absolute URL, of
+// the site of the request's host.
+func (s *Server) handleRobots(w http.ResponseWriter, r *http.Request) {
+	body := s.robots.body
+	if body == nil {
+		text := s.robots.rules
+		if s.robots.sitemap {
+			text += "\nSitemap: " + s.siteURL(r) + "/sitemap.xml\n"
+		}
+		body = []byte(text)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "max-age=3600")
+	_ = writeBody(w, body)
+}
@@ -0,0 +1,97 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.Robots.Enabled = true
+	srv.config.Robots.Disallow = []string{"/private/"}
+	srv.config.Robots.Allow = []string{"/private/press/"}
+	srv.config.Sitemap.Enabled = true
+	srv.config.HTML.SiteURL = "https://example.com/"
+
+	get := func(srv *Server) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+		return w
+	}
+
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := get(srv)
+	want := "User-agent: *\nDisallow: /private/\nAllow: /private/press/\n\nSitemap: https://example.com/sitemap.xml\n"
+	if w.Code != http.StatusOK || w.Body.String() != want || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("GET /robots.txt: %d %v\n%s", w.Code, w.Header(), w.Body.String())
+	}
+
+	// Nothing disallowed, no sitemap
+	cfg := srv.config
+	cfg.Robots.Disallow, cfg.Robots.Allow = nil, nil
+	cfg.Sitemap.Enabled = false
+	if srv, err = newServer(cfg, srv.tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(srv); w.Body.String() != "User-agent: *\nDisallow:\n" {
+		t.Errorf("Unexpected robots.txt %q", w.Body.String())
+	}
+
+	// A file is served as is
+	file := filepath.Join(t.TempDir(), "robots.txt")
+	if err := os.WriteFile(file, []byte("User-agent: *\nDisallow: /\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Robots.File = file
+	if srv, err = newServer(cfg, srv.tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(srv); w.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("Unexpected robots.txt %q", w.Body.String())
+	}
+
+	// Disabled
+	cfg.Robots.Enabled = false
+	if srv, err = newServer(cfg, srv.tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(srv); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when disabled, got %d", w.Code)
+	}
+}
+
+func TestNoIndex(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	tmpl, _, _, err := loadTemplate("", srv.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.config.Sitemap.Enabled = true
+	if srv, err = newServer(srv.config, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "hidden.md", "---\nnoindex: true\n---\n# Hidden\n")
+
+	get := func(p string) string {
+		w := httptest.NewRecorder()
+		srv.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w.Body.String()
+	}
+	const meta = `<meta name="robots" content="noindex">`
+	if body := get("/hidden"); !strings.Contains(body, meta) {
+		t.Errorf("Missing %s in %s", meta, body)
+	}
+	if body := get("/about"); strings.Contains(body, meta) {
+		t.Errorf("Unexpected %s in %s", meta, body)
+	}
+	if body := get("/sitemap.xml"); strings.Contains(body, "/hidden") || !strings.Contains(body, "/about") {
+		t.Errorf("Expected only indexed pages in the sitemap: %s", body)
+	}
+}
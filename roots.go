@@ -0,0 +1,77 @@
+package gomadore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- Content Roots ---
+
+// RootDirs is markdown_rootdir: one directory ("./docs"), or several merged
+// into one URL space (["./docs", "./shared-docs"]). A path that exists in
+// more than one directory is served from the first, so a project can
+// override single pages, assets and directory configuration of a shared
+// documentation set.
+type RootDirs []string
+
+// UnmarshalTOML implements toml.Unmarshaler: a string or an array of strings.
+func (r *RootDirs) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*r = RootDirs{v}
+	case []any:
+		dirs := make(RootDirs, 0, len(v))
+		for _, d := range v {
+			s, ok := d.(string)
+			if !ok {
+				return fmt.Errorf("markdown_rootdir: expected a string, got %T", d)
+			}
+			dirs = append(dirs, s)
+		}
+		*r = dirs
+	default:
+		return fmt.Errorf("markdown_rootdir: expected a string or an array of strings, got %T", v)
+	}
+	return nil
+}
+
+// primary returns the first directory ("" if there is none).
+func (r RootDirs) primary() string {
+	if len(r) == 0 {
+		return ""
+	}
+	return r[0]
+}
+
+// source returns the directory and the slash separated path (from that
+// directory) of the markdown file of a page ("sub/deep" -> "sub/deep.md"):
+// the first directory that has one, with the first of exts found there.
+func (r RootDirs) source(stem string, exts []string) (root, rel string, ok bool) {
+	for _, root := range r {
+		file, ok := findMarkdownFile(filepath.Join(root, filepath.FromSlash(stem)), exts)
+		if !ok {
+			continue
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			continue
+		}
+		return root, filepath.ToSlash(rel), true
+	}
+	return "", "", false
+}
+
+// relPath returns the directory a file is under and the slash separated
+// path of the file from there, and false if it is under none of them.
+func (r RootDirs) relPath(file string) (root, rel string, ok bool) {
+	for _, root := range r {
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			continue
+		}
+		return root, filepath.ToSlash(rel), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,116 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestRootDirsTOML(t *testing.T) {
+	tests := []struct {
+		src  string
+		want RootDirs
+		ok   bool
+	}{
+		{`markdown_rootdir = "./docs"`, RootDirs{"./docs"}, true},
+		{`markdown_rootdir = ["./docs", "./shared"]`, RootDirs{"./docs", "./shared"}, true},
+		{`markdown_rootdir = 1`, nil, false},
+		{`markdown_rootdir = ["./docs", 1]`, nil, false},
+	}
+	for _, tt := range tests {
+		var cfg struct {
+			Dirs RootDirs `toml:"markdown_rootdir"`
+		}
+		_, err := toml.Decode(tt.src, &cfg)
+		if (err == nil) != tt.ok || !slices.Equal(cfg.Dirs, tt.want) {
+			t.Errorf("Decode(%s) = %v, %v; want %v", tt.src, cfg.Dirs, err, tt.want)
+		}
+	}
+}
+
+func TestMultipleRoots(t *testing.T) {
+	srv, project := setupTestServer(t)
+	base := t.TempDir()
+	for _, d := range []string{"guide", "img"} {
+		if err := os.MkdirAll(filepath.Join(base, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFile(t, base, "about.md", "# Base About")
+	createFile(t, base, "install.md", "# Install")
+	createFile(t, base, "guide/index.md", "# Guide")
+	createFile(t, base, "img/logo.png", "png")
+
+	srv.config.HTML.MarkdownRootDir = RootDirs{project, base}
+	srv.config.HTML.AutoIndex = true
+	srv.config.Static.Enabled = true
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/about", http.StatusOK, "About"},
+		{"/install", http.StatusOK, "Install"},
+		{"/guide/", http.StatusOK, "Guide"},
+		{"/img/logo.png", http.StatusOK, "png"},
+		{"/sub/deep", http.StatusOK, "Deep"},
+		{"/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GET %s: status %d, body %q; want %d with %q", tt.path, w.Code, w.Body.String(), tt.code, tt.want)
+		}
+	}
+	if body := get("/about").Body.String(); strings.Contains(body, "Base About") {
+		t.Errorf("The first root should take precedence: %s", body)
+	}
+
+	pages, err := srv.pages.all()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, p := range pages {
+		paths = append(paths, p.Path)
+	}
+	for _, want := range []string{"/about", "/guide/index", "/install", "/sub/deep"} {
+		if !slices.Contains(paths, want) {
+			t.Errorf("Page %s missing in %v", want, paths)
+		}
+	}
+	if n := len(slices.Compact(slices.Clone(paths))); n != len(paths) {
+		t.Errorf("Duplicate pages in %v", paths)
+	}
+
+	// Removing the override serves the base page again
+	if err := os.Remove(filepath.Join(project, "about.md")); err != nil {
+		t.Fatal(err)
+	}
+	srv.invalidateFiles([]string{filepath.Join(project, "about.md")})
+	if body := get("/about").Body.String(); !strings.Contains(body, "Base About") {
+		t.Errorf("Expected the base page after removing the override, got %s", body)
+	}
+	pages, _ = srv.pages.all()
+	for _, p := range pages {
+		if p.Path == "/about" && p.Title != "Base About" {
+			t.Errorf("Page index not updated: %+v", p)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gomadore
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Default sanitizer policy
+const defaultSanitizePolicy = "ugc"
+
+// CSS properties of syntax highlighting kept by the sanitizer
+var highlightStyleProps = []string{"color", "background-color", "font-style", "font-weight"}
+
+// Classes of code blocks kept by the sanitizer
+var (
+	languageClass  = regexp.MustCompile(`^language-[\w+#.-]+$`)
+	highlightClass = regexp.MustCompile(`^highlight$`)
+	mathClass      = regexp.MustCompile(`^math (inline|display)$`)
+)
+
+// --- HTML Sanitizing ---
+
+// sanitizer filters rendered markdown through a bluemonday policy.
+// Every method is safe to call on nil (sanitize_html disabled).
+type sanitizer struct {
+	policy *bluemonday.Policy
+}
+
+// newSanitizer returns nil if sanitize_html is disabled.
+//
+//	ugc:    formatting, links, images, lists and tables (links get rel="nofollow")
+//	strict: no HTML at all (text only), plus allow_elements
+func newSanitizer(cfg Config) *sanitizer {
+	if !cfg.HTML.SanitizeHTML {
+		return nil
+	}
+	var p *bluemonday.Policy
+	switch cfg.Sanitize.Policy {
+	case "strict":
+		p = bluemonday.StrictPolicy()
+	default:
+		p = bluemonday.UGCPolicy()
+	}
+	// Language of fenced code blocks
+	p.AllowAttrs("class").Matching(languageClass).OnElements("code")
+	if len(cfg.Sanitize.AllowElements) > 0 {
+		p.AllowElements(cfg.Sanitize.AllowElements...)
+	}
+	if len(cfg.Sanitize.AllowAttributes) > 0 {
+		p.AllowAttrs(cfg.Sanitize.AllowAttributes...).Globally()
+	}
+	if cfg.HTML.HighlightStyle != "" && cfg.HTML.HighlightStyle != "none" {
+		// Highlighted code blocks carry their colors as inline styles
+		p.AllowAttrs("class").Matching(highlightClass).OnElements("pre")
+		p.AllowStyles(highlightStyleProps...).OnElements("pre", "span")
+		p.AllowElements("pre", "code", "span")
+	}
+	if cfg.Math.Enabled {
+		p.AllowAttrs("class").Matching(mathClass).OnElements("span", "div")
+		p.AllowElements("span", "div")
+	}
+	return &sanitizer{policy: p}
+}
+
+// sanitize returns the HTML with everything the policy does not allow removed.
+func (s *sanitizer) sanitize(html string) string {
+	if s == nil {
+		return html
+	}
+	return s.policy.Sanitize(html)
+}
@@ -0,0 +1,84 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSanitizer(t *testing.T) {
+	var cfg Config
+	if s := newSanitizer(cfg); s != nil || s.sanitize("<b>x</b>") != "<b>x</b>" {
+		t.Fatal("Disabled sanitizer should pass HTML through")
+	}
+
+	cfg.HTML.SanitizeHTML = true
+	cfg.Sanitize.Policy = "ugc"
+	s := newSanitizer(cfg)
+	tests := []struct {
+		in, want string
+	}{
+		{`<p>ok<script>alert(1)</script></p>`, `<p>ok</p>`},
+		{`<a href="javascript:alert(1)">x</a>`, `x`},
+		{`<img src="x" onerror="alert(1)">`, `<img src="x">`},
+		{`<h2 id="intro">Intro</h2>`, `<h2 id="intro">Intro</h2>`},
+		{`<iframe src="https://example.com/"></iframe>`, ``},
+		{`<pre style="color:red"><code class="language-go">x</code></pre>`, `<pre><code class="language-go">x</code></pre>`},
+	}
+	for _, tt := range tests {
+		if got := s.sanitize(tt.in); got != tt.want {
+			t.Errorf("ugc: sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	t.Run("Allowed elements and attributes", func(t *testing.T) {
+		cfg := cfg
+		cfg.Sanitize.Policy = "strict"
+		cfg.Sanitize.AllowElements = []string{"details", "summary"}
+		cfg.Sanitize.AllowAttributes = []string{"title"}
+		got := newSanitizer(cfg).sanitize(`<details title="n" onclick="x()"><summary>s</summary><p>p</p></details>`)
+		if got != `<details title="n"><summary>s</summary>p</details>` {
+			t.Errorf("Unexpected output %q", got)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		cfg := cfg
+		cfg.Sanitize.Policy = "strict"
+		if got := newSanitizer(cfg).sanitize(`<p>a <b>b</b></p>`); got != `a b` {
+			t.Errorf("Unexpected output %q", got)
+		}
+	})
+
+	t.Run("Syntax highlighting", func(t *testing.T) {
+		cfg := cfg
+		cfg.HTML.HighlightStyle = "github"
+		in := `<pre class="highlight" style="color:#24292f;background-color:#f6f8fa;"><code class="language-go"><span style="color:#cf222e">func</span></code></pre>`
+		if got := newSanitizer(cfg).sanitize(in); !strings.Contains(got, `<span style="color: #cf222e">func</span>`) || !strings.Contains(got, `class="highlight"`) {
+			t.Errorf("Highlighting removed: %q", got)
+		}
+	})
+}
+
+func TestSanitizeHTMLPage(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SanitizeHTML = true
+	srv.config.Sanitize.Policy = "ugc"
+	srv.sanitizer = newSanitizer(srv.config)
+	srv.config.TOC.MinLevel, srv.config.TOC.MaxLevel = 2, 4
+	createFile(t, dir, "links.md", "# Links\n\n[TOC]\n\n## Part\n\n[site](https://example.com/)\n")
+
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/links", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`<a href="https://example.com/" rel="nofollow">site</a>`,
+		`<h2 id="part">Part</h2>`,
+		`<nav class="toc"><ul><li><a href="#part">Part</a></li></ul></nav>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Body lacks %q: %s", want, body)
+		}
+	}
+}
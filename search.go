@@ -0,0 +1,651 @@
+package gomadore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// Default maximum number of search results
+	defaultSearchMaxResults = 50
+	// Length of a result snippet (runes)
+	searchSnippetLength = 200
+	// Context shown before the first match in a snippet (runes)
+	searchSnippetLead = 60
+	// Removed documents kept at least before the index is compacted
+	searchCompactMin = 64
+)
+
+// --- Full-Text Search ---
+
+// searchDoc is an indexed document (meta is nil for a removed document).
+type searchDoc struct {
+	stem string // page path without the leading slash ("sub/deep")
+	meta *pageMeta
+	text string // plain text of the body
+}
+
+// searchIndex is an in-memory inverted index over all markdown documents.
+// It is built from the page index at startup (or on first use), updated
+// file by file by the watcher, and rebuilt after invalidation.
+type searchIndex struct {
+	mu       sync.Mutex
+	pages    *pageIndex
+	valid    bool
+	docs     []searchDoc
+	ids      map[string]int         // stem -> doc id
+	live     int                    // number of documents not removed
+	postings map[string]map[int]int // term -> doc id -> term frequency
+	titles   map[string]map[int]bool
+
+	protected func(pagePath string) bool // pages left out (see pageProtected)
+}
+
+func newSearchIndex(pages *pageIndex, protected func(pagePath string) bool) *searchIndex {
+	return &searchIndex{pages: pages, protected: protected}
+}
+
+// invalidate forces a rebuild on the next search. Safe to call on nil.
+func (ix *searchIndex) invalidate() {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	ix.valid = false
+	ix.docs, ix.ids, ix.postings, ix.titles = nil, nil, nil, nil
+	ix.mu.Unlock()
+}
+
+// prepare builds the index unless it is already built. Safe to call on nil.
+func (ix *searchIndex) prepare() {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.valid {
+		return
+	}
+	start := time.Now()
+	if err := ix.build(); err != nil {
+		slog.Error("Failed to build search index", "err", err)
+		return
+	}
+	slog.Info("Search index built", "documents", ix.live, "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// update re-indexes changed markdown files (relative, slash separated paths);
+// removed files are dropped from the index, which is compacted once there
+// are more removed documents than others. If the index has not been built
+// yet, it is left to be built on first use. Safe to call on nil.
+func (ix *searchIndex) update(rels []string) {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !ix.valid {
+		return
+	}
+	for _, rel := range rels {
+		stem := strings.TrimSuffix(rel, path.Ext(rel))
+		if id, ok := ix.ids[stem]; ok {
+			ix.remove(id)
+		}
+		if err := ix.add(stem); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			// Fall back to a full rebuild on next use
+			slog.Warn("Failed to update search index", "file", rel, "err", err)
+			ix.valid = false
+			return
+		}
+	}
+	if removed := len(ix.docs) - ix.live; removed > searchCompactMin && removed > ix.live {
+		ix.compact()
+	}
+}
+
+// compact renumbers the documents without the removed ones. Caller holds
+// the lock.
+func (ix *searchIndex) compact() {
+	docs := ix.docs
+	ix.reset()
+	for _, d := range docs {
+		if d.meta != nil {
+			ix.insert(d)
+		}
+	}
+}
+
+// reset empties the index. Caller holds the lock.
+func (ix *searchIndex) reset() {
+	ix.docs, ix.live = nil, 0
+	ix.ids = make(map[string]int)
+	ix.postings = make(map[string]map[int]int)
+	ix.titles = make(map[string]map[int]bool)
+}
+
+// build indexes every page of the page index. Caller holds the lock.
+func (ix *searchIndex) build() error {
+	ix.reset()
+
+	pages, err := ix.pages.all()
+	if err != nil {
+		return err
+	}
+	for _, p := range pages {
+		if err := ix.add(strings.TrimPrefix(p.Path, "/")); err != nil {
+			return err
+		}
+	}
+	ix.valid = true
+	return nil
+}
+
+// add indexes the markdown file of a page ("sub/deep"; drafts are skipped
+// unless show_drafts is set, and protected pages always). Caller holds the
+// lock.
+func (ix *searchIndex) add(stem string) error {
+	root, rel, ok := ix.pages.source(stem)
+	if !ok {
+		return fs.ErrNotExist
+	}
+	if ix.protected("/" + stem) {
+		return nil
+	}
+	p, text, err := ix.pages.files.load(root, rel)
+	if err != nil {
+		return err
+	}
+	if p.Draft && !ix.pages.drafts {
+		return nil
+	}
+	ix.insert(searchDoc{stem: stem, meta: p, text: text})
+	return nil
+}
+
+// insert indexes a document under the next id. Caller holds the lock.
+func (ix *searchIndex) insert(d searchDoc) {
+	id := len(ix.docs)
+	ix.docs = append(ix.docs, d)
+	ix.ids[d.stem] = id
+	ix.live++
+
+	for _, term := range tokenize(d.text) {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[int]int)
+		}
+		ix.postings[term][id]++
+	}
+	for _, term := range tokenize(d.meta.Title) {
+		if ix.titles[term] == nil {
+			ix.titles[term] = make(map[int]bool)
+		}
+		ix.titles[term][id] = true
+	}
+}
+
+// remove drops a document from the index. Its id is not reused.
+// Caller holds the lock.
+func (ix *searchIndex) remove(id int) {
+	d := ix.docs[id]
+	for _, term := range tokenize(d.text) {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	for _, term := range tokenize(d.meta.Title) {
+		delete(ix.titles[term], id)
+		if len(ix.titles[term]) == 0 {
+			delete(ix.titles, term)
+		}
+	}
+	delete(ix.ids, d.stem)
+	ix.docs[id] = searchDoc{}
+	ix.live--
+}
+
+// searchResult is a single search hit.
+type searchResult struct {
+	URL     string        `json:"url"`
+	Title   string        `json:"title"`
+	Snippet template.HTML `json:"snippet"`
+	Score   float64       `json:"score"`
+}
+
+// searchFilter restricts search results by page metadata.
+type searchFilter struct {
+	Tags   []string  `json:"tags,omitempty"`   // all tags required (case-insensitive)
+	Author string    `json:"author,omitempty"` // exact author (case-insensitive)
+	After  time.Time `json:"after,omitzero"`   // date on or after
+	Before time.Time `json:"before,omitzero"`  // date before
+	Path   string    `json:"path,omitempty"`   // URL path prefix
+}
+
+// parseSearchFilter reads the filter parameters (tag, author, after, before, path) of a query string.
+func parseSearchFilter(q url.Values) (searchFilter, error) {
+	f := searchFilter{
+		Author: strings.TrimSpace(q.Get("author")),
+		Path:   strings.TrimSpace(q.Get("path")),
+	}
+	for _, tag := range q["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			f.Tags = append(f.Tags, tag)
+		}
+	}
+	if f.Path != "" && !strings.HasPrefix(f.Path, "/") {
+		f.Path = "/" + f.Path
+	}
+
+	for _, p := range []struct {
+		key string
+		dst *time.Time
+	}{{"after", &f.After}, {"before", &f.Before}} {
+		v := strings.TrimSpace(q.Get(p.key))
+		if v == "" {
+			continue
+		}
+		t, ok := metaTime(map[string]any{p.key: v}, p.key)
+		if !ok {
+			return f, fmt.Errorf("invalid %s date: %q", p.key, v)
+		}
+		*p.dst = t
+	}
+	return f, nil
+}
+
+// IsZero reports whether the filter matches every page.
+func (f searchFilter) IsZero() bool {
+	return len(f.Tags) == 0 && f.Author == "" && f.After.IsZero() && f.Before.IsZero() && f.Path == ""
+}
+
+// Match reports whether a page passes the filter.
+func (f searchFilter) Match(p *pageMeta) bool {
+	for _, tag := range f.Tags {
+		if !p.HasTag(tag) {
+			return false
+		}
+	}
+	if f.Author != "" && !strings.EqualFold(p.Author, f.Author) {
+		return false
+	}
+	if !f.After.IsZero() && p.Date.Before(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !p.Date.Before(f.Before) {
+		return false
+	}
+	return f.Path == "" || strings.HasPrefix(p.URL, f.Path)
+}
+
+// search returns the documents containing every term of the query and
+// passing the filter, best first. Without query terms, every page passing a
+// (non-empty) filter is returned, newest first.
+func (ix *searchIndex) search(query string, filter searchFilter, limit int) ([]searchResult, error) {
+	terms := uniqueTerms(tokenize(query))
+	if len(terms) == 0 && filter.IsZero() {
+		return nil, nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.valid {
+		if err := ix.build(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(terms) == 0 {
+		var matched []searchDoc
+		for _, d := range ix.docs {
+			if d.meta != nil && filter.Match(d.meta) {
+				matched = append(matched, d)
+			}
+		}
+		slices.SortFunc(matched, func(a, b searchDoc) int {
+			if c := b.meta.Date.Compare(a.meta.Date); c != 0 {
+				return c
+			}
+			return strings.Compare(a.meta.URL, b.meta.URL)
+		})
+		if limit > 0 && len(matched) > limit {
+			matched = matched[:limit]
+		}
+		results := make([]searchResult, 0, len(matched))
+		for _, d := range matched {
+			results = append(results, searchResult{URL: d.meta.URL, Title: d.meta.Title, Snippet: snippet(d.text, nil)})
+		}
+		return results, nil
+	}
+
+	n := float64(ix.live)
+	scores := make(map[int]float64)
+	for i, term := range terms {
+		matched := make(map[int]float64)
+		idf := math.Log(1 + n/float64(1+len(ix.postings[term])+len(ix.titles[term])))
+		for id, tf := range ix.postings[term] {
+			matched[id] += float64(tf) * idf
+		}
+		for id := range ix.titles[term] {
+			// Title matches weigh more than body matches
+			matched[id] += 3 * idf
+		}
+
+		// AND: keep only documents matching every term
+		if i == 0 {
+			scores = matched
+			continue
+		}
+		for id := range scores {
+			if s, ok := matched[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	words := queryWords(query)
+	results := make([]searchResult, 0, len(scores))
+	for id, score := range scores {
+		d := ix.docs[id]
+		if !filter.Match(d.meta) {
+			continue
+		}
+		results = append(results, searchResult{
+			URL:     d.meta.URL,
+			Title:   d.meta.Title,
+			Snippet: snippet(d.text, words),
+			Score:   math.Round(score*1000) / 1000,
+		})
+	}
+	slices.SortFunc(results, func(a, b searchResult) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.URL, b.URL)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// isCJK reports whether r belongs to a script written without spaces.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenize splits text into lower case terms. Words are split at non-letters;
+// runs of CJK characters (no word separators) are indexed as bigrams.
+func tokenize(s string) []string {
+	var tokens []string
+	var word, cjk []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+		switch len(cjk) {
+		case 0:
+		case 1:
+			tokens = append(tokens, string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		switch {
+		case isCJK(r):
+			if len(word) > 0 {
+				tokens = append(tokens, string(word))
+				word = word[:0]
+			}
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(cjk) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func uniqueTerms(terms []string) []string {
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
+
+// queryWords returns the lower case words of a query used for highlighting.
+func queryWords(query string) [][]rune {
+	var words [][]rune
+	for w := range strings.FieldsSeq(query) {
+		var rs []rune
+		for _, r := range w {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				rs = append(rs, unicode.ToLower(r))
+			}
+		}
+		if len(rs) > 0 {
+			words = append(words, rs)
+		}
+	}
+	return words
+}
+
+// isWordRune reports whether r is part of a space separated word.
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// matchAt returns the length of the query word matching text at position i (0 if none).
+// Except for CJK, a match must start and end at word boundaries like the indexed terms.
+func matchAt(lower []rune, i int, words [][]rune) int {
+	best := 0
+	for _, w := range words {
+		end := i + len(w)
+		if len(w) <= best || end > len(lower) || !slices.Equal(lower[i:end], w) {
+			continue
+		}
+		if i > 0 && isWordRune(w[0]) && isWordRune(lower[i-1]) {
+			continue
+		}
+		if end < len(lower) && isWordRune(w[len(w)-1]) && isWordRune(lower[end]) {
+			continue
+		}
+		best = len(w)
+	}
+	return best
+}
+
+// snippet returns an HTML excerpt of text around the first match, with every
+// occurrence of the query words wrapped in <mark>.
+func snippet(text string, words [][]rune) template.HTML {
+	rs := []rune(text)
+	lower := make([]rune, len(rs))
+	for i, r := range rs {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// Window around the first match (or the beginning of the text)
+	start := 0
+	for i := range lower {
+		if matchAt(lower, i, words) > 0 {
+			start = max(0, i-searchSnippetLead)
+			break
+		}
+	}
+	end := min(len(rs), start+searchSnippetLength)
+	if end-start < searchSnippetLength {
+		start = max(0, end-searchSnippetLength)
+	}
+
+	var buf strings.Builder
+	if start > 0 {
+		buf.WriteString("…")
+	}
+	plain := start
+	for i := start; i < end; {
+		n := matchAt(lower, i, words)
+		if n == 0 {
+			i++
+			continue
+		}
+		n = min(n, end-i)
+		buf.WriteString(template.HTMLEscapeString(string(rs[plain:i])))
+		buf.WriteString("<mark>")
+		buf.WriteString(template.HTMLEscapeString(string(rs[i : i+n])))
+		buf.WriteString("</mark>")
+		i += n
+		plain = i
+	}
+	buf.WriteString(template.HTMLEscapeString(string(rs[plain:end])))
+	if end < len(rs) {
+		buf.WriteString("…")
+	}
+	return template.HTML(buf.String())
+}
+
+// searchResponse is the outcome of a search request.
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Filter  searchFilter   `json:"filter"`
+	Total   int            `json:"total"`
+	Results []searchResult `json:"results"`
+}
+
+// Results page body, rendered into the site template.
+// Active filters are kept as hidden fields so that refining the query keeps them.
+var searchResultsTmpl = template.Must(template.New("search").Parse(`<form class="search" action="/search" method="get">
+    <input type="search" name="q" value="{{ .Query }}" aria-label="Search">
+    {{- with .Filter }}
+    {{- range .Tags }}
+    <input type="hidden" name="tag" value="{{ . }}">
+    {{- end }}
+    {{- with .Author }}
+    <input type="hidden" name="author" value="{{ . }}">
+    {{- end }}
+    {{- if not .After.IsZero }}
+    <input type="hidden" name="after" value="{{ .After.Format "2006-01-02" }}">
+    {{- end }}
+    {{- if not .Before.IsZero }}
+    <input type="hidden" name="before" value="{{ .Before.Format "2006-01-02" }}">
+    {{- end }}
+    {{- with .Path }}
+    <input type="hidden" name="path" value="{{ . }}">
+    {{- end }}
+    {{- end }}
+    <button type="submit">Search</button>
+</form>
+{{- if or .Query (not .Filter.IsZero) }}
+<p class="search-summary">{{ .Total }} result(s){{ with .Query }} for &quot;{{ . }}&quot;{{ end }}</p>
+<ol class="search-results">
+{{- range .Results }}
+    <li><a href="{{ .URL }}">{{ or .Title .URL }}</a><p>{{ .Snippet }}</p></li>
+{{- end }}
+</ol>
+{{- end }}`))
+
+// runSearch executes the query of a request and reports errors to the client.
+func (s *Server) runSearch(w http.ResponseWriter, r *http.Request) (*searchResponse, bool) {
+	q := r.URL.Query()
+	filter, err := parseSearchFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	limit := s.config.Search.MaxResults
+	if limit <= 0 {
+		limit = defaultSearchMaxResults
+	}
+	query := strings.TrimSpace(q.Get("q"))
+	results, err := s.search.search(query, filter, limit)
+	if err != nil {
+		slog.Error("Search failed", "query", query, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if results == nil {
+		results = []searchResult{}
+	}
+	return &searchResponse{Query: query, Filter: filter, Total: len(results), Results: results}, true
+}
+
+// handleSearch serves the HTML results page (/search?q=).
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	res, ok := s.runSearch(w, r)
+	if !ok {
+		return
+	}
+
+	var body bytes.Buffer
+	if err := searchResultsTmpl.Execute(&body, res); err != nil {
+		http.Error(w, "Template execution failed", http.StatusInternalServerError)
+		return
+	}
+
+	title := "Search"
+	if res.Query != "" {
+		title = fmt.Sprintf("Search: %s", res.Query)
+	}
+	st := s.siteFor(r)
+	if st.title != "" {
+		title = fmt.Sprintf("%s - %s", title, st.title)
+	}
+
+	page, err := s.executeTemplate(st.tmpl, s.templateData(st, title, template.HTML(body.String()), "search"))
+	if err != nil {
+		slog.Error("Template execution failed", "path", r.URL.Path, "err", err)
+		http.Error(w, "Template execution failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", s.htmlContentType())
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = writeBody(w, page)
+}
+
+// handleSearchJSON serves the JSON variant (/search.json?q=).
+func (s *Server) handleSearchJSON(w http.ResponseWriter, r *http.Request) {
+	res, ok := s.runSearch(w, r)
+	if !ok {
+		return
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = writeBody(w, append(b, '\n'))
+}
@@ -0,0 +1,359 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setupSearchServer creates a server with search enabled and some searchable pages
+func setupSearchServer(t *testing.T) *Server {
+	t.Helper()
+	srv, dir := setupTestServer(t)
+	srv.config.Search.Enabled = true
+	srv.config.HTML.SiteTitle = "Example"
+	srv.tmpl = template.Must(template.New("base").Parse(`<title>{{.Title}}</title>{{.Body}}`))
+	srv.search = newSearchIndex(srv.pages, srv.pageProtected)
+
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createFile(t, dir, "golang.md", "---\ntitle: Go Tips\nauthor: Alice\ndate: 2025-01-10\ntags: [go, tips]\n---\nWriting **Go** code is fun.\n\nThe gopher says 1 < 2 & goodbye.")
+	createFile(t, dir, "docs/rust.md", "---\nauthor: Bob\ndate: 2025-03-01\ntags: [rust, go]\n---\n# Rust Notes\nOwnership and borrowing. Go is mentioned once.")
+	createFile(t, dir, "nihongo.md", "# 日本語\n全文検索のテストです。")
+	return srv
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"go1.25 release", []string{"go1", "25", "release"}},
+		{"全文検索", []string{"全文", "文検", "検索"}},
+		{"Go言語", []string{"go", "言語"}},
+		{"猫 です", []string{"猫", "です"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	t.Run("Highlight and escape", func(t *testing.T) {
+		got := string(snippet("Use <b> tags & Go, not go-lang", queryWords("go")))
+		want := "Use &lt;b&gt; tags &amp; <mark>Go</mark>, not <mark>go</mark>-lang"
+		if got != want {
+			t.Errorf("snippet mismatch:\n got: %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("Window around first match", func(t *testing.T) {
+		text := strings.Repeat("lorem ", 100) + "needle" + strings.Repeat(" ipsum", 100)
+		got := string(snippet(text, queryWords("needle")))
+		if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+			t.Errorf("Expected ellipses on both sides, got %q", got)
+		}
+		if !strings.Contains(got, "<mark>needle</mark>") {
+			t.Errorf("Expected highlighted match, got %q", got)
+		}
+		if n := len([]rune(strings.NewReplacer("<mark>", "", "</mark>", "", "…", "").Replace(got))); n != searchSnippetLength {
+			t.Errorf("Snippet length mismatch: got %d", n)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		if got := string(snippet("short text", queryWords("zzz"))); got != "short text" {
+			t.Errorf("Expected leading text, got %q", got)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	srv := setupSearchServer(t)
+	mux := srv.routes()
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	search := func(t *testing.T, q string) searchResponse {
+		t.Helper()
+		w := get(t, "/search.json?q="+q)
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		var res searchResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return res
+	}
+
+	t.Run("JSON results ranked", func(t *testing.T) {
+		res := search(t, "go")
+		if res.Total != 2 || len(res.Results) != 2 {
+			t.Fatalf("Expected 2 results, got %+v", res)
+		}
+		// Title match ranks first
+		if res.Results[0].URL != "/golang" || res.Results[0].Title != "Go Tips" {
+			t.Errorf("Ranking mismatch: got %+v", res.Results)
+		}
+		if !strings.Contains(string(res.Results[0].Snippet), "<mark>Go</mark>") {
+			t.Errorf("Snippet not highlighted: %s", res.Results[0].Snippet)
+		}
+		if !strings.Contains(string(res.Results[0].Snippet), "1 &lt; 2 &amp; goodbye") {
+			t.Errorf("Snippet not escaped: %s", res.Results[0].Snippet)
+		}
+	})
+
+	t.Run("All terms required", func(t *testing.T) {
+		res := search(t, "go+ownership")
+		if res.Total != 1 || res.Results[0].URL != "/docs/rust" {
+			t.Errorf("Expected only /rust, got %+v", res.Results)
+		}
+	})
+
+	t.Run("CJK", func(t *testing.T) {
+		res := search(t, "検索")
+		if res.Total != 1 || res.Results[0].URL != "/nihongo" {
+			t.Fatalf("Expected /nihongo, got %+v", res.Results)
+		}
+		if !strings.Contains(string(res.Results[0].Snippet), "<mark>検索</mark>") {
+			t.Errorf("Snippet not highlighted: %s", res.Results[0].Snippet)
+		}
+	})
+
+	t.Run("Empty query", func(t *testing.T) {
+		res := search(t, "")
+		if res.Total != 0 || res.Results == nil {
+			t.Errorf("Expected empty result list, got %+v", res)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"go&tag=TIPS", []string{"/golang"}},
+			{"go&tag=go&tag=rust", []string{"/docs/rust"}},
+			{"go&tag=tips&author=bob", nil},
+			{"go&author=bob", []string{"/docs/rust"}},
+			{"go&after=2025-02-01", []string{"/docs/rust"}},
+			{"go&before=2025-03-01", []string{"/golang"}},
+			{"go&path=docs/", []string{"/docs/rust"}},
+			// Filters without query list matching pages, newest first
+			{"&tag=go", []string{"/docs/rust", "/golang"}},
+		}
+		for _, tt := range tests {
+			res := search(t, tt.query)
+			var got []string
+			for _, r := range res.Results {
+				got = append(got, r.URL)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+			}
+		}
+
+		if w := get(t, "/search.json?q=go&after=yesterday"); w.Code != http.StatusBadRequest {
+			t.Errorf("Invalid date: expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Filters kept in form", func(t *testing.T) {
+		body := get(t, "/search?q=go&tag=tips&after=2025-01-01").Body.String()
+		for _, want := range []string{
+			`<input type="hidden" name="tag" value="tips">`,
+			`<input type="hidden" name="after" value="2025-01-01">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Body should contain %q", want)
+			}
+		}
+	})
+
+	t.Run("Max results", func(t *testing.T) {
+		srv.config.Search.MaxResults = 1
+		defer func() { srv.config.Search.MaxResults = 0 }()
+		if res := search(t, "go"); len(res.Results) != 1 {
+			t.Errorf("Expected 1 result, got %d", len(res.Results))
+		}
+	})
+
+	t.Run("HTML results page", func(t *testing.T) {
+		w := get(t, "/search?q=gopher")
+		if w.Code != http.StatusOK {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			"<title>Search: gopher - Example</title>",
+			`<a href="/golang">Go Tips</a>`,
+			"<mark>gopher</mark>",
+			`value="gopher"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Body should contain %q", want)
+			}
+		}
+	})
+
+	t.Run("Index rebuilt after purge", func(t *testing.T) {
+		createFile(t, srv.config.HTML.MarkdownRootDir[0], "new.md", "# New\nFresh gopher content")
+		if res := search(t, "fresh"); res.Total != 0 {
+			t.Errorf("Index should be cached until purged, got %+v", res.Results)
+		}
+		srv.purgeCache()
+		if res := search(t, "fresh"); res.Total != 1 {
+			t.Errorf("Expected new page after purge, got %+v", res.Results)
+		}
+	})
+}
+
+func TestSearchIndexUpdate(t *testing.T) {
+	srv := setupSearchServer(t)
+	dir := srv.config.HTML.MarkdownRootDir[0]
+	ix := srv.search
+
+	urls := func(query string) []string {
+		t.Helper()
+		results, err := ix.search(query, searchFilter{}, 0)
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		var out []string
+		for _, r := range results {
+			out = append(out, r.URL)
+		}
+		return out
+	}
+
+	// Updates before the first build are left to the build
+	ix.update([]string{"golang.md"})
+	ix.prepare()
+	if !ix.valid || ix.live != 7 {
+		t.Fatalf("Expected a built index of 7 documents, got valid=%v live=%d", ix.valid, ix.live)
+	}
+
+	// Modified, created and removed files (as reported by the watcher)
+	createFile(t, dir, "golang.md", "# Go Tips\nNow about generics.")
+	createFile(t, dir, "new.md", "# Fresh\nOwnership again.")
+	if err := os.Remove(filepath.Join(dir, "docs", "rust.md")); err != nil {
+		t.Fatal(err)
+	}
+	srv.invalidateFiles([]string{
+		filepath.Join(dir, "golang.md"),
+		filepath.Join(dir, "new.md"),
+		filepath.Join(dir, "docs", "rust.md"),
+	})
+
+	if !ix.valid {
+		t.Fatal("Index should be updated in place, not invalidated")
+	}
+	if got := urls("generics"); !slices.Equal(got, []string{"/golang"}) {
+		t.Errorf("Modified file not re-indexed: %v", got)
+	}
+	if got := urls("gopher"); len(got) != 0 {
+		t.Errorf("Old content still indexed: %v", got)
+	}
+	if got := urls("ownership"); !slices.Equal(got, []string{"/new"}) {
+		t.Errorf("Expected only the new page, got %v", got)
+	}
+	if got, err := ix.search("", searchFilter{Path: "/docs/"}, 0); err != nil || len(got) != 0 {
+		t.Errorf("Removed page still listed: %v (%v)", got, err)
+	}
+	if ix.live != 7 {
+		t.Errorf("Expected 7 documents, got %d", ix.live)
+	}
+
+	// Frequent edits do not grow the index: every update leaves the old
+	// entry behind until the index is compacted
+	for range 3 * searchCompactMin {
+		ix.update([]string{"golang.md"})
+	}
+	createFile(t, dir, "golang.md", "# Go Tips\nNow about iterators.")
+	ix.update([]string{"golang.md"})
+	if len(ix.docs) > 2*searchCompactMin+ix.live || ix.live != 7 {
+		t.Errorf("Expected a compacted index of 7 documents, got %d entries (%d documents)", len(ix.docs), ix.live)
+	}
+	if got := urls("ownership"); !slices.Equal(got, []string{"/new"}) {
+		t.Errorf("Search after compaction: %v", got)
+	}
+	if got := urls("iterators"); !slices.Equal(got, []string{"/golang"}) {
+		t.Errorf("Last edit not found after compaction: %v", got)
+	}
+	if got := urls("generics"); len(got) != 0 {
+		t.Errorf("Old content still indexed after compaction: %v", got)
+	}
+}
+
+func TestSearchProtected(t *testing.T) {
+	srv := setupSearchServer(t)
+	dir := srv.config.HTML.MarkdownRootDir[0]
+	urls := func(query string) []string {
+		t.Helper()
+		srv.search.prepare()
+		results, err := srv.search.search(query, searchFilter{}, 0)
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		var out []string
+		for _, r := range results {
+			out = append(out, r.URL)
+		}
+		return out
+	}
+	if got := urls("ownership"); !slices.Equal(got, []string{"/docs/rust"}) {
+		t.Fatalf("Expected the page before protecting it, got %v", got)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protect := map[string]func(){
+		"auth": func() { srv.auth = &basicAuth{paths: []string{"/docs"}} },
+		"oidc": func() { srv.oidc = &oidcAuth{paths: []string{"/docs/"}} },
+		"directory": func() {
+			createFile(t, filepath.Join(dir, "docs"), overlayFileName, "[auth]\nenabled = true\nusers = [\"carol:"+string(hash)+"\"]\n")
+		},
+	}
+	for name, set := range protect {
+		srv.auth, srv.oidc = nil, nil
+		_ = os.Remove(filepath.Join(dir, "docs", overlayFileName))
+		set()
+		srv.purgeCache()
+		if got := urls("ownership"); len(got) != 0 {
+			t.Errorf("%s: protected page found: %v", name, got)
+		}
+		if got := urls("go"); !slices.Equal(got, []string{"/golang"}) {
+			t.Errorf("%s: expected only the unprotected page, got %v", name, got)
+		}
+	}
+
+	// Protecting the whole site protects /search.json as well
+	srv.auth, srv.oidc = &basicAuth{}, nil
+	_ = os.Remove(filepath.Join(dir, "docs", overlayFileName))
+	srv.purgeCache()
+	if got := urls("ownership"); len(got) != 1 {
+		t.Errorf("Expected the page with the whole site protected, got %v", got)
+	}
+}
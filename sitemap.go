@@ -0,0 +1,111 @@
+package gomadore
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Valid <changefreq> values
+var sitemapChangeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+// --- Sitemap (sitemaps.org protocol 0.9) ---
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// sitemapEntry builds the sitemap entry of a page from its front matter:
+//
+//	sitemap:
+//	  priority: 0.8        # 0.0 - 1.0
+//	  changefreq: weekly   # always, hourly, daily, weekly, monthly, yearly, never
+//	  exclude: true        # or "sitemap: false"
+//
+// Invalid values are logged and ignored. ok is false if the page is excluded,
+// also by "noindex: true".
+func sitemapEntry(p *pageMeta, base string) (u sitemapURL, ok bool) {
+	u = sitemapURL{
+		Loc:     base + p.URL,
+		LastMod: p.ModTime.UTC().Format(time.RFC3339),
+	}
+	if metaBool(p.Meta, "noindex") {
+		return u, false
+	}
+
+	var hints map[string]any
+	switch v := p.Meta["sitemap"].(type) {
+	case nil:
+		return u, true
+	case bool:
+		return u, v
+	case map[string]any:
+		hints = v
+	default:
+		slog.Warn("Ignore invalid sitemap front matter", "file", p.File, "sitemap", v)
+		return u, true
+	}
+
+	if exclude, _ := hints["exclude"].(bool); exclude {
+		return u, false
+	}
+	if v, found := hints["priority"]; found {
+		if f, err := strconv.ParseFloat(fmt.Sprint(v), 64); err == nil && f >= 0 && f <= 1 {
+			u.Priority = strconv.FormatFloat(f, 'f', -1, 64)
+		} else {
+			slog.Warn("Ignore invalid sitemap priority", "file", p.File, "priority", v)
+		}
+	}
+	if freq := strings.ToLower(metaString(hints, "changefreq")); freq != "" {
+		if slices.Contains(sitemapChangeFreqs, freq) {
+			u.ChangeFreq = freq
+		} else {
+			slog.Warn("Ignore invalid sitemap changefreq", "file", p.File, "changefreq", freq)
+		}
+	}
+	return u, true
+}
+
+// handleSitemap serves /sitemap.xml listing every markdown page that is not
+// excluded by its front matter or protected (see pageProtected).
+func (s *Server) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	pages, err := s.pages.all()
+	if err != nil {
+		slog.Error("Failed to scan pages for sitemap", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	base := s.siteURL(r)
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, p := range pages {
+		if s.pageProtected(p.Path) {
+			continue
+		}
+		if u, ok := sitemapEntry(p, base); ok {
+			set.URLs = append(set.URLs, u)
+		}
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_ = writeBody(w, append([]byte(xml.Header), out...))
+}
@@ -0,0 +1,128 @@
+package gomadore
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestSitemap(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.Sitemap.Enabled = true
+	srv.config.HTML.SiteURL = "https://example.com/"
+	mux := srv.routes()
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("StatusCode mismatch: got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type mismatch: got %s", ct)
+	}
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Invalid sitemap: %v", err)
+	}
+	var locs []string
+	for _, u := range set.URLs {
+		locs = append(locs, u.Loc)
+		if u.LastMod == "" {
+			t.Errorf("%s: lastmod missing", u.Loc)
+		}
+	}
+	want := []string{
+		"https://example.com/about",
+		"https://example.com/",
+		"https://example.com/sub/deep",
+		"https://example.com/t1/cococo",
+	}
+	if !slices.Equal(locs, want) {
+		t.Errorf("URL mismatch:\n got: %v\nwant: %v", locs, want)
+	}
+
+	t.Run("Protected pages", func(t *testing.T) {
+		srv.auth = &basicAuth{paths: []string{"/sub"}}
+		srv.oidc = &oidcAuth{paths: []string{"/t1/cococo"}}
+		defer func() { srv.auth, srv.oidc = nil, nil }()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sitemap.xml", nil))
+		var set sitemapURLSet
+		if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+			t.Fatalf("Invalid sitemap: %v", err)
+		}
+		var locs []string
+		for _, u := range set.URLs {
+			locs = append(locs, u.Loc)
+		}
+		if want := []string{"https://example.com/about", "https://example.com/"}; !slices.Equal(locs, want) {
+			t.Errorf("URL mismatch:\n got: %v\nwant: %v", locs, want)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv.config.Sitemap.Enabled = false
+		defer func() { srv.config.Sitemap.Enabled = true }()
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sitemap.xml", nil)
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 when disabled, got %d", w.Code)
+		}
+	})
+}
+
+func TestSitemapFrontMatter(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Sitemap.Enabled = true
+	srv.config.HTML.SiteURL = "https://example.com"
+	createFile(t, dir, "important.md", "---\nsitemap:\n  priority: 0.9\n  changefreq: Daily\n---\n# Important")
+	createFile(t, dir, "archive.md", "+++\n[sitemap]\npriority = 0.1\nchangefreq = \"never\"\n+++\n# Archive")
+	createFile(t, dir, "hidden.md", "---\nsitemap: false\n---\n# Hidden")
+	createFile(t, dir, "excluded.md", "---\nsitemap:\n  exclude: true\n---\n# Excluded")
+	createFile(t, dir, "invalid.md", "---\nsitemap:\n  priority: 2\n  changefreq: sometimes\n---\n# Invalid")
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, req)
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Invalid sitemap: %v", err)
+	}
+	got := make(map[string]sitemapURL)
+	for _, u := range set.URLs {
+		got[u.Loc] = u
+	}
+
+	tests := []struct {
+		loc        string
+		listed     bool
+		priority   string
+		changefreq string
+	}{
+		{"https://example.com/important", true, "0.9", "daily"},
+		{"https://example.com/archive", true, "0.1", "never"},
+		{"https://example.com/invalid", true, "", ""},
+		{"https://example.com/about", true, "", ""},
+		{"https://example.com/hidden", false, "", ""},
+		{"https://example.com/excluded", false, "", ""},
+	}
+	for _, tt := range tests {
+		u, listed := got[tt.loc]
+		if listed != tt.listed {
+			t.Errorf("%s: listed = %v, want %v", tt.loc, listed, tt.listed)
+			continue
+		}
+		if u.Priority != tt.priority || u.ChangeFreq != tt.changefreq {
+			t.Errorf("%s: got priority=%q changefreq=%q, want %q %q", tt.loc, u.Priority, u.ChangeFreq, tt.priority, tt.changefreq)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package gomadore
+
+import (
+	"log/slog"
+	"time"
+)
+
+// --- Slow Render Warning ---
+
+// renderTimings measures the rendering of one markdown page.
+type renderTimings struct {
+	size     int           // bytes of the markdown file
+	parse    time.Duration // markdown to AST
+	render   time.Duration // AST to HTML, with sanitizing and the TOC
+	template time.Duration // page template execution
+	total    time.Duration // whole renderPage, including navigation and backlinks
+}
+
+// warnSlowRender logs a warning when rendering a page took longer than
+// slow_render_ms (0: disabled), to find pathological documents such as huge
+// tables or deeply nested lists.
+func (s *Server) warnSlowRender(reqPath string, t renderTimings) {
+	limit := time.Duration(s.config.HTML.SlowRenderMs) * time.Millisecond
+	if limit <= 0 || t.total <= limit {
+		return
+	}
+	slog.Warn("Slow page render",
+		"path", reqPath,
+		"size", t.size,
+		"total_ms", durationMs(t.total),
+		"parse_ms", durationMs(t.parse),
+		"render_ms", durationMs(t.render),
+		"template_ms", durationMs(t.template),
+		"limit_ms", s.config.HTML.SlowRenderMs,
+	)
+}
+
+// durationMs returns d in milliseconds, with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,47 @@
+package gomadore
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarnSlowRender(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	srv, _ := setupTestServer(t)
+	slow := renderTimings{size: 2048, parse: 300 * time.Millisecond, render: 150 * time.Millisecond, template: 50500 * time.Microsecond, total: 510 * time.Millisecond}
+
+	srv.warnSlowRender("/huge", slow)
+	if buf.Len() != 0 {
+		t.Errorf("Disabled by default, got %s", buf.String())
+	}
+
+	srv.config.HTML.SlowRenderMs = 500
+	srv.warnSlowRender("/fast", renderTimings{total: 499 * time.Millisecond})
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warning under the limit, got %s", buf.String())
+	}
+	srv.warnSlowRender("/huge", slow)
+	for _, want := range []string{`msg="Slow page render"`, "path=/huge", "size=2048", "total_ms=510", "parse_ms=300", "render_ms=150", "template_ms=50.5", "limit_ms=500"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Warning lacks %q: %s", want, buf.String())
+		}
+	}
+}
+
+func TestRenderTimings(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	pd, err := srv.renderDocument("/about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pd.timings.size == 0 || pd.timings.parse <= 0 || pd.timings.render <= 0 {
+		t.Errorf("Expected the size and durations to be measured, got %+v", pd.timings)
+	}
+}
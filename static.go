@@ -0,0 +1,244 @@
+package gomadore
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Precompressed sidecar encodings in order of preference
+var sidecarEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// MIME types that are missing from some systems' mime.types (or Go's
+// built-in table); registered unless the system already knows the extension.
+var staticMIMETypes = map[string]string{
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".mp3":   "audio/mpeg",
+	".ogg":   "audio/ogg",
+	".txt":   "text/plain; charset=utf-8",
+	".csv":   "text/csv; charset=utf-8",
+	".map":   "application/json",
+	".zip":   "application/zip",
+}
+
+func init() {
+	for ext, typ := range staticMIMETypes {
+		if mime.TypeByExtension(ext) == "" {
+			_ = mime.AddExtensionType(ext, typ)
+		}
+	}
+}
+
+// --- Static Files ---
+
+// staticFile resolves a request path to a servable non-markdown file under
+// the content roots, or else under assets_dir ("" if there is none).
+// Hidden files and directories, and directory configuration files
+// (_gomadore.toml), are never served.
+func (s *Server) staticFile(urlPath string) string {
+	if strings.HasSuffix(urlPath, "/") || markdownExt(urlPath, s.config.HTML.MarkdownExts) != "" {
+		return ""
+	}
+	for seg := range strings.SplitSeq(urlPath, "/") {
+		if strings.HasPrefix(seg, ".") || strings.EqualFold(seg, overlayFileName) {
+			return ""
+		}
+	}
+
+	for _, dir := range append(slices.Clone(s.config.HTML.MarkdownRootDir), s.config.Static.AssetsDir) {
+		if dir == "" {
+			continue
+		}
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		file := filepath.Join(root, filepath.FromSlash(path.Clean("/"+urlPath)))
+		if rel, err := filepath.Rel(root, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			return file
+		}
+	}
+	return ""
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows the
+// encoding (q=0 excludes it; "*" matches any encoding).
+func acceptsEncoding(header, encoding string) bool {
+	accepted := false
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encoding && name != "*" {
+			continue
+		}
+		q := 1.0
+		for p := range strings.SplitSeq(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if name == encoding {
+			// An explicit entry takes precedence over "*"
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// serveStatic serves a non-markdown file under the content root or assets_dir.
+// It returns false if the request is not for such a file (or static files are
+// disabled, and it is not in the attachment directory of a page).
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) bool {
+	if !s.config.Static.Enabled && !(boolOr(s.config.Markdown.PageAssets, false) && s.isPageAsset(r.URL.Path)) {
+		return false
+	}
+	file := s.staticFile(r.URL.Path)
+	if file == "" {
+		return false
+	}
+	// The attachments of a page are protected like the page
+	if page, _ := s.pageAssetOwner(r.URL.Path); page != "" && !s.authorizePage(w, r, page) {
+		return true
+	}
+
+	// The Content-Type always describes the original file
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+
+	serveFile := file
+	if s.config.Static.Precompressed {
+		hasSidecar := false
+		for _, sc := range sidecarEncodings {
+			info, err := os.Stat(file + sc.ext)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			hasSidecar = true
+			if acceptsEncoding(r.Header.Get("Accept-Encoding"), sc.encoding) {
+				serveFile = file + sc.ext
+				w.Header().Set("Content-Encoding", sc.encoding)
+				break
+			}
+		}
+		if hasSidecar {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+	}
+
+	f, err := os.Open(serveFile)
+	if err != nil {
+		http.NotFound(w, r)
+		return true
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+
+	if contentType == "" && serveFile != file {
+		// Sniff the uncompressed original (ServeContent would sniff the compressed bytes)
+		contentType = sniffContentType(file)
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	slog.Debug("Serve static file", "path", r.URL.Path, "file", serveFile)
+	http.ServeContent(w, r, filepath.Base(file), info.ModTime(), f)
+	return true
+}
+
+// serveRawMarkdown serves the markdown source of a page for a request path
+// ending in a markdown suffix ("/sub/deep.md" -> sub/deep.md). Only the file
+// the page is rendered from is served: with markdown_extensions = [".md",
+// ".txt"], "/a.txt" is not found if a.md exists. It returns false if the
+// request is not for a markdown file (or serve_raw_markdown is disabled).
+func (s *Server) serveRawMarkdown(w http.ResponseWriter, r *http.Request) bool {
+	ext := markdownExt(r.URL.Path, s.config.HTML.MarkdownExts)
+	if !s.config.HTML.ServeRawMarkdown || ext == "" {
+		return false
+	}
+	st := s.siteFor(r)
+	for seg := range strings.SplitSeq(r.URL.Path, "/") {
+		if strings.HasPrefix(seg, ".") {
+			s.notFound(w, r, st)
+			return true
+		}
+	}
+
+	// The source is protected like the page
+	pagePath := strings.TrimSuffix(r.URL.Path, ext)
+	if !s.authorizePage(w, r, pagePath) {
+		return true
+	}
+	file, err := s.markdownFile(pagePath)
+	if err != nil || !strings.EqualFold(markdownExt(file, s.config.HTML.MarkdownExts), ext) {
+		s.notFound(w, r, st)
+		return true
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		s.notFound(w, r, st)
+		return true
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || (!s.config.HTML.ShowDrafts && isDraftFile(file, s.config.HTML.SourceEncoding)) {
+		s.notFound(w, r, st)
+		return true
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	slog.Debug("Serve markdown source", "path", r.URL.Path, "file", file)
+	if enc := s.config.HTML.SourceEncoding; enc != "" && enc != sourceEncodingUTF8 {
+		// Served as UTF-8 like the rendered page
+		content, err := readSource(file, enc)
+		if err != nil {
+			s.notFound(w, r, st)
+			return true
+		}
+		http.ServeContent(w, r, filepath.Base(file), info.ModTime(), bytes.NewReader(content))
+		return true
+	}
+	http.ServeContent(w, r, filepath.Base(file), info.ModTime(), f)
+	return true
+}
+
+// sniffContentType detects the content type from the beginning of a file.
+func sniffContentType(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,215 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		encoding string
+		want     bool
+	}{
+		{"gzip, deflate, br", "br", true},
+		{"gzip", "br", false},
+		{"br;q=0, gzip", "br", false},
+		{"br;q=0.5", "br", true},
+		{"*", "gzip", true},
+		{"*;q=0, gzip", "gzip", true},
+		{"*, gzip;q=0", "gzip", false},
+		{"", "gzip", false},
+		{"GZIP", "gzip", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsEncoding(tt.header, tt.encoding); got != tt.want {
+			t.Errorf("acceptsEncoding(%q, %q) = %v, want %v", tt.header, tt.encoding, got, tt.want)
+		}
+	}
+}
+
+func TestServeStatic(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Static.Enabled = true
+	srv.config.Static.Precompressed = true
+
+	if err := os.MkdirAll(filepath.Join(dir, "assets", ".private"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createFile(t, dir, "assets/style.css", "body{}")
+	createFile(t, dir, "assets/style.css.br", "BROTLI")
+	createFile(t, dir, "assets/style.css.gz", "GZIP")
+	createFile(t, dir, "assets/logo.png", "\x89PNG\r\n\x1a\nDATA")
+	createFile(t, dir, "assets/data.unknownext", "<html><body>x</body></html>")
+	createFile(t, dir, "assets/data.unknownext.gz", "GZIP")
+	createFile(t, dir, "assets/.private/secret.txt", "secret")
+	createFile(t, dir, ".env", "secret")
+
+	get := func(t *testing.T, path, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	t.Run("Sidecar selection", func(t *testing.T) {
+		tests := []struct {
+			accept   string
+			body     string
+			encoding string
+		}{
+			{"gzip, br", "BROTLI", "br"},
+			{"gzip", "GZIP", "gzip"},
+			{"br;q=0, gzip", "GZIP", "gzip"},
+			{"", "body{}", ""},
+		}
+		for _, tt := range tests {
+			w := get(t, "/assets/style.css", tt.accept)
+			if w.Code != http.StatusOK || w.Body.String() != tt.body {
+				t.Errorf("Accept-Encoding %q: got %d %q, want %q", tt.accept, w.Code, w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Accept-Encoding %q: Content-Encoding mismatch: got %q", tt.accept, got)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" {
+				t.Errorf("Accept-Encoding %q: Content-Type mismatch: got %q", tt.accept, ct)
+			}
+			if v := w.Header().Get("Vary"); v != "Accept-Encoding" {
+				t.Errorf("Accept-Encoding %q: Vary mismatch: got %q", tt.accept, v)
+			}
+		}
+	})
+
+	t.Run("Content-Type of sidecar without known extension", func(t *testing.T) {
+		w := get(t, "/assets/data.unknownext", "gzip")
+		if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("Content-Type should be sniffed from the original, got %q", ct)
+		}
+	})
+
+	t.Run("No sidecar", func(t *testing.T) {
+		w := get(t, "/assets/logo.png", "gzip, br")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("Unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+			t.Errorf("No encoding headers expected without sidecars: %v", w.Header())
+		}
+	})
+
+	t.Run("Precompressed disabled", func(t *testing.T) {
+		srv.config.Static.Precompressed = false
+		defer func() { srv.config.Static.Precompressed = true }()
+		if w := get(t, "/assets/style.css", "br"); w.Body.String() != "body{}" {
+			t.Errorf("Expected original file, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("Not served", func(t *testing.T) {
+		for _, p := range []string{"/.env", "/assets/.private/secret.txt", "/about.md", "/assets/", "/assets/missing.css"} {
+			if w := get(t, p, ""); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected 404, got %d", p, w.Code)
+			}
+		}
+	})
+
+	t.Run("Markdown pages still rendered", func(t *testing.T) {
+		if w := get(t, "/about", ""); w.Code != http.StatusOK || w.Header().Get("X-Cache") == "" {
+			t.Errorf("Expected rendered page, got %d", w.Code)
+		}
+	})
+
+	t.Run("Assets dir", func(t *testing.T) {
+		assets := t.TempDir()
+		srv.config.Static.AssetsDir = assets
+		defer func() { srv.config.Static.AssetsDir = "" }()
+		for _, d := range []string{"fonts", "assets"} {
+			if err := os.MkdirAll(filepath.Join(assets, d), 0755); err != nil {
+				t.Fatalf("Failed to create dir: %v", err)
+			}
+		}
+		createFile(t, assets, "fonts/body.woff2", "wOF2")
+		createFile(t, assets, "assets/logo.png", "SHADOWED")
+		createFile(t, assets, "notes.md", "# Notes")
+		createFile(t, assets, ".hidden", "secret")
+
+		w := get(t, "/fonts/body.woff2", "")
+		if w.Code != http.StatusOK || w.Body.String() != "wOF2" {
+			t.Errorf("Expected asset, got %d %q", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "font/woff2" {
+			t.Errorf("Content-Type mismatch: got %q", ct)
+		}
+		// markdown_rootdir takes precedence
+		if w := get(t, "/assets/logo.png", ""); w.Body.String() == "SHADOWED" {
+			t.Error("File under markdown_rootdir should take precedence")
+		}
+		for _, p := range []string{"/notes.md", "/.hidden", "/../" + filepath.Base(assets) + "/fonts/body.woff2"} {
+			if w := get(t, p, ""); w.Code == http.StatusOK {
+				t.Errorf("%s: should not be served", p)
+			}
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv.config.Static.Enabled = false
+		defer func() { srv.config.Static.Enabled = true }()
+		if w := get(t, "/assets/logo.png", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 when disabled, got %d", w.Code)
+		}
+	})
+}
+
+func TestServeRawMarkdown(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, ".secret.md", "# Secret")
+
+	get := func(t *testing.T, p string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, p, nil))
+		return w
+	}
+
+	if w := get(t, "/about.md"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when disabled, got %d", w.Code)
+	}
+
+	srv.config.HTML.ServeRawMarkdown = true
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/about.md", http.StatusOK, "# About\nThis is about page"},
+		{"/sub/deep.md", http.StatusOK, "# Deep Page\nDeep content"},
+		{"/index.md", http.StatusOK, "# Top Page\nHello World"},
+		{"/missing.md", http.StatusNotFound, ""},
+		{"/.secret.md", http.StatusNotFound, ""},
+		{"/sub.md", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := get(t, tt.path)
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/markdown; charset=utf-8" {
+				t.Errorf("Unexpected Content-Type %q", ct)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("Unexpected body %q", w.Body.String())
+			}
+		})
+	}
+}
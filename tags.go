@@ -0,0 +1,170 @@
+package gomadore
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// --- Tag Pages ---
+
+// tagLink is a tag with the URL of its generated page, available in
+// templates as {{ .Tags }} (the tags of a page) and {{ .TagEntries }}.
+type tagLink struct {
+	Name  string
+	URL   string // "" unless [tags] is enabled
+	Count int    // number of pages (tag index only)
+}
+
+// tagPagePath reports whether an internal page path is the tag index
+// ("/tags/index", tag "") or the page of a tag ("/tags/go/index").
+func tagPagePath(reqPath string) (string, bool) {
+	rest, ok := strings.CutPrefix(reqPath, "/tags/")
+	if !ok {
+		return "", false
+	}
+	if rest == "index" {
+		return "", true
+	}
+	tag, ok := strings.CutSuffix(rest, "/index")
+	if !ok || tag == "" || strings.Contains(tag, "/") {
+		return "", false
+	}
+	return tag, true
+}
+
+// tagPagePaths returns the internal paths of the tag index and of the page
+// of every tag of pages (tags differing in case share a page).
+func tagPagePaths(pages []*pageMeta) []string {
+	paths := []string{"/tags/index"}
+	seen := make(map[string]bool)
+	for _, p := range pages {
+		for _, t := range p.Tags {
+			key := strings.ToLower(t)
+			if seen[key] || strings.Contains(t, "/") {
+				continue
+			}
+			seen[key] = true
+			paths = append(paths, "/tags/"+t+"/index")
+		}
+	}
+	return paths
+}
+
+// tagURL returns the URL of the generated page of a tag ("" if tag pages
+// are disabled).
+func (s *Server) tagURL(tag string) string {
+	if !s.config.Tags.Enabled {
+		return ""
+	}
+	return urlPathFor("tags/"+url.PathEscape(tag)+"/index", s.config.HTML.StrictHtmlUrl)
+}
+
+// pageTags returns the tags of a page with the URLs of their pages.
+func (s *Server) pageTags(tags []string) []tagLink {
+	var links []tagLink
+	for _, t := range tags {
+		links = append(links, tagLink{Name: t, URL: s.tagURL(t)})
+	}
+	return links
+}
+
+// renderTagPage renders the list of all tags (tag "") or the list of the
+// pages with a tag, newest first, leaving out protected pages. It returns an error wrapping
+// fs.ErrNotExist for a tag no page has.
+func (s *Server) renderTagPage(st *site, tag string) ([]byte, error) {
+	pages, err := s.pages.all()
+	if err != nil {
+		return nil, err
+	}
+	pages = slices.DeleteFunc(slices.Clone(pages), func(p *pageMeta) bool { return s.pageProtected(p.Path) })
+
+	var (
+		b          strings.Builder
+		heading    string
+		tagEntries []tagLink
+		entries    []dirIndexEntry
+	)
+	if tag == "" {
+		// Tags are grouped case-insensitively and shown as first written
+		counts := make(map[string]*tagLink)
+		for _, p := range pages {
+			for _, t := range p.Tags {
+				key := strings.ToLower(t)
+				if counts[key] == nil {
+					counts[key] = &tagLink{Name: t, URL: s.tagURL(t)}
+				}
+				counts[key].Count++
+			}
+		}
+		tagEntries = make([]tagLink, 0, len(counts))
+		for _, l := range counts {
+			tagEntries = append(tagEntries, *l)
+		}
+		slices.SortFunc(tagEntries, func(a, b tagLink) int {
+			return cmp.Or(strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)), strings.Compare(a.Name, b.Name))
+		})
+
+		heading = "Tags"
+		b.WriteString("<h1>Tags</h1>\n<ul class=\"tag-index\">\n")
+		for _, e := range tagEntries {
+			fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a> (%d)</li>\n", template.HTMLEscapeString(e.URL), template.HTMLEscapeString(e.Name), e.Count)
+		}
+		b.WriteString("</ul>\n")
+	} else {
+		var tagged []*pageMeta
+		for _, p := range pages {
+			if p.HasTag(tag) {
+				tagged = append(tagged, p)
+			}
+		}
+		if len(tagged) == 0 {
+			return nil, fmt.Errorf("tag %q: %w", tag, fs.ErrNotExist)
+		}
+		slices.SortStableFunc(tagged, func(a, b *pageMeta) int { return b.Date.Compare(a.Date) })
+		for _, p := range tagged {
+			entries = append(entries, dirIndexEntry{URL: p.URL, Title: cmp.Or(p.Title, p.Path)})
+		}
+
+		heading = "#" + tag
+		b.WriteString("<h1>" + template.HTMLEscapeString(heading) + "</h1>\n<ul class=\"tag-pages\">\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", template.HTMLEscapeString(e.URL), template.HTMLEscapeString(e.Title))
+		}
+		b.WriteString("</ul>\n")
+	}
+
+	title := heading
+	if s.forcedTitle != "" {
+		title = s.forcedTitle
+	} else if st.title != "" {
+		title = fmt.Sprintf("%s - %s", title, st.title)
+	}
+
+	data := s.templateData(st, title, template.HTML(b.String()), "tags")
+	data["Tag"] = tag
+	data["TagEntries"] = tagEntries
+	data["IndexEntries"] = entries
+	respBody, err := s.executeTemplate(st.tmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errTemplateExecution, err)
+	}
+	return respBody, nil
+}
+
+// dropCachedTagPages drops the cached tag pages of every site, since any
+// changed page may change them.
+func (s *Server) dropCachedTagPages() {
+	s.cache.Lock()
+	defer s.cache.Unlock()
+	for key := range s.cache.items {
+		// Cache keys are the page path, prefixed by the host name for virtual hosts
+		if i := strings.IndexByte(key, '/'); i >= 0 && strings.HasPrefix(key[i:], "/tags/") {
+			s.cache.remove(key)
+		}
+	}
+}
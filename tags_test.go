@@ -0,0 +1,91 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTagPagePath(t *testing.T) {
+	tests := []struct {
+		path string
+		tag  string
+		ok   bool
+	}{
+		{"/tags/index", "", true},
+		{"/tags/go/index", "go", true},
+		{"/tags/go/setup", "", false},
+		{"/tags/a/b/index", "", false},
+		{"/blog/tags/index", "", false},
+	}
+	for _, tt := range tests {
+		if tag, ok := tagPagePath(tt.path); tag != tt.tag || ok != tt.ok {
+			t.Errorf("tagPagePath(%q) = %q, %v; want %q, %v", tt.path, tag, ok, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestTagPages(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.Tags.Enabled = true
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`{{.Title}}|{{range .Tags}}[{{.Name}} {{.URL}}]{{end}}|{{range .TagEntries}}[{{.Name}}:{{.Count}}]{{end}}|{{range .IndexEntries}}[{{.Title}}]{{end}}`))
+	createFile(t, dir, "old.md", "---\ntitle: Old\ndate: 2025-01-01\ntags: [Go, web]\n---\n")
+	createFile(t, dir, "new.md", "---\ntitle: New\ndate: 2026-01-01\ntags: go\n---\n")
+
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+
+	if body := get("/old").Body.String(); !strings.Contains(body, "|[Go /tags/Go/][web /tags/web/]|") {
+		t.Errorf("Expected the tags of the page, got %s", body)
+	}
+	if body := get("/tags/").Body.String(); !strings.Contains(body, "|[go:2][web:1]|") {
+		t.Errorf("Unexpected tag index: %s", body)
+	}
+	if body := get("/tags/go/").Body.String(); !strings.HasPrefix(body, "#go") || !strings.HasSuffix(body, "|[New][Old]") {
+		t.Errorf("Unexpected tag page: %s", body)
+	}
+	if w := get("/tags/none/"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown tag, got %d", w.Code)
+	}
+
+	t.Run("Protected pages", func(t *testing.T) {
+		srv.auth = &basicAuth{paths: []string{"/old"}}
+		srv.purgeCache()
+		defer func() {
+			srv.auth = nil
+			srv.purgeCache()
+		}()
+		if body := get("/tags/").Body.String(); !strings.Contains(body, "|[go:1]|") {
+			t.Errorf("Expected only the tags of unprotected pages, got %s", body)
+		}
+		if w := get("/tags/web/"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for a tag of protected pages only, got %d", w.Code)
+		}
+	})
+
+	t.Run("Changed page refreshes tag pages", func(t *testing.T) {
+		createFile(t, dir, "new.md", "---\ntitle: New\ntags: [rust]\n---\n")
+		srv.invalidateFiles([]string{filepath.Join(dir, "new.md")})
+		if body := get("/tags/go/").Body.String(); strings.Contains(body, "[New]") {
+			t.Errorf("Tag page not refreshed: %s", body)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv.config.Tags.Enabled = false
+		srv.purgeCache()
+		if w := get("/tags/"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+		if body := get("/old").Body.String(); !strings.Contains(body, "[Go ]") {
+			t.Errorf("Expected tags without URLs, got %s", body)
+		}
+	})
+}
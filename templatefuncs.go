@@ -0,0 +1,191 @@
+package gomadore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+)
+
+// --- Template Functions ---
+
+// templateFuncs are available to every page template, in addition to the
+// built-in functions of html/template. Functions taking the value to work on
+// take it last, so that it can be piped: {{ .Description | truncate 120 }}.
+var templateFuncs = template.FuncMap{
+	"dateFormat":  dateFormat,
+	"now":         time.Now,
+	"markdownify": markdownify,
+	"truncate":    truncate,
+	"slugify":     slugify,
+	"lower":       func(v any) string { return strings.ToLower(toString(v)) },
+	"upper":       func(v any) string { return strings.ToUpper(toString(v)) },
+	"trim":        func(v any) string { return strings.TrimSpace(toString(v)) },
+	"replace":     func(old, repl string, v any) string { return strings.ReplaceAll(toString(v), old, repl) },
+	"split":       func(sep string, v any) []string { return strings.Split(toString(v), sep) },
+	"join":        join,
+	"default":     defaultValue,
+	"dict":        dict,
+	"list":        func(items ...any) []any { return items },
+	"safeHTML":    func(v any) template.HTML { return template.HTML(toString(v)) },
+	"safeCSS":     func(v any) template.CSS { return template.CSS(toString(v)) },
+	"safeURL":     func(v any) template.URL { return template.URL(toString(v)) },
+}
+
+// Converter of markdownify: the default extensions (GitHub Flavored
+// Markdown), and raw HTML left out as in pages.
+var funcMarkdown = goldmark.New(goldmark.WithExtensions(markdownExtensions(Config{})...))
+
+// Layouts tried by dateFormat for dates given as text
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// toString converts a template value to text: strings and the typed strings
+// of html/template as they are, other values as printed by fmt.
+func toString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case template.HTML:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// dateFormat formats a time, or a date in text (RFC 3339 or "2006-01-02",
+// e.g. .DocumentDateTime or a front matter date), with a Go layout:
+// {{ .DocumentDateTime | dateFormat "January 2, 2006" }}.
+func dateFormat(layout string, v any) (string, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case *time.Time:
+		if v != nil {
+			return v.Format(layout), nil
+		}
+		return "", nil
+	}
+	s := strings.TrimSpace(toString(v))
+	if s == "" {
+		return "", nil
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.Format(layout), nil
+		}
+	}
+	return "", fmt.Errorf("dateFormat: cannot parse %q as a date", s)
+}
+
+// markdownify renders markdown text, such as a front matter value. A single
+// paragraph is returned without its <p> tags, so that it can be used inline.
+func markdownify(v any) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := funcMarkdown.Convert([]byte(toString(v)), &buf); err != nil {
+		return "", err
+	}
+	out := bytes.TrimSpace(buf.Bytes())
+	if inner, ok := bytes.CutPrefix(out, []byte("<p>")); ok {
+		if inner, ok := bytes.CutSuffix(inner, []byte("</p>")); ok && !bytes.Contains(inner, []byte("<p>")) {
+			out = inner
+		}
+	}
+	return template.HTML(out), nil
+}
+
+// truncate shortens text to at most n characters, ending with "…" if cut.
+// A word is not split if a space is in the last third of the kept text.
+func truncate(n int, v any) string {
+	s := toString(v)
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	text := string([]rune(s)[:n-1])
+	if i := strings.LastIndexFunc(text, unicode.IsSpace); i > len(text)*2/3 {
+		text = text[:i]
+	}
+	return strings.TrimRightFunc(text, unicode.IsSpace) + "…"
+}
+
+// slugify converts text to a URL path segment: letters and digits in lower
+// case (including non-ASCII ones), other runs of characters as one "-".
+func slugify(v any) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(toString(v)) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// join joins the elements of a list (e.g. front matter tags) with sep.
+func join(sep string, v any) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(v)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = toString(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+// defaultValue returns v, or def if v is empty (missing, false, zero, or an
+// empty text, list or map): {{ .Meta.subtitle | default "Untitled" }}.
+func defaultValue(def, v any) any {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rv.Len() == 0 {
+			return def
+		}
+	default:
+		if rv.IsZero() {
+			return def
+		}
+	}
+	return v
+}
+
+// dict builds a map from key and value pairs, e.g. to pass several values
+// to a partial: {{ template "card.html" dict "Title" .Title "Tags" .Tags }}.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: odd number of arguments")
+	}
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
@@ -0,0 +1,83 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		data any
+		want string
+	}{
+		{`{{ .D | dateFormat "Jan 2, 2006" }}`, map[string]any{"D": "2025-01-02"}, "Jan 2, 2025"},
+		{`{{ .D | dateFormat "2006/01/02 15:04" }}`, map[string]any{"D": template.HTML("2025-01-02T15:04:05Z")}, "2025/01/02 15:04"},
+		{`{{ .D | dateFormat "Monday" }}`, map[string]any{"D": time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}, "Thursday"},
+		{`{{ .S | markdownify }}`, map[string]any{"S": "A *short* [link](/about)"}, `A <em>short</em> <a href="/about">link</a>`},
+		{`{{ .S | markdownify }}`, map[string]any{"S": "One\n\nTwo <script>x</script>"}, "<p>One</p>\n<p>Two <!-- raw HTML omitted -->x<!-- raw HTML omitted --></p>"},
+		{`{{ .S | truncate 12 }}`, map[string]any{"S": "The quick brown fox"}, "The quick…"},
+		{`{{ .S | truncate 6 }}`, map[string]any{"S": "日本語のページです"}, "日本語のペ…"},
+		{`{{ .S | truncate 40 }}`, map[string]any{"S": "Short"}, "Short"},
+		{`{{ .S | slugify }}`, map[string]any{"S": "  Hello, World! Ünïcode 2025 "}, "hello-world-ünïcode-2025"},
+		{`{{ .Missing | default "Untitled" }}`, map[string]any{}, "Untitled"},
+		{`{{ .S | default "Untitled" }}`, map[string]any{"S": ""}, "Untitled"},
+		{`{{ .L | default "none" }}`, map[string]any{"L": []string{}}, "none"},
+		{`{{ .N | default 10 }}`, map[string]any{"N": 3}, "3"},
+		{`{{ with dict "a" 1 "b" (list "x" "y") }}{{ .a }} {{ index .b 1 }}{{ end }}`, nil, "1 y"},
+		{`{{ .L | join ", " }}`, map[string]any{"L": []any{"go", "web"}}, "go, web"},
+		{`{{ .S | split "," | len }}`, map[string]any{"S": "a,b,c"}, "3"},
+		{`{{ .S | replace "-" " " | upper | trim }}`, map[string]any{"S": " a-b "}, "A B"},
+		{`{{ .S }}|{{ .S | safeHTML }}`, map[string]any{"S": "<b>x</b>"}, "&lt;b&gt;x&lt;/b&gt;|<b>x</b>"},
+		{`<p style="{{ .S | safeCSS }}">`, map[string]any{"S": "color: red"}, `<p style="color: red">`},
+		{`<a href="{{ .S }}"><a href="{{ .S | safeURL }}">`, map[string]any{"S": "x-app:open"}, `<a href="#ZgotmplZ"><a href="x-app:open">`},
+	}
+	for _, tt := range tests {
+		tmpl, err := template.New("t").Funcs(templateFuncs).Parse(tt.tmpl)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.tmpl, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, tt.data); err != nil {
+			t.Errorf("%s: %v", tt.tmpl, err)
+			continue
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s = %q, want %q", tt.tmpl, b.String(), tt.want)
+		}
+	}
+
+	for _, bad := range []string{`{{ "soon" | dateFormat "2006" }}`, `{{ dict "a" }}`, `{{ dict 1 2 }}`} {
+		tmpl := template.Must(template.New("t").Funcs(templateFuncs).Parse(bad))
+		if err := tmpl.Execute(&strings.Builder{}, nil); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestTemplateFuncsInLayout(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	tmplDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, tmplDir, "base.html", `<html>{{ template "card.html" dict "Slug" (slugify .Title) "Date" (.DocumentDateTime | dateFormat "2006") }}{{ .Body }}</html>`)
+	createFile(t, tmplDir, "card.html", `<div id="{{ .Slug }}">{{ .Date }}</div>`)
+	tmpl, _, err := parseTemplatePath(tmplDir)
+	if err != nil {
+		t.Fatalf("parseTemplatePath failed: %v", err)
+	}
+	srv.tmpl = tmpl
+
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest("GET", "/about", nil))
+	year := time.Now().Format("2006")
+	if body := w.Body.String(); !strings.Contains(body, `<div id="about">`) || !strings.Contains(body, ">"+year+"</div>") {
+		t.Errorf("Expected the card partial, got %s", body)
+	}
+}
@@ -0,0 +1,179 @@
+package gomadore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Page layout of a template directory
+const templateDirLayout = "base.html"
+
+// --- Template Files ---
+
+// parseTemplatePath parses a template file, or every *.html file of a
+// template directory. In a directory, base.html is the page layout and the
+// other files are partials it includes by file name
+// ({{ template "header.html" . }}). It returns the layout and its source
+// (the files of a directory, each preceded by a comment with its name).
+func parseTemplatePath(p string) (*template.Template, string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !info.IsDir() {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, "", err
+		}
+		t, err := template.New("base").Funcs(templateFuncs).Parse(string(b))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse template: %w", err)
+		}
+		return t, string(b), nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(p, "*.html"))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) == 0 {
+		return nil, "", errors.New("no *.html files in template directory")
+	}
+	set, err := template.New(filepath.Base(files[0])).Funcs(templateFuncs).ParseFiles(files...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	t := set.Lookup(templateDirLayout)
+	if t == nil {
+		return nil, "", fmt.Errorf("template directory has no %s", templateDirLayout)
+	}
+
+	var src strings.Builder
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(&src, "{{/* %s */}}\n%s", filepath.Base(f), b)
+		if len(b) > 0 && b[len(b)-1] != '\n' {
+			src.WriteByte('\n')
+		}
+	}
+	return t, src.String(), nil
+}
+
+// pageTemplate returns the template named by a page's front matter
+// "template" value: a template defined under that name, or the file of that
+// name in a template directory ("landing" selects landing.html). Unknown names
+// fall back to the page layout.
+func pageTemplate(t *template.Template, name, reqPath string) *template.Template {
+	if name == "" {
+		return t
+	}
+	for _, n := range []string{name, name + ".html"} {
+		if pt := t.Lookup(n); pt != nil {
+			return pt
+		}
+	}
+	slog.Warn("Ignore unknown page template", "path", reqPath, "template", name)
+	return t
+}
+
+// --- Template Hot Reload ---
+
+// templatePaths returns the template files and directories in use: the -t
+// path or template_filepath, and the templates of virtual hosts.
+func templatePaths(flagPath string, cfg Config) []string {
+	var paths []string
+	if p := cmp.Or(flagPath, cfg.HTML.TemplateFilePath); p != "" {
+		paths = append(paths, p)
+	}
+	for _, vc := range cfg.VHosts {
+		if vc.TemplateFilePath != "" {
+			paths = append(paths, vc.TemplateFilePath)
+		}
+	}
+	return paths
+}
+
+// watchReloadPaths calls reload after a file (a template or the
+// configuration file), or an *.html file of a template directory, has
+// changed and no further change followed within debounce. Directories
+// containing the files are watched instead of the files, since editors
+// often save by replacing the file.
+func watchReloadPaths(ctx context.Context, paths []string, debounce time.Duration, reload func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Error("Reload watcher error", "err", err)
+		return
+	}
+	defer func() {
+		if err := watcher.Close(); err != nil {
+			slog.Error("Failed to close reload watcher", "err", err)
+		}
+	}()
+
+	files := make(map[string]bool) // watched template files
+	dirs := make(map[string]bool)  // watched template directories
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		info, err := os.Stat(p)
+		if err != nil {
+			slog.Error("Failed to watch for reload", "path", p, "err", err)
+			continue
+		}
+		dir := p
+		if info.IsDir() {
+			dirs[p] = true
+		} else {
+			files[p] = true
+			dir = filepath.Dir(p)
+		}
+		if err := watcher.Add(dir); err != nil {
+			slog.Error("Failed to watch for reload", "path", p, "err", err)
+		}
+	}
+
+	var debounceTimer *time.Timer
+	for {
+		select {
+		case <-ctx.Done():
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(event.Name)
+			if event.Op == fsnotify.Chmod ||
+				!files[name] && !(dirs[filepath.Dir(name)] && strings.HasSuffix(name, ".html")) {
+				continue
+			}
+			slog.Debug("Template or configuration change detected", "file", name)
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			debounceTimer = time.AfterFunc(debounce, reload)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Reload watcher error", "err", err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package gomadore
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateDirectory(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, tmplDir, "base.html", `<html>{{ template "header.html" . }}<main>{{ .Body }}</main>{{ template "footer.html" . }}</html>`)
+	createFile(t, tmplDir, "header.html", `<header id="partial-header">{{ .Title }}</header>`)
+	createFile(t, tmplDir, "footer.html", `<footer id="partial-footer">{{ .Language }}</footer>`)
+
+	tmpl, src, err := parseTemplatePath(tmplDir)
+	if err != nil {
+		t.Fatalf("parseTemplatePath failed: %v", err)
+	}
+	for _, name := range []string{"base.html", "header.html", "footer.html"} {
+		if !strings.Contains(src, "{{/* "+name+" */}}") {
+			t.Errorf("source does not list %s:\n%s", name, src)
+		}
+	}
+
+	srv.tmpl = tmpl
+	req := httptest.NewRequestWithContext(t.Context(), "GET", "/index", nil)
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<header id="partial-header">Top Page`) {
+		t.Errorf("header partial not rendered: %s", body)
+	}
+	if !strings.Contains(body, `<footer id="partial-footer">`) {
+		t.Errorf("footer partial not rendered: %s", body)
+	}
+	if !strings.Contains(body, "Hello World") {
+		t.Errorf("page body not rendered: %s", body)
+	}
+
+	t.Run("MissingLayout", func(t *testing.T) {
+		d := t.TempDir()
+		createFile(t, d, "header.html", `<header></header>`)
+		if _, _, err := parseTemplatePath(d); err == nil || !strings.Contains(err.Error(), "base.html") {
+			t.Errorf("expected missing base.html error, got %v", err)
+		}
+	})
+
+	t.Run("EmptyDirectory", func(t *testing.T) {
+		if _, _, err := parseTemplatePath(t.TempDir()); err == nil {
+			t.Error("expected error for a directory without templates")
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		createFile(t, dir, "single.html", `<p>{{ .Title }}</p>`)
+		tmpl, src, err := parseTemplatePath(filepath.Join(dir, "single.html"))
+		if err != nil {
+			t.Fatalf("parseTemplatePath failed: %v", err)
+		}
+		if src != `<p>{{ .Title }}</p>` || tmpl.Name() != "base" {
+			t.Errorf("unexpected template %q / %q", tmpl.Name(), src)
+		}
+	})
+}
+
+func TestPageTemplate(t *testing.T) {
+	srv, dir := setupTestServer(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, tmplDir, "base.html", `<div id="base">{{ .Body }}</div>`)
+	createFile(t, tmplDir, "landing.html", `<div id="landing">{{ template "hero.html" . }}{{ .Body }}</div>`)
+	createFile(t, tmplDir, "hero.html", `<section id="hero">{{ .Title }}</section>`)
+	createFile(t, tmplDir, "defined.html", `{{ define "reference" }}<div id="reference">{{ .Body }}</div>{{ end }}`)
+
+	tmpl, _, err := parseTemplatePath(tmplDir)
+	if err != nil {
+		t.Fatalf("parseTemplatePath failed: %v", err)
+	}
+	srv.tmpl = tmpl
+
+	createFile(t, dir, "welcome.md", "---\ntemplate: landing\n---\n# Welcome\n")
+	createFile(t, dir, "api.md", "---\ntemplate: reference\n---\n# API\n")
+	createFile(t, dir, "unknown.md", "---\ntemplate: missing\n---\n# Unknown\n")
+
+	tests := []struct {
+		path   string
+		marker string
+	}{
+		{"/index", `<div id="base">`},
+		{"/welcome", `<div id="landing"><section id="hero">Welcome`},
+		{"/api", `<div id="reference">`},
+		{"/unknown", `<div id="base">`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequestWithContext(t.Context(), "GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		if w.Code != 200 || !strings.Contains(w.Body.String(), tt.marker) {
+			t.Errorf("%s: status %d, expected %q in %s", tt.path, w.Code, tt.marker, w.Body.String())
+		}
+	}
+}
+
+func TestWatchTemplates(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "layout.html", "<html>{{ .Body }}</html>")
+	tmplDir := filepath.Join(dir, "theme")
+	if err := os.Mkdir(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, tmplDir, "base.html", "{{ .Body }}")
+
+	reloads := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go watchReloadPaths(ctx, []string{filepath.Join(dir, "layout.html"), tmplDir}, 20*time.Millisecond, func() {
+		reloads <- struct{}{}
+	})
+	time.Sleep(50 * time.Millisecond) // let the watcher start
+
+	expect := func(t *testing.T, want bool) {
+		t.Helper()
+		select {
+		case <-reloads:
+			if !want {
+				t.Error("Unexpected reload")
+			}
+		case <-time.After(300 * time.Millisecond):
+			if want {
+				t.Error("Expected a reload")
+			}
+		}
+	}
+
+	t.Run("Template file", func(t *testing.T) {
+		createFile(t, dir, "layout.html", "<html><main>{{ .Body }}</main></html>")
+		expect(t, true)
+	})
+	t.Run("Other file beside it", func(t *testing.T) {
+		createFile(t, dir, "notes.txt", "x")
+		expect(t, false)
+	})
+	t.Run("Partial in a template directory", func(t *testing.T) {
+		createFile(t, tmplDir, "header.html", "<header></header>")
+		expect(t, true)
+	})
+}
+
+func TestTemplatePaths(t *testing.T) {
+	var cfg Config
+	cfg.HTML.TemplateFilePath = "./site.html"
+	cfg.VHosts = []VHostConfig{{Hosts: []string{"a.example.com"}, TemplateFilePath: "./a.html"}, {Hosts: []string{"b.example.com"}}}
+	if got := templatePaths("", cfg); !slices.Equal(got, []string{"./site.html", "./a.html"}) {
+		t.Errorf("Unexpected paths: %v", got)
+	}
+	if got := templatePaths("./theme/", cfg); got[0] != "./theme/" {
+		t.Errorf("Expected -t to take precedence, got %v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package gomadore
+
+import (
+	"net/http"
+	"time"
+)
+
+// Default limits of the HTTP listeners (seconds, bytes)
+const (
+	defaultReadHeaderTimeout = 10
+	defaultReadTimeout       = 30
+	defaultWriteTimeout      = 60
+	defaultIdleTimeout       = 120
+	defaultMaxHeaderBytes    = 1 << 20
+)
+
+// --- Server Timeouts ---
+
+// newHTTPServer returns an http.Server with the timeouts and header size
+// limit of [general], so slow or idle clients cannot hold connections open
+// indefinitely.
+func newHTTPServer(addr string, h http.Handler, cfg Config) *http.Server {
+	g := cfg.General
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: timeoutSeconds(g.ReadHeaderTimeout),
+		ReadTimeout:       timeoutSeconds(g.ReadTimeout),
+		WriteTimeout:      timeoutSeconds(g.WriteTimeout),
+		IdleTimeout:       timeoutSeconds(g.IdleTimeout),
+		MaxHeaderBytes:    g.MaxHeaderBytes,
+	}
+}
+
+// timeoutSeconds converts a timeout option. Negative values are kept, so
+// that http.Server disables the timeout instead of falling back to
+// ReadTimeout as it does for 0.
+func timeoutSeconds(sec int) time.Duration {
+	return time.Duration(sec) * time.Second
+}
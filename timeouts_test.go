@@ -0,0 +1,28 @@
+package gomadore
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	var cfg Config
+	cfg.General.IdleTimeout = -1
+	cfg.General.WriteTimeout = 5
+	cfg.applyDefaults()
+
+	srv := newHTTPServer("127.0.0.1:0", http.NotFoundHandler(), cfg)
+	if srv.ReadHeaderTimeout != defaultReadHeaderTimeout*time.Second || srv.ReadTimeout != defaultReadTimeout*time.Second {
+		t.Errorf("Expected default read timeouts, got %v, %v", srv.ReadHeaderTimeout, srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != 5*time.Second {
+		t.Errorf("WriteTimeout: got %v", srv.WriteTimeout)
+	}
+	if srv.IdleTimeout >= 0 {
+		t.Errorf("Expected a negative idle_timeout to disable it, got %v", srv.IdleTimeout)
+	}
+	if srv.MaxHeaderBytes != defaultMaxHeaderBytes {
+		t.Errorf("MaxHeaderBytes: got %d", srv.MaxHeaderBytes)
+	}
+}
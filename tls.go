@@ -0,0 +1,143 @@
+package gomadore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	// Default minimum TLS version
+	defaultTLSMinVersion = "1.2"
+	// Default port of the HTTP to HTTPS redirect listener
+	defaultTLSHTTPPort = 80
+	// Default directory of certificates obtained with ACME
+	defaultACMECacheDir = "acme-cache"
+)
+
+// Values of [tls] mode
+const (
+	tlsModeFile = "file" // cert_file and key_file
+	tlsModeACME = "acme" // certificates obtained and renewed with ACME
+)
+
+// Accepted values of [tls] min_version
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// --- TLS ---
+
+// newTLSConfig loads the configured certificate and key.
+func newTLSConfig(cfg Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tlsMinVersion(cfg),
+	}, nil
+}
+
+func tlsMinVersion(cfg Config) uint16 {
+	minVersion, ok := tlsVersions[cfg.TLS.MinVersion]
+	if !ok {
+		minVersion = tlsVersions[defaultTLSMinVersion]
+	}
+	return minVersion
+}
+
+// newACMEManager returns an autocert manager that obtains certificates for
+// acme_domains (and no other host) on the first handshake, keeps them in
+// acme_cache_dir and renews them before they expire. Using it accepts the
+// terms of service of the CA.
+func newACMEManager(cfg Config) (*autocert.Manager, error) {
+	if err := os.MkdirAll(cfg.TLS.ACMECacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("acme cache: %w", err)
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.TLS.ACMECacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.TLS.ACMEDomains...),
+		Email:      cfg.TLS.ACMEEmail,
+	}
+	if cfg.TLS.ACMEDirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.TLS.ACMEDirectoryURL}
+	}
+	return m, nil
+}
+
+// acmeTLSConfig returns the TLS configuration of an ACME manager. It also
+// answers TLS-ALPN-01 challenges, so no HTTP listener is needed.
+func acmeTLSConfig(m *autocert.Manager, cfg Config) *tls.Config {
+	tc := m.TLSConfig()
+	tc.MinVersion = tlsMinVersion(cfg)
+	return tc
+}
+
+// setClientAuth makes tc verify client certificates against the CAs of
+// client_ca_file: required with require_client_cert, else only if the
+// client presents one. ACME challenge handshakes (TLS-ALPN-01, offering
+// only the acme-tls/1 protocol) are exempt, since the CA has no client
+// certificate.
+func setClientAuth(tc *tls.Config, cfg Config) error {
+	if cfg.TLS.ClientCAFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(cfg.TLS.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return fmt.Errorf("client_ca_file %s: no PEM certificates", cfg.TLS.ClientCAFile)
+	}
+
+	challenge := tc.Clone()
+	tc.ClientCAs = pool
+	tc.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.TLS.RequireClientCert {
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if cfg.TLS.Mode == tlsModeACME {
+		tc.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			// autocert only answers handshakes offering nothing else
+			if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acme.ALPNProto {
+				return challenge, nil
+			}
+			return nil, nil
+		}
+	}
+	return nil
+}
+
+// httpsRedirectHandler redirects every request to the same URL on HTTPS.
+// The port is omitted if httpsPort is 443.
+func httpsRedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]") // IPv6 without port
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
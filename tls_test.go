@@ -0,0 +1,268 @@
+package gomadore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and key for 127.0.0.1 and
+// returns their paths.
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gomadore test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	var cfg Config
+	cfg.TLS.CertFile = certFile
+	cfg.TLS.KeyFile = keyFile
+	for version, want := range map[string]uint16{"": tls.VersionTLS12, "1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13} {
+		cfg.TLS.MinVersion = version
+		tc, err := newTLSConfig(cfg)
+		if err != nil {
+			t.Fatalf("newTLSConfig failed: %v", err)
+		}
+		if tc.MinVersion != want || len(tc.Certificates) != 1 {
+			t.Errorf("min_version %q: got MinVersion %x, %d certificates", version, tc.MinVersion, len(tc.Certificates))
+		}
+	}
+
+	cfg.TLS.KeyFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := newTLSConfig(cfg); err == nil {
+		t.Error("Expected error for missing key file")
+	}
+
+	t.Run("Serve", func(t *testing.T) {
+		srv, _ := setupTestServer(t)
+		cfg.TLS.KeyFile = keyFile
+		cfg.TLS.MinVersion = "1.2"
+		tc, err := newTLSConfig(cfg)
+		if err != nil {
+			t.Fatalf("newTLSConfig failed: %v", err)
+		}
+		ts := httptest.NewUnstartedServer(srv.routes())
+		ts.TLS = tc
+		ts.StartTLS()
+		defer ts.Close()
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		resp, err := client.Get(ts.URL + "/about")
+		if err != nil {
+			t.Fatalf("HTTPS request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+			t.Errorf("Expected 200 over TLS, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		port   int
+		host   string
+		target string
+		want   string
+	}{
+		{443, "example.com", "/docs/a?x=1", "https://example.com/docs/a?x=1"},
+		{443, "example.com:80", "/", "https://example.com/"},
+		{8443, "example.com:8080", "/a", "https://example.com:8443/a"},
+		{443, "[::1]:80", "/", "https://[::1]/"},
+		{8443, "[::1]", "/", "https://[::1]:8443/"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, tt.target, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		httpsRedirectHandler(tt.port).ServeHTTP(w, req)
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tt.want {
+			t.Errorf("%s%s: got %d %q, want %q", tt.host, tt.target, w.Code, w.Header().Get("Location"), tt.want)
+		}
+	}
+}
+
+func TestACMEManager(t *testing.T) {
+	var cfg Config
+	cfg.TLS.Mode = tlsModeACME
+	cfg.TLS.ACMEDomains = []string{"docs.example.com"}
+	cfg.TLS.ACMECacheDir = filepath.Join(t.TempDir(), "acme")
+	cfg.TLS.ACMEDirectoryURL = "https://acme-staging.example.com/directory"
+	cfg.TLS.MinVersion = "1.3"
+
+	m, err := newACMEManager(cfg)
+	if err != nil {
+		t.Fatalf("newACMEManager failed: %v", err)
+	}
+	if info, err := os.Stat(cfg.TLS.ACMECacheDir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("cache directory not created with 0700: %v", err)
+	}
+	if m.Client == nil || m.Client.DirectoryURL != cfg.TLS.ACMEDirectoryURL {
+		t.Error("directory URL not applied")
+	}
+	if err := m.HostPolicy(t.Context(), "docs.example.com"); err != nil {
+		t.Errorf("configured domain rejected: %v", err)
+	}
+	if err := m.HostPolicy(t.Context(), "other.example.com"); err == nil {
+		t.Error("unlisted domain accepted")
+	}
+
+	tc := acmeTLSConfig(m, cfg)
+	if tc.GetCertificate == nil || tc.MinVersion != tls.VersionTLS13 || !slices.Contains(tc.NextProtos, "acme-tls/1") {
+		t.Errorf("unexpected TLS config: min %x, protos %v", tc.MinVersion, tc.NextProtos)
+	}
+
+	// HTTP-01 challenges are answered before the redirect
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/docs/", nil)
+	req.Host = "docs.example.com"
+	w := httptest.NewRecorder()
+	m.HTTPHandler(httpsRedirectHandler(443)).ServeHTTP(w, req)
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("non-challenge request not redirected: %d", w.Code)
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	clientCert, clientKey := writeTestCert(t) // self-signed: its own CA
+	otherCert, otherKey := writeTestCert(t)
+
+	var cfg Config
+	cfg.TLS.CertFile = certFile
+	cfg.TLS.KeyFile = keyFile
+	cfg.TLS.ClientCAFile = clientCert
+
+	get := func(t *testing.T, require bool, certFile, keyFile string, protos ...string) error {
+		t.Helper()
+		cfg.TLS.RequireClientCert = require
+		tc, err := newTLSConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(protos) > 0 {
+			cfg.TLS.Mode = tlsModeACME
+			defer func() { cfg.TLS.Mode = "" }()
+		}
+		if err := setClientAuth(tc, cfg); err != nil {
+			t.Fatalf("setClientAuth failed: %v", err)
+		}
+		srv, _ := setupTestServer(t)
+		ts := httptest.NewUnstartedServer(srv.routes())
+		ts.TLS = tc
+		ts.StartTLS()
+		defer ts.Close()
+
+		clientTLS := &tls.Config{InsecureSkipVerify: true, NextProtos: protos}
+		if certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientTLS.Certificates = []tls.Certificate{cert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := client.Get(ts.URL + "/about")
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+
+	if err := get(t, true, clientCert, clientKey); err != nil {
+		t.Errorf("Expected the issued certificate to be accepted: %v", err)
+	}
+	if err := get(t, true, "", ""); err == nil {
+		t.Error("Expected a connection without certificate to fail")
+	}
+	if err := get(t, true, otherCert, otherKey); err == nil {
+		t.Error("Expected a certificate of another CA to be rejected")
+	}
+	if err := get(t, false, "", ""); err != nil {
+		t.Errorf("Expected an optional certificate to be optional: %v", err)
+	}
+	if err := get(t, false, otherCert, otherKey); err == nil {
+		t.Error("Expected a presented certificate to be verified")
+	}
+
+	t.Run("ACME challenge", func(t *testing.T) {
+		cfg.TLS.Mode = tlsModeACME
+		cfg.TLS.RequireClientCert = true
+		defer func() { cfg.TLS.Mode = "" }()
+		tc := &tls.Config{}
+		if err := setClientAuth(tc, cfg); err != nil {
+			t.Fatal(err)
+		}
+		challenge, _ := tc.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{"acme-tls/1"}})
+		if challenge == nil || challenge.ClientAuth != tls.NoClientCert {
+			t.Errorf("Expected challenge handshakes without client certificates, got %+v", challenge)
+		}
+		for _, protos := range [][]string{{"h2"}, {"acme-tls/1", "http/1.1"}} {
+			if c, _ := tc.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: protos}); c != nil {
+				t.Errorf("Expected handshakes offering %v to use the base configuration", protos)
+			}
+		}
+
+		// Offering acme-tls/1 besides HTTP does not skip the client certificate
+		if err := get(t, true, "", "", "acme-tls/1", "http/1.1"); err == nil {
+			t.Error("Expected a connection without certificate to fail")
+		}
+		if err := get(t, true, clientCert, clientKey, "acme-tls/1", "http/1.1"); err != nil {
+			t.Errorf("Expected the issued certificate to be accepted: %v", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		cfg.TLS.ClientCAFile = filepath.Join(t.TempDir(), "missing.pem")
+		if err := setClientAuth(&tls.Config{}, cfg); err == nil {
+			t.Error("Expected error for a missing CA file")
+		}
+		cfg.TLS.ClientCAFile = keyFile
+		if err := setClientAuth(&tls.Config{}, cfg); err == nil {
+			t.Error("Expected error for a file without certificates")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package gomadore
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	// Default heading levels included in the table of contents
+	defaultTOCMinLevel = 2
+	defaultTOCMaxLevel = 4
+)
+
+// Paragraph that is replaced by the table of contents
+const tocMarker = "<p>[TOC]</p>"
+
+// --- Table of Contents ---
+
+// tocEntry is a heading in the table of contents.
+type tocEntry struct {
+	Level    int         `json:"level"`
+	ID       string      `json:"id"`
+	Title    string      `json:"title"`
+	Children []*tocEntry `json:"children,omitempty"`
+}
+
+// buildTOC collects the headings between minLevel and maxLevel into a tree.
+// A heading is nested under the closest preceding heading of a lower level.
+func buildTOC(doc ast.Node, src []byte, minLevel, maxLevel int) []*tocEntry {
+	var root []*tocEntry
+	var stack []*tocEntry // path from the top level to the last entry
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if h.Level < minLevel || h.Level > maxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+		e := &tocEntry{Level: h.Level, Title: nodeText(h, src)}
+		if id, ok := h.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				e.ID = string(b)
+			}
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= e.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			root = append(root, e)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, e)
+		}
+		stack = append(stack, e)
+		return ast.WalkSkipChildren, nil
+	})
+	return root
+}
+
+// renderTOC renders the entries as nested lists ("" if there are none).
+func renderTOC(entries []*tocEntry) template.HTML {
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`<nav class="toc">`)
+	writeTOCList(&b, entries)
+	b.WriteString("</nav>")
+	return template.HTML(b.String())
+}
+
+func writeTOCList(b *strings.Builder, entries []*tocEntry) {
+	b.WriteString("<ul>")
+	for _, e := range entries {
+		b.WriteString("<li>")
+		if e.ID != "" {
+			b.WriteString(`<a href="#` + template.HTMLEscapeString(e.ID) + `">` + template.HTMLEscapeString(e.Title) + "</a>")
+		} else {
+			b.WriteString(template.HTMLEscapeString(e.Title))
+		}
+		if len(e.Children) > 0 {
+			writeTOCList(b, e.Children)
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+// injectTOC replaces "[TOC]" paragraphs of rendered markdown with the table of contents.
+func injectTOC(body string, toc template.HTML) string {
+	return strings.ReplaceAll(body, tocMarker, string(toc))
+}
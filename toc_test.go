@@ -0,0 +1,75 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestBuildTOC(t *testing.T) {
+	src := []byte("# Title\n\n## Install\n\n### From *source*\n\n#### Deep\n\n##### Too deep\n\n## Usage\n\n### Flags\n\n## Usage\n")
+	md := goldmark.New(goldmark.WithParserOptions(parser.WithAutoHeadingID()))
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	entries := buildTOC(doc, src, 2, 4)
+	if len(entries) != 3 {
+		t.Fatalf("Top level count mismatch: got %d", len(entries))
+	}
+	install := entries[0]
+	if install.Title != "Install" || install.ID != "install" || install.Level != 2 {
+		t.Errorf("Entry mismatch: %+v", install)
+	}
+	if len(install.Children) != 1 || install.Children[0].Title != "From source" || len(install.Children[0].Children) != 1 {
+		t.Errorf("Nesting mismatch: %+v", install.Children)
+	}
+	if deep := install.Children[0].Children[0]; deep.Title != "Deep" || len(deep.Children) != 0 {
+		t.Errorf("Level limit not applied: %+v", deep)
+	}
+	if entries[2].ID != "usage-1" {
+		t.Errorf("Duplicate heading ID mismatch: got %q", entries[2].ID)
+	}
+
+	got := string(renderTOC(entries))
+	want := `<nav class="toc"><ul><li><a href="#install">Install</a><ul><li><a href="#from-source">From source</a><ul><li><a href="#deep">Deep</a></li></ul></li></ul></li>` +
+		`<li><a href="#usage">Usage</a><ul><li><a href="#flags">Flags</a></li></ul></li><li><a href="#usage-1">Usage</a></li></ul></nav>`
+	if got != want {
+		t.Errorf("renderTOC mismatch:\n got: %s\nwant: %s", got, want)
+	}
+
+	if renderTOC(buildTOC(doc, src, 6, 6)) != "" {
+		t.Error("Empty TOC should render as empty string")
+	}
+}
+
+func TestTemplateTOC(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	srv.config.TOC.MinLevel = 2
+	srv.config.TOC.MaxLevel = 3
+	srv.tmpl = template.Must(template.New("base").Parse(
+		`[TOC:{{.TOC}}][Entries:{{range .TOCEntries}}{{.Title}}({{len .Children}}),{{end}}]{{.Body}}`))
+
+	createFile(t, dir, "ref.md", "# Reference\n\n[TOC]\n\n## A <b>&</b> B\n\n### Sub\n\n## C\n")
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/ref", nil)
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, req)
+	body := w.Body.String()
+
+	toc := `<nav class="toc"><ul><li><a href="#a-bb-b">A &amp; B</a><ul><li><a href="#sub">Sub</a></li></ul></li><li><a href="#c">C</a></li></ul></nav>`
+	if !strings.Contains(body, "[TOC:"+toc+"]") {
+		t.Errorf("TOC variable mismatch: %s", body)
+	}
+	if !strings.Contains(body, "[Entries:A &amp; B(1),C(0),]") {
+		t.Errorf("TOCEntries mismatch: %s", body)
+	}
+	// The marker is replaced in the body
+	if strings.Contains(body, "<p>[TOC]</p>") || strings.Count(body, toc) != 2 {
+		t.Errorf("[TOC] marker should be replaced: %s", body)
+	}
+}
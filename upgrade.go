@@ -0,0 +1,40 @@
+package gomadore
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Time a new process has to start serving after SIGUSR2 (seconds)
+const upgradeTimeout = 30
+
+// namedListener is a listener passed on to a new process by an upgrade; the
+// name identifies it there ("main", "redirect", "metrics").
+type namedListener struct {
+	name string
+	ln   net.Listener
+}
+
+// --- Binary Upgrade ---
+
+// writePIDFile writes the process ID to general.pid_file (if set), so a
+// supervisor can follow the process that serves after an upgrade.
+func writePIDFile(file string) error {
+	if file == "" {
+		return nil
+	}
+	return os.WriteFile(file, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// removePIDFile removes the PID file unless a newer process has replaced it.
+func removePIDFile(file string) {
+	if file == "" {
+		return
+	}
+	b, err := os.ReadFile(file)
+	if err == nil && string(bytes.TrimSpace(b)) == strconv.Itoa(os.Getpid()) {
+		_ = os.Remove(file)
+	}
+}
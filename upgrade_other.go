@@ -0,0 +1,20 @@
+//go:build !unix
+
+package gomadore
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// upgradeSignal is nil: binary upgrades need file descriptor passing.
+var upgradeSignal os.Signal
+
+func inheritedListener(name string) (net.Listener, error) { return nil, nil }
+
+func notifyUpgraded() {}
+
+func upgrade(exe string, args []string, lns []namedListener) error {
+	return errors.New("binary upgrades are not supported on this platform")
+}
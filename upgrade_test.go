@@ -0,0 +1,34 @@
+package gomadore
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestPIDFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gomadore.pid")
+	if err := writePIDFile(file); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil || string(b) != strconv.Itoa(os.Getpid())+"\n" {
+		t.Fatalf("Unexpected PID file: %q %v", b, err)
+	}
+
+	// Replaced by a newer process: kept
+	createFile(t, filepath.Dir(file), "gomadore.pid", "999999\n")
+	removePIDFile(file)
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("Expected the PID file of another process to be kept: %v", err)
+	}
+
+	if err := writePIDFile(file); err != nil {
+		t.Fatal(err)
+	}
+	removePIDFile(file)
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Expected the PID file to be removed: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+//go:build unix
+
+package gomadore
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// upgradeSignal makes the process start a new binary (see upgrade).
+var upgradeSignal os.Signal = syscall.SIGUSR2
+
+// Environment of a process started by an upgrade: the names of the
+// inherited listeners, in the order of their file descriptors from 3 on.
+// The descriptor after them signals readiness.
+const envUpgradeListeners = "GOMADORE_UPGRADE_LISTENERS"
+
+var inherited = sync.OnceValue(func() map[string]*os.File {
+	names := os.Getenv(envUpgradeListeners)
+	if names == "" {
+		return nil
+	}
+	_ = os.Unsetenv(envUpgradeListeners) // not passed on to hooks and later upgrades
+	files := make(map[string]*os.File)
+	list := strings.Split(names, ",")
+	for i, name := range list {
+		files[name] = os.NewFile(uintptr(3+i), name)
+	}
+	files[""] = os.NewFile(uintptr(3+len(list)), "ready")
+	return files
+})
+
+// inheritedListener returns the listener of that name passed by the process
+// that started this one for an upgrade, or nil.
+func inheritedListener(name string) (net.Listener, error) {
+	f := inherited()[name]
+	if f == nil {
+		return nil, nil
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited listener %s: %w", name, err)
+	}
+	return ln, nil
+}
+
+// notifyUpgraded tells the process that started this one for an upgrade
+// that it is serving, so the old process can stop. Safe to call otherwise.
+func notifyUpgraded() {
+	if f := inherited()[""]; f != nil {
+		_, _ = f.Write([]byte{1})
+		_ = f.Close()
+	}
+}
+
+// upgrade starts exe with args, passing it the listeners, and waits until
+// the new process is serving. On error the new process is stopped, and the
+// caller keeps serving.
+func upgrade(exe string, args []string, lns []namedListener) error {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	var names []string
+	for _, l := range lns {
+		fl, ok := l.ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("listener %s cannot be passed on", l.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("listener %s: %w", l.name, err)
+		}
+		files = append(files, f)
+		names = append(names, l.name)
+	}
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(slices.DeleteFunc(os.Environ(), func(e string) bool {
+		return strings.HasPrefix(e, envUpgradeListeners+"=")
+	}), envUpgradeListeners+"="+strings.Join(names, ","))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Only the new process may hold the write end, so that its exit ends the read
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	done := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		_, err := ready.Read(b)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			go func() { _ = cmd.Wait() }() // reap the new process if it exits first
+			return nil
+		}
+		_ = cmd.Wait()
+		return errors.New("new process exited before serving")
+	case <-time.After(upgradeTimeout * time.Second):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return errors.New("new process did not start serving in time")
+	}
+}
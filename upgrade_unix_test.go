@@ -0,0 +1,65 @@
+//go:build unix
+
+package gomadore
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// TestUpgradeHelperProcess is the new process started by TestUpgrade.
+func TestUpgradeHelperProcess(t *testing.T) {
+	if os.Getenv("GOMADORE_TEST_UPGRADE") != "1" {
+		t.Skip("helper process")
+	}
+	ln, err := inheritedListener("main")
+	if err != nil || ln == nil {
+		t.Fatalf("No inherited listener: %v", err)
+	}
+	done := make(chan struct{})
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "new process")
+		close(done)
+	}))
+	notifyUpgraded()
+	<-done
+}
+
+func TestUpgrade(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOMADORE_TEST_UPGRADE", "1")
+	if err := upgrade(os.Args[0], []string{"-test.run=^TestUpgradeHelperProcess$"}, []namedListener{{"main", ln}}); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	// The old process stops accepting; the new one serves the same address
+	addr := ln.Addr().String()
+	ln.Close()
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("Request after the upgrade failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "new process" {
+		t.Errorf("Expected the new process to answer, got %q", body)
+	}
+
+	t.Run("New process fails", func(t *testing.T) {
+		t.Setenv("GOMADORE_TEST_UPGRADE", "0")
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		// The helper skips without serving and exits
+		if err := upgrade(os.Args[0], []string{"-test.run=^TestUpgradeHelperProcess$"}, []namedListener{{"main", ln}}); err == nil {
+			t.Error("Expected an error when the new process exits")
+		}
+	})
+}
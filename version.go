@@ -0,0 +1,94 @@
+package gomadore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+func init() {
+	resolveVersion()
+}
+
+// resolveVersion fills build information not set via -ldflags from the
+// information the Go toolchain embeds into the binary (module version, VCS stamp).
+func resolveVersion() {
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		if Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if Commit == "" {
+					Commit = s.Value
+				}
+			case "vcs.time":
+				if BuildDate == "" {
+					BuildDate = s.Value
+				}
+			}
+		}
+	}
+
+	if Version == "" {
+		Version = "dev"
+	}
+	if Revision == "" {
+		Revision = "unknown"
+		if Commit != "" {
+			Revision = Commit[:min(len(Commit), 7)]
+		}
+	}
+}
+
+// versionInfo is the build information printed by "-v -json".
+type versionInfo struct {
+	Version   string   `json:"version"`
+	Revision  string   `json:"revision"`
+	Commit    string   `json:"commit"`
+	BuildDate string   `json:"build_date"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	BuildTags []string `json:"build_tags"`
+}
+
+func currentVersionInfo() versionInfo {
+	v := versionInfo{
+		Version:   Version,
+		Revision:  Revision,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		BuildTags: []string{},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key != "-tags" {
+				continue
+			}
+			for tag := range strings.SplitSeq(s.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					v.BuildTags = append(v.BuildTags, tag)
+				}
+			}
+		}
+	}
+	return v
+}
+
+// printVersion writes the version line, or the full build information as JSON.
+func printVersion(w io.Writer, asJSON bool) error {
+	if !asJSON {
+		_, err := fmt.Fprintf(w, "%s/gomadore (%s-%s)\n", Maintainer, Version, Revision)
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(currentVersionInfo())
+}
@@ -0,0 +1,76 @@
+package gomadore
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveVersion(t *testing.T) {
+	saved := [4]string{Version, Revision, Commit, BuildDate}
+	defer func() { Version, Revision, Commit, BuildDate = saved[0], saved[1], saved[2], saved[3] }()
+
+	t.Run("ldflags take precedence", func(t *testing.T) {
+		Version, Revision, Commit, BuildDate = "v9.9.9", "release", "0123456789abcdef", "2025-01-01T00:00:00Z"
+		resolveVersion()
+		if Version != "v9.9.9" || Revision != "release" || Commit != "0123456789abcdef" || BuildDate != "2025-01-01T00:00:00Z" {
+			t.Errorf("Values set at link time were overwritten: %s %s %s %s", Version, Revision, Commit, BuildDate)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		Version, Revision, Commit, BuildDate = "", "", "0123456789abcdef", ""
+		resolveVersion()
+		// Test binaries have no module version
+		if Version != "dev" {
+			t.Errorf("Version mismatch: got %s", Version)
+		}
+		if Revision != "0123456" {
+			t.Errorf("Revision should be the short commit, got %s", Revision)
+		}
+
+		Revision, Commit = "", ""
+		resolveVersion()
+		if Commit == "" && Revision != "unknown" {
+			t.Errorf("Revision mismatch: got %s", Revision)
+		}
+	})
+}
+
+func TestPrintVersion(t *testing.T) {
+	saved := [4]string{Version, Revision, Commit, BuildDate}
+	defer func() { Version, Revision, Commit, BuildDate = saved[0], saved[1], saved[2], saved[3] }()
+	Version, Revision, Commit, BuildDate = "v1.2.3", "release", "abcdef0", "2025-06-01T12:00:00Z"
+
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printVersion(&buf, false); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != "kumakaba/gomadore (v1.2.3-release)\n" {
+			t.Errorf("Output mismatch: got %q", got)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printVersion(&buf, true); err != nil {
+			t.Fatal(err)
+		}
+		var v versionInfo
+		if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if v.Version != "v1.2.3" || v.Revision != "release" || v.Commit != "abcdef0" || v.BuildDate != "2025-06-01T12:00:00Z" {
+			t.Errorf("Build info mismatch: %+v", v)
+		}
+		if v.GoVersion != runtime.Version() || v.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+			t.Errorf("Runtime info mismatch: %+v", v)
+		}
+		if v.BuildTags == nil || !strings.Contains(buf.String(), `"build_tags": [`) {
+			t.Errorf("build_tags should be an array: %s", buf.String())
+		}
+	})
+}
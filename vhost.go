@@ -0,0 +1,278 @@
+package gomadore
+
+import (
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VHostConfig overrides site settings for requests to the given host names.
+// Empty values inherit the [html] and [cache] settings. With a markdown_rootdir
+// of its own, the virtual host is served as a separate site (see newSubsites).
+type VHostConfig struct {
+	Hosts            []string `toml:"hosts" validate:"required,min=1,dive,required"`
+	MarkdownRootDir  RootDirs `toml:"markdown_rootdir" validate:"omitempty,dive,dir"`
+	SiteURL          string   `toml:"site_url" validate:"omitempty,url"`
+	SiteTitle        string   `toml:"site_title"`
+	SiteLang         string   `toml:"site_lang"`
+	SiteAuthor       string   `toml:"site_author"`
+	BaseCSSUrl       string   `toml:"base_css_url"`
+	ScreenCSSUrl     string   `toml:"screen_css_url"`
+	PrintCSSUrl      string   `toml:"print_css_url"`
+	TemplateFilePath string   `toml:"template_filepath"`
+	CacheLimit       *int     `toml:"cache_limit"`
+}
+
+// vhost is a configured virtual host.
+type vhost struct {
+	cfg  VHostConfig
+	key  string             // cache key prefix (first host name)
+	tmpl *template.Template // nil: use the default template
+}
+
+// site holds the effective settings for rendering a request.
+type site struct {
+	key        string // cache key prefix ("" for the default site)
+	url        string // configured site URL ("" to derive from the request)
+	title      string
+	author     string
+	lang       string
+	baseCSS    string
+	screenCSS  string
+	printCSS   string
+	tmpl       *template.Template
+	cacheLimit int
+}
+
+// cacheKey returns the cache key of a page for this site.
+func (st *site) cacheKey(pageKey string) string {
+	return st.key + pageKey
+}
+
+// normalizeHost lower-cases a host name and strips the port and trailing dot.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// newVHosts builds the virtual host table (host name -> vhost) of the
+// virtual hosts that share markdown_rootdir.
+func newVHosts(configs []VHostConfig) (map[string]*vhost, error) {
+	vhosts := make(map[string]*vhost)
+	for _, vc := range configs {
+		if len(vc.MarkdownRootDir) > 0 {
+			continue
+		}
+		vh := &vhost{cfg: vc, key: normalizeHost(vc.Hosts[0])}
+		if vc.TemplateFilePath != "" {
+			t, _, err := parseTemplatePath(vc.TemplateFilePath)
+			if err != nil {
+				return nil, fmt.Errorf("vhost %s: %w", vh.key, err)
+			}
+			vh.tmpl = t
+		}
+		for _, h := range vc.Hosts {
+			h = normalizeHost(h)
+			if _, dup := vhosts[h]; dup {
+				return nil, fmt.Errorf("vhost %s: host is configured twice", h)
+			}
+			vhosts[h] = vh
+		}
+	}
+	return vhosts, nil
+}
+
+// newSubsites builds a Server for every virtual host with its own
+// markdown_rootdir, from a copy of the configuration with the virtual host's
+// settings applied. A subsite has its own cache, page index, navigation,
+// search index and file watcher. Listener-wide features stay with the main
+// Server (access log, metrics, authentication including OIDC), and
+// webmentions, the git webhook and the edit API only work on the main site.
+// The feed title and the manifest name are the subsite's title, and IndexNow
+// is only enabled for a subsite with its own site_url.
+func newSubsites(cfg Config, t *template.Template, vhosts map[string]*vhost) ([]*Server, map[string]*Server, error) {
+	var subsites []*Server
+	byHost := make(map[string]*Server)
+	for _, vc := range cfg.VHosts {
+		if len(vc.MarkdownRootDir) == 0 {
+			continue
+		}
+		key := normalizeHost(vc.Hosts[0])
+
+		sub := cfg
+		sub.VHosts = nil
+		sub.AccessLog.Enabled = false
+		sub.Metrics.Enabled = false
+		sub.Auth.Enabled = false
+		sub.Auth.OIDC.Enabled = false
+		sub.Headers.Enabled = false
+		sub.Webmention.Enabled = false
+		sub.Git.Enabled = false
+		sub.Edit.Enabled = false
+		sub.HTML.MarkdownRootDir = vc.MarkdownRootDir
+		sub.HTML.TemplateFilePath = ""
+		override := func(dst *string, v string) {
+			if v != "" {
+				*dst = v
+			}
+		}
+		// Without its own site_url, absolute URLs are derived from the request
+		sub.HTML.SiteURL = vc.SiteURL
+		if vc.SiteURL == "" {
+			sub.IndexNow.Enabled = false
+		}
+		override(&sub.HTML.SiteTitle, vc.SiteTitle)
+		override(&sub.HTML.SiteAuthor, vc.SiteAuthor)
+		override(&sub.HTML.SiteLang, vc.SiteLang)
+		override(&sub.HTML.BaseCSSUrl, vc.BaseCSSUrl)
+		override(&sub.HTML.ScreenCSSUrl, vc.ScreenCSSUrl)
+		override(&sub.HTML.PrintCSSUrl, vc.PrintCSSUrl)
+		if vc.CacheLimit != nil {
+			sub.Cache.CacheLimit = max(*vc.CacheLimit, 0)
+		}
+		// Defaults of the main site's title (see applyDefaults) name the subsite
+		sub.Feed.Title = sub.HTML.SiteTitle
+		sub.Manifest.Name = sub.HTML.SiteTitle
+		sub.Manifest.ShortName = ""
+		sub.Manifest.Description = ""
+
+		tmpl := t
+		if vc.TemplateFilePath != "" {
+			var err error
+			if tmpl, _, err = parseTemplatePath(vc.TemplateFilePath); err != nil {
+				return nil, nil, fmt.Errorf("vhost %s: %w", key, err)
+			}
+		}
+		srv, err := newServer(sub, tmpl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("vhost %s: %w", key, err)
+		}
+		subsites = append(subsites, srv)
+
+		for _, h := range vc.Hosts {
+			h = normalizeHost(h)
+			if _, dup := byHost[h]; dup || vhosts[h] != nil {
+				return nil, nil, fmt.Errorf("vhost %s: host is configured twice", h)
+			}
+			byHost[h] = srv
+		}
+	}
+	return subsites, byHost, nil
+}
+
+// servers returns the Server and its subsites.
+func (s *Server) servers() []*Server {
+	return append([]*Server{s}, s.subsites...)
+}
+
+// hostRoutes returns the routes of the server, dispatching requests for the
+// host names of subsites to their routes.
+func (s *Server) hostRoutes() http.Handler {
+	mux := s.routes()
+	if len(s.subsiteFor) == 0 {
+		return mux
+	}
+	routes := make(map[*Server]http.Handler, len(s.subsites))
+	for _, sub := range s.subsites {
+		routes[sub] = sub.routes()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sub := s.subsiteFor[normalizeHost(r.Host)]; sub != nil {
+			routes[sub].ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// siteFor returns the effective site settings for a request's host.
+func (s *Server) siteFor(r *http.Request) *site {
+	return s.siteForHost(r.Host)
+}
+
+// siteForHost returns the effective site settings for a host name
+// ("" for the default site).
+func (s *Server) siteForHost(host string) *site {
+	st := &site{
+		url:        s.config.HTML.SiteURL,
+		title:      s.config.HTML.SiteTitle,
+		author:     s.config.HTML.SiteAuthor,
+		lang:       s.config.HTML.SiteLang,
+		baseCSS:    s.config.HTML.BaseCSSUrl,
+		screenCSS:  s.config.HTML.ScreenCSSUrl,
+		printCSS:   s.config.HTML.PrintCSSUrl,
+		tmpl:       s.tmpl,
+		cacheLimit: s.config.Cache.CacheLimit,
+	}
+
+	vh := s.vhosts[normalizeHost(host)]
+	if vh == nil {
+		return st
+	}
+	st.key = vh.key
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	override(&st.url, vh.cfg.SiteURL)
+	override(&st.title, vh.cfg.SiteTitle)
+	override(&st.author, vh.cfg.SiteAuthor)
+	override(&st.lang, vh.cfg.SiteLang)
+	override(&st.baseCSS, vh.cfg.BaseCSSUrl)
+	override(&st.screenCSS, vh.cfg.ScreenCSSUrl)
+	override(&st.printCSS, vh.cfg.PrintCSSUrl)
+	if vh.tmpl != nil {
+		st.tmpl = vh.tmpl
+	}
+	if vh.cfg.CacheLimit != nil {
+		st.cacheLimit = max(*vh.cfg.CacheLimit, 0)
+	}
+	return st
+}
+
+// siteURL returns the absolute base URL (without trailing slash).
+// The configured site_url of the request's site is preferred over the Host header.
+func (s *Server) siteURL(r *http.Request) string {
+	if u := s.siteFor(r).url; u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return s.proxies.scheme(r) + "://" + r.Host
+}
+
+// dropCachedPage removes a page from the cache of every site.
+func (s *Server) dropCachedPage(pageKey string) {
+	s.cache.Lock()
+	defer s.cache.Unlock()
+	s.cache.remove(pageKey)
+	s.renders.Forget(pageKey) // later requests must not wait for a render of the old file
+	for _, vh := range s.vhosts {
+		s.cache.remove(vh.key + pageKey)
+		s.renders.Forget(vh.key + pageKey)
+	}
+}
+
+// cacheGCInterval returns the interval of the cache cleaner: half of the
+// shortest positive cache limit of all sites and [[cache.rule]] entries, at
+// least 60 seconds. It returns 0 if no page has an expiring cache.
+func (s *Server) cacheGCInterval() time.Duration {
+	shortest := s.config.Cache.CacheLimit
+	for _, vh := range s.vhosts {
+		if l := vh.cfg.CacheLimit; l != nil && *l > 0 && (shortest <= 0 || *l < shortest) {
+			shortest = *l
+		}
+	}
+	d := time.Duration(max(shortest, 0)) * time.Second
+	if r := s.cacheRules.shortest(); r > 0 && (d == 0 || r < d) {
+		d = r
+	}
+	if d == 0 {
+		return 0
+	}
+	return max(d/2, 60*time.Second)
+}
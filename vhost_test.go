@@ -0,0 +1,236 @@
+package gomadore
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupVHostServer creates a server with one virtual host (two host names)
+func setupVHostServer(t *testing.T) *Server {
+	t.Helper()
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SiteTitle = "Default Site"
+	srv.config.HTML.SiteLang = "en"
+	srv.tmpl = template.Must(template.New("base").Parse(`default:{{.Title}}|{{.Language}}|{{.BaseCSS}}`))
+
+	createFile(t, dir, "docs.tmpl", `docs:{{.Title}}|{{.Language}}|{{.Author}}|{{.BaseCSS}}`)
+	limit := 30
+	vhosts, err := newVHosts([]VHostConfig{{
+		Hosts:            []string{"Docs.Example.com", "www.docs.example.com"},
+		SiteURL:          "https://docs.example.com/",
+		SiteTitle:        "Docs",
+		SiteLang:         "ja",
+		SiteAuthor:       "Docs Team",
+		BaseCSSUrl:       "/docs.css",
+		TemplateFilePath: dir + "/docs.tmpl",
+		CacheLimit:       &limit,
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create vhosts: %v", err)
+	}
+	srv.vhosts = vhosts
+	return srv
+}
+
+func TestNewVHosts(t *testing.T) {
+	_, err := newVHosts([]VHostConfig{
+		{Hosts: []string{"a.example.com"}},
+		{Hosts: []string{"b.example.com", "A.example.com."}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "configured twice") {
+		t.Errorf("Expected duplicate host error, got %v", err)
+	}
+
+	_, err = newVHosts([]VHostConfig{{Hosts: []string{"a.example.com"}, TemplateFilePath: "/nonexistent.tmpl"}})
+	if err == nil {
+		t.Error("Expected error for missing template")
+	}
+}
+
+func TestVHosts(t *testing.T) {
+	srv := setupVHostServer(t)
+
+	get := func(t *testing.T, host, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, req)
+		return w
+	}
+
+	t.Run("Per-host settings", func(t *testing.T) {
+		w := get(t, "docs.example.com:8080", "/about")
+		if got, want := w.Body.String(), "docs:About - Docs|ja|Docs Team|/docs.css"; got != want {
+			t.Errorf("Body mismatch: got %q, want %q", got, want)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "max-age=30" {
+			t.Errorf("Cache-Control should use the host's cache_limit, got %s", cc)
+		}
+
+		w = get(t, "other.example.com", "/about")
+		if got, want := w.Body.String(), "default:About - Default Site|en|"; got != want {
+			t.Errorf("Unknown host should use the default site: got %q, want %q", got, want)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "max-age=60" {
+			t.Errorf("Cache-Control mismatch: got %s", cc)
+		}
+	})
+
+	t.Run("Host-aware cache keys", func(t *testing.T) {
+		srv.purgeCache()
+		get(t, "docs.example.com", "/about")
+		get(t, "localhost", "/about")
+
+		srv.cache.RLock()
+		_, vhostCached := srv.cache.items["docs.example.com/about"]
+		_, defaultCached := srv.cache.items["/about"]
+		srv.cache.RUnlock()
+		if !vhostCached || !defaultCached {
+			t.Fatalf("Expected separate cache entries, got vhost=%v default=%v", vhostCached, defaultCached)
+		}
+
+		// Alias host names share the cache of their virtual host
+		w := get(t, "WWW.docs.example.com", "/about")
+		if w.Header().Get("X-Cache") != "HIT" || !strings.HasPrefix(w.Body.String(), "docs:") {
+			t.Errorf("Alias should hit the vhost cache: %s %q", w.Header().Get("X-Cache"), w.Body.String())
+		}
+
+		srv.dropCachedPage("/about")
+		srv.cache.RLock()
+		n := len(srv.cache.items)
+		srv.cache.RUnlock()
+		if n != 0 {
+			t.Errorf("dropCachedPage should remove the page of every host, %d left", n)
+		}
+	})
+
+	t.Run("Site URL", func(t *testing.T) {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.Host = "www.docs.example.com"
+		if got := srv.siteURL(req); got != "https://docs.example.com" {
+			t.Errorf("siteURL mismatch: got %s", got)
+		}
+		req.Host = "localhost:18085"
+		if got := srv.siteURL(req); got != "http://localhost:18085" {
+			t.Errorf("siteURL mismatch: got %s", got)
+		}
+	})
+
+	t.Run("Cache GC interval", func(t *testing.T) {
+		// Shortest limit is 30s (vhost), minimum interval is 60s
+		if got := srv.cacheGCInterval(); got != 60*time.Second {
+			t.Errorf("Interval mismatch: got %v", got)
+		}
+		srv.config.Cache.CacheLimit = 0
+		*srv.vhosts["docs.example.com"].cfg.CacheLimit = 600
+		if got := srv.cacheGCInterval(); got != 300*time.Second {
+			t.Errorf("Interval mismatch: got %v", got)
+		}
+	})
+}
+
+func TestSubsites(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.tmpl = template.Must(template.New("base").Parse(`{{.Title}}|{{.Body}}`))
+
+	docsDir := t.TempDir()
+	createFile(t, docsDir, "index.md", "# Docs Home")
+	createFile(t, docsDir, "guide.md", "# Guide")
+
+	cfg := srv.config
+	cfg.HTML.SiteTitle = "Main"
+	cfg.VHosts = []VHostConfig{{
+		Hosts:           []string{"docs.example.com"},
+		MarkdownRootDir: RootDirs{docsDir},
+		SiteTitle:       "Docs",
+	}}
+	multi, err := newServer(cfg, srv.tmpl)
+	if err != nil {
+		t.Fatalf("newServer failed: %v", err)
+	}
+	if len(multi.subsites) != 1 || len(multi.vhosts) != 0 {
+		t.Fatalf("Expected one subsite, got %d (vhosts %d)", len(multi.subsites), len(multi.vhosts))
+	}
+
+	h := multi.handler()
+	get := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Each host serves its own tree
+	if w := get("docs.example.com:8080", "/guide"); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "Guide - Docs|") {
+		t.Errorf("Subsite page: %d %q", w.Code, w.Body.String())
+	}
+	if w := get("docs.example.com", "/about"); w.Code != http.StatusNotFound {
+		t.Errorf("Main site page served by the subsite: %d", w.Code)
+	}
+	if w := get("other.example.com", "/about"); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "About - Main|") {
+		t.Errorf("Main site page: %d %q", w.Code, w.Body.String())
+	}
+	if w := get("other.example.com", "/guide"); w.Code != http.StatusNotFound {
+		t.Errorf("Subsite page served by the multi site: %d", w.Code)
+	}
+
+	// Navigation of the subsite only covers its tree
+	sub := multi.subsiteFor["docs.example.com"]
+	if sub.config.Git.Enabled || sub.config.Edit.Enabled || sub.config.Auth.OIDC.Enabled || sub.git != nil || sub.edit != nil {
+		t.Error("Git, the edit API and OIDC should stay with the main site")
+	}
+	var titles []string
+	for _, n := range sub.nav.get("") {
+		titles = append(titles, n.Title)
+	}
+	if strings.Join(titles, ",") != "Docs Home,Guide" {
+		t.Errorf("Subsite navigation: %v", titles)
+	}
+	if multi.cacheItems() != 2 {
+		t.Errorf("Expected 2 cached pages over all sites, got %d", multi.cacheItems())
+	}
+	multi.flushCaches()
+	if multi.cacheItems() != 0 {
+		t.Errorf("Expected a flush to empty every site, got %d cached pages", multi.cacheItems())
+	}
+
+	t.Run("Site defaults", func(t *testing.T) {
+		cfg := cfg
+		cfg.HTML.SiteURL = "https://example.com"
+		cfg.Feed.Enabled = true
+		cfg.Feed.Title = "Main" // as set by applyDefaults
+		cfg.Manifest.Name = "Main"
+		cfg.IndexNow.Enabled = true
+		cfg.IndexNow.Key = "0123456789abcdef"
+		multi, err := newServer(cfg, srv.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sub := multi.subsites[0]
+		if c := sub.config; c.Feed.Title != "Docs" || c.Manifest.Name != "Docs" || c.HTML.SiteURL != "" {
+			t.Errorf("Subsite defaults: feed %q, manifest %q, site_url %q", c.Feed.Title, c.Manifest.Name, c.HTML.SiteURL)
+		}
+		if multi.indexNow == nil || sub.indexNow != nil {
+			t.Error("IndexNow should only be enabled for sites with a site_url")
+		}
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/feed.xml", nil)
+		req.Host = "docs.example.com"
+		w := httptest.NewRecorder()
+		multi.handler().ServeHTTP(w, req)
+		if body := w.Body.String(); !strings.Contains(body, "<title>Docs</title>") || !strings.Contains(body, "http://docs.example.com/guide") || strings.Contains(body, "https://example.com") {
+			t.Errorf("Subsite feed: %s", body)
+		}
+	})
+
+	// Host names must be unique over shared and separate virtual hosts
+	cfg.VHosts = append(cfg.VHosts, VHostConfig{Hosts: []string{"Docs.example.com"}})
+	if _, err := newServer(cfg, srv.tmpl); err == nil || !strings.Contains(err.Error(), "configured twice") {
+		t.Errorf("Expected duplicate host error, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package gomadore
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// --- Cache Warming ---
+
+// warmCache renders every page of every site of the server into the cache,
+// at most GOMAXPROCS at a time. Pages that fail to render are logged and
+// left to the first request. It returns the number of cached pages.
+func (s *Server) warmCache() int {
+	start := time.Now()
+	pages, err := s.pages.all()
+	if err != nil {
+		slog.Error("Failed to list pages for cache warming", "err", err)
+		return 0
+	}
+	paths := make([]string, 0, len(pages))
+	for _, p := range pages {
+		paths = append(paths, p.Path)
+	}
+	if s.config.Tags.Enabled {
+		paths = append(paths, tagPagePaths(pages)...)
+		paths = slices.Compact(slices.Sorted(slices.Values(paths)))
+	}
+
+	// The default site and each virtual host sharing markdown_rootdir
+	sites := []*site{s.siteForHost("")}
+	seen := make(map[string]bool)
+	for host, vh := range s.vhosts {
+		if !seen[vh.key] {
+			seen[vh.key] = true
+			sites = append(sites, s.siteForHost(host))
+		}
+	}
+
+	// Rendering more pages than max_cache_items would only evict them again
+	limit := s.config.Cache.MaxCacheItems
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	n := 0
+	for _, st := range sites {
+		for _, p := range paths {
+			if limit > 0 && n >= limit {
+				break
+			}
+			n++
+			g.Go(func() error {
+				key := st.cacheKey(p)
+				if _, err, _ := s.renders.Do(key, func() (any, error) {
+					return s.renderAndCache(st, p, key)
+				}); err != nil {
+					slog.Warn("Failed to warm cache", "path", p, "host", st.key, "err", err)
+				}
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+
+	cached := s.cache.len()
+	slog.Info("Cache warmed", "pages", cached, "duration", time.Since(start).Round(time.Millisecond))
+	return cached
+}
+
+// cachedKeys returns the cache keys of the cached pages.
+func (s *Server) cachedKeys() []string {
+	s.cache.RLock()
+	defer s.cache.RUnlock()
+	return slices.Collect(maps.Keys(s.cache.items))
+}
+
+// rerender renders the pages of keys that are no longer cached (dropped by
+// an invalidation) into the cache again, at most GOMAXPROCS at a time, so
+// that the next request for a changed page is still a hit. Pages whose file
+// was removed are skipped. It returns the number of rendered pages.
+func (s *Server) rerender(keys []string) int {
+	start := time.Now()
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	var n atomic.Int64
+	for _, key := range keys {
+		if _, ok := s.cachedPage(key); ok {
+			continue
+		}
+		// Cache keys are the page path, prefixed by the host name for virtual hosts
+		i := strings.IndexByte(key, '/')
+		if i < 0 {
+			continue
+		}
+		st, p := s.siteForHost(key[:i]), key[i:]
+		g.Go(func() error {
+			_, err, _ := s.renders.Do(key, func() (any, error) {
+				return s.renderAndCache(st, p, key)
+			})
+			switch {
+			case err == nil:
+				n.Add(1)
+			case !errors.Is(err, fs.ErrNotExist):
+				slog.Warn("Failed to re-render a changed page", "path", p, "host", st.key, "err", err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if n.Load() > 0 {
+		slog.Debug("Re-rendered changed pages", "pages", n.Load(), "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return int(n.Load())
+}
@@ -0,0 +1,71 @@
+package gomadore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWarmCache(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	createFile(t, dir, "a.md", "# A\n")
+	createFile(t, dir, "b.md", "# B\n")
+	createFile(t, dir, "draft.md", "---\ndraft: true\n---\n# Draft\n")
+
+	pages, err := srv.pages.all()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.warmCache(); n != len(pages) {
+		t.Fatalf("Expected %d warmed pages, got %d", len(pages), n)
+	}
+	if _, ok := srv.cache.items["/draft"]; ok {
+		t.Error("Expected drafts not to be warmed")
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleRequest(w, httptest.NewRequest(http.MethodGet, "/a", nil))
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("Expected a warmed page to be a cache hit, got %q", got)
+	}
+
+	t.Run("max_cache_items", func(t *testing.T) {
+		srv.purgeCache()
+		srv.config.Cache.MaxCacheItems = 2
+		if n := srv.warmCache(); n != 2 {
+			t.Errorf("Expected 2 warmed pages, got %d", n)
+		}
+	})
+}
+
+func TestRerender(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	get := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleRequest(w, httptest.NewRequest(http.MethodGet, p, nil))
+		return w
+	}
+	for _, p := range []string{"/about", "/sub/deep"} {
+		get(p)
+	}
+	before := srv.cachedKeys()
+
+	createFile(t, dir, "about.md", "# About\nEdited about page")
+	if err := os.Remove(filepath.Join(dir, "sub", "deep.md")); err != nil {
+		t.Fatal(err)
+	}
+	srv.invalidateFiles([]string{filepath.Join(dir, "about.md"), filepath.Join(dir, "sub", "deep.md")})
+	if n := srv.rerender(before); n != 1 {
+		t.Errorf("Expected 1 re-rendered page (the other was removed), got %d", n)
+	}
+	w := get("/about")
+	if w.Header().Get("X-Cache") != "HIT" || !strings.Contains(w.Body.String(), "Edited about page") {
+		t.Errorf("Expected the edited page from the cache, got %s %s", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	if _, ok := srv.cachedPage("/sub/deep"); ok {
+		t.Error("A removed page should not be cached again")
+	}
+}
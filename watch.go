@@ -0,0 +1,81 @@
+package gomadore
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Default quiet period of the file watcher before the cache is invalidated (ms)
+const defaultWatchDebounceMs = 100
+
+// --- Watcher Ignore Patterns ---
+
+// checkWatchIgnore reports the first malformed pattern of watch_ignore.
+func checkWatchIgnore(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("watch_ignore %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// watchIgnored reports whether a file or directory under root matches one of
+// the watch_ignore patterns, or is inside a directory that does. Patterns
+// use path.Match syntax on slash separated paths relative to root, where
+// "**" matches any number of directories ("build/**", "**/node_modules/**").
+// A pattern without a slash matches a name at any depth ("*.tmp").
+func watchIgnored(patterns []string, root, file string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == "." {
+		return false
+	}
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+	for _, p := range patterns {
+		pat := strings.Split(strings.Trim(p, "/"), "/")
+		if len(pat) == 1 {
+			pat = []string{"**", pat[0]}
+		}
+		for n := 1; n <= len(segs); n++ {
+			if globSegments(pat, segs[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoredByWatcher reports whether a file or directory under one of the
+// content roots matches watch_ignore (relative to its root).
+func (s *Server) ignoredByWatcher(file string) bool {
+	root, _, ok := s.config.HTML.MarkdownRootDir.relPath(file)
+	return ok && watchIgnored(s.config.Cache.WatchIgnore, root, file)
+}
+
+// globSegments matches path segments against pattern segments, where "**"
+// matches zero or more segments.
+func globSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if globSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
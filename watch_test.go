@@ -0,0 +1,38 @@
+package gomadore
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchIgnored(t *testing.T) {
+	root := filepath.Join("srv", "docs")
+	patterns := []string{"*.tmp", ".git/**", "**/node_modules/**", "build/*.html"}
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"page.md", false},
+		{"draft.tmp", true},
+		{"sub/deep/x.tmp", true},
+		{".git", true},
+		{".git/objects/ab/cdef", true},
+		{"sub/.git/HEAD", false},
+		{"node_modules/a/b.js", true},
+		{"sub/node_modules", true},
+		{"build/index.html", true},
+		{"build/index.md", false},
+		{"sub/build/index.html", false},
+	}
+	for _, tt := range tests {
+		if got := watchIgnored(patterns, root, filepath.Join(root, filepath.FromSlash(tt.file))); got != tt.want {
+			t.Errorf("watchIgnored(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+	if watchIgnored(nil, root, filepath.Join(root, "a.tmp")) {
+		t.Error("Expected nothing to be ignored without patterns")
+	}
+	if err := checkWatchIgnore([]string{"[a-"}); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
@@ -0,0 +1,417 @@
+package gomadore
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+)
+
+const (
+	// Upper bound of a fetched source document
+	webmentionMaxSourceBytes = 1 << 20
+	// Number of sources verified concurrently
+	webmentionWorkers = 4
+	// Mentions waiting for verification; more are refused with 503
+	webmentionQueueSize = 100
+	// Default limits of stored mentions (per page and in total)
+	defaultWebmentionMaxPerPage  = 100
+	defaultWebmentionMaxMentions = 10000
+	// Upper bound (characters) of a stored title
+	webmentionMaxTitle = 200
+	// Default timeout (seconds) for fetching a source
+	defaultWebmentionTimeout = 10
+)
+
+// --- Webmention (https://www.w3.org/TR/webmention/) ---
+
+// Webmention is a verified mention of a page, exposed to templates.
+type Webmention struct {
+	Source   string    `json:"source"`
+	Title    string    `json:"title,omitempty"`
+	Verified time.Time `json:"verified"`
+}
+
+// webmentionStore keeps verified mentions per page key, persisted as a JSON
+// file. A page gets at most maxPerPage mentions, and the store at most
+// maxTotal.
+type webmentionStore struct {
+	mu         sync.RWMutex
+	file       string
+	mentions   map[string][]Webmention // page key -> mentions
+	count      int
+	maxPerPage int
+	maxTotal   int
+}
+
+// errWebmentionLimit is returned for a new mention beyond the limits.
+var errWebmentionLimit = errors.New("too many webmentions")
+
+// loadWebmentionStore reads the store of [webmention].
+func loadWebmentionStore(cfg Config) (*webmentionStore, error) {
+	wc := cfg.Webmention
+	st := &webmentionStore{
+		file:       wc.StoreFile,
+		mentions:   make(map[string][]Webmention),
+		maxPerPage: cmp.Or(wc.MaxPerPage, defaultWebmentionMaxPerPage),
+		maxTotal:   cmp.Or(wc.MaxMentions, defaultWebmentionMaxMentions),
+	}
+	data, err := os.ReadFile(st.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &st.mentions); err != nil {
+			return nil, fmt.Errorf("invalid webmention store (%s): %w", st.file, err)
+		}
+	}
+	for _, list := range st.mentions {
+		st.count += len(list)
+	}
+	return st, nil
+}
+
+// forPage returns a copy of the mentions of a page.
+func (st *webmentionStore) forPage(key string) []Webmention {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return slices.Clone(st.mentions[key])
+}
+
+// upsert adds or replaces the mention from source (or removes it if m is nil)
+// and writes the store to disk. A new source is refused with
+// errWebmentionLimit if the page or the store is full.
+func (st *webmentionStore) upsert(key, source string, m *Webmention) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	old := st.mentions[key]
+	list := slices.DeleteFunc(slices.Clone(old), func(e Webmention) bool { return e.Source == source })
+	if m != nil {
+		if len(list) == len(old) && (len(list) >= st.maxPerPage || st.count >= st.maxTotal) {
+			return errWebmentionLimit
+		}
+		list = append(list, *m)
+	}
+	st.count += len(list) - len(old)
+	if len(list) == 0 {
+		delete(st.mentions, key)
+	} else {
+		st.mentions[key] = list
+	}
+	return st.save()
+}
+
+// save writes the store atomically (temp file + rename). Caller holds the lock.
+func (st *webmentionStore) save() error {
+	data, err := json.MarshalIndent(st.mentions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(st.file), ".webmentions-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), st.file)
+}
+
+// webmentionReceiver accepts mentions and verifies them in the background.
+type webmentionReceiver struct {
+	s      *Server
+	store  *webmentionStore
+	client *http.Client
+	queue  chan webmentionJob
+	wg     sync.WaitGroup // queued and in-flight verifications (used by tests)
+}
+
+// webmentionJob is a mention waiting for verification.
+type webmentionJob struct {
+	key, source, target string
+	wg                  *sync.WaitGroup // of the receiver that queued it
+}
+
+func newWebmentionReceiver(s *Server) (*webmentionReceiver, error) {
+	wc := s.config.Webmention
+	store, err := loadWebmentionStore(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := wc.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebmentionTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: time.Duration(timeout) * time.Second}
+	if !wc.AllowPrivateSources {
+		// Refuse to fetch sources on loopback/private networks (SSRF protection)
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return fmt.Errorf("webmention source address not allowed: %s", host)
+			}
+			return nil
+		}
+	}
+
+	return &webmentionReceiver{
+		s:     s,
+		store: store,
+		client: &http.Client{
+			Timeout:   time.Duration(timeout) * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+		queue: make(chan webmentionJob, webmentionQueueSize),
+	}, nil
+}
+
+// takeOver makes wr, the receiver of a reloaded server, use the store and
+// the queue of prev, the receiver of the server it replaces, if both use
+// the same store file: the file has one writer, and queued mentions are
+// verified by the workers of wr. Call it before wr is started.
+func (wr *webmentionReceiver) takeOver(prev *webmentionReceiver) {
+	if wr == nil || prev == nil || wr.store.file != prev.store.file {
+		return
+	}
+	prev.store.mu.Lock()
+	prev.store.maxPerPage, prev.store.maxTotal = wr.store.maxPerPage, wr.store.maxTotal
+	prev.store.mu.Unlock()
+	wr.store, wr.queue = prev.store, prev.queue
+}
+
+// start runs the workers verifying queued mentions until ctx is done.
+// Safe to call on nil.
+func (wr *webmentionReceiver) start(ctx context.Context) {
+	if wr == nil {
+		return
+	}
+	for range webmentionWorkers {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-wr.queue:
+					// A verification under way is finished after a reload
+					wr.verify(context.WithoutCancel(ctx), job.key, job.source, job.target)
+					job.wg.Done()
+				}
+			}
+		}()
+	}
+}
+
+// mentionsFor returns the verified mentions of a page. Safe to call on nil.
+func (wr *webmentionReceiver) mentionsFor(key string) []Webmention {
+	if wr == nil {
+		return nil
+	}
+	return wr.store.forPage(key)
+}
+
+// handleWebmention validates the request synchronously and queues
+// verification, or answers 503 if the queue is full.
+func (wr *webmentionReceiver) handleWebmention(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	source := r.PostFormValue("source")
+	target := r.PostFormValue("target")
+
+	key, err := wr.validate(r, source, target)
+	if err != nil {
+		slog.Debug("Webmention rejected", "source", source, "target", target, "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wr.wg.Add(1)
+	select {
+	case wr.queue <- webmentionJob{key, source, target, &wr.wg}:
+	default:
+		wr.wg.Done()
+		slog.Warn("Webmention queue is full; mention refused", "source", source, "target", target)
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "Webmention accepted\n")
+}
+
+// validate checks source/target and returns the page key of the target.
+func (wr *webmentionReceiver) validate(r *http.Request, source, target string) (string, error) {
+	su, err := url.Parse(source)
+	if err != nil || (su.Scheme != "http" && su.Scheme != "https") || su.Host == "" {
+		return "", errors.New("source must be an http(s) URL")
+	}
+	tu, err := url.Parse(target)
+	if err != nil || (tu.Scheme != "http" && tu.Scheme != "https") || tu.Host == "" {
+		return "", errors.New("target must be an http(s) URL")
+	}
+	if source == target {
+		return "", errors.New("source and target must differ")
+	}
+
+	site, err := url.Parse(wr.s.siteURL(r))
+	if err != nil || !strings.EqualFold(site.Host, tu.Host) {
+		return "", errors.New("target is not on this site")
+	}
+
+	key := pageKey(tu.Path)
+	file, err := wr.s.markdownFile(key)
+	if err != nil {
+		return "", errors.New("target page does not exist")
+	}
+	if _, err := os.Stat(file); err != nil {
+		return "", errors.New("target page does not exist")
+	}
+	return key, nil
+}
+
+// verify fetches the source and stores (or removes) the mention.
+func (wr *webmentionReceiver) verify(ctx context.Context, key, source, target string) {
+	mention, err := wr.fetchMention(ctx, source, target)
+	if err != nil {
+		slog.Info("Webmention verification failed", "source", source, "target", target, "err", err)
+		return
+	}
+
+	if err := wr.store.upsert(key, source, mention); errors.Is(err, errWebmentionLimit) {
+		slog.Warn("Webmention dropped: the store is full", "source", source, "target", target)
+		return
+	} else if err != nil {
+		slog.Error("Failed to save webmention", "file", wr.store.file, "err", err)
+		return
+	}
+	if mention != nil {
+		slog.Info("Webmention verified", "source", source, "target", target)
+	} else {
+		slog.Info("Webmention removed", "source", source, "target", target)
+	}
+
+	// Re-render the page with its new mentions
+	wr.s.dropCachedPage(key)
+}
+
+// fetchMention returns the mention if source links to target, or nil if the
+// source was deleted or no longer links to target.
+func (wr *webmentionReceiver) fetchMention(ctx context.Context, source, target string) (*Webmention, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html, */*;q=0.5")
+	req.Header.Set("User-Agent", "gomadore-webmention/"+wr.s.version)
+
+	resp, err := wr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("source returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, webmentionMaxSourceBytes))
+	if err != nil {
+		return nil, err
+	}
+	doc := string(body)
+	if !strings.Contains(doc, `"`+target+`"`) && !strings.Contains(doc, `'`+target+`'`) {
+		return nil, nil
+	}
+
+	return &Webmention{
+		Source:   source,
+		Title:    mentionTitle(htmlTitle(doc)),
+		Verified: time.Now(),
+	}, nil
+}
+
+// mentionTitle returns the title on one line, without control characters
+// and shortened to webmentionMaxTitle characters.
+func mentionTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.Join(strings.Fields(title), " "))
+	return truncate(webmentionMaxTitle, title)
+}
+
+// htmlTitle returns the unescaped content of the first <title> element.
+func htmlTitle(doc string) string {
+	start := indexFoldASCII(doc, "<title")
+	if start < 0 {
+		return ""
+	}
+	gt := strings.IndexByte(doc[start:], '>')
+	if gt < 0 {
+		return ""
+	}
+	start += gt + 1
+	end := indexFoldASCII(doc[start:], "</title>")
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(doc[start : start+end]))
+}
+
+// indexFoldASCII returns the index of the first instance of the ASCII
+// string substr in s, ignoring ASCII case, or -1. Unlike searching a lower
+// case copy, the index is valid in s whatever s contains.
+func indexFoldASCII(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
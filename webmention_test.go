@@ -0,0 +1,252 @@
+package gomadore
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func setupWebmentionServer(t *testing.T) (*Server, *webmentionReceiver) {
+	t.Helper()
+	srv, dir := setupTestServer(t)
+	srv.config.HTML.SiteURL = "https://example.com"
+	srv.config.Webmention.Enabled = true
+	srv.config.Webmention.StoreFile = filepath.Join(dir, "webmentions.json")
+	srv.config.Webmention.AllowPrivateSources = true // httptest listens on loopback
+
+	wr, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	srv.webmentions = wr
+	wr.start(t.Context())
+	return srv, wr
+}
+
+func postWebmention(t *testing.T, h http.Handler, source, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	form := url.Values{"source": {source}, "target": {target}}
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/webmention", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebmention(t *testing.T) {
+	srv, wr := setupWebmentionServer(t)
+	mux := srv.routes()
+
+	linking := true
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if linking {
+			fmt.Fprint(w, `<html><head><title>Reply &amp; more</title></head><body><a href="https://example.com/about">about</a></body></html>`)
+		} else {
+			fmt.Fprint(w, `<html><body>No link</body></html>`)
+		}
+	}))
+	defer source.Close()
+
+	srv.tmpl = template.Must(template.New("base").Parse(`{{ range .Webmentions }}[{{ .Source }}|{{ .Title }}]{{ end }}`))
+
+	t.Run("Verified mention", func(t *testing.T) {
+		w := postWebmention(t, mux, source.URL+"/post", "https://example.com/about")
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("StatusCode mismatch: got %d (%s)", w.Code, w.Body.String())
+		}
+		wr.wg.Wait()
+
+		got := wr.mentionsFor("/about")
+		if len(got) != 1 || got[0].Title != "Reply & more" {
+			t.Fatalf("Unexpected mentions: %+v", got)
+		}
+
+		// Rendered into the page
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/about", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), "["+source.URL+"/post|Reply &amp; more]") {
+			t.Errorf("Page does not contain mention: %s", rec.Body.String())
+		}
+		if link := rec.Header().Get("Link"); link != `</webmention>; rel="webmention"` {
+			t.Errorf("Discovery Link header mismatch: got %q", link)
+		}
+
+		// Persisted
+		st, err := loadWebmentionStore(srv.config)
+		if err != nil || len(st.forPage("/about")) != 1 {
+			t.Errorf("Mention was not persisted: %v", err)
+		}
+	})
+
+	t.Run("Removed when link disappears", func(t *testing.T) {
+		linking = false
+		if w := postWebmention(t, mux, source.URL+"/post", "https://example.com/about"); w.Code != http.StatusAccepted {
+			t.Fatalf("StatusCode mismatch: got %d", w.Code)
+		}
+		wr.wg.Wait()
+
+		if got := wr.mentionsFor("/about"); len(got) != 0 {
+			t.Errorf("Mention should be removed, got %+v", got)
+		}
+	})
+
+	t.Run("Rejected requests", func(t *testing.T) {
+		tests := []struct {
+			name, source, target string
+		}{
+			{"Missing source", "", "https://example.com/about"},
+			{"Non-http source", "ftp://example.org/x", "https://example.com/about"},
+			{"Foreign target", source.URL, "https://other.example/about"},
+			{"Unknown target page", source.URL, "https://example.com/nothing"},
+			{"Same source and target", "https://example.com/about", "https://example.com/about"},
+		}
+		for _, tt := range tests {
+			if w := postWebmention(t, mux, tt.source, tt.target); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d", tt.name, w.Code)
+			}
+		}
+	})
+}
+
+func TestWebmentionLimits(t *testing.T) {
+	srv, wr := setupWebmentionServer(t)
+	srv.config.Webmention.MaxPerPage = 2
+	srv.config.Webmention.MaxMentions = 3
+	wr, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webmention", wr.handleWebmention)
+
+	t.Run("Full queue", func(t *testing.T) {
+		// No workers are started, so nothing leaves the queue
+		for i := range webmentionQueueSize {
+			if w := postWebmention(t, mux, fmt.Sprintf("https://example.org/%d", i), "https://example.com/about"); w.Code != http.StatusAccepted {
+				t.Fatalf("Mention %d: expected 202, got %d", i, w.Code)
+			}
+		}
+		w := postWebmention(t, mux, "https://example.org/more", "https://example.com/about")
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected 503 with Retry-After, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("Stored mentions", func(t *testing.T) {
+		st := wr.store
+		add := func(key, source string) error {
+			return st.upsert(key, source, &Webmention{Source: source, Verified: time.Now()})
+		}
+		for _, source := range []string{"https://a.example/", "https://b.example/"} {
+			if err := add("/about", source); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := add("/about", "https://c.example/"); !errors.Is(err, errWebmentionLimit) {
+			t.Errorf("Expected the page to be full, got %v", err)
+		}
+		if err := add("/about", "https://a.example/"); err != nil {
+			t.Errorf("Updating a mention of a full page failed: %v", err)
+		}
+		if err := add("/", "https://c.example/"); err != nil {
+			t.Fatal(err)
+		}
+		if err := add("/sub/deep", "https://d.example/"); !errors.Is(err, errWebmentionLimit) {
+			t.Errorf("Expected the store to be full, got %v", err)
+		}
+		if err := st.upsert("/about", "https://a.example/", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := add("/sub/deep", "https://d.example/"); err != nil {
+			t.Errorf("Removing a mention should make room: %v", err)
+		}
+	})
+
+	long := strings.Repeat("word ", 100)
+	if got := mentionTitle("A\n\ttitle\x00 " + long); !strings.HasPrefix(got, "A title word") || utf8.RuneCountInString(got) > webmentionMaxTitle {
+		t.Errorf("mentionTitle: got %q", got)
+	}
+}
+
+func TestWebmentionTakeOver(t *testing.T) {
+	srv, _ := setupWebmentionServer(t)
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="https://example.com/about">about</a>`)
+	}))
+	defer source.Close()
+
+	// Queued by the previous server, whose workers do not run
+	prev, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := postWebmention(t, http.HandlerFunc(prev.handleWebmention), source.URL, "https://example.com/about"); w.Code != http.StatusAccepted {
+		t.Fatalf("StatusCode mismatch: got %d", w.Code)
+	}
+
+	srv.config.Webmention.MaxPerPage = 5
+	next, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next.takeOver(prev)
+	if next.store != prev.store || prev.store.maxPerPage != 5 {
+		t.Fatal("Expected the store to be handed over with the new limits")
+	}
+	next.start(t.Context())
+	prev.wg.Wait()
+	if got := next.mentionsFor("/about"); len(got) != 1 {
+		t.Errorf("Expected the queued mention to be verified, got %+v", got)
+	}
+
+	srv.config.Webmention.StoreFile += ".new"
+	other, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.takeOver(next); other.store == next.store {
+		t.Error("A store of another file should not be handed over")
+	}
+}
+
+func TestWebmention_PrivateSourceBlocked(t *testing.T) {
+	srv, wr := setupWebmentionServer(t)
+	srv.config.Webmention.AllowPrivateSources = false
+	wr, err := newWebmentionReceiver(srv)
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="https://example.com/about">about</a>`)
+	}))
+	defer source.Close()
+
+	if _, err := wr.fetchMention(t.Context(), source.URL, "https://example.com/about"); err == nil {
+		t.Error("Fetching a loopback source should fail")
+	}
+}
+
+func TestHTMLTitle(t *testing.T) {
+	tests := map[string]string{
+		`<html><head><TITLE lang="en"> Hello &lt;World&gt; </TITLE></head>`: "Hello <World>",
+		`<html><body>no title</body></html>`:                                "",
+		// Lower casing would change the length of these before the title
+		strings.Repeat("\xff", 50) + "<title>Invalid UTF-8</title>": "Invalid UTF-8",
+		"İİİİ<TITLE>Dotted İ</Title>":                               "Dotted İ",
+	}
+	for doc, want := range tests {
+		if got := htmlTitle(doc); got != want {
+			t.Errorf("htmlTitle(%q): got %q, want %q", doc, got, want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package gomadore
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// CSS classes of wiki links
+const (
+	wikiLinkClass       = "wikilink"
+	wikiLinkBrokenClass = "wikilink broken" // no page matches
+)
+
+// --- Wiki Links ([[Page Name]]) ---
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLink is [[target]], [[target|label]] or [[target#heading]]. Its label
+// is kept as a Text child, so the plain text of a page (search, word count)
+// includes it.
+type wikiLink struct {
+	ast.BaseInline
+	target   string // page name or path ("" for a heading of the same page)
+	fragment string // heading, without "#"
+}
+
+func (n *wikiLink) Kind() ast.NodeKind { return kindWikiLink }
+
+func (n *wikiLink) Dump(src []byte, level int) {
+	ast.DumpHelper(n, src, level, map[string]string{"Target": n.target, "Fragment": n.fragment}, nil)
+}
+
+// wikiLinks resolves wiki links against the page index when a page is
+// rendered, as used by Obsidian and most wikis.
+type wikiLinks struct {
+	pages *pageIndex // set once the index exists; nil: every link is broken
+}
+
+// Extend implements goldmark.Extender.
+func (e *wikiLinks) Extend(m goldmark.Markdown) {
+	// Before the link parser (200), which would take "[[" as a bracket
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(e, 199)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(e, 100)))
+}
+
+// Trigger implements parser.InlineParser.
+func (e *wikiLinks) Trigger() []byte { return []byte{'['} }
+
+// Parse implements parser.InlineParser. A wiki link ends with its line.
+func (e *wikiLinks) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, seg := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	inner := line[2 : 2+end]
+	if len(bytes.TrimSpace(inner)) == 0 || bytes.ContainsAny(inner, "[]") {
+		return nil
+	}
+
+	// The label is the part after "|", else the target as written
+	labelStart, labelEnd := 2, 2+end
+	ref := inner
+	if i := bytes.IndexByte(inner, '|'); i >= 0 {
+		ref = inner[:i]
+		labelStart = 2 + i + 1
+	}
+	target, fragment, _ := strings.Cut(string(ref), "#")
+	n := &wikiLink{target: strings.TrimSpace(target), fragment: strings.TrimSpace(fragment)}
+	label := text.NewSegment(seg.Start+labelStart, seg.Start+labelEnd)
+	label = label.TrimLeftSpace(block.Source())
+	label = label.TrimRightSpace(block.Source())
+	if label.IsEmpty() {
+		label = text.NewSegment(seg.Start+2, seg.Start+2+len(ref))
+	}
+	n.AppendChild(n, ast.NewTextSegment(label))
+	block.Advance(2 + end + 2)
+	return n
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (e *wikiLinks) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindWikiLink, e.render)
+}
+
+func (e *wikiLinks) render(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</a>")
+		return ast.WalkContinue, nil
+	}
+	n := node.(*wikiLink)
+	href, ok := "", n.target == ""
+	if !ok {
+		href, ok = e.resolve(n.target)
+	}
+	if !ok {
+		_, _ = fmt.Fprintf(w, `<a class="%s">`, wikiLinkBrokenClass)
+		return ast.WalkContinue, nil
+	}
+	if n.fragment != "" {
+		href += "#" + headingSlug(n.fragment)
+	}
+	_, _ = fmt.Fprintf(w, `<a class="%s" href="%s">`, wikiLinkClass, html.EscapeString(href))
+	return ast.WalkContinue, nil
+}
+
+// resolve returns the URL of the page a wiki link target names.
+func (e *wikiLinks) resolve(target string) (string, bool) {
+	if e == nil || e.pages == nil {
+		return "", false
+	}
+	pages, err := e.pages.all()
+	if err != nil {
+		return "", false
+	}
+	if p := resolveWikiLink(pages, target); p != nil {
+		return p.URL, true
+	}
+	return "", false
+}
+
+// resolveWikiLink returns the page a wiki link target names (nil if none).
+// A target with a slash is a path from the content root ("guide/Setup");
+// otherwise it matches the file name of a page ("Some Page" matches
+// some-page.md, and "guide" guide/index.md), or else its title. Names are
+// compared case-insensitively, with spaces, "-" and "_" alike. The first
+// page by path wins.
+func resolveWikiLink(pages []*pageMeta, target string) *pageMeta {
+	key := wikiKey(strings.TrimSuffix(strings.Trim(target, "/"), ".md"))
+
+	if strings.Contains(key, "/") {
+		for _, p := range pages {
+			if wikiKey(strings.TrimPrefix(p.Path, "/")) == key || (p.IsIndex() && wikiKey(strings.TrimPrefix(path.Dir(p.Path), "/")) == key) {
+				return p
+			}
+		}
+		return nil
+	}
+	for _, p := range pages {
+		name := path.Base(p.Path)
+		if p.IsIndex() && p.Path != "/index" {
+			name = path.Base(path.Dir(p.Path))
+		}
+		if wikiKey(name) == key {
+			return p
+		}
+	}
+	for _, p := range pages {
+		if wikiKey(p.Title) == key {
+			return p
+		}
+	}
+	return nil
+}
+
+// wikiTargets returns the page targets of the wiki links of a document.
+func wikiTargets(doc ast.Node) []string {
+	var targets []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if l, ok := n.(*wikiLink); ok && entering && l.target != "" {
+			targets = append(targets, l.target)
+		}
+		return ast.WalkContinue, nil
+	})
+	return targets
+}
+
+// wikiKey normalizes a page name for comparison.
+func wikiKey(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// headingSlug converts heading text into the ID goldmark generates for it
+// (ASCII letters and digits in lower case, spaces as "-"). Repeated
+// headings, which get a numbered ID, cannot be linked to.
+func headingSlug(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(strings.TrimSpace(s)) {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(c + 'a' - 'A')
+		case c >= 'a' && c <= 'z' || c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case c == ' ' || c == '\t' || c == '-' || c == '_':
+			b.WriteByte('-')
+		}
+	}
+	return cmp.Or(b.String(), "heading")
+}
@@ -0,0 +1,80 @@
+package gomadore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWikiLinks(t *testing.T) {
+	srv, dir := setupTestServer(t)
+	enabled := true
+	srv.config.Markdown.WikiLinks = &enabled
+	srv, err := newServer(srv.config, srv.tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "guide"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "release-notes.md", "# Changes\n")
+	createFile(t, dir, "guide/index.md", "# Guide\n")
+	createFile(t, dir, "guide/Setup_Steps.md", "# Installing\n")
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"[[Release Notes]]", `<a class="wikilink" href="/release-notes">Release Notes</a>`},
+		{"[[release_notes|the notes]]", `<a class="wikilink" href="/release-notes">the notes</a>`},
+		{"[[Changes]]", `<a class="wikilink" href="/release-notes">Changes</a>`},
+		{"[[guide]]", `<a class="wikilink" href="/guide/">guide</a>`},
+		{"[[guide/setup steps#First Step]]", `<a class="wikilink" href="/guide/Setup_Steps#first-step">guide/setup steps#First Step</a>`},
+		{"[[Deep Page]]", `<a class="wikilink" href="/sub/deep">Deep Page</a>`},
+		{"[[#Usage Notes]]", `<a class="wikilink" href="#usage-notes">#Usage Notes</a>`},
+		{"[[Nowhere | missing]]", `<a class="wikilink broken">missing</a>`},
+		{"[[<b>]]", `<a class="wikilink broken">&lt;b&gt;</a>`},
+		{"[[]] and [not] [[a]b]]", `[[]] and [not] [[a]b]]`},
+		{"[link](/about)", `<a href="/about">link</a>`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := srv.md.Convert([]byte(tt.src), &buf); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != "<p>"+tt.want+"</p>" {
+			t.Errorf("%s:\n got %s\nwant <p>%s</p>", tt.src, got, tt.want)
+		}
+	}
+
+	// Label text is part of the plain text, and broken links are checked
+	createFile(t, dir, "links.md", "# Links\n\n[[Changes|see the notes]] and [[Nowhere]]\n")
+	_, text, err := srv.files.load(dir, "links.md")
+	if err != nil || !strings.Contains(text, "see the notes") {
+		t.Errorf("Expected the label in the plain text, got %q (%v)", text, err)
+	}
+	srv.pages.invalidate()
+	if got := srv.backlinks("/release-notes"); len(got) != 1 || got[0].URL != "/links" {
+		t.Errorf("Expected a backlink from the wiki link, got %+v", got)
+	}
+	var out bytes.Buffer
+	if _, err := srv.checkSite(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "broken wiki link [[Nowhere]]") || strings.Contains(out.String(), "[[Changes") {
+		t.Errorf("Unexpected check output:\n%s", out.String())
+	}
+}
+
+func TestWikiLinksDisabled(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	var buf bytes.Buffer
+	if err := srv.md.Convert([]byte("[[About]]"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "wikilink") {
+		t.Errorf("Expected wiki links to be off by default, got %s", buf.String())
+	}
+}